@@ -1,4 +1,5 @@
 use anyhow::{Context, Result};
+use bytesize::ByteSize;
 use reqwest::multipart;
 use serde::{Deserialize, Serialize};
 use std::path::Path;
@@ -24,7 +25,9 @@ pub struct ReleaseResponse {
     pub id: String,
     pub version: String,
     pub channel: String,
-    pub bundle_url: String,
+    /// Minted by the server when the release is read; absent when it has no object storage.
+    #[serde(default)]
+    pub download_url: Option<String>,
     pub rollout_percentage: u8,
     pub is_active: bool,
     pub is_encrypted: bool,
@@ -32,6 +35,18 @@ pub struct ReleaseResponse {
     pub created_at: String,
 }
 
+impl ReleaseResponse {
+    /// The URL to download the release's bundle from.
+    pub fn download_url(&self) -> Result<&str> {
+        self.download_url.as_deref().with_context(|| {
+            format!(
+                "The server returned no download URL for version {}",
+                self.version
+            )
+        })
+    }
+}
+
 #[derive(Debug, Deserialize)]
 pub struct RollbackResponse {
     pub message: String,
@@ -52,6 +67,7 @@ pub struct ReleaseMetadata {
     pub base_version: Option<String>,
     pub key_id: Option<String>,
     pub size: u64,
+    pub override_size_budget: bool,
 }
 
 #[derive(Debug, Deserialize)]
@@ -59,6 +75,101 @@ pub struct ErrorResponse {
     pub error: String,
 }
 
+/// Error body of a rejected bundle or patch upload, which names the size budget it exceeded or the
+/// secrets that keep it from being published, if any.
+#[derive(Debug, Deserialize)]
+pub struct UploadErrorResponse {
+    pub error: String,
+    #[serde(default)]
+    pub size_budget: Option<SizeBudget>,
+    #[serde(default)]
+    pub secret_scan: Option<SecretScan>,
+}
+
+/// The channel size budget an upload exceeded, with the figures behind it.
+#[derive(Debug, Deserialize)]
+pub struct SizeBudget {
+    pub channel: String,
+    pub budget: String,
+    pub artifact: String,
+    pub size: u64,
+    #[serde(default)]
+    pub max_size: u64,
+    #[serde(default)]
+    pub active_version: String,
+    #[serde(default)]
+    pub active_size: u64,
+    #[serde(default)]
+    pub growth_percent: f64,
+    #[serde(default)]
+    pub max_growth_percent: f64,
+}
+
+/// Secrets found in a bundle at or above the severity its channel refuses to publish.
+#[derive(Debug, Deserialize)]
+pub struct SecretScan {
+    pub channel: String,
+    pub block_severity: String,
+    pub findings: Vec<SecretFinding>,
+}
+
+/// A credential, or a likely key, found in a bundle. The match is redacted by the server.
+#[derive(Debug, Deserialize)]
+pub struct SecretFinding {
+    pub rule: String,
+    pub severity: String,
+    pub path: String,
+    pub offset: u64,
+    pub r#match: String,
+}
+
+impl UploadErrorResponse {
+    /// Describes the error for the terminal, spelling out an exceeded size budget or the secrets found.
+    pub fn describe(&self) -> String {
+        if let Some(scan) = &self.secret_scan {
+            let mut out = format!(
+                "Secrets found in the bundle; channel '{}' does not publish findings of {} severity or above:",
+                scan.channel, scan.block_severity
+            );
+            for f in &scan.findings {
+                out.push_str(&format!(
+                    "\n  [{}] {} in {} at byte {}: {}",
+                    f.severity, f.rule, f.path, f.offset, f.r#match
+                ));
+            }
+            out.push_str("\nRemove them from the bundle and upload it again.");
+            return out;
+        }
+        let Some(b) = &self.size_budget else {
+            return self.error.clone();
+        };
+        let exceeded = if b.budget == "max_growth" {
+            format!(
+                "the {} is {}, {:.1}% larger than version {} ({}); channel '{}' allows {:.1}% growth",
+                b.artifact,
+                ByteSize(b.size),
+                b.growth_percent,
+                b.active_version,
+                ByteSize(b.active_size),
+                b.channel,
+                b.max_growth_percent
+            )
+        } else {
+            format!(
+                "the {} is {}; channel '{}' allows at most {}",
+                b.artifact,
+                ByteSize(b.size),
+                b.channel,
+                ByteSize(b.max_size)
+            )
+        };
+        format!(
+            "Size budget exceeded: {}. Re-run with --override-size-budget to publish it anyway.",
+            exceeded
+        )
+    }
+}
+
 impl ApiClient {
     /// Create a new API client with the given base URL and API token.
     pub fn new(base_url: &str, token: &str) -> Self {
@@ -150,10 +261,12 @@ impl ApiClient {
 
         if !resp.status().is_success() {
             let status = resp.status();
-            let err: ErrorResponse = resp.json().await.unwrap_or(ErrorResponse {
+            let err: UploadErrorResponse = resp.json().await.unwrap_or(UploadErrorResponse {
                 error: format!("HTTP {}", status),
+                size_budget: None,
+                secret_scan: None,
             });
-            anyhow::bail!("Upload failed ({}): {}", status, err.error);
+            anyhow::bail!("Upload failed ({}): {}", status, err.describe());
         }
 
         resp.json::<ReleaseResponse>()
@@ -242,9 +355,11 @@ impl ApiClient {
         &self,
         release_id: &str,
         base_version: &str,
+        base_hash: &str,
         hash: &str,
         signature: &str,
         patch_path: &Path,
+        override_size_budget: bool,
     ) -> Result<()> {
         let url = format!("{}/releases/{}/patches", self.base_url, release_id);
 
@@ -254,9 +369,11 @@ impl ApiClient {
 
         let metadata = serde_json::json!({
             "base_version": base_version,
+            "base_hash": base_hash,
             "hash": hash,
             "signature": signature,
             "size": patch_bytes.len(),
+            "override_size_budget": override_size_budget,
         });
 
         let form = multipart::Form::new()
@@ -278,10 +395,12 @@ impl ApiClient {
             .context("Failed to upload patch")?;
 
         if !resp.status().is_success() {
-            let err: ErrorResponse = resp.json().await.unwrap_or(ErrorResponse {
+            let err: UploadErrorResponse = resp.json().await.unwrap_or(UploadErrorResponse {
                 error: "Unknown error".to_string(),
+                size_budget: None,
+                secret_scan: None,
             });
-            anyhow::bail!("Patch upload failed: {}", err.error);
+            anyhow::bail!("Patch upload failed: {}", err.describe());
         }
 
         Ok(())
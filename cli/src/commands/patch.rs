@@ -12,6 +12,7 @@ pub async fn execute(
     new_ver: &str,
     channel: &str,
     output: Option<&str>,
+    override_size_budget: bool,
 ) -> Result<()> {
     let config = Config::load_or_exit()?;
     let client = ApiClient::new(&config.api_endpoint, &config.api_token);
@@ -40,7 +41,7 @@ pub async fn execute(
 
     // 3. Download and Resolve bundles (handle encryption)
     println!("  ⏬ Downloading base version {}...", old_ver.cyan());
-    client.download_bundle(&old_info.bundle_url, &old_path).await?;
+    client.download_bundle(old_info.download_url()?, &old_path).await?;
     let resolved_old = if old_info.is_encrypted {
         println!("  🔓 Decrypting base version...");
         let key_hex = if let Some(kid) = &old_info.key_id {
@@ -58,7 +59,7 @@ pub async fn execute(
     };
     
     println!("  ⏬ Downloading target version {}...", new_ver.cyan());
-    client.download_bundle(&new_info.bundle_url, &new_path).await?;
+    client.download_bundle(new_info.download_url()?, &new_path).await?;
     let resolved_new = if new_info.is_encrypted {
         println!("  🔓 Decrypting target version...");
         let key_hex = if let Some(kid) = &new_info.key_id {
@@ -82,10 +83,11 @@ pub async fn execute(
     // 5. Hash and sign (of the PLAIN patch)
     let patch_hash = utils::sha256_file(patch_path.to_str().unwrap())?;
     let patch_sig = signing::sign_file(patch_path.to_str().unwrap())?;
+    let base_hash = utils::sha256_file(resolved_old.to_str().unwrap())?;
 
     // 6. Upload
     println!("  ⏫ Uploading patch to backend...");
-    client.upload_patch(&new_info.id, &old_info.version, &patch_hash, &patch_sig, &patch_path).await?;
+    client.upload_patch(&new_info.id, &old_info.version, &base_hash, &patch_hash, &patch_sig, &patch_path, override_size_budget).await?;
 
     println!();
     println!("  {} Patch generated and uploaded successfully!", "✓".green().bold());
@@ -27,6 +27,7 @@ pub async fn execute(
     entry_file: &str,
     build_dir: &str,
     encrypt: bool,
+    override_size_budget: bool,
 ) -> Result<()> {
     // Validate inputs
     if rollout == 0 || rollout > 100 {
@@ -132,35 +133,24 @@ pub async fn execute(
         base_version: None,
         key_id: active_key_id,
         size: std::fs::metadata(&final_zip_path)?.len(),
+        override_size_budget,
     };
 
     let release = client.upload_release(&metadata, &final_zip_path).await?;
     spinner.finish_with_message("Uploaded successfully ✓".green().to_string());
 
     // ── Step 5.5: Generate Diff patches for previous versions ──
+    // The server checks a patch by rebuilding the bundle as uploaded, and a bundle encrypted here is uploaded
+    // encrypted; the stored copy of an encrypted release is sealed by the server in the chunked format, which
+    // this CLI cannot decrypt to diff from. So patches are only made between plain bundles.
     let previous_releases = client.list_releases(channel).await?;
-    if let Some(prev) = previous_releases.iter().find(|r| r.id != release.id) {
+    if let Some(prev) = previous_releases.iter().find(|r| r.id != release.id).filter(|prev| !encrypt && !prev.is_encrypted) {
         let spinner = create_spinner(format!("Generating diff patch from version {}...", prev.version).as_str());
         
         // Download previous bundle
         let prev_bundle_path = std::path::PathBuf::from(format!("{}/prev_bundle.zip", build_dir));
-        client.download_bundle(&prev.bundle_url, &prev_bundle_path).await?;
-
-        // If prev was encrypted, decrypt it first to binary diff plain bytes (more efficient)
-        let resolved_prev_path = if prev.is_encrypted {
-            let key_hex = if let Some(kid) = &prev.key_id {
-                let keys = config.encryption_keys.as_ref().context("Keyring not found")?;
-                keys.get(kid).context(format!("Encryption key {} not found in keyring", kid))?
-            } else {
-                config.encryption_key.as_ref().context("Legacy encryption key not found")?
-            };
-            let key = hex::decode(key_hex)?;
-            let decrypted_path = format!("{}/prev_bundle.dec", build_dir);
-            utils::encryption::decrypt_file(&prev_bundle_path, std::path::Path::new(&decrypted_path), &key)?;
-            std::path::PathBuf::from(decrypted_path)
-        } else {
-            prev_bundle_path.clone()
-        };
+        client.download_bundle(prev.download_url()?, &prev_bundle_path).await?;
+        let resolved_prev_path = prev_bundle_path.clone();
 
         // Create patch (Compare plain old to plain new)
         let patch_path = std::path::PathBuf::from(format!("{}/diff.patch", build_dir));
@@ -169,9 +159,11 @@ pub async fn execute(
         // Hash and sign the patch (of the PLAIN patch)
         let patch_hash = utils::sha256_file(patch_path.to_str().unwrap())?;
         let patch_sig = signing::sign_file(patch_path.to_str().unwrap())?;
+        // Devices are matched to the patch by the hash of the plain bundle it applies to
+        let base_hash = utils::sha256_file(resolved_prev_path.to_str().unwrap())?;
 
         // Upload patch
-        client.upload_patch(&release.id, &prev.version, &patch_hash, &patch_sig, &patch_path).await?;
+        client.upload_patch(&release.id, &prev.version, &base_hash, &patch_hash, &patch_sig, &patch_path, override_size_budget).await?;
         
         spinner.finish_with_message(format!("Diff patch generated ({} -> {}) ✓", prev.version, version).green().to_string());
         
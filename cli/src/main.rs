@@ -66,6 +66,10 @@ enum Commands {
         /// Encrypt the bundle with AES-256-GCM
         #[arg(short, long, default_value_t = false)]
         encrypt: bool,
+
+        /// Publish even if the bundle exceeds the channel's size budget (recorded in the audit log)
+        #[arg(long, default_value_t = false)]
+        override_size_budget: bool,
     },
 
     /// Rollback to a previous version on a channel
@@ -116,6 +120,10 @@ enum Commands {
         /// Optional output file path
         #[arg(short, long)]
         output: Option<String>,
+
+        /// Upload the patch even if it exceeds the channel's size budget (recorded in the audit log)
+        #[arg(long, default_value_t = false)]
+        override_size_budget: bool,
     },
 }
 
@@ -174,6 +182,7 @@ async fn main() -> Result<()> {
             entry_file,
             build_dir,
             encrypt,
+            override_size_budget,
         } => {
             print_banner();
             commands::release::execute(
@@ -185,6 +194,7 @@ async fn main() -> Result<()> {
                 &entry_file,
                 &build_dir,
                 encrypt,
+                override_size_budget,
             )
             .await?;
         }
@@ -208,9 +218,9 @@ async fn main() -> Result<()> {
             print_banner();
             commands::keys::execute(subcommand)?;
         }
-        Commands::Patch { old, new, channel, output } => {
+        Commands::Patch { old, new, channel, output, override_size_budget } => {
             print_banner();
-            commands::patch::execute(&old, &new, &channel, output.as_deref()).await?;
+            commands::patch::execute(&old, &new, &channel, output.as_deref(), override_size_budget).await?;
         }
     }
 
@@ -14,7 +14,7 @@ export interface Release {
     app_id: string
     version: string
     channel: string
-    bundle_url: string
+    bundle_key: string
     hash: string
     signature: string
     mandatory: boolean
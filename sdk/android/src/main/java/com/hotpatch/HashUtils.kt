@@ -10,7 +10,7 @@ object HashUtils {
         return hash.equals(expectedHash, ignoreCase = true)
     }
 
-    private fun sha256(file: File): String {
+    fun sha256(file: File): String {
         val digest = MessageDigest.getInstance("SHA-256")
         val inputStream = FileInputStream(file)
         val buffer = ByteArray(8192)
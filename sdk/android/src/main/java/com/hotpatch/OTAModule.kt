@@ -10,11 +10,28 @@ class OTAModule(reactContext: ReactApplicationContext) : ReactContextBaseJavaMod
     fun setup(
             apiUrl: String,
             appId: String,
+            appKey: String,
             channel: String,
             encryptionKey: String?,
             signingKey: String?
     ) {
-        OTAUpdateManager.setup(apiUrl, appId, channel, encryptionKey, signingKey)
+        OTAUpdateManager.setup(apiUrl, appId, appKey, channel, encryptionKey, signingKey)
+    }
+
+    @ReactMethod
+    fun setUserId(userId: String?) {
+        OTAUpdateManager.setUserId(userId)
+    }
+
+    @ReactMethod
+    fun setTags(tags: ReadableMap) {
+        val values = mutableMapOf<String, String>()
+        val iterator = tags.keySetIterator()
+        while (iterator.hasNextKey()) {
+            val key = iterator.nextKey()
+            tags.getString(key)?.let { values[key] = it }
+        }
+        OTAUpdateManager.setTags(values)
     }
 
     @ReactMethod
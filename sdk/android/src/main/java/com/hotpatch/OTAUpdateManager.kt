@@ -6,13 +6,18 @@ import java.io.*
 import java.util.concurrent.ExecutorService
 import java.util.concurrent.Executors
 import okhttp3.*
+import okhttp3.HttpUrl.Companion.toHttpUrl
 import org.json.JSONObject
 
 object OTAUpdateManager {
     private const val TAG = "HotPatch"
+    private const val SDK_VERSION = "0.1.0"
     private var apiUrl: String = ""
     private var appId: String = ""
+    private var appKey: String = ""
     private var channel: String = "production"
+    private var userId: String? = null
+    private var tags: Map<String, String> = emptyMap()
     private var encryptionKey: String? = null
     private var signingPublicKey: String? = null
     private var pinnedDomain: String? = null
@@ -24,18 +29,28 @@ object OTAUpdateManager {
     fun setup(
             url: String,
             id: String,
+            apiKey: String,
             ch: String,
             key: String? = null,
             signingKey: String? = null
     ) {
         apiUrl = url
         appId = id
+        appKey = apiKey
         channel = ch
         encryptionKey = key
         signingPublicKey = signingKey
         rebuildClient()
     }
 
+    fun setUserId(id: String?) {
+        userId = id
+    }
+
+    fun setTags(values: Map<String, String>) {
+        tags = values
+    }
+
     fun setupCertificatePinning(domain: String, hash: String) {
         pinnedDomain = domain
         pinnedHash = hash
@@ -124,9 +139,31 @@ object OTAUpdateManager {
 
         executor.execute {
             try {
-                val url =
-                        "$apiUrl/update/check?appId=$appId&deviceId=$deviceId&version=$currentVersion&platform=android&channel=$channel"
-                val request = Request.Builder().url(url).header("X-App-Key", appId).build()
+                val locale = java.util.Locale.getDefault()
+                val builder =
+                        "$apiUrl/update/check"
+                                .toHttpUrl()
+                                .newBuilder()
+                                .addQueryParameter("appId", appId)
+                                .addQueryParameter("deviceId", deviceId)
+                                .addQueryParameter("version", currentVersion)
+                                .addQueryParameter("platform", "android")
+                                .addQueryParameter("channel", channel)
+                                // Targeting attributes
+                                .addQueryParameter("osVersion", android.os.Build.VERSION.RELEASE)
+                                .addQueryParameter("deviceModel", android.os.Build.MODEL)
+                                .addQueryParameter("locale", locale.toLanguageTag())
+                                .addQueryParameter("country", locale.country)
+                                .addQueryParameter("sdkVersion", SDK_VERSION)
+                userId?.let { builder.addQueryParameter("userId", it) }
+                // Patches are matched on the exact bundle this device runs
+                val currentBundleZip = File(context.filesDir, "ota/bundle.zip")
+                if (currentBundleZip.exists()) {
+                    builder.addQueryParameter("bundleHash", HashUtils.sha256(currentBundleZip))
+                }
+                tags.forEach { (key, value) -> builder.addQueryParameter("tag.$key", value) }
+                val url = builder.build()
+                val request = Request.Builder().url(url).header("X-App-Key", appKey).build()
                 val response = client.newCall(request).execute()
                 val body = response.body?.string()
 
@@ -158,28 +195,52 @@ object OTAUpdateManager {
                 val pendingDir = File(context.filesDir, "ota/pending")
                 if (!pendingDir.exists()) pendingDir.mkdirs()
 
-                // Download
-                val downloadedFile = File(pendingDir, "bundle.tmp")
-                val request = Request.Builder().url(bundleUrl).build()
-                val response = client.newCall(request).execute()
-
-                val fos = FileOutputStream(downloadedFile)
-                fos.write(response.body?.bytes())
-                fos.close()
-
-                var finalZip = downloadedFile
+                var finalZip: File
+                var downloadSize = 0L
+                val patchChain = updateJson.optJSONArray("patchChain")
 
-                // Step A: Handle Differential Patch
-                if (updateJson.optBoolean("isPatch")) {
-                    Log.i(TAG, "Applying differential patch...")
-                    val currentBundleZip = File(context.filesDir, "ota/bundle.zip")
-                    if (!currentBundleZip.exists()) {
+                if (patchChain != null && patchChain.length() > 0) {
+                    // Step A': Chain of patches, each checked against the bundle it reproduces
+                    Log.i(TAG, "Applying a chain of ${patchChain.length()} patches...")
+                    var current = File(context.filesDir, "ota/bundle.zip")
+                    if (!current.exists()) {
                         Log.e(TAG, "Base bundle missing for patch!")
                         return@execute
                     }
-                    val patchedZip = File(pendingDir, "bundle.patched.zip")
-                    applyPatch(currentBundleZip, downloadedFile, patchedZip)
-                    finalZip = patchedZip
+                    for (i in 0 until patchChain.length()) {
+                        val hop = patchChain.getJSONObject(i)
+                        val patchFile = File(pendingDir, "hop$i.patch")
+                        download(hop.getString("url"), patchFile)
+                        downloadSize += patchFile.length()
+
+                        val patchedZip = File(pendingDir, "bundle.hop$i.zip")
+                        applyPatch(current, patchFile, patchedZip)
+                        if (!HashUtils.verifyHash(patchedZip, hop.getString("hash"))) {
+                            Log.e(TAG, "Patch chain produced an unexpected bundle at ${hop.optString("version")}!")
+                            return@execute
+                        }
+                        current = patchedZip
+                    }
+                    finalZip = current
+                } else {
+                    // Download
+                    val downloadedFile = File(pendingDir, "bundle.tmp")
+                    download(bundleUrl, downloadedFile)
+                    downloadSize = downloadedFile.length()
+                    finalZip = downloadedFile
+
+                    // Step A: Handle Differential Patch
+                    if (updateJson.optBoolean("isPatch")) {
+                        Log.i(TAG, "Applying differential patch...")
+                        val currentBundleZip = File(context.filesDir, "ota/bundle.zip")
+                        if (!currentBundleZip.exists()) {
+                            Log.e(TAG, "Base bundle missing for patch!")
+                            return@execute
+                        }
+                        val patchedZip = File(pendingDir, "bundle.patched.zip")
+                        applyPatch(currentBundleZip, downloadedFile, patchedZip)
+                        finalZip = patchedZip
+                    }
                 }
 
                 // Step C: Verify Integrity
@@ -203,7 +264,7 @@ object OTAUpdateManager {
                 if (updateJson.optBoolean("isEncrypted")) {
                     Log.i(TAG, "Decrypting bundle...")
                     val decryptedZip = File(pendingDir, "bundle.decrypted.zip")
-                    decryptFile(finalZip, decryptedZip, encryptionKey)
+                    decryptFile(finalZip, decryptedZip, encryptionKey, updateJson.optString("encryptionFormat"))
                     finalZip = decryptedZip
                 }
 
@@ -238,7 +299,6 @@ object OTAUpdateManager {
                 // Report installation
                 val releaseId = updateJson.optString("id", updateJson.optString("release_id"))
                 val isPatch = updateJson.optBoolean("isPatch")
-                val downloadSize = downloadedFile.length()
 
                 reportInstallation(context, releaseId, "applied", isPatch, downloadSize)
 
@@ -281,7 +341,7 @@ object OTAUpdateManager {
                                         body.toString()
                                 )
                         )
-                        .addHeader("X-App-Key", appId)
+                        .addHeader("X-App-Key", appKey)
                         .build()
 
         client.newCall(request)
@@ -297,13 +357,25 @@ object OTAUpdateManager {
                 )
     }
 
+    private fun download(url: String, target: File) {
+        val request = Request.Builder().url(url).build()
+        client.newCall(request).execute().use { response ->
+            if (!response.isSuccessful) throw IOException("Download failed: HTTP ${response.code}")
+            FileOutputStream(target).use { fos -> response.body?.byteStream()?.copyTo(fos) }
+        }
+    }
+
     private fun applyPatch(oldFile: File, patchFile: File, outputFile: File) {
         Log.i(TAG, "Applying binary patch from ${oldFile.name}")
         PatchUtils.applyPatch(oldFile, patchFile, outputFile)
     }
 
-    private fun decryptFile(inputFile: File, outputFile: File, keyHex: String?) {
+    private fun decryptFile(inputFile: File, outputFile: File, keyHex: String?, format: String) {
         if (keyHex == null) throw Exception("Decryption key missing")
+        if (format == "aes-256-gcm-chunked") {
+            decryptChunkedFile(inputFile, outputFile, hexToBytes(keyHex))
+            return
+        }
 
         val data = inputFile.readBytes()
         val nonce = data.sliceArray(0 until 12)
@@ -320,6 +392,40 @@ object OTAUpdateManager {
         outputFile.writeBytes(decrypted)
     }
 
+    // Chunked format: "HPC1" + 4-byte chunk size + 8-byte nonce prefix, then sealed chunks.
+    // Chunk i uses nonce prefix + 4-byte big-endian i and AAD 1 for the last chunk, 0 otherwise,
+    // so only one chunk is held in memory at a time.
+    private fun decryptChunkedFile(inputFile: File, outputFile: File, key: ByteArray) {
+        val secretKey = javax.crypto.spec.SecretKeySpec(key, "AES")
+        DataInputStream(BufferedInputStream(FileInputStream(inputFile))).use { input ->
+            FileOutputStream(outputFile).use { output ->
+                val magic = ByteArray(4)
+                input.readFully(magic)
+                if (String(magic, Charsets.US_ASCII) != "HPC1") throw Exception("Not a chunked bundle")
+                val chunkSize = input.readInt()
+                if (chunkSize <= 0 || chunkSize > 16 shl 20) throw Exception("Invalid chunk size $chunkSize")
+                val nonce = ByteArray(12)
+                input.readFully(nonce, 0, 8)
+
+                var remaining = inputFile.length() - 16
+                val sealed = ByteArray(chunkSize + 16)
+                var counter = 0
+                do {
+                    val length = minOf(remaining, sealed.size.toLong()).toInt()
+                    input.readFully(sealed, 0, length)
+                    remaining -= length
+                    val last = remaining == 0L
+
+                    java.nio.ByteBuffer.wrap(nonce, 8, 4).putInt(counter++)
+                    val cipher = javax.crypto.Cipher.getInstance("AES/GCM/NoPadding")
+                    cipher.init(javax.crypto.Cipher.DECRYPT_MODE, secretKey, javax.crypto.spec.GCMParameterSpec(128, nonce))
+                    cipher.updateAAD(byteArrayOf(if (last) 1 else 0))
+                    output.write(cipher.doFinal(sealed, 0, length))
+                } while (!last)
+            }
+        }
+    }
+
     private fun unzip(zipFile: File, targetDir: File) {
         val zipIn = java.util.zip.ZipInputStream(java.io.FileInputStream(zipFile))
         var entry = zipIn.nextEntry
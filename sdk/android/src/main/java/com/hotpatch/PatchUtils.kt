@@ -98,12 +98,13 @@ object PatchUtils {
     private fun readLong(input: DataInput): Long {
         val b = ByteArray(8)
         input.readFully(b)
-        // Little-endian read
+        // Little-endian magnitude with the sign in the top bit (bsdiff's offtin); seeks are often negative
         var result: Long = 0
         for (i in 7 downTo 0) {
             result = (result shl 8) or (b[i].toLong() and 0xFF)
         }
-        return result
+        val magnitude = result and Long.MAX_VALUE
+        return if (result < 0) -magnitude else magnitude
     }
 
     private fun readFully(input: InputStream, buffer: ByteArray, offset: Int, length: Int) {
@@ -8,6 +8,11 @@ struct HashUtils {
         return hash.lowercased() == expectedHash.lowercased()
     }
     
+    static func sha256(fileURL: URL) -> String? {
+        guard let data = try? Data(contentsOf: fileURL) else { return nil }
+        return sha256(data: data)
+    }
+    
     private static func sha256(data: Data) -> String {
         var hash = [UInt8](repeating: 0, count: Int(CC_SHA256_DIGEST_LENGTH))
         data.withUnsafeBytes {
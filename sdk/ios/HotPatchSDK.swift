@@ -8,8 +8,16 @@ class HotPatchSDK: NSObject {
         return false
     }
     
-    @objc func setup(_ apiUrl: String, appId: String, channel: String, encryptionKey: String?, signingKey: String?) {
-        OTAUpdateManager.shared.setup(url: apiUrl, id: appId, ch: channel, key: encryptionKey, signingKey: signingKey)
+    @objc func setup(_ apiUrl: String, appId: String, appKey: String, channel: String, encryptionKey: String?, signingKey: String?) {
+        OTAUpdateManager.shared.setup(url: apiUrl, id: appId, apiKey: appKey, ch: channel, key: encryptionKey, signingKey: signingKey)
+    }
+    
+    @objc func setUserId(_ userId: String?) {
+        OTAUpdateManager.shared.setUserId(userId)
+    }
+    
+    @objc func setTags(_ tags: [String: String]) {
+        OTAUpdateManager.shared.setTags(tags)
     }
     
     @objc func checkForUpdate(_ resolve: @escaping RCTPromiseResolveBlock, rejecter reject: @escaping RCTPromiseRejectBlock) {
@@ -4,9 +4,12 @@
 @interface RCT_EXTERN_MODULE (HotPatchSDK, NSObject)
 
 RCT_EXTERN_METHOD(setup : (NSString *)apiUrl appId : (NSString *)
-                      appId channel : (NSString *)
+                      appId appKey : (NSString *)
+                          appKey channel : (NSString *)
                           channel encryptionKey : (NSString *)encryptionKey
                               signingKey : (NSString *)signingKey)
+RCT_EXTERN_METHOD(setUserId : (NSString *)userId)
+RCT_EXTERN_METHOD(setTags : (NSDictionary *)tags)
 RCT_EXTERN_METHOD(checkForUpdate : (RCTPromiseResolveBlock)
                       resolve rejecter : (RCTPromiseRejectBlock)reject)
 RCT_EXTERN_METHOD(applyUpdate : (NSDictionary *)updateJson resolver : (
@@ -1,4 +1,5 @@
 import Foundation
+import UIKit
 #if canImport(CryptoKit)
 import CryptoKit
 #endif
@@ -7,26 +8,39 @@ import CryptoKit
 public class OTAUpdateManager: NSObject, URLSessionDelegate {
     private var apiUrl: String = ""
     private var appId: String = ""
+    private var appKey: String = ""
     private var channel: String = "production"
+    private var userId: String? = nil
+    private var tags: [String: String] = [:]
     private var encryptionKey: String? = nil
     private var signingPublicKey: String? = nil
     private var pinnedDomain: String? = nil
     private var pinnedPublicKeyHash: String? = nil
     private let TAG = "HotPatch"
+    private let SDK_VERSION = "0.1.0"
     
     private var session: URLSession!
     
     @objc public static let shared = OTAUpdateManager()
     
-    @objc public func setup(url: String, id: String, ch: String, key: String? = nil, signingKey: String? = nil) {
+    @objc public func setup(url: String, id: String, apiKey: String, ch: String, key: String? = nil, signingKey: String? = nil) {
         self.apiUrl = url
         self.appId = id
+        self.appKey = apiKey
         self.channel = ch
         self.encryptionKey = key
         self.signingPublicKey = signingKey
         rebuildSession()
     }
     
+    @objc public func setUserId(_ id: String?) {
+        self.userId = id
+    }
+    
+    @objc public func setTags(_ values: [String: String]) {
+        self.tags = values
+    }
+    
     @objc public func setupCertificatePinning(domain: String, hash: String) {
         self.pinnedDomain = domain
         self.pinnedPublicKeyHash = hash
@@ -99,14 +113,40 @@ public class OTAUpdateManager: NSObject, URLSessionDelegate {
     
     func checkForUpdate(version: String, deviceId: String, completion: @escaping ([String: Any]?) -> Void) {
         UserDefaults.standard.set(deviceId, forKey: "HotPatch_DeviceId")
-        let urlString = "\(apiUrl)/update/check?appId=\(appId)&deviceId=\(deviceId)&version=\(version)&platform=ios&channel=\(channel)"
-        guard let url = URL(string: urlString) else {
+        var components = URLComponents(string: "\(apiUrl)/update/check")
+        var query = [
+            URLQueryItem(name: "appId", value: appId),
+            URLQueryItem(name: "deviceId", value: deviceId),
+            URLQueryItem(name: "version", value: version),
+            URLQueryItem(name: "platform", value: "ios"),
+            URLQueryItem(name: "channel", value: channel),
+            // Targeting attributes
+            URLQueryItem(name: "osVersion", value: UIDevice.current.systemVersion),
+            URLQueryItem(name: "deviceModel", value: Self.deviceModel()),
+            URLQueryItem(name: "locale", value: Locale.current.identifier.replacingOccurrences(of: "_", with: "-")),
+            URLQueryItem(name: "country", value: Locale.current.regionCode),
+            URLQueryItem(name: "sdkVersion", value: SDK_VERSION)
+        ]
+        if let userId = userId {
+            query.append(URLQueryItem(name: "userId", value: userId))
+        }
+        // Patches are matched on the exact bundle this device runs
+        let currentBundleZip = FileManager.default.urls(for: .documentDirectory, in: .userDomainMask)[0]
+            .appendingPathComponent("ota/bundle.zip")
+        if let bundleHash = HashUtils.sha256(fileURL: currentBundleZip) {
+            query.append(URLQueryItem(name: "bundleHash", value: bundleHash))
+        }
+        for (key, value) in tags {
+            query.append(URLQueryItem(name: "tag.\(key)", value: value))
+        }
+        components?.queryItems = query
+        guard let url = components?.url else {
             completion(nil)
             return
         }
         
         var request = URLRequest(url: url)
-        request.addValue(appId, forHTTPHeaderField: "X-App-Key")
+        request.addValue(appKey, forHTTPHeaderField: "X-App-Key")
         
         session.dataTask(with: request) { data, response, error in
             guard let data = data, error == nil else {
@@ -124,39 +164,72 @@ public class OTAUpdateManager: NSObject, URLSessionDelegate {
     }
     
     func downloadAndApply(updateJson: [String: Any], completion: @escaping (Bool) -> Void) {
-        let bundleUrlStr = updateJson["bundleUrl"] as? String ?? ""
-        guard let bundleUrl = URL(string: bundleUrlStr) else {
+        let paths = FileManager.default.urls(for: .documentDirectory, in: .userDomainMask)
+        let otaDir = paths[0].appendingPathComponent("ota")
+        let pendingDir = otaDir.appendingPathComponent("pending")
+        let bundleDir = otaDir.appendingPathComponent("bundle")
+        let previousDir = otaDir.appendingPathComponent("previous")
+
+        // A patch chain is downloaded hop by hop; anything else is the single artifact at bundleUrl
+        var downloads: [(URL, URL)] = []
+        let patchChain = updateJson["patchChain"] as? [[String: Any]] ?? []
+        if !patchChain.isEmpty {
+            for (i, hop) in patchChain.enumerated() {
+                guard let hopUrl = URL(string: hop["url"] as? String ?? "") else {
+                    completion(false)
+                    return
+                }
+                downloads.append((hopUrl, pendingDir.appendingPathComponent("hop\(i).patch")))
+            }
+        } else {
+            let bundleUrlStr = updateJson["bundleUrl"] as? String ?? ""
+            guard let bundleUrl = URL(string: bundleUrlStr) else {
+                completion(false)
+                return
+            }
+            downloads.append((bundleUrl, pendingDir.appendingPathComponent("bundle.tmp")))
+        }
+
+        do {
+            if !FileManager.default.fileExists(atPath: pendingDir.path) {
+                try FileManager.default.createDirectory(at: pendingDir, withIntermediateDirectories: true)
+            }
+        } catch {
             completion(false)
             return
         }
-        
-        session.downloadTask(with: bundleUrl) { location, response, error in
-            guard let location = location, error == nil else {
+
+        downloadAll(downloads[...]) { ok in
+            guard ok else {
                 completion(false)
                 return
             }
-            
+
             do {
-                let paths = FileManager.default.urls(for: .documentDirectory, in: .userDomainMask)
-                let otaDir = paths[0].appendingPathComponent("ota")
-                let pendingDir = otaDir.appendingPathComponent("pending")
-                let bundleDir = otaDir.appendingPathComponent("bundle")
-                let previousDir = otaDir.appendingPathComponent("previous")
-                
-                if !FileManager.default.fileExists(atPath: pendingDir.path) {
-                    try FileManager.default.createDirectory(at: pendingDir, withIntermediateDirectories: true)
-                }
-                
-                let downloadedFile = pendingDir.appendingPathComponent("bundle.tmp")
-                if FileManager.default.fileExists(atPath: downloadedFile.path) {
-                    try FileManager.default.removeItem(at: downloadedFile)
-                }
-                try FileManager.default.moveItem(at: location, to: downloadedFile)
-                
+                let downloadedFile = downloads[0].1
                 var finalZip = downloadedFile
-                
-                // Step A: Handle Differential Patch
-                if let isPatch = updateJson["isPatch"] as? Bool, isPatch {
+                var downloadSize: Int64 = 0
+                for (_, file) in downloads {
+                    downloadSize += (try? FileManager.default.attributesOfItem(atPath: file.path)[.size] as? Int64) ?? 0
+                }
+
+                if !patchChain.isEmpty {
+                    // Step A': Chain of patches, each checked against the bundle it reproduces
+                    print("[\(self.TAG)] Applying a chain of \(patchChain.count) patches...")
+                    var current = otaDir.appendingPathComponent("bundle.zip")
+                    for (i, hop) in patchChain.enumerated() {
+                        let patchedZip = pendingDir.appendingPathComponent("bundle.hop\(i).zip")
+                        try self.applyPatch(oldFile: current, patchFile: downloads[i].1, outputFile: patchedZip)
+                        if !HashUtils.verifyHash(fileURL: patchedZip, expectedHash: hop["hash"] as? String ?? "") {
+                            print("[\(self.TAG)] Patch chain produced an unexpected bundle at \(hop["version"] as? String ?? "")!")
+                            completion(false)
+                            return
+                        }
+                        current = patchedZip
+                    }
+                    finalZip = current
+                } else if let isPatch = updateJson["isPatch"] as? Bool, isPatch {
+                    // Step A: Handle Differential Patch
                     print("[\(self.TAG)] Applying differential patch...")
                     let currentBundleZip = otaDir.appendingPathComponent("bundle.zip")
                     let patchedZip = pendingDir.appendingPathComponent("bundle.patched.zip")
@@ -186,7 +259,8 @@ public class OTAUpdateManager: NSObject, URLSessionDelegate {
                 if let isEncrypted = updateJson["isEncrypted"] as? Bool, isEncrypted {
                     print("[\(self.TAG)] Decrypting bundle...")
                     let decryptedZip = pendingDir.appendingPathComponent("bundle.decrypted.zip")
-                    try self.decryptFile(at: finalZip, to: decryptedZip)
+                    let format = updateJson["encryptionFormat"] as? String ?? ""
+                    try self.decryptFile(at: finalZip, to: decryptedZip, format: format)
                     finalZip = decryptedZip
                 }
                 
@@ -227,9 +301,8 @@ public class OTAUpdateManager: NSObject, URLSessionDelegate {
                 // Report installation
                 let releaseId = updateJson["id"] as? String ?? updateJson["release_id"] as? String ?? ""
                 let isPatch = updateJson["isPatch"] as? Bool ?? false
-                let downloadSize = try? FileManager.default.attributesOfItem(atPath: downloadedFile.path)[.size] as? Int64 ?? 0
                 
-                self.reportInstallation(releaseId: releaseId, status: "applied", isPatch: isPatch, downloadSize: downloadSize ?? 0)
+                self.reportInstallation(releaseId: releaseId, status: "applied", isPatch: isPatch, downloadSize: downloadSize)
                 
                 if let mandatory = updateJson["mandatory"] as? Bool, mandatory {
                     print("[\(self.TAG)] Mandatory update! Triggering reload...")
@@ -242,13 +315,42 @@ public class OTAUpdateManager: NSObject, URLSessionDelegate {
                 print("[\(self.TAG)] Update failed: \(error)")
                 completion(false)
             }
+        }
+    }
+
+    // Downloads each URL to its file in turn through self.session, so certificate pinning is applied.
+    private func downloadAll(_ downloads: ArraySlice<(URL, URL)>, completion: @escaping (Bool) -> Void) {
+        guard let next = downloads.first else {
+            completion(true)
+            return
+        }
+        let (url, destination) = next
+        session.downloadTask(with: url) { location, response, error in
+            guard let location = location, error == nil else {
+                completion(false)
+                return
+            }
+            do {
+                if FileManager.default.fileExists(atPath: destination.path) {
+                    try FileManager.default.removeItem(at: destination)
+                }
+                try FileManager.default.moveItem(at: location, to: destination)
+            } catch {
+                completion(false)
+                return
+            }
+            self.downloadAll(downloads.dropFirst(), completion: completion)
         }.resume()
     }
     
-    private func decryptFile(at source: URL, to destination: URL) throws {
+    private func decryptFile(at source: URL, to destination: URL, format: String) throws {
         guard let keyHex = self.encryptionKey else {
             throw NSError(domain: "HotPatch", code: 400, userInfo: [NSLocalizedDescriptionKey: "Encryption key missing"])
         }
+        if format == "aes-256-gcm-chunked" {
+            try decryptChunkedFile(at: source, to: destination, keyData: Data(hexString: keyHex))
+            return
+        }
         
         let fileData = try Data(contentsOf: source)
         if fileData.count < 12 {
@@ -280,6 +382,51 @@ public class OTAUpdateManager: NSObject, URLSessionDelegate {
         #endif
     }
     
+    /// Chunked format: "HPC1" + 4-byte chunk size + 8-byte nonce prefix, then sealed chunks.
+    /// Chunk i uses nonce prefix + 4-byte big-endian i and AAD 1 for the last chunk, 0 otherwise,
+    /// so only one chunk is held in memory at a time.
+    private func decryptChunkedFile(at source: URL, to destination: URL, keyData: Data) throws {
+        let invalid = NSError(domain: "HotPatch", code: 400, userInfo: [NSLocalizedDescriptionKey: "Invalid encrypted bundle"])
+        let input = try FileHandle(forReadingFrom: source)
+        defer { try? input.close() }
+        FileManager.default.createFile(atPath: destination.path, contents: nil)
+        let output = try FileHandle(forWritingTo: destination)
+        defer { try? output.close() }
+        
+        let header = input.readData(ofLength: 16)
+        guard header.count == 16, header.prefix(4) == Data("HPC1".utf8) else { throw invalid }
+        let chunkSize = header.subdata(in: 4..<8).reduce(0) { $0 << 8 | Int($1) }
+        guard chunkSize > 0, chunkSize <= 16 << 20 else { throw invalid }
+        let prefix = header.subdata(in: 8..<16)
+        
+        let key = SymmetricKey(data: keyData)
+        let fileSize = try FileManager.default.attributesOfItem(atPath: source.path)[.size] as? Int ?? 0
+        var remaining = fileSize - 16
+        var counter: UInt32 = 0
+        var last = false
+        while !last {
+            let sealed = input.readData(ofLength: min(remaining, chunkSize + 16))
+            guard sealed.count >= 16 else { throw invalid }
+            remaining -= sealed.count
+            last = remaining == 0
+            
+            var nonce = prefix
+            withUnsafeBytes(of: counter.bigEndian) { nonce.append(contentsOf: $0) }
+            let box = try AES.GCM.SealedBox(nonce: AES.GCM.Nonce(data: nonce), ciphertext: sealed.prefix(sealed.count - 16), tag: sealed.suffix(16))
+            output.write(try AES.GCM.open(box, using: key, authenticating: Data([last ? 1 : 0])))
+            counter += 1
+        }
+    }
+    
+    /// Hardware model identifier, e.g. "iPhone15,2" (UIDevice.model only reports "iPhone").
+    private static func deviceModel() -> String {
+        var systemInfo = utsname()
+        uname(&systemInfo)
+        return withUnsafeBytes(of: &systemInfo.machine) { buffer in
+            String(decoding: buffer.prefix(while: { $0 != 0 }), as: UTF8.self)
+        }
+    }
+    
     private func reportInstallation(releaseId: String, status: String, isPatch: Bool, downloadSize: Int64) {
         guard let deviceId = UserDefaults.standard.string(forKey: "HotPatch_DeviceId") else { return }
         let urlString = "\(apiUrl)/installations"
@@ -288,7 +435,7 @@ public class OTAUpdateManager: NSObject, URLSessionDelegate {
         var request = URLRequest(url: url)
         request.httpMethod = "POST"
         request.addValue("application/json", forHTTPHeaderField: "Content-Type")
-        request.addValue(appId, forHTTPHeaderField: "X-App-Key")
+        request.addValue(appKey, forHTTPHeaderField: "X-App-Key")
         
         let body: [String: Any] = [
             "device_id": deviceId,
@@ -15,16 +15,35 @@ class OTAClient {
             HotPatchSDK.setup(
                 config.apiUrl,
                 config.appId,
+                config.appKey,
                 config.channel,
                 config.encryptionKey || null,
                 config.signingKey || null
             );
+            if (config.userId) {
+                HotPatchSDK.setUserId(config.userId);
+            }
+            if (config.tags) {
+                HotPatchSDK.setTags(config.tags);
+            }
             if (config.checkOnLaunch) {
                 this.checkForUpdate();
             }
         }
     }
 
+    setUserId(userId: string | null) {
+        if (HotPatchSDK) {
+            HotPatchSDK.setUserId(userId);
+        }
+    }
+
+    setTags(tags: Record<string, string>) {
+        if (HotPatchSDK) {
+            HotPatchSDK.setTags(tags);
+        }
+    }
+
     async checkForUpdate(): Promise<UpdateCheckResponse | null> {
         if (!this.config || !HotPatchSDK) return null;
         try {
@@ -1,8 +1,11 @@
 export interface OTAConfig {
     apiUrl: string;
     appId: string;
+    appKey: string;
     channel: string;
     checkOnLaunch?: boolean;
+    userId?: string; // Stable user ID for releases that bucket rollouts by user
+    tags?: Record<string, string>; // Custom attributes for release targeting rules ("tags.<key>")
     encryptionKey?: string;
     signingKey?: string;
 }
@@ -15,15 +18,24 @@ export interface UpdateCheckResponse {
     mandatory?: boolean;
     version?: string;
     isEncrypted?: boolean;
+    encryptionFormat?: 'aes-256-gcm' | 'aes-256-gcm-chunked'; // Chunked bundles are decrypted as they stream
     encryptionKeyId?: string;
     isPatch?: boolean;
     patchUrl?: string;
     baseVersion?: string;
+    patchChain?: PatchHop[]; // Patches applied in order when no single patch reaches the release; bundleUrl is the first
     releaseNotes?: string;
     rolloutPercentage?: number;
     size?: number;
 }
 
+export interface PatchHop {
+    url: string;
+    hash: string; // SHA-256 of the bundle this patch reproduces
+    size: number;
+    version: string;
+}
+
 export interface InstallationReport {
     status: 'applied' | 'failed' | 'rolled_back';
     releaseId: string;
@@ -80,9 +80,18 @@ func main() {
 		&models.App{},
 		&models.Channel{},
 		&models.Release{},
+		&models.ReleaseTransition{},
+		&models.ReleaseApproval{},
 		&models.Patch{},
+		&models.PatchJob{},
+		&models.BundleManifest{},
+		&models.SecretFinding{},
 		&models.Device{},
 		&models.Installation{},
+		&models.Revert{},
+		&models.RolloutPlan{},
+		&models.DeviceOverride{},
+		&models.UploadSession{},
 		&models.ApiKey{},
 		&models.SigningKey{},
 		&models.AuditLog{},
@@ -135,25 +144,48 @@ func main() {
 	analyticsRepo := repository.NewAnalyticsRepository(db)
 	securityRepo := repository.NewSecurityRepository(db)
 	settingsRepo := repository.NewSettingsRepository(db)
+	rolloutRepo := repository.NewRolloutRepository(db)
+	overrideRepo := repository.NewOverrideRepository(db)
+	uploadRepo := repository.NewUploadRepository(db)
 
 	// ── Initialize services ──
 	securityService := services.NewSecurityService(securityRepo)
 	settingsService := services.NewSettingsService(settingsRepo, securityService)
 	encryptionService := services.NewEncryptionService()
-	releaseService := services.NewReleaseService(releaseRepo, s3Store, settingsService, securityService, encryptionService, redisClient)
-	updateService := services.NewUpdateService(releaseRepo, deviceRepo, redisClient)
+	freezeService := services.NewFreezeService(channelRepo)
+	lifecycle := services.NewReleaseLifecycle(releaseRepo, settingsService)
+	rolloutService := services.NewRolloutService(rolloutRepo, releaseRepo, settingsService, securityService, freezeService, lifecycle, redisClient)
+	releaseService := services.NewReleaseService(releaseRepo, channelRepo, s3Store, settingsService, securityService, encryptionService, rolloutService, freezeService, lifecycle, redisClient, int64(cfg.MaxBundleSize)<<20, services.PatchGeneration{Bases: cfg.PatchGenerationBases, MaxSizePercent: cfg.PatchMaxSizePercent})
+	uploadService := services.NewUploadService(uploadRepo, s3Store, releaseService, settingsService, time.Duration(cfg.UploadSessionTTL)*time.Hour)
+	updateService := services.NewUpdateService(releaseRepo, deviceRepo, overrideRepo, s3Store, redisClient)
 	deviceService := services.NewDeviceService(deviceRepo, securityService)
+	overrideService := services.NewOverrideService(overrideRepo, releaseRepo, securityService, redisClient)
 	channelService := services.NewChannelService(channelRepo, settingsService)
 	analyticsService := services.NewAnalyticsService(analyticsRepo, deviceRepo, releaseRepo)
 	emailService := services.NewEmailService(cfg.BackendURL)
 	paymentService := services.NewPaymentService(settingsRepo, cfg, securityService)
+	appKeyService := services.NewAppKeyService(settingsRepo, redisClient)
+	healthService := services.NewHealthService(channelRepo, releaseRepo, deviceRepo, releaseService, rolloutService, lifecycle, settingsService, securityService, redisClient)
+
+	// ── Start background scheduler ──
+	scheduler := services.NewScheduler(time.Duration(cfg.SchedulerInterval) * time.Second)
+	scheduler.Register("scheduled releases", releaseService.PublishDue)
+	scheduler.Register("rollout plans", rolloutService.AdvanceDuePlans)
+	scheduler.Register("release health", healthService.EvaluateActiveReleases)
+	scheduler.Register("device overrides", overrideService.PruneExpired)
+	scheduler.Register("upload sessions", uploadService.PruneExpired)
+	scheduler.Register("patch generation", releaseService.GenerateDuePatches)
+	scheduler.Start(context.Background())
 
 	// ── Initialize handlers ──
 	authHandler := handlers.NewAuthHandler(db, channelService, emailService, cfg.JWTSecret, cfg.JWTExpiration, cfg.SuperadminEmail, cfg.SuperadminPassword, cfg.BackendURL, cfg.FrontendURL, cfg.GoogleClientID, cfg.GoogleClientSecret)
-	adminHandler := handlers.NewAdminHandler(db)
+	adminHandler := handlers.NewAdminHandler(db, appKeyService)
 	releaseHandler := handlers.NewReleaseHandler(releaseService)
+	uploadHandler := handlers.NewUploadHandler(uploadService)
+	rolloutHandler := handlers.NewRolloutHandler(rolloutService)
 	updateHandler := handlers.NewUpdateHandler(updateService)
 	deviceHandler := handlers.NewDeviceHandler(deviceService)
+	overrideHandler := handlers.NewOverrideHandler(overrideService)
 	channelHandler := handlers.NewChannelHandler(channelService)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
 	securityHandler := handlers.NewSecurityHandler(securityService)
@@ -175,10 +207,15 @@ func main() {
 		redisClient,
 		db,
 		startTime,
+		appKeyService,
+		settingsService,
 		authHandler,
 		releaseHandler,
+		uploadHandler,
+		rolloutHandler,
 		updateHandler,
 		deviceHandler,
+		overrideHandler,
 		channelHandler,
 		analyticsHandler,
 		securityHandler,
@@ -11,11 +11,12 @@ import (
 )
 
 type AdminHandler struct {
-	db *gorm.DB
+	db            *gorm.DB
+	appKeyService *services.AppKeyService
 }
 
-func NewAdminHandler(db *gorm.DB) *AdminHandler {
-	return &AdminHandler{db: db}
+func NewAdminHandler(db *gorm.DB, appKeyService *services.AppKeyService) *AdminHandler {
+	return &AdminHandler{db: db, appKeyService: appKeyService}
 }
 
 // ListAllApps returns all registered applications in the system.
@@ -92,6 +93,7 @@ func (h *AdminHandler) UpdateApp(c *gin.Context) {
 		app.Tier = *req.Tier
 	}
 	var rawNewKey string
+	oldKey := app.APIKey
 	if req.RegenerateKey {
 		rawNewKey = "hp_" + uuid.New().String()
 		app.APIKey = services.HashApiKey(rawNewKey)
@@ -102,6 +104,11 @@ func (h *AdminHandler) UpdateApp(c *gin.Context) {
 		return
 	}
 
+	// Stop accepting the old key from SDK caches
+	if rawNewKey != "" {
+		h.appKeyService.InvalidateAppKey(c.Request.Context(), oldKey)
+	}
+
 	resp := models.AppResponse{
 		ID:        app.ID,
 		Name:      app.Name,
@@ -126,11 +133,19 @@ func (h *AdminHandler) DeleteApp(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Delete(&models.App{}, "id = ?", appID).Error; err != nil {
+	var app models.App
+	if err := h.db.First(&app, "id = ?", appID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "App not found"})
+		return
+	}
+
+	if err := h.db.Delete(&app).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete app"})
 		return
 	}
 
+	h.appKeyService.InvalidateAppKey(c.Request.Context(), app.APIKey)
+
 	c.Status(http.StatusNoContent)
 }
 
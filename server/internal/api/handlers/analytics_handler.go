@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -86,14 +87,23 @@ func (h *AnalyticsHandler) GetTrends(c *gin.Context) {
 
 // GetReleaseAnalytics handles GET /analytics/releases/:id.
 func (h *AnalyticsHandler) GetReleaseAnalytics(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
 	releaseID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
 		return
 	}
 
-	stats, err := h.service.GetReleaseDetails(c.Request.Context(), releaseID)
+	stats, err := h.service.GetReleaseDetails(c.Request.Context(), appID, releaseID)
 	if err != nil {
+		if errors.Is(err, services.ErrReleaseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -82,6 +83,10 @@ func (h *ChannelHandler) Update(c *gin.Context) {
 
 	channel, err := h.service.Update(appID, slug, &req)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidFreezeWindow) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -29,8 +30,16 @@ func (h *DeviceHandler) RegisterDevice(c *gin.Context) {
 		return
 	}
 
+	if !bindSDKApp(c, &req.AppID) {
+		return
+	}
+
 	device, err := h.service.RegisterOrUpdate(&req)
 	if err != nil {
+		if errors.Is(err, services.ErrDeviceAppMismatch) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -47,8 +56,18 @@ func (h *DeviceHandler) ReportInstallation(c *gin.Context) {
 		return
 	}
 
-	installation, err := h.service.ReportInstallation(&req)
+	appID, err := uuid.Parse(c.GetString("app_id"))
 	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "App ID not found in app key"})
+		return
+	}
+
+	installation, err := h.service.ReportInstallation(appID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrDeviceAppMismatch) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -98,14 +117,23 @@ func (h *DeviceHandler) ListDevices(c *gin.Context) {
 
 // GetInstallationStats handles GET /releases/:id/stats
 func (h *DeviceHandler) GetInstallationStats(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
 	releaseID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
 		return
 	}
 
-	stats, err := h.service.GetInstallationStats(releaseID)
+	stats, err := h.service.GetInstallationStats(appID, releaseID)
 	if err != nil {
+		if errors.Is(err, services.ErrReleaseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
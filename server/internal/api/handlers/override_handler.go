@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/services"
+)
+
+// OverrideHandler handles per-device release override endpoints.
+type OverrideHandler struct {
+	service *services.OverrideService
+}
+
+// NewOverrideHandler creates a new OverrideHandler.
+func NewOverrideHandler(service *services.OverrideService) *OverrideHandler {
+	return &OverrideHandler{service: service}
+}
+
+// List returns every unexpired device override of the app.
+// GET /device-overrides
+func (h *OverrideHandler) List(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	overrides, err := h.service.List(appID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, overrides)
+}
+
+// Get returns the override of a device.
+// GET /device-overrides/:device_id
+func (h *OverrideHandler) Get(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	override, err := h.service.Get(appID, c.Param("device_id"))
+	if err != nil {
+		respondOverrideError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, override)
+}
+
+// Set pins a device to a release, replacing any existing pin.
+// PUT /device-overrides/:device_id
+func (h *OverrideHandler) Set(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.SetDeviceOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	override, err := h.service.Set(c.Request.Context(), appID, c.Param("device_id"), &req, c.GetString("subject"), c.ClientIP())
+	if err != nil {
+		respondOverrideError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, override)
+}
+
+// Delete unpins a device.
+// DELETE /device-overrides/:device_id
+func (h *OverrideHandler) Delete(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), appID, c.Param("device_id"), c.GetString("subject"), c.ClientIP()); err != nil {
+		respondOverrideError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Device override removed"})
+}
+
+func respondOverrideError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrOverrideNotFound), errors.Is(err, services.ErrReleaseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidOverride):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -57,22 +59,64 @@ func (h *ReleaseHandler) Create(c *gin.Context) {
 	defer file.Close()
 
 	// Create the release
-	release, err := h.service.Create(c.Request.Context(), &req, appID, file)
+	release, err := h.service.Create(c.Request.Context(), &req, appID, file, c.GetString("subject"), c.ClientIP())
 	if err != nil {
-		// Check for version conflict (409)
-		if err.Error() == "version "+req.Version+" already exists for channel "+req.Channel {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondCreateReleaseError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, release)
 }
 
+// respondCreateReleaseError maps an error from creating a release to its HTTP response.
+func respondCreateReleaseError(c *gin.Context, err error) {
+	if respondSizeBudgetError(c, err) || respondSecretScanError(c, err) {
+		return
+	}
+	// Check for version conflict (409)
+	if errors.Is(err, services.ErrVersionExists) || errors.Is(err, services.ErrChannelFrozen) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidVersion) || errors.Is(err, services.ErrInvalidVersionRange) || errors.Is(err, services.ErrInvalidRolloutPlan) || errors.Is(err, services.ErrInvalidTargetingRule) || errors.Is(err, services.ErrInvalidSchedule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrBundleTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrHashMismatch) || errors.Is(err, services.ErrInvalidSignature) || errors.Is(err, services.ErrInvalidBundle) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// respondSizeBudgetError responds to an upload over its channel's size budget with the budget exceeded and
+// the figures behind it, so the CLI can show them. Returns false for any other error.
+func respondSizeBudgetError(c *gin.Context, err error) bool {
+	var budgetErr *services.SizeBudgetError
+	if !errors.As(err, &budgetErr) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "size_budget": budgetErr})
+	return true
+}
+
+// respondSecretScanError responds to a release held back by secrets in its bundle with the findings at or
+// above its channel's blocking severity. Returns false for any other error.
+func respondSecretScanError(c *gin.Context, err error) bool {
+	var scanErr *services.SecretScanError
+	if !errors.As(err, &scanErr) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "secret_scan": scanErr})
+	return true
+}
+
 // List retrieves releases with optional filters.
-// GET /releases?app_id=...&channel=...&is_active=...&page=...&per_page=...
+// GET /releases?app_id=...&channel=...&status=...&is_active=...&scheduled=...&page=...&per_page=...
 func (h *ReleaseHandler) List(c *gin.Context) {
 	// Get app_id from JWT context
 	appIDStr, exists := c.Get("app_id")
@@ -98,8 +142,13 @@ func (h *ReleaseHandler) List(c *gin.Context) {
 		val := query.IsActive == "true"
 		isActive = &val
 	}
+	var scheduled *bool
+	if query.Scheduled != "" {
+		val := query.Scheduled == "true"
+		scheduled = &val
+	}
 
-	releases, total, err := h.service.List(appID, query.Channel, isActive, query.Page, query.PerPage)
+	releases, total, err := h.service.List(c.Request.Context(), appID, query.Channel, query.Status, isActive, scheduled, query.Page, query.PerPage)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -116,15 +165,24 @@ func (h *ReleaseHandler) List(c *gin.Context) {
 // GetByID retrieves a single release detail.
 // GET /releases/:id
 func (h *ReleaseHandler) GetByID(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
 		return
 	}
 
-	release, err := h.service.GetByID(id)
+	release, err := h.service.GetByID(c.Request.Context(), appID, id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Release not found"})
+		if errors.Is(err, services.ErrReleaseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Release not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -132,16 +190,44 @@ func (h *ReleaseHandler) GetByID(c *gin.Context) {
 }
 
 // Rollback designates a previous version as the active release.
+// With {"to_embedded": true} the release is pulled and devices revert to their embedded bundle instead.
 // PATCH /releases/:id/rollback
 func (h *ReleaseHandler) Rollback(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
 		return
 	}
 
-	release, err := h.service.Rollback(id)
+	// The body is optional
+	var req models.RollbackRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var release *models.Release
+	if req.ToEmbedded {
+		release, err = h.service.RollbackToEmbedded(appID, id)
+	} else {
+		release, err = h.service.Rollback(appID, id)
+	}
 	if err != nil {
+		if errors.Is(err, services.ErrReleaseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTransition) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -155,6 +241,11 @@ func (h *ReleaseHandler) Rollback(c *gin.Context) {
 // UpdateRollout adjusts the rollout percentage for a release.
 // PATCH /releases/:id/rollout
 func (h *ReleaseHandler) UpdateRollout(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
@@ -167,8 +258,26 @@ func (h *ReleaseHandler) UpdateRollout(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UpdateRollout(c.Request.Context(), id, req.RolloutPercentage); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Reshuffle first: a frozen channel rejects it before the rollout change is applied or deferred
+	if req.ReshuffleCohort {
+		if err := h.service.ReshuffleCohort(c.Request.Context(), appID, id); err != nil {
+			respondRolloutUpdateError(c, err)
+			return
+		}
+	}
+
+	release, err := h.service.UpdateRollout(c.Request.Context(), appID, id, req.RolloutPercentage)
+	if err != nil {
+		respondRolloutUpdateError(c, err)
+		return
+	}
+
+	if release.PendingRollout != nil {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":                    "Channel is frozen; rollout change deferred",
+			"rollout_percentage":         release.RolloutPercentage,
+			"pending_rollout_percentage": *release.PendingRollout,
+		})
 		return
 	}
 
@@ -178,25 +287,431 @@ func (h *ReleaseHandler) UpdateRollout(c *gin.Context) {
 	})
 }
 
-// Archive soft-deletes a release.
+// Promote creates a release in another channel from an existing one, reusing its stored bundle and patches.
+// POST /releases/:id/promote
+func (h *ReleaseHandler) Promote(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	var req models.PromoteReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	release, err := h.service.Promote(c.Request.Context(), appID, id, &req, c.GetString("subject"), c.ClientIP())
+	if err != nil {
+		if respondSecretScanError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrReleaseNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrVersionExists), errors.Is(err, services.ErrChannelFrozen):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidPromotion), errors.Is(err, services.ErrInvalidRolloutPlan), errors.Is(err, services.ErrInvalidSchedule):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, release)
+}
+
+// SetStatus moves a release to another lifecycle status: publish, schedule or unschedule a draft,
+// pause or resume a live release, or archive it.
+// PUT /releases/:id/status
+func (h *ReleaseHandler) SetStatus(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	var req models.SetReleaseStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	release, err := h.service.SetStatus(c.Request.Context(), appID, id, &req, c.GetString("subject"), c.ClientIP())
+	if err != nil {
+		if respondSecretScanError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrReleaseNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidTransition), errors.Is(err, services.ErrChannelFrozen):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidSchedule):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, release)
+}
+
+// History returns the lifecycle transitions of a release.
+// GET /releases/:id/history
+func (h *ReleaseHandler) History(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	transitions, err := h.service.History(appID, id)
+	if err != nil {
+		if errors.Is(err, services.ErrReleaseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transitions)
+}
+
+// PatchJobs returns the server-side patch generation jobs of a release.
+// GET /releases/:id/patch-jobs
+func (h *ReleaseHandler) PatchJobs(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	jobs, err := h.service.PatchJobs(appID, id)
+	if err != nil {
+		if errors.Is(err, services.ErrReleaseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// Manifest returns the files of a release's bundle with their sizes and hashes, recorded at upload.
+// GET /releases/:id/manifest
+func (h *ReleaseHandler) Manifest(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	manifest, err := h.service.Manifest(appID, id)
+	if err != nil {
+		if errors.Is(err, services.ErrReleaseNotFound) || errors.Is(err, services.ErrManifestNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, manifest)
+}
+
+// Secrets returns the credentials and likely keys found in a release's bundle when it was uploaded, and
+// whether they keep it from being published in its channel.
+// GET /releases/:id/secrets
+func (h *ReleaseHandler) Secrets(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	scan, err := h.service.SecretScan(appID, id)
+	if err != nil {
+		if errors.Is(err, services.ErrReleaseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, scan)
+}
+
+// Diff compares the files of a release's bundle with those of another release.
+// GET /releases/:id/diff?against=:otherId
+func (h *ReleaseHandler) Diff(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+	against, err := uuid.Parse(c.Query("against"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "against must be the ID of the release to compare with"})
+		return
+	}
+
+	diff, err := h.service.DiffReleases(appID, id, against)
+	if err != nil {
+		if errors.Is(err, services.ErrReleaseNotFound) || errors.Is(err, services.ErrManifestNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// GeneratePatches queues generation of the patches a release is missing and returns the jobs queued.
+// POST /releases/:id/patch-jobs
+func (h *ReleaseHandler) GeneratePatches(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	jobs, err := h.service.GeneratePatches(appID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrReleaseNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPatchGenerationUnavailable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, jobs)
+}
+
+// Approve records the caller's approval of a release awaiting approval; the last required approval publishes it.
+// POST /releases/:id/approve
+func (h *ReleaseHandler) Approve(c *gin.Context) {
+	h.review(c, h.service.Approve)
+}
+
+// Reject records the caller's rejection of a release awaiting approval.
+// POST /releases/:id/reject
+func (h *ReleaseHandler) Reject(c *gin.Context) {
+	h.review(c, h.service.Reject)
+}
+
+// review handles an approval or rejection by a dashboard user.
+func (h *ReleaseHandler) review(c *gin.Context, decide func(context.Context, uuid.UUID, uuid.UUID, *models.ReviewReleaseRequest, string, string) (*models.Release, error)) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+	reviewer, ok := reviewerFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	// The body is optional
+	var req models.ReviewReleaseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	release, err := decide(c.Request.Context(), appID, id, &req, reviewer, c.ClientIP())
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, release)
+}
+
+// OverrideApproval publishes a release awaiting approval without its remaining approvals.
+// POST /releases/:id/approval-override
+func (h *ReleaseHandler) OverrideApproval(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+	reviewer, ok := reviewerFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	var req models.OverrideApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	release, err := h.service.OverrideApproval(c.Request.Context(), appID, id, &req, reviewer, c.ClientIP())
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, release)
+}
+
+// Approvals returns the review decisions on a release.
+// GET /releases/:id/approvals
+func (h *ReleaseHandler) Approvals(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	approvals, err := h.service.Approvals(appID, id)
+	if err != nil {
+		if errors.Is(err, services.ErrReleaseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, approvals)
+}
+
+// reviewerFromContext returns the identity of the dashboard user reviewing a release. CLI tokens are
+// issued per app rather than per person, so they cannot approve, reject or override.
+// It writes the error response and returns false for any other caller.
+func reviewerFromContext(c *gin.Context) (string, bool) {
+	subject := c.GetString("subject")
+	if c.GetString("role") != "user" || subject == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Release reviews require a dashboard user"})
+		return "", false
+	}
+	return subject, true
+}
+
+func respondReviewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrReleaseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSelfApproval):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAlreadyReviewed), errors.Is(err, services.ErrInvalidTransition), errors.Is(err, services.ErrChannelFrozen):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// Archive moves a release to archived: it stops being served and can no longer be published. Its record
+// and history are kept.
 // DELETE /releases/:id
 func (h *ReleaseHandler) Archive(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
 		return
 	}
 
-	if err := h.service.Archive(c.Request.Context(), id); err != nil {
+	if err := h.service.Archive(c.Request.Context(), appID, id); err != nil {
+		if errors.Is(err, services.ErrReleaseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTransition) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Release archived"})
 }
+
 // AddPatch handles uploading a patch for an existing release.
 // POST /releases/:id/patches
 func (h *ReleaseHandler) AddPatch(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
 	releaseID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
@@ -217,11 +732,41 @@ func (h *ReleaseHandler) AddPatch(c *gin.Context) {
 	}
 	defer file.Close()
 
-	patch, err := h.service.AddPatch(c.Request.Context(), releaseID, &req, file)
+	patch, err := h.service.AddPatch(c.Request.Context(), appID, releaseID, &req, file, c.GetString("subject"), c.ClientIP())
 	if err != nil {
+		if respondSizeBudgetError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrReleaseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidVersion) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrBundleTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrHashMismatch) || errors.Is(err, services.ErrInvalidSignature) || errors.Is(err, services.ErrPatchVerificationFailed) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, patch)
 }
+
+func respondRolloutUpdateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrReleaseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrChannelFrozen):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/services"
+)
+
+// RolloutHandler handles staged rollout plan endpoints.
+type RolloutHandler struct {
+	service *services.RolloutService
+}
+
+// NewRolloutHandler creates a new RolloutHandler.
+func NewRolloutHandler(service *services.RolloutService) *RolloutHandler {
+	return &RolloutHandler{service: service}
+}
+
+// GetPlan returns the rollout plan of a release.
+// GET /releases/:id/rollout-plan
+func (h *RolloutHandler) GetPlan(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+	releaseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	plan, err := h.service.GetPlan(appID, releaseID)
+	if err != nil {
+		respondRolloutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// SetPlan attaches a rollout plan to a release, replacing any existing plan.
+// PUT /releases/:id/rollout-plan
+func (h *RolloutHandler) SetPlan(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+	releaseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	var req models.SetRolloutPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.service.SetPlan(c.Request.Context(), appID, releaseID, req.Steps, c.GetString("subject"))
+	if err != nil {
+		respondRolloutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// Pause stops the scheduler from advancing a release's rollout plan.
+// POST /releases/:id/rollout-plan/pause
+func (h *RolloutHandler) Pause(c *gin.Context) {
+	h.transition(c, h.service.Pause)
+}
+
+// Resume restarts a paused rollout plan.
+// POST /releases/:id/rollout-plan/resume
+func (h *RolloutHandler) Resume(c *gin.Context) {
+	h.transition(c, h.service.Resume)
+}
+
+// Skip moves a rollout plan to its next step without waiting for the hold to elapse.
+// POST /releases/:id/rollout-plan/skip
+func (h *RolloutHandler) Skip(c *gin.Context) {
+	h.transition(c, h.service.Skip)
+}
+
+func (h *RolloutHandler) transition(c *gin.Context, action func(ctx context.Context, appID, releaseID uuid.UUID, actor string) (*models.RolloutPlan, error)) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+	releaseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	plan, err := action(c.Request.Context(), appID, releaseID, c.GetString("subject"))
+	if err != nil {
+		respondRolloutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func respondRolloutError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrReleaseNotFound), errors.Is(err, services.ErrRolloutPlanNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidRolloutPlan):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrRolloutPlanState), errors.Is(err, services.ErrChannelFrozen):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -86,6 +87,10 @@ func (h *SecurityHandler) CreateSigningKey(c *gin.Context) {
 	}
 
 	key, err := h.service.CreateSigningKey(appID, &req)
+	if errors.Is(err, services.ErrInvalidSigningKey) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
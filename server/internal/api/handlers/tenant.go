@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// appIDFromContext returns the app the caller is authorized for, as bound by the auth middleware.
+// It writes the error response and returns false when the context carries no valid app.
+func appIDFromContext(c *gin.Context) (uuid.UUID, bool) {
+	appIDStr, exists := c.Get("app_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "App ID not found in token"})
+		return uuid.Nil, false
+	}
+	appID, err := uuid.Parse(appIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid app ID"})
+		return uuid.Nil, false
+	}
+	return appID, true
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hotpatch/server/internal/models"
@@ -27,11 +28,20 @@ func (h *UpdateHandler) CheckForUpdate(c *gin.Context) {
 	// Support both query params and JSON body
 	if c.Request.Method == "GET" {
 		req = models.UpdateCheckRequest{
-			AppID:    c.Query("appId"),
-			DeviceID: c.Query("deviceId"),
-			Version:  c.Query("version"),
-			Platform: c.Query("platform"),
-			Channel:  c.Query("channel"),
+			AppID:         c.Query("appId"),
+			DeviceID:      c.Query("deviceId"),
+			Version:       c.Query("version"),
+			NativeVersion: c.Query("nativeVersion"),
+			Platform:      c.Query("platform"),
+			Channel:       c.Query("channel"),
+			UserID:        c.Query("userId"),
+			BundleHash:    c.Query("bundleHash"),
+			OSVersion:     c.Query("osVersion"),
+			DeviceModel:   c.Query("deviceModel"),
+			Locale:        c.Query("locale"),
+			Country:       c.Query("country"),
+			SDKVersion:    c.Query("sdkVersion"),
+			Tags:          queryTags(c),
 		}
 	} else {
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -40,10 +50,14 @@ func (h *UpdateHandler) CheckForUpdate(c *gin.Context) {
 		}
 	}
 
+	if !bindSDKApp(c, &req.AppID) {
+		return
+	}
+
 	// Validate required fields
-	if req.AppID == "" || req.DeviceID == "" || req.Version == "" || req.Platform == "" || req.Channel == "" {
+	if req.DeviceID == "" || req.Version == "" || req.Platform == "" || req.Channel == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "appId, deviceId, version, platform, and channel are all required",
+			"error": "deviceId, version, platform, and channel are all required",
 		})
 		return
 	}
@@ -56,3 +70,35 @@ func (h *UpdateHandler) CheckForUpdate(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// bindSDKApp checks the app ID sent by the SDK against the app resolved from its X-App-Key.
+// An omitted app ID defaults to the key's app; a mismatch is rejected with 403.
+func bindSDKApp(c *gin.Context, appID *string) bool {
+	keyAppID := c.GetString("app_id")
+	if *appID == "" {
+		*appID = keyAppID
+		return true
+	}
+	if !strings.EqualFold(*appID, keyAppID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "appId does not match the app key"})
+		return false
+	}
+	*appID = keyAppID
+	return true
+}
+
+// queryTags collects custom targeting tags sent as "tag.<key>=<value>" query parameters.
+func queryTags(c *gin.Context) map[string]string {
+	var tags map[string]string
+	for key, values := range c.Request.URL.Query() {
+		name := strings.TrimPrefix(key, "tag.")
+		if name == key || name == "" || len(values) == 0 {
+			continue
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags[name] = values[0]
+	}
+	return tags
+}
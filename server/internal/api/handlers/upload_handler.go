@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/services"
+)
+
+// uploadOffsetHeader carries the offset an appended chunk starts at, and the upload's offset in responses.
+const uploadOffsetHeader = "Upload-Offset"
+
+// UploadHandler handles resumable bundle upload endpoints.
+type UploadHandler struct {
+	service *services.UploadService
+}
+
+// NewUploadHandler creates a new UploadHandler.
+func NewUploadHandler(service *services.UploadService) *UploadHandler {
+	return &UploadHandler{service: service}
+}
+
+// Create starts a resumable upload of a bundle of the given size.
+// POST /uploads
+func (h *UploadHandler) Create(c *gin.Context) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.service.Create(c.Request.Context(), appID, &req, c.GetString("subject"))
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.Header(uploadOffsetHeader, "0")
+	c.JSON(http.StatusCreated, session)
+}
+
+// Get returns an upload, including the offset to resume appending at.
+// GET /uploads/:id
+func (h *UploadHandler) Get(c *gin.Context) {
+	appID, id, ok := uploadIDFromRequest(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.service.Get(appID, id)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+	c.JSON(http.StatusOK, session)
+}
+
+// Append stores the request body as the next chunk of an upload, starting at the Upload-Offset header.
+// PATCH /uploads/:id
+func (h *UploadHandler) Append(c *gin.Context) {
+	appID, id, ok := uploadIDFromRequest(c)
+	if !ok {
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": uploadOffsetHeader + " header must be a non-negative integer"})
+		return
+	}
+
+	session, err := h.service.Append(c.Request.Context(), appID, id, offset, c.Request.Body)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+	c.JSON(http.StatusOK, session)
+}
+
+// Complete assembles an upload once all of its bytes have been appended.
+// POST /uploads/:id/complete
+func (h *UploadHandler) Complete(c *gin.Context) {
+	appID, id, ok := uploadIDFromRequest(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.service.Complete(c.Request.Context(), appID, id)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// Abort discards an upload.
+// DELETE /uploads/:id
+func (h *UploadHandler) Abort(c *gin.Context) {
+	appID, id, ok := uploadIDFromRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Abort(c.Request.Context(), appID, id); err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Upload discarded"})
+}
+
+// CreateRelease creates a release from a completed upload. The body is the release metadata
+// accepted by POST /releases; its size is taken from the upload.
+// POST /uploads/:id/release
+func (h *UploadHandler) CreateRelease(c *gin.Context) {
+	appID, id, ok := uploadIDFromRequest(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateReleaseRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid metadata JSON: " + err.Error()})
+		return
+	}
+
+	release, err := h.service.CreateRelease(c.Request.Context(), appID, id, &req, c.GetString("subject"), c.ClientIP())
+	if err != nil {
+		if errors.Is(err, services.ErrUploadNotFound) || errors.Is(err, services.ErrUploadConflict) {
+			respondUploadError(c, err)
+			return
+		}
+		respondCreateReleaseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, release)
+}
+
+// uploadIDFromRequest returns the app from the token and the upload ID from the path, or responds with an error.
+func uploadIDFromRequest(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	appID, ok := appIDFromContext(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid upload ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return appID, id, true
+}
+
+func respondUploadError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUploadNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUploadConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidChunk):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrBundleTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,11 +1,14 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/services"
 )
 
 // JWTClaims defines the custom JWT claims.
@@ -15,8 +18,14 @@ type JWTClaims struct {
 	jwt.RegisteredClaims
 }
 
+// AppAccessAuthorizer decides whether a dashboard user may act on an app.
+type AppAccessAuthorizer interface {
+	AuthorizeAppAccess(userID, appID uuid.UUID) error
+}
+
 // AuthMiddleware validates JWT tokens for CLI and dashboard access.
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// Dashboard users select an app with the X-App-ID header, which is only honoured for apps they own.
+func AuthMiddleware(jwtSecret string, apps AppAccessAuthorizer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -67,7 +76,27 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		// If a dashboard user provides an X-App-ID header, use it instead of the User ID in the token
 		headerAppID := c.GetHeader("X-App-ID")
 		if headerAppID != "" && role == "user" {
-			appID = headerAppID
+			userID, userErr := uuid.Parse(claims.AppID)
+			selected, appErr := uuid.Parse(headerAppID)
+			if userErr != nil || appErr != nil {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error": services.ErrAppAccessDenied.Error(),
+				})
+				return
+			}
+			if err := apps.AuthorizeAppAccess(userID, selected); err != nil {
+				if errors.Is(err, services.ErrAppAccessDenied) {
+					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+						"error": err.Error(),
+					})
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+					"error": "Unable to verify app access",
+				})
+				return
+			}
+			appID = selected.String()
 		}
 
 		c.Set("app_id", appID)
@@ -78,9 +107,10 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 	}
 }
 
-// AppKeyMiddleware validates app API keys for SDK update-check requests.
-// This is a lighter-weight auth for the high-throughput /update/check endpoint.
-func AppKeyMiddleware() gin.HandlerFunc {
+// AppKeyMiddleware validates app API keys for SDK requests.
+// This is a lighter-weight auth for the high-throughput SDK endpoints: the key is resolved
+// (through the app key cache) to its app, which is bound to the request as "app_id".
+func AppKeyMiddleware(appKeys *services.AppKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		apiKey := c.GetHeader("X-App-Key")
 		if apiKey == "" {
@@ -90,8 +120,22 @@ func AppKeyMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// The API key is validated against the apps table in the handler
-		c.Set("api_key", apiKey)
+		appID, err := appKeys.ResolveAppKey(c.Request.Context(), apiKey)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAppKey) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"error": "Invalid app key",
+				})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Unable to validate app key",
+			})
+			return
+		}
+
+		c.Set("app_id", appID.String())
+		c.Set("role", "sdk")
 		c.Next()
 	}
 }
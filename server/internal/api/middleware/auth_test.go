@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/services"
+)
+
+const testJWTSecret = "test-secret"
+
+// fakeAppAccess grants access to the apps listed for each user.
+type fakeAppAccess map[uuid.UUID][]uuid.UUID
+
+func (f fakeAppAccess) AuthorizeAppAccess(userID, appID uuid.UUID) error {
+	for _, owned := range f[userID] {
+		if owned == appID {
+			return nil
+		}
+	}
+	return services.ErrAppAccessDenied
+}
+
+func signTestToken(t *testing.T, appID, role string) string {
+	t.Helper()
+	claims := &JWTClaims{
+		AppID: appID,
+		Role:  role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+// serveAuth runs a request through AuthMiddleware and returns the status and the bound app_id.
+func serveAuth(t *testing.T, access AppAccessAuthorizer, token, headerAppID string) (int, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(AuthMiddleware(testJWTSecret, access))
+
+	var boundAppID string
+	r.GET("/test", func(c *gin.Context) {
+		boundAppID = c.GetString("app_id")
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if headerAppID != "" {
+		req.Header.Set("X-App-ID", headerAppID)
+	}
+	r.ServeHTTP(w, req)
+	return w.Code, boundAppID
+}
+
+// ── AuthMiddleware Tenant Tests ───────────────────────────────
+
+func TestAuthMiddleware_OwnerCanSelectApp(t *testing.T) {
+	userID, appID := uuid.New(), uuid.New()
+	access := fakeAppAccess{userID: {appID}}
+
+	code, bound := serveAuth(t, access, signTestToken(t, userID.String(), "user"), appID.String())
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if bound != appID.String() {
+		t.Errorf("app_id = %q, want %q", bound, appID)
+	}
+}
+
+func TestAuthMiddleware_RejectsAppOfAnotherUser(t *testing.T) {
+	owner, attacker, appID := uuid.New(), uuid.New(), uuid.New()
+	access := fakeAppAccess{owner: {appID}, attacker: {uuid.New()}}
+
+	code, _ := serveAuth(t, access, signTestToken(t, attacker.String(), "user"), appID.String())
+	if code != http.StatusForbidden {
+		t.Errorf("expected 403 for another user's app, got %d", code)
+	}
+}
+
+func TestAuthMiddleware_RejectsMalformedAppHeader(t *testing.T) {
+	userID := uuid.New()
+	code, _ := serveAuth(t, fakeAppAccess{}, signTestToken(t, userID.String(), "user"), "not-a-uuid")
+	if code != http.StatusForbidden {
+		t.Errorf("expected 403 for malformed X-App-ID, got %d", code)
+	}
+}
+
+func TestAuthMiddleware_CLITokenIgnoresAppHeader(t *testing.T) {
+	tokenApp, otherApp := uuid.New(), uuid.New()
+
+	code, bound := serveAuth(t, fakeAppAccess{}, signTestToken(t, tokenApp.String(), "cli"), otherApp.String())
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if bound != tokenApp.String() {
+		t.Errorf("CLI token must stay bound to its own app: app_id = %q, want %q", bound, tokenApp)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/hotpatch/server/internal/api/handlers"
 	"github.com/hotpatch/server/internal/api/middleware"
+	"github.com/hotpatch/server/internal/services"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
@@ -21,10 +22,15 @@ func SetupRoutes(
 	redisClient *redis.Client,
 	db *gorm.DB,
 	startTime time.Time,
+	appKeyService *services.AppKeyService,
+	settingsService *services.SettingsService,
 	authHandler *handlers.AuthHandler,
 	releaseHandler *handlers.ReleaseHandler,
+	uploadHandler *handlers.UploadHandler,
+	rolloutHandler *handlers.RolloutHandler,
 	updateHandler *handlers.UpdateHandler,
 	deviceHandler *handlers.DeviceHandler,
+	overrideHandler *handlers.OverrideHandler,
 	channelHandler *handlers.ChannelHandler,
 	analyticsHandler *handlers.AnalyticsHandler,
 	securityHandler *handlers.SecurityHandler,
@@ -41,8 +47,8 @@ func SetupRoutes(
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     allowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-App-Key", "X-App-ID"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-App-Key", "X-App-ID", "Upload-Offset"},
+		ExposeHeaders:    []string{"Content-Length", "Upload-Offset"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -98,6 +104,7 @@ func SetupRoutes(
 	// ── SDK routes (App Key auth — high throughput) ──
 	sdk := r.Group("/")
 	sdk.Use(middleware.RateLimitMiddleware(60, 1*time.Minute, redisClient)) // 60 req/min per IP
+	sdk.Use(middleware.AppKeyMiddleware(appKeyService))
 	{
 		sdk.GET("/update/check", updateHandler.CheckForUpdate)
 		sdk.POST("/devices", deviceHandler.RegisterDevice)
@@ -106,7 +113,7 @@ func SetupRoutes(
 
 	// ── CLI / Dashboard routes (JWT required) ──
 	api := r.Group("/")
-	api.Use(middleware.AuthMiddleware(jwtSecret))
+	api.Use(middleware.AuthMiddleware(jwtSecret, settingsService))
 	{
 		// Releases
 		api.POST("/releases", releaseHandler.Create)
@@ -116,10 +123,45 @@ func SetupRoutes(
 		api.PATCH("/releases/:id/rollout", releaseHandler.UpdateRollout)
 		api.DELETE("/releases/:id", releaseHandler.Archive)
 		api.POST("/releases/:id/patches", releaseHandler.AddPatch)
+		api.POST("/releases/:id/promote", releaseHandler.Promote)
+		api.PUT("/releases/:id/status", releaseHandler.SetStatus)
+		api.GET("/releases/:id/history", releaseHandler.History)
+		api.GET("/releases/:id/manifest", releaseHandler.Manifest)
+		api.GET("/releases/:id/diff", releaseHandler.Diff)
+		api.GET("/releases/:id/secrets", releaseHandler.Secrets)
+		api.GET("/releases/:id/patch-jobs", releaseHandler.PatchJobs)
+		api.POST("/releases/:id/patch-jobs", releaseHandler.GeneratePatches)
+
+		// Resumable bundle uploads
+		api.POST("/uploads", uploadHandler.Create)
+		api.GET("/uploads/:id", uploadHandler.Get)
+		api.PATCH("/uploads/:id", uploadHandler.Append)
+		api.POST("/uploads/:id/complete", uploadHandler.Complete)
+		api.DELETE("/uploads/:id", uploadHandler.Abort)
+		api.POST("/uploads/:id/release", uploadHandler.CreateRelease)
+
+		// Release approvals
+		api.GET("/releases/:id/approvals", releaseHandler.Approvals)
+		api.POST("/releases/:id/approve", releaseHandler.Approve)
+		api.POST("/releases/:id/reject", releaseHandler.Reject)
+		api.POST("/releases/:id/approval-override", releaseHandler.OverrideApproval)
+
+		// Staged rollout plans
+		api.GET("/releases/:id/rollout-plan", rolloutHandler.GetPlan)
+		api.PUT("/releases/:id/rollout-plan", rolloutHandler.SetPlan)
+		api.POST("/releases/:id/rollout-plan/pause", rolloutHandler.Pause)
+		api.POST("/releases/:id/rollout-plan/resume", rolloutHandler.Resume)
+		api.POST("/releases/:id/rollout-plan/skip", rolloutHandler.Skip)
 
 		// Devices (dashboard view)
 		api.GET("/devices", deviceHandler.ListDevices)
 
+		// Device overrides (pin a device to a release)
+		api.GET("/device-overrides", overrideHandler.List)
+		api.GET("/device-overrides/:device_id", overrideHandler.Get)
+		api.PUT("/device-overrides/:device_id", overrideHandler.Set)
+		api.DELETE("/device-overrides/:device_id", overrideHandler.Delete)
+
 		// Release stats
 		api.GET("/releases/:id/stats", deviceHandler.GetInstallationStats)
 
@@ -159,7 +201,7 @@ func SetupRoutes(
 
 	// ── Superadmin Panel roots (JWT + Superadmin Role required) ──
 	admin := r.Group("/admin")
-	admin.Use(middleware.AuthMiddleware(jwtSecret))
+	admin.Use(middleware.AuthMiddleware(jwtSecret, settingsService))
 	{
 		// Only allow superadmin role
 		admin.Use(func(c *gin.Context) {
@@ -27,9 +27,24 @@ type Config struct {
 	AWSAccessKey string
 	AWSSecretKey string
 
+	// Bundle delivery
+	CDNBaseURL        string // Optional CDN in front of the bucket
+	DownloadURLExpiry int    // minutes a presigned download URL stays valid
+
+	// Bundle uploads
+	MaxBundleSize    int // MiB; the largest bundle any tier may upload
+	UploadSessionTTL int // hours a resumable upload stays open before it is garbage collected
+
+	// Patch generation
+	PatchGenerationBases int // most recent releases of a channel each new release is diffed against; 0 disables
+	PatchMaxSizePercent  int // generated patches above this share of the full bundle are not kept
+
 	// Redis (optional)
 	RedisURL string
 
+	// Background jobs
+	SchedulerInterval int // seconds between scheduler ticks
+
 	// App
 	Environment        string // "development" | "production"
 	BackendURL         string
@@ -54,27 +69,34 @@ func Load() (*Config, error) {
 	_ = godotenv.Load()
 
 	cfg := &Config{
-		Port:                getEnv("PORT", "8080"),
-		DatabaseURL:         getEnv("DATABASE_URL", ""),
-		JWTSecret:           getEnv("JWT_SECRET", ""),
-		JWTExpiration:       getEnvInt("JWT_EXPIRATION_HOURS", 72),
-		S3Bucket:            getEnv("S3_BUCKET", "hotpatch-bundles"),
-		S3Endpoint:          getEnv("S3_ENDPOINT", ""),
-		S3Region:            getEnv("S3_REGION", "auto"),
-		AWSAccessKey:        getEnv("AWS_ACCESS_KEY_ID", ""),
-		AWSSecretKey:        getEnv("AWS_SECRET_ACCESS_KEY", ""),
-		RedisURL:            getEnv("REDIS_URL", ""),
-		Environment:         getEnv("ENVIRONMENT", "development"),
-		BackendURL:          getEnv("BACKEND_URL", "http://localhost:8080"),
-		FrontendURL:         getEnv("FRONTEND_URL", "http://localhost:3000"),
-		SuperadminEmail:     getEnv("SUPERADMIN_EMAIL", "admin@hotpatch.io"),
-		SuperadminPassword:  getEnv("SUPERADMIN_PASSWORD", "admin123"),
-		GoogleClientID:      getEnv("GOOGLE_CLIENT_ID", ""),
-		GoogleClientSecret:  getEnv("GOOGLE_CLIENT_SECRET", ""),
-		StripeSecretKey:     getEnv("STRIPE_SECRET_KEY", ""),
-		StripeWebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
-		StripePriceIDPro:    getEnv("STRIPE_PRICE_ID_PRO", ""),
-		StripePriceIDEnt:    getEnv("STRIPE_PRICE_ID_ENTERPRISE", ""),
+		Port:                 getEnv("PORT", "8080"),
+		DatabaseURL:          getEnv("DATABASE_URL", ""),
+		JWTSecret:            getEnv("JWT_SECRET", ""),
+		JWTExpiration:        getEnvInt("JWT_EXPIRATION_HOURS", 72),
+		S3Bucket:             getEnv("S3_BUCKET", "hotpatch-bundles"),
+		S3Endpoint:           getEnv("S3_ENDPOINT", ""),
+		S3Region:             getEnv("S3_REGION", "auto"),
+		AWSAccessKey:         getEnv("AWS_ACCESS_KEY_ID", ""),
+		AWSSecretKey:         getEnv("AWS_SECRET_ACCESS_KEY", ""),
+		CDNBaseURL:           getEnv("CDN_BASE_URL", ""),
+		DownloadURLExpiry:    getEnvInt("DOWNLOAD_URL_EXPIRY_MINUTES", 15),
+		MaxBundleSize:        getEnvInt("MAX_BUNDLE_SIZE_MB", 200),
+		UploadSessionTTL:     getEnvInt("UPLOAD_SESSION_TTL_HOURS", 24),
+		PatchGenerationBases: getEnvInt("PATCH_GENERATION_BASES", 3),
+		PatchMaxSizePercent:  getEnvInt("PATCH_MAX_SIZE_PERCENT", 50),
+		RedisURL:             getEnv("REDIS_URL", ""),
+		SchedulerInterval:    getEnvInt("SCHEDULER_INTERVAL_SECONDS", 30),
+		Environment:          getEnv("ENVIRONMENT", "development"),
+		BackendURL:           getEnv("BACKEND_URL", "http://localhost:8080"),
+		FrontendURL:          getEnv("FRONTEND_URL", "http://localhost:3000"),
+		SuperadminEmail:      getEnv("SUPERADMIN_EMAIL", "admin@hotpatch.io"),
+		SuperadminPassword:   getEnv("SUPERADMIN_PASSWORD", "admin123"),
+		GoogleClientID:       getEnv("GOOGLE_CLIENT_ID", ""),
+		GoogleClientSecret:   getEnv("GOOGLE_CLIENT_SECRET", ""),
+		StripeSecretKey:      getEnv("STRIPE_SECRET_KEY", ""),
+		StripeWebhookSecret:  getEnv("STRIPE_WEBHOOK_SECRET", ""),
+		StripePriceIDPro:     getEnv("STRIPE_PRICE_ID_PRO", ""),
+		StripePriceIDEnt:     getEnv("STRIPE_PRICE_ID_ENTERPRISE", ""),
 	}
 
 	if cfg.DatabaseURL == "" {
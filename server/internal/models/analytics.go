@@ -30,6 +30,8 @@ type DailyMetric struct {
 type ReleaseAnalytics struct {
 	ReleaseID       string            `json:"release_id"`
 	Version         string            `json:"version"`
+	Channel         string            `json:"channel"`
+	PromotedFrom    *string           `json:"promoted_from"` // Set when the build was promoted from another channel
 	StatusCounts    map[string]int64  `json:"status_counts"` // downloaded, installed, failed, rolled_back
 	AdoptionPercent float64           `json:"adoption_percent"`
 	InstallTimeline []DailyMetric     `json:"install_timeline"`
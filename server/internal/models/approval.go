@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Release approval decisions.
+const (
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+	ApprovalOverride = "override" // published without the remaining approvals
+)
+
+// ReleaseApproval records one reviewer's decision on a release awaiting approval.
+// Each reviewer decides once per release.
+type ReleaseApproval struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	AppID     uuid.UUID `json:"app_id" gorm:"type:uuid;not null;index"`
+	ReleaseID uuid.UUID `json:"release_id" gorm:"type:uuid;not null;uniqueIndex:idx_release_approvals_release_actor"`
+	Actor     string    `json:"actor" gorm:"not null;size:255;uniqueIndex:idx_release_approvals_release_actor"`
+	Decision  string    `json:"decision" gorm:"not null;size:20"` // "approved" | "rejected" | "override"
+	Comment   string    `json:"comment" gorm:"not null;size:500;default:''"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// ReviewReleaseRequest is the optional request body for approving or rejecting a release.
+type ReviewReleaseRequest struct {
+	Comment string `json:"comment" binding:"max=500"`
+}
+
+// OverrideApprovalRequest is the request body for publishing a release without its remaining approvals.
+type OverrideApprovalRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
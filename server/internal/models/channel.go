@@ -18,6 +18,27 @@ type Channel struct {
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 
+	// Health gate: halt the channel's active releases when installs go bad
+	HealthGateEnabled     bool    `json:"health_gate_enabled" gorm:"not null;default:false"`
+	HealthMaxFailureRate  float64 `json:"health_max_failure_rate" gorm:"not null;default:5"`     // % of installs reported "failed"
+	HealthMaxRollbackRate float64 `json:"health_max_rollback_rate" gorm:"not null;default:2"`    // % of installs reported "rolled_back"
+	HealthMinSampleSize   int     `json:"health_min_sample_size" gorm:"not null;default:100"`    // installs required before the gate is evaluated
+	HealthAction          string  `json:"health_action" gorm:"not null;size:20;default:'pause'"` // "pause" | "rollback"
+
+	// Freeze windows: no activations or rollout changes while one is open
+	FreezeWindows []FreezeWindow `json:"freeze_windows" gorm:"serializer:json;type:text"`
+	FreezeAction  string         `json:"freeze_action" gorm:"not null;size:20;default:'reject'"` // "reject" | "defer"
+
+	// Approvals: releases wait for this many reviewers other than the uploader before going live; 0 = off
+	RequiredApprovals int `json:"required_approvals" gorm:"not null;default:0"`
+
+	// Size budget: uploads over it are refused unless overridden; 0 = no limit
+	MaxBundleSize        int64   `json:"max_bundle_size" gorm:"not null;default:0"`         // bytes a bundle or patch may take
+	MaxSizeGrowthPercent float64 `json:"max_size_growth_percent" gorm:"not null;default:0"` // % a bundle may grow over the channel's active release
+
+	// Secret scanning: bundles with a finding at or above this severity are not published; "off" = never blocked
+	SecretBlockSeverity string `json:"secret_block_severity" gorm:"not null;size:20;default:'off'"`
+
 	App      App       `json:"-" gorm:"foreignKey:AppID"`
 }
 
@@ -35,4 +56,39 @@ type UpdateChannelRequest struct {
 	Description *string `json:"description"`
 	Color       *string `json:"color"`
 	AutoRollout *bool   `json:"auto_rollout"`
+
+	HealthGateEnabled     *bool    `json:"health_gate_enabled"`
+	HealthMaxFailureRate  *float64 `json:"health_max_failure_rate" binding:"omitempty,min=0,max=100"`
+	HealthMaxRollbackRate *float64 `json:"health_max_rollback_rate" binding:"omitempty,min=0,max=100"`
+	HealthMinSampleSize   *int     `json:"health_min_sample_size" binding:"omitempty,min=1"`
+	HealthAction          *string  `json:"health_action" binding:"omitempty,oneof=pause rollback"`
+
+	FreezeWindows *[]FreezeWindow `json:"freeze_windows"`
+	FreezeAction  *string         `json:"freeze_action" binding:"omitempty,oneof=reject defer"`
+
+	RequiredApprovals *int `json:"required_approvals" binding:"omitempty,min=0,max=5"`
+
+	MaxBundleSize        *int64   `json:"max_bundle_size" binding:"omitempty,min=0"`
+	MaxSizeGrowthPercent *float64 `json:"max_size_growth_percent" binding:"omitempty,min=0"`
+
+	SecretBlockSeverity *string `json:"secret_block_severity" binding:"omitempty,oneof=off low medium high critical"`
+}
+
+// Channel health gate actions.
+const (
+	HealthActionPause    = "pause"
+	HealthActionRollback = "rollback"
+)
+
+// ReleaseHealth summarises the installations reported for a release, as evaluated by the channel health gate.
+type ReleaseHealth struct {
+	ReleaseID    uuid.UUID `json:"release_id"`
+	Version      string    `json:"version"`
+	Channel      string    `json:"channel"`
+	SampleSize   int64     `json:"sample_size"`
+	Applied      int64     `json:"applied"`
+	Failed       int64     `json:"failed"`
+	RolledBack   int64     `json:"rolled_back"`
+	FailureRate  float64   `json:"failure_rate"`  // %
+	RollbackRate float64   `json:"rollback_rate"` // %
 }
@@ -24,7 +24,7 @@ type Installation struct {
 	ID          uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	DeviceID    uuid.UUID `json:"device_id" gorm:"type:uuid;not null;index"`
 	ReleaseID   uuid.UUID `json:"release_id" gorm:"type:uuid;not null;index"`
-	Status       string    `json:"status" gorm:"not null;size:20"` // "applied" | "failed" | "rolled_back"
+	Status       string    `json:"status" gorm:"not null;size:20"` // "applied" | "failed" | "rolled_back" | "reverted"
 	IsPatch      bool      `json:"is_patch" gorm:"not null;default:false"`
 	DownloadSize int64     `json:"download_size" gorm:"not null;default:0"`
 	InstalledAt  time.Time `json:"installed_at" gorm:"autoCreateTime"`
@@ -36,7 +36,7 @@ type Installation struct {
 // RegisterDeviceRequest is the request body for device registration.
 type RegisterDeviceRequest struct {
 	DeviceID       string `json:"device_id" binding:"required"`
-	AppID          string `json:"app_id" binding:"required"`
+	AppID          string `json:"app_id"` // Defaults to the app of the X-App-Key
 	Platform       string `json:"platform" binding:"required,oneof=android ios"`
 	CurrentVersion string `json:"current_version"`
 }
@@ -45,30 +45,66 @@ type RegisterDeviceRequest struct {
 type ReportInstallationRequest struct {
 	DeviceID  string `json:"device_id" binding:"required"`
 	ReleaseID    string `json:"release_id" binding:"required"`
-	Status       string `json:"status" binding:"required,oneof=applied failed rolled_back"`
+	Status       string `json:"status" binding:"required,oneof=applied failed rolled_back reverted"`
 	IsPatch      bool   `json:"is_patch"`
 	DownloadSize int64  `json:"download_size"`
+	RevertID     string `json:"revert_id"` // Set when reporting the outcome of a server-issued revert
 }
 
 // UpdateCheckRequest is the request body for the /update/check endpoint.
 type UpdateCheckRequest struct {
-	AppID    string `json:"appId" binding:"required"`
-	DeviceID string `json:"deviceId" binding:"required"`
-	Version  string `json:"version" binding:"required"`
-	Platform string `json:"platform" binding:"required,oneof=android ios"`
-	Channel  string `json:"channel" binding:"required"`
+	AppID         string `json:"appId"` // Defaults to the app of the X-App-Key
+	DeviceID      string `json:"deviceId" binding:"required"`
+	Version       string `json:"version" binding:"required"`
+	NativeVersion string `json:"nativeVersion"` // App-store build version of the host binary
+	Platform      string `json:"platform" binding:"required,oneof=android ios"`
+	Channel       string `json:"channel" binding:"required"`
+	UserID        string `json:"userId"`     // Stable app user ID; used for bucketing by releases with bucket_by "user"
+	BundleHash    string `json:"bundleHash"` // SHA-256 of the bundle the device runs; patches are matched on it when sent
+
+	// Targeting attributes
+	OSVersion   string            `json:"osVersion"`
+	DeviceModel string            `json:"deviceModel"`
+	Locale      string            `json:"locale"`
+	Country     string            `json:"country"`
+	SDKVersion  string            `json:"sdkVersion"`
+	Tags        map[string]string `json:"tags"` // Custom key/value tags set by the app
 }
 
+// Update check actions returned to the SDK.
+const (
+	UpdateActionUpdate = "update"
+	UpdateActionRevert = "revert"
+)
+
 // UpdateCheckResponse is the response for the /update/check endpoint.
 type UpdateCheckResponse struct {
-	ID              string `json:"id,omitempty"`
-	UpdateAvailable bool   `json:"updateAvailable"`
-	BundleURL       string `json:"bundleUrl,omitempty"`
-	Hash            string `json:"hash,omitempty"`
-	Signature       string `json:"signature,omitempty"`
-	Mandatory       bool   `json:"mandatory,omitempty"`
-	Version         string `json:"version,omitempty"`
-	IsEncrypted     bool   `json:"isEncrypted,omitempty"`
-	IsPatch         bool   `json:"isPatch,omitempty"`
-	BaseVersion     string `json:"baseVersion,omitempty"`
+	ID               string `json:"id,omitempty"`
+	UpdateAvailable  bool   `json:"updateAvailable"`
+	Action           string `json:"action,omitempty"`           // "update" | "revert"
+	RevertID         string `json:"revertId,omitempty"`         // Echoed back via /installations once the revert is applied
+	RevertToEmbedded bool   `json:"revertToEmbedded,omitempty"` // Revert to the bundle shipped in the binary
+	BundleURL        string `json:"bundleUrl,omitempty"`
+	Hash             string `json:"hash,omitempty"`
+	Signature        string `json:"signature,omitempty"`
+	Mandatory        bool   `json:"mandatory,omitempty"`
+	Version          string `json:"version,omitempty"`
+	IsEncrypted      bool   `json:"isEncrypted,omitempty"`
+	EncryptionFormat string `json:"encryptionFormat,omitempty"` // "aes-256-gcm" or "aes-256-gcm-chunked" when encrypted
+	IsPatch          bool   `json:"isPatch,omitempty"`
+	BaseVersion      string `json:"baseVersion,omitempty"`
+	Size             int64  `json:"size,omitempty"` // Bytes to download: the full bundle, or every patch of the chain
+
+	// Patches to apply in order when no single patch reaches the release. BundleURL is the first of them.
+	// Only offered to SDKs that send their bundle hash.
+	PatchChain []PatchHop `json:"patchChain,omitempty"`
+}
+
+// PatchHop is one patch of a chain. Applying it to the bundle the previous hop produced yields the bundle
+// of release Version, which hashes to Hash.
+type PatchHop struct {
+	URL     string `json:"url"`
+	Hash    string `json:"hash"`
+	Size    int64  `json:"size"`
+	Version string `json:"version"`
 }
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Channel freeze actions: what happens to an activation or rollout change requested during a freeze.
+const (
+	FreezeActionReject = "reject" // refuse the change with 409
+	FreezeActionDefer  = "defer"  // hold the change and let the scheduler apply it when the window closes
+)
+
+// FreezeWindow is a period during which a channel's releases must not change. It is either a weekly
+// recurring window (StartDay/StartTime to EndDay/EndTime in Timezone) or a one-off window (StartsAt to EndsAt).
+type FreezeWindow struct {
+	Name string `json:"name,omitempty"`
+
+	// Weekly recurring window, e.g. "friday" "18:00" to "monday" "08:00"
+	StartDay  string `json:"start_day,omitempty"`
+	StartTime string `json:"start_time,omitempty"` // "HH:MM", 24-hour
+	EndDay    string `json:"end_day,omitempty"`
+	EndTime   string `json:"end_time,omitempty"`
+	Timezone  string `json:"timezone,omitempty"` // IANA name; defaults to UTC
+
+	// One-off window, e.g. a holiday code freeze
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// ChannelFreeze describes a freeze window a channel is currently in.
+type ChannelFreeze struct {
+	Channel string    `json:"channel"`
+	Window  string    `json:"window"`
+	Until   time.Time `json:"until"`
+	Action  string    `json:"action"`
+}
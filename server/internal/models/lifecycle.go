@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Release lifecycle statuses.
+const (
+	ReleaseDraft           = "draft"            // uploaded, not yet published or scheduled
+	ReleasePendingApproval = "pending_approval" // waiting for the approvals its channel requires
+	ReleaseRejected        = "rejected"         // turned down by a reviewer; never published
+	ReleaseScheduled       = "scheduled"        // waiting for its publish_at
+	ReleaseRollingOut      = "rolling_out"      // live below 100%
+	ReleaseCompleted       = "completed"        // live at 100%
+	ReleasePaused          = "paused"           // live for devices that installed it; not offered to new devices
+	ReleaseHalted          = "halted"           // stopped by the channel health gate; like paused
+	ReleaseSuperseded      = "superseded"       // replaced by a newer release; devices keep it
+	ReleaseRolledBack      = "rolled_back"      // pulled; devices running it are reverted
+	ReleaseArchived        = "archived"         // retired for good
+)
+
+// ReleaseTransition records one change of a release's lifecycle status.
+type ReleaseTransition struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	AppID      uuid.UUID `json:"app_id" gorm:"type:uuid;not null;index"`
+	ReleaseID  uuid.UUID `json:"release_id" gorm:"type:uuid;not null;index"`
+	FromStatus string    `json:"from_status" gorm:"not null;size:20;default:''"` // empty for the release's first status
+	ToStatus   string    `json:"to_status" gorm:"not null;size:20"`
+	Actor      string    `json:"actor" gorm:"not null;size:255"`
+	Reason     string    `json:"reason" gorm:"not null;size:500;default:''"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// SetReleaseStatusRequest is the request body for PUT /releases/:id/status.
+// Status is one of draft, scheduled, rolling_out, paused or archived; PublishAt is required for scheduled.
+type SetReleaseStatusRequest struct {
+	Status    string     `json:"status" binding:"required,oneof=draft scheduled rolling_out completed paused archived"`
+	PublishAt *time.Time `json:"publish_at"`
+	Reason    string     `json:"reason" binding:"max=500"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bundle file kinds.
+const (
+	BundleFileBundle    = "bundle"    // the JavaScript or Hermes bytecode bundle the app loads
+	BundleFileSourceMap = "sourcemap" // a source map shipped alongside the bundle
+	BundleFileAsset     = "asset"     // images, fonts and other files the bundle references
+)
+
+// BundleManifest describes the contents of a release bundle, recorded when the bundle is inspected at
+// upload. Bundles encrypted before upload cannot be inspected and have none.
+type BundleManifest struct {
+	ReleaseID     uuid.UUID    `json:"release_id" gorm:"type:uuid;primaryKey"`
+	EntryFile     string       `json:"entry_file" gorm:"not null;size:255"`
+	Hermes        bool         `json:"hermes" gorm:"not null;default:false"`                        // The entry file is Hermes bytecode rather than JavaScript source
+	HermesVersion uint32       `json:"hermes_bytecode_version,omitempty" gorm:"not null;default:0"` // Bytecode version of a Hermes bundle; it must match the app's Hermes runtime
+	FileCount     int          `json:"file_count" gorm:"not null;default:0"`
+	AssetCount    int          `json:"asset_count" gorm:"not null;default:0"`
+	UnpackedSize  int64        `json:"unpacked_size" gorm:"not null;default:0"` // Bytes of all files once extracted
+	Files         []BundleFile `json:"files" gorm:"serializer:json;type:text"`
+
+	SecretFindingsTruncated bool `json:"secret_findings_truncated" gorm:"not null;default:false"` // The secret scan found more than it recorded
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// BundleFile is one file of a bundle archive.
+type BundleFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Hash string `json:"hash"` // SHA256 hex of the extracted file
+	Kind string `json:"kind"`
+}
+
+// ManifestDiff lists how the files of a release's bundle differ from those of another release.
+type ManifestDiff struct {
+	ReleaseID uuid.UUID    `json:"release_id"`
+	AgainstID uuid.UUID    `json:"against_id"`
+	Added     []FileChange `json:"added"`
+	Removed   []FileChange `json:"removed"`
+	Modified  []FileChange `json:"modified"`
+	Summary   DiffSummary  `json:"summary"`
+}
+
+// FileChange is a file added, removed or modified between two bundles. Old fields are empty for added
+// files and new fields for removed ones.
+type FileChange struct {
+	Path      string `json:"path"`
+	Kind      string `json:"kind"`
+	OldSize   int64  `json:"old_size"`
+	NewSize   int64  `json:"new_size"`
+	SizeDelta int64  `json:"size_delta"`
+	OldHash   string `json:"old_hash,omitempty"`
+	NewHash   string `json:"new_hash,omitempty"`
+}
+
+// DiffSummary totals a ManifestDiff. Bundle sizes are of the stored archives, unpacked sizes of the files in them.
+type DiffSummary struct {
+	FilesAdded        int   `json:"files_added"`
+	FilesRemoved      int   `json:"files_removed"`
+	FilesModified     int   `json:"files_modified"`
+	FilesUnchanged    int   `json:"files_unchanged"`
+	OldBundleSize     int64 `json:"old_bundle_size"`
+	NewBundleSize     int64 `json:"new_bundle_size"`
+	BundleSizeDelta   int64 `json:"bundle_size_delta"`
+	OldUnpackedSize   int64 `json:"old_unpacked_size"`
+	NewUnpackedSize   int64 `json:"new_unpacked_size"`
+	UnpackedSizeDelta int64 `json:"unpacked_size_delta"`
+}
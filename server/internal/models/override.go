@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeviceOverride pins one device to a release, bypassing channel, rollout and targeting.
+// DeviceID is the SDK-generated ID (Device.DeviceID), so a device can be pinned before it registers.
+type DeviceOverride struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	AppID     uuid.UUID  `json:"app_id" gorm:"type:uuid;not null;uniqueIndex:idx_device_overrides_app_device"`
+	DeviceID  string     `json:"device_id" gorm:"not null;size:255;uniqueIndex:idx_device_overrides_app_device"`
+	ReleaseID uuid.UUID  `json:"release_id" gorm:"type:uuid;not null;index"`
+	Reason    string     `json:"reason" gorm:"size:500"`
+	CreatedBy string     `json:"created_by" gorm:"size:255"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil pins the device until it is unpinned
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Release Release `json:"-" gorm:"foreignKey:ReleaseID"`
+}
+
+// IsExpired reports whether the override no longer applies at the given time.
+func (o *DeviceOverride) IsExpired(now time.Time) bool {
+	return o.ExpiresAt != nil && !now.Before(*o.ExpiresAt)
+}
+
+// SetDeviceOverrideRequest is the request body for pinning a device to a release.
+type SetDeviceOverrideRequest struct {
+	ReleaseID string     `json:"release_id" binding:"required"`
+	Reason    string     `json:"reason" binding:"max=500"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
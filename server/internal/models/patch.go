@@ -8,14 +8,18 @@ import (
 
 // Patch represents a binary diff between an old version and a new release.
 type Patch struct {
-	ID          uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	ReleaseID   uuid.UUID `json:"release_id" gorm:"type:uuid;not null;index"`
-	BaseVersion string    `json:"base_version" gorm:"not null;size:50"` // The version this patch applies to
-	PatchURL    string    `json:"patch_url" gorm:"not null"`
-	Hash        string    `json:"hash" gorm:"not null;size:64"`
-	Signature   string    `json:"signature" gorm:"not null"`
-	Size        int64     `json:"size" gorm:"not null"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ReleaseID    uuid.UUID  `json:"release_id" gorm:"type:uuid;not null;index"`
+	BaseVersion  string     `json:"base_version" gorm:"not null;size:50"`         // The version this patch applies to
+	BaseHash     string     `json:"base_hash" gorm:"not null;size:64;default:''"` // SHA-256 of the bundle this patch applies to; empty when unknown
+	PatchKey     string     `json:"patch_key" gorm:"not null;default:''"`         // Object storage key
+	Hash         string     `json:"hash" gorm:"not null;size:64"`
+	Signature    string     `json:"signature" gorm:"not null"`
+	SigningKeyID *uuid.UUID `json:"signing_key_id" gorm:"type:uuid"`         // The app signing key the signature was verified against at upload
+	Generated    bool       `json:"generated" gorm:"not null;default:false"` // Diffed by the server rather than uploaded
+	Verified     bool       `json:"verified" gorm:"not null;default:false"`  // Known to reproduce the release bundle; devices verify the patched bundle against the release's hash and signature
+	Size         int64      `json:"size" gorm:"not null"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
 
 	Release Release `json:"-" gorm:"foreignKey:ReleaseID"`
 }
@@ -23,7 +27,39 @@ type Patch struct {
 // AddPatchRequest is the request to add a patch artifact to an existing release.
 type AddPatchRequest struct {
 	BaseVersion string `json:"base_version" binding:"required"`
+	BaseHash    string `json:"base_hash"` // SHA-256 of the bundle the patch was diffed from; defaults to the hash of the base version's release
 	Hash        string `json:"hash" binding:"required"`
 	Signature   string `json:"signature" binding:"required"`
 	Size        int64  `json:"size" binding:"required"`
+
+	OverrideSizeBudget bool `json:"override_size_budget"` // Accept the patch even though it exceeds the channel's size budget; recorded in the audit log
+}
+
+// Patch generation job statuses.
+const (
+	PatchJobQueued    = "queued"    // waiting for the scheduler
+	PatchJobRunning   = "running"   // being generated
+	PatchJobCompleted = "completed" // the patch was generated and stored
+	PatchJobSkipped   = "skipped"   // no patch is worth storing, e.g. it would be too large; see Reason
+	PatchJobFailed    = "failed"    // gave up after repeated errors; see Reason
+)
+
+// PatchJob generates, in the background, the patch that takes devices from a base release to a newer
+// release of the same channel.
+type PatchJob struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	AppID         uuid.UUID  `json:"app_id" gorm:"type:uuid;not null;index"`
+	ReleaseID     uuid.UUID  `json:"release_id" gorm:"type:uuid;not null;index"`
+	BaseReleaseID uuid.UUID  `json:"base_release_id" gorm:"type:uuid;not null"`
+	BaseVersion   string     `json:"base_version" gorm:"not null;size:50"`
+	Status        string     `json:"status" gorm:"not null;size:20;index"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	PatchID       *uuid.UUID `json:"patch_id" gorm:"type:uuid"`                  // Set once the patch is stored
+	PatchSize     int64      `json:"patch_size" gorm:"not null;default:0"`       // Bytes; also set when the patch was too large to keep
+	BundleSize    int64      `json:"bundle_size" gorm:"not null;default:0"`      // Bytes of the full bundle the patch replaces
+	Reason        string     `json:"reason" gorm:"not null;size:500;default:''"` // Why the job was skipped, or its last error
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 }
@@ -8,54 +8,128 @@ import (
 
 // Release represents a published OTA bundle release.
 type Release struct {
-	ID                uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	AppID             uuid.UUID `json:"app_id" gorm:"type:uuid;not null;index"`
-	Version           string    `json:"version" gorm:"not null;size:50"`
-	Channel           string    `json:"channel" gorm:"not null;size:50;default:'production'"`
-	BundleURL         string    `json:"bundle_url" gorm:"not null"`
-	Hash              string    `json:"hash" gorm:"not null;size:64"`             // SHA256 hex
-	Signature         string    `json:"signature" gorm:"not null"`                // Ed25519 base64
-	Mandatory         bool      `json:"mandatory" gorm:"not null;default:false"`
-	RolloutPercentage int       `json:"rollout_percentage" gorm:"not null;default:100;type:smallint"`
-	IsEncrypted       bool      `json:"is_encrypted" gorm:"not null;default:false"`
-	IsPatch           bool      `json:"is_patch" gorm:"not null;default:false"`
-	BaseVersion       string    `json:"base_version" gorm:"size:50"` // Only for patches
-	KeyID             *string   `json:"key_id" gorm:"size:50"`
-	Size              int64     `json:"size" gorm:"not null;default:0"`
-	IsActive          bool      `json:"is_active" gorm:"not null;default:true;index"`
-	CreatedAt         time.Time `json:"created_at" gorm:"autoCreateTime"`
-
-	App           App            `json:"-" gorm:"foreignKey:AppID"`
-	Installations []Installation `json:"installations,omitempty" gorm:"foreignKey:ReleaseID"`
-	Patches       []Patch        `json:"patches,omitempty" gorm:"foreignKey:ReleaseID"`
+	ID                  uuid.UUID       `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	AppID               uuid.UUID       `json:"app_id" gorm:"type:uuid;not null;index"`
+	Version             string          `json:"version" gorm:"not null;size:50"`
+	Channel             string          `json:"channel" gorm:"not null;size:50;default:'production'"`
+	BundleKey           string          `json:"bundle_key" gorm:"not null;default:''"` // Object storage key; URLs are minted per request
+	DownloadURL         string          `json:"download_url,omitempty" gorm:"-"`       // Minted from BundleKey when the release is read through the API
+	Hash                string          `json:"hash" gorm:"not null;size:64"`          // SHA256 hex
+	Signature           string          `json:"signature" gorm:"not null"`             // Ed25519 base64
+	SigningKeyID        *uuid.UUID      `json:"signing_key_id" gorm:"type:uuid"`       // The app signing key the signature was verified against at upload
+	Mandatory           bool            `json:"mandatory" gorm:"not null;default:false"`
+	RolloutPercentage   int             `json:"rollout_percentage" gorm:"not null;default:100;type:smallint"`
+	RolloutSalt         string          `json:"rollout_salt" gorm:"not null;size:64;default:''"`    // Mixed into cohort bucketing; empty = unsalted (releases created before salting)
+	BucketBy            string          `json:"bucket_by" gorm:"not null;size:10;default:'device'"` // "device" | "user"
+	IsEncrypted         bool            `json:"is_encrypted" gorm:"not null;default:false"`
+	EncryptionFormat    string          `json:"encryption_format,omitempty" gorm:"not null;size:30;default:''"` // How an encrypted bundle is sealed; see EncryptionChunked
+	IsPatch             bool            `json:"is_patch" gorm:"not null;default:false"`
+	BaseVersion         string          `json:"base_version" gorm:"size:50"`                                // Only for patches
+	TargetNativeVersion string          `json:"target_native_version" gorm:"not null;size:100;default:''"`  // Semver range of compatible native builds; empty = any
+	TargetingRules      []TargetingRule `json:"targeting_rules,omitempty" gorm:"serializer:json;type:text"` // Device attributes the release is restricted to; empty = all devices
+	KeyID               *string         `json:"key_id" gorm:"size:50"`
+	Size                int64           `json:"size" gorm:"not null;default:0"`
+	IsActive            bool            `json:"is_active" gorm:"not null;default:true;index"`
+	Status              string          `json:"status" gorm:"not null;size:20;default:'completed';index"`
+	RolledBackAt        *time.Time      `json:"rolled_back_at"` // Set when a channel rollback pulls this release; devices on it are reverted
+	HaltedAt            *time.Time      `json:"halted_at"`      // Set when the channel health gate stopped this release's rollout
+	HaltReason          string          `json:"halt_reason" gorm:"not null;size:255;default:''"`
+	HealthWindowStart   *time.Time      `json:"health_window_start"` // Installs before this are ignored by the health gate; set when a halt is cleared
+	CreatedAt           time.Time       `json:"created_at" gorm:"autoCreateTime"`
+
+	// Scheduling: a release with PublishAt waits inactive until the scheduler publishes it, and a rollout
+	// change made during a channel freeze window is held in PendingRollout until the window closes
+	PublishAt      *time.Time `json:"publish_at" gorm:"index"`
+	PendingRollout *int       `json:"pending_rollout_percentage"`
+
+	// Lineage: the release this one was promoted from; it shares that release's stored bundle and patches
+	PromotedFrom *uuid.UUID `json:"promoted_from" gorm:"type:uuid;index"`
+
+	// Approval: who uploaded or promoted the release, and how many other reviewers must approve it
+	// before it goes live (taken from the channel when the release is submitted)
+	CreatedBy         string `json:"created_by" gorm:"not null;size:255;default:''"`
+	RequiredApprovals int    `json:"required_approvals" gorm:"not null;default:0"`
+
+	App            App             `json:"-" gorm:"foreignKey:AppID"`
+	Installations  []Installation  `json:"installations,omitempty" gorm:"foreignKey:ReleaseID"`
+	Patches        []Patch         `json:"patches,omitempty" gorm:"foreignKey:ReleaseID"`
+	Manifest       *BundleManifest `json:"-" gorm:"foreignKey:ReleaseID"` // Saved with the release; served by GET /releases/:id/manifest
+	SecretFindings []SecretFinding `json:"-" gorm:"foreignKey:ReleaseID"` // Saved with the release; served by GET /releases/:id/secrets
 }
 
+// Bundle encryption formats. Releases encrypted before chunking have an empty format and use EncryptionSingle.
+const (
+	EncryptionSingle  = "aes-256-gcm"         // [12-byte nonce][ciphertext + tag], decrypted in one piece
+	EncryptionChunked = "aes-256-gcm-chunked" // Independently sealed 64 KiB chunks the SDKs decrypt as they stream
+)
+
+// Rollout bucketing keys.
+const (
+	BucketByDevice = "device"
+	BucketByUser   = "user"
+)
+
 // CreateReleaseRequest is the JSON metadata part of a multipart release upload.
 type CreateReleaseRequest struct {
-	Version           string `json:"version" binding:"required"`
-	Channel           string `json:"channel" binding:"omitempty"`
-	Platform          string `json:"platform" binding:"required,oneof=android ios"`
-	Mandatory         bool   `json:"mandatory"`
-	RolloutPercentage int    `json:"rollout_percentage" binding:"omitempty,min=1,max=100"`
-	Hash              string `json:"hash" binding:"required"`
-	Signature         string `json:"signature" binding:"required"`
-	IsEncrypted       bool   `json:"is_encrypted"`
-	IsPatch           bool   `json:"is_patch"`
-	BaseVersion       string `json:"base_version"`
-	KeyID             string `json:"key_id"`
-	Size              int64  `json:"size" binding:"required"`
+	Version             string          `json:"version" binding:"required"`
+	Channel             string          `json:"channel" binding:"omitempty"`
+	Platform            string          `json:"platform" binding:"required,oneof=android ios"`
+	Mandatory           bool            `json:"mandatory"`
+	RolloutPercentage   int             `json:"rollout_percentage" binding:"omitempty,min=1,max=100"`
+	RolloutSalt         string          `json:"rollout_salt" binding:"omitempty,max=64"`         // Reuse another release's salt to keep its cohort; random by default
+	BucketBy            string          `json:"bucket_by" binding:"omitempty,oneof=device user"` // Bucket by the SDK's user ID instead of the device ID
+	Hash                string          `json:"hash" binding:"required"`
+	Signature           string          `json:"signature" binding:"required"`
+	IsEncrypted         bool            `json:"is_encrypted"`
+	IsPatch             bool            `json:"is_patch"`
+	BaseVersion         string          `json:"base_version"`
+	TargetNativeVersion string          `json:"target_native_version"` // e.g. ">=2.3.0 <3.0.0" or "^2.3.0"
+	TargetingRules      []TargetingRule `json:"targeting_rules"`       // e.g. [{"attribute": "os_version", "operator": "semver", "value": ">=17.0.0"}]
+	KeyID               string          `json:"key_id"`
+	Size                int64           `json:"size" binding:"required"`
+
+	// Optional staged rollout, e.g. 1% → 5% → 25% → 100%; overrides RolloutPercentage
+	RolloutPlan []RolloutStep `json:"rollout_plan"`
+
+	// Optional go-live time; the release is uploaded now and activated by the scheduler
+	PublishAt *time.Time `json:"publish_at"`
+
+	// Upload as a draft that goes live only when published through PUT /releases/:id/status
+	Draft bool `json:"draft"`
+
+	// Accept the bundle even though it exceeds the channel's size budget; recorded in the audit log
+	OverrideSizeBudget bool `json:"override_size_budget"`
+}
+
+// PromoteReleaseRequest is the request body for POST /releases/:id/promote.
+// The promoted release reuses the source's bundle, patches, signature and targeting; rollout starts afresh.
+type PromoteReleaseRequest struct {
+	Channel           string        `json:"channel" binding:"required,max=50"`
+	RolloutPercentage int           `json:"rollout_percentage" binding:"omitempty,min=1,max=100"`
+	RolloutPlan       []RolloutStep `json:"rollout_plan"`
+	Mandatory         *bool         `json:"mandatory"`  // Defaults to the source release's setting
+	PublishAt         *time.Time    `json:"publish_at"` // Optional go-live time in the target channel
 }
 
 // ReleaseListQuery holds query parameters for listing releases.
 type ReleaseListQuery struct {
-	AppID    string `form:"app_id"`
-	Channel  string `form:"channel"`
-	IsActive string `form:"is_active"`
-	Page     int    `form:"page,default=1"`
-	PerPage  int    `form:"per_page,default=20"`
+	AppID     string `form:"app_id"`
+	Channel   string `form:"channel"`
+	IsActive  string `form:"is_active"`
+	Status    string `form:"status"`
+	Scheduled string `form:"scheduled"` // "true" lists only releases waiting for their publish_at
+	Page      int    `form:"page,default=1"`
+	PerPage   int    `form:"per_page,default=20"`
+}
+
+// RollbackRequest is the optional request body for PATCH /releases/:id/rollback.
+// With ToEmbedded set, the given release (and anything newer) is pulled and devices revert to the embedded bundle.
+type RollbackRequest struct {
+	ToEmbedded bool `json:"to_embedded"`
 }
 
 // UpdateRolloutRequest is the request body for patching rollout percentage.
 type UpdateRolloutRequest struct {
-	RolloutPercentage int `json:"rollout_percentage" binding:"required,min=1,max=100"`
+	RolloutPercentage int  `json:"rollout_percentage" binding:"required,min=1,max=100"`
+	ReshuffleCohort   bool `json:"reshuffle_cohort"` // Pick a new random cohort instead of growing the current one
 }
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revert tracks a server-issued instruction for a device to leave a rolled-back release.
+type Revert struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	AppID         uuid.UUID  `json:"app_id" gorm:"type:uuid;not null;index"`
+	DeviceID      uuid.UUID  `json:"device_id" gorm:"type:uuid;not null;index"`
+	FromReleaseID uuid.UUID  `json:"from_release_id" gorm:"type:uuid;not null;index"`
+	ToReleaseID   *uuid.UUID `json:"to_release_id" gorm:"type:uuid"`                   // nil means the embedded bundle
+	Status        string     `json:"status" gorm:"not null;size:20;default:'pending'"` // "pending" | "completed" | "failed"
+	IssuedAt      time.Time  `json:"issued_at" gorm:"autoCreateTime"`
+	CompletedAt   *time.Time `json:"completed_at"`
+
+	Device      Device  `json:"-" gorm:"foreignKey:DeviceID"`
+	FromRelease Release `json:"-" gorm:"foreignKey:FromReleaseID"`
+}
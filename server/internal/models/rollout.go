@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rollout plan statuses.
+const (
+	RolloutPlanScheduled = "scheduled" // waiting for its release to be published
+	RolloutPlanActive    = "active"
+	RolloutPlanPaused    = "paused"
+	RolloutPlanCompleted = "completed"
+	RolloutPlanCancelled = "cancelled"
+)
+
+// RolloutStep is one stage of a staged rollout: the release is served to Percentage of devices
+// for at least HoldMinutes before the scheduler moves on to the next step.
+type RolloutStep struct {
+	Percentage  int `json:"percentage" binding:"required,min=1,max=100"`
+	HoldMinutes int `json:"hold_minutes" binding:"min=0"`
+}
+
+// RolloutPlan drives a release's rollout percentage through a series of steps.
+// All state lives in the database so the scheduler picks up where it left off after a restart.
+type RolloutPlan struct {
+	ID            uuid.UUID     `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	AppID         uuid.UUID     `json:"app_id" gorm:"type:uuid;not null;index"`
+	ReleaseID     uuid.UUID     `json:"release_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Steps         []RolloutStep `json:"steps" gorm:"serializer:json;type:text;not null"`
+	CurrentStep   int           `json:"current_step" gorm:"not null;default:0"`          // index into Steps
+	Status        string        `json:"status" gorm:"not null;size:20;default:'active'"` // "scheduled" | "active" | "paused" | "completed" | "cancelled"
+	StepStartedAt time.Time     `json:"step_started_at"`                                 // when the current step began serving
+	NextStepAt    *time.Time    `json:"next_step_at" gorm:"index"`                       // nil unless active with a step remaining
+	CreatedAt     time.Time     `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Release Release `json:"-" gorm:"foreignKey:ReleaseID"`
+}
+
+// SetRolloutPlanRequest is the request body for attaching a rollout plan to a release.
+type SetRolloutPlanRequest struct {
+	Steps []RolloutStep `json:"steps" binding:"required,min=1,max=20,dive"`
+}
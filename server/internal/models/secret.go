@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Secret finding severities, lowest first.
+const (
+	SecretSeverityLow      = "low"      // a string that looks random enough to be a key
+	SecretSeverityMedium   = "medium"   // a credential that only reaches test environments
+	SecretSeverityHigh     = "high"     // an identifier of a live credential
+	SecretSeverityCritical = "critical" // a live secret key
+)
+
+// SecretSeverities lists the secret finding severities, lowest first.
+var SecretSeverities = []string{SecretSeverityLow, SecretSeverityMedium, SecretSeverityHigh, SecretSeverityCritical}
+
+// SecretFinding is a credential, or a string that looks like one, found in a release bundle when it was
+// scanned at upload. The matched text is stored redacted.
+type SecretFinding struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	ReleaseID uuid.UUID `json:"release_id" gorm:"type:uuid;not null;index"`
+	Rule      string    `json:"rule" gorm:"not null;size:50"`
+	Severity  string    `json:"severity" gorm:"not null;size:20"`
+	Path      string    `json:"path" gorm:"not null;size:255"`             // File of the bundle archive the match is in
+	Offset    int64     `json:"offset" gorm:"column:byte_offset;not null"` // Byte offset of the match in the extracted file
+	Match     string    `json:"match" gorm:"not null;size:100"`            // Matched text with all but its first characters masked
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// SecretScan reports the findings of the secret scan of a release's bundle, and whether they keep it from
+// being published in its channel.
+type SecretScan struct {
+	ReleaseID     uuid.UUID       `json:"release_id"`
+	Scanned       bool            `json:"scanned"`        // False for bundles the server cannot read: patches, bundles encrypted before upload and older releases
+	BlockSeverity string          `json:"block_severity"` // The channel's blocking severity, or "off"
+	Blocked       bool            `json:"blocked"`        // A finding is at or above the blocking severity
+	Truncated     bool            `json:"truncated"`      // More was found than recorded; the most severe findings are kept
+	Findings      []SecretFinding `json:"findings"`
+}
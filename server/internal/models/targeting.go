@@ -0,0 +1,29 @@
+package models
+
+// Targeting rule operators.
+const (
+	TargetingOpEquals = "equals" // attribute equals value (case-insensitive)
+	TargetingOpIn     = "in"     // attribute equals one of values (case-insensitive)
+	TargetingOpSemver = "semver" // attribute is a version inside the range in value, e.g. ">=14.0.0 <17.0.0"
+	TargetingOpRegex  = "regex"  // attribute matches the regular expression in value
+)
+
+// Targeting rule attributes reported by the SDK with each update check.
+// Custom tags are addressed as "tags.<key>".
+const (
+	TargetingAttrOSVersion   = "os_version"
+	TargetingAttrDeviceModel = "device_model"
+	TargetingAttrLocale      = "locale"
+	TargetingAttrCountry     = "country"
+	TargetingAttrSDKVersion  = "sdk_version"
+	TargetingAttrTagPrefix   = "tags."
+)
+
+// TargetingRule restricts a release to devices whose attribute satisfies the operator.
+// A release is only offered to devices that satisfy all of its rules.
+type TargetingRule struct {
+	Attribute string   `json:"attribute"`
+	Operator  string   `json:"operator"`
+	Value     string   `json:"value,omitempty"`  // equals, semver, regex
+	Values    []string `json:"values,omitempty"` // in
+}
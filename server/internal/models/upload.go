@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Upload session statuses.
+const (
+	UploadInProgress = "uploading" // Accepting chunks
+	UploadCompleted  = "completed" // All bytes received; a release can be created from it
+)
+
+// UploadSession is a resumable bundle upload. Each appended chunk is stored as one part of an S3
+// multipart upload, so a client that loses its connection asks for the offset and carries on from there.
+// Sessions expire and are garbage collected whether or not a release was created from them.
+type UploadSession struct {
+	ID          uuid.UUID    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	AppID       uuid.UUID    `json:"app_id" gorm:"type:uuid;not null;index"`
+	ObjectKey   string       `json:"-" gorm:"not null;size:255"`
+	MultipartID string       `json:"-" gorm:"not null;size:1024"`                           // S3 multipart upload ID
+	Size        int64        `json:"size" gorm:"not null"`                                  // Declared total bundle size
+	Offset      int64        `json:"offset" gorm:"column:upload_offset;not null;default:0"` // Bytes received so far
+	Parts       []UploadPart `json:"-" gorm:"serializer:json;type:text"`
+	HashState   []byte       `json:"-"`                                    // Marshalled SHA-256 state of the bytes received so far
+	SHA256      string       `json:"sha256,omitempty" gorm:"size:64"`      // Set once the upload is complete
+	Status      string       `json:"status" gorm:"not null;size:20;index"` // "uploading" | "completed"
+	CreatedBy   string       `json:"created_by" gorm:"size:255"`
+	ExpiresAt   time.Time    `json:"expires_at" gorm:"not null;index"`
+	CreatedAt   time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// UploadPart is one appended chunk of an upload session.
+type UploadPart struct {
+	ETag string `json:"etag"`
+	Size int64  `json:"size"`
+}
+
+// CreateUploadRequest is the request body for POST /uploads.
+type CreateUploadRequest struct {
+	Size int64 `json:"size" binding:"required,min=1"` // Total bundle size in bytes
+}
@@ -18,7 +18,7 @@ func NewAnalyticsRepository(db *gorm.DB) *AnalyticsRepository {
 	return &AnalyticsRepository{db: db}
 }
 
-// GetVersionDistribution returns counts of devices per version for an app.
+// GetVersionDistribution returns counts of devices per version for an app, in no particular order.
 func (r *AnalyticsRepository) GetVersionDistribution(appID uuid.UUID) ([]models.VersionDistribution, error) {
 	var dist []models.VersionDistribution
 
@@ -30,7 +30,6 @@ func (r *AnalyticsRepository) GetVersionDistribution(appID uuid.UUID) ([]models.
 		Select("current_version as version, COUNT(*) as count").
 		Where("app_id = ?", appID).
 		Group("current_version").
-		Order("count DESC").
 		Find(&dist).Error
 
 	if err == nil && total > 0 {
@@ -48,6 +48,13 @@ func (r *ChannelRepository) ListByApp(appID uuid.UUID) ([]models.Channel, error)
 	return channels, err
 }
 
+// ListHealthGated retrieves every channel, across all apps, that has its health gate enabled.
+func (r *ChannelRepository) ListHealthGated() ([]models.Channel, error) {
+	var channels []models.Channel
+	err := r.db.Where("health_gate_enabled = ?", true).Find(&channels).Error
+	return channels, err
+}
+
 // Update modifies an existing channel.
 func (r *ChannelRepository) Update(channel *models.Channel) error {
 	return r.db.Save(channel).Error
@@ -38,6 +38,13 @@ func (r *DeviceRepository) GetByDeviceID(deviceID string) (*models.Device, error
 	return &device, nil
 }
 
+// ReleaseBelongsToApp reports whether a release exists and is owned by the given app.
+func (r *DeviceRepository) ReleaseBelongsToApp(releaseID, appID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Release{}).Where("id = ? AND app_id = ?", releaseID, appID).Count(&count).Error
+	return count > 0, err
+}
+
 // ListByApp retrieves all devices for a given app with pagination.
 func (r *DeviceRepository) ListByApp(appID uuid.UUID, page, perPage int) ([]models.Device, int64, error) {
 	var devices []models.Device
@@ -87,17 +94,26 @@ func (r *DeviceRepository) GetInstallationsByRelease(releaseID uuid.UUID) ([]mod
 
 // CountInstallationsByStatus returns counts grouped by status for a release.
 func (r *DeviceRepository) CountInstallationsByStatus(releaseID uuid.UUID) (map[string]int64, error) {
+	return r.CountInstallationsByStatusSince(releaseID, time.Time{})
+}
+
+// CountInstallationsByStatusSince returns counts grouped by status for a release,
+// counting only installations reported at or after since.
+func (r *DeviceRepository) CountInstallationsByStatusSince(releaseID uuid.UUID, since time.Time) (map[string]int64, error) {
 	type Result struct {
 		Status string
 		Count  int64
 	}
-	var results []Result
-	err := r.db.
+	query := r.db.
 		Model(&models.Installation{}).
 		Select("status, COUNT(*) as count").
-		Where("release_id = ?", releaseID).
-		Group("status").
-		Find(&results).Error
+		Where("release_id = ?", releaseID)
+	if !since.IsZero() {
+		query = query.Where("installed_at >= ?", since)
+	}
+
+	var results []Result
+	err := query.Group("status").Find(&results).Error
 	if err != nil {
 		return nil, err
 	}
@@ -108,3 +124,36 @@ func (r *DeviceRepository) CountInstallationsByStatus(releaseID uuid.UUID) (map[
 	}
 	return counts, nil
 }
+
+// GetPendingRevert finds the open revert instruction for a device leaving a given release.
+func (r *DeviceRepository) GetPendingRevert(deviceID, fromReleaseID uuid.UUID) (*models.Revert, error) {
+	var revert models.Revert
+	err := r.db.
+		Where("device_id = ? AND from_release_id = ? AND status = 'pending'", deviceID, fromReleaseID).
+		First(&revert).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revert, nil
+}
+
+// SaveRevert creates or updates a revert instruction.
+func (r *DeviceRepository) SaveRevert(revert *models.Revert) error {
+	return r.db.Save(revert).Error
+}
+
+// CompleteRevert records the SDK-reported outcome of a revert instruction issued to a device.
+func (r *DeviceRepository) CompleteRevert(id, deviceID uuid.UUID, status string) error {
+	now := time.Now()
+	result := r.db.
+		Model(&models.Revert{}).
+		Where("id = ? AND device_id = ?", id, deviceID).
+		Updates(map[string]interface{}{"status": status, "completed_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
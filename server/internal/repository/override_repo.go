@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OverrideRepository handles database operations for per-device release overrides.
+type OverrideRepository struct {
+	db *gorm.DB
+}
+
+// NewOverrideRepository creates a new OverrideRepository.
+func NewOverrideRepository(db *gorm.DB) *OverrideRepository {
+	return &OverrideRepository{db: db}
+}
+
+// Upsert creates the override of a device, or replaces the release, reason and expiry of an existing one.
+func (r *OverrideRepository) Upsert(override *models.DeviceOverride) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "app_id"}, {Name: "device_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"release_id", "reason", "created_by", "expires_at", "updated_at"}),
+	}).Create(override).Error
+}
+
+// Get retrieves the override of a device, scoped to the owning app.
+func (r *OverrideRepository) Get(appID uuid.UUID, deviceID string) (*models.DeviceOverride, error) {
+	var override models.DeviceOverride
+	err := r.db.First(&override, "app_id = ? AND device_id = ?", appID, deviceID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+// ListActive returns every override of an app that has not expired, newest first.
+func (r *OverrideRepository) ListActive(appID uuid.UUID, now time.Time) ([]models.DeviceOverride, error) {
+	var overrides []models.DeviceOverride
+	err := r.db.
+		Where("app_id = ? AND (expires_at IS NULL OR expires_at > ?)", appID, now).
+		Order("created_at DESC").
+		Find(&overrides).Error
+	return overrides, err
+}
+
+// Delete removes the override of a device. Returns false when the device had none.
+func (r *OverrideRepository) Delete(appID uuid.UUID, deviceID string) (bool, error) {
+	result := r.db.Where("app_id = ? AND device_id = ?", appID, deviceID).Delete(&models.DeviceOverride{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListExpired returns overrides that expired at or before the given time, oldest first.
+func (r *OverrideRepository) ListExpired(now time.Time, limit int) ([]models.DeviceOverride, error) {
+	var overrides []models.DeviceOverride
+	err := r.db.
+		Where("expires_at IS NOT NULL AND expires_at <= ?", now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&overrides).Error
+	return overrides, err
+}
+
+// DeleteIfExpired removes an override only if it is still expired, so a device re-pinned
+// in the meantime keeps its new override. Returns false when nothing was removed.
+func (r *OverrideRepository) DeleteIfExpired(id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.Where("id = ? AND expires_at IS NOT NULL AND expires_at <= ?", id, now).Delete(&models.DeviceOverride{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/hotpatch/server/internal/models"
 	"gorm.io/gorm"
@@ -18,26 +20,65 @@ func NewReleaseRepository(db *gorm.DB) *ReleaseRepository {
 
 // Create inserts a new release into the database.
 func (r *ReleaseRepository) Create(release *models.Release) error {
-	return r.db.Create(release).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return createRelease(tx, release)
+	})
+}
+
+// createRelease inserts a release, and its bundle manifest when it has one, keeping it inactive when it is
+// stored inactive. GORM leaves zero values to the column default, which would make every new draft or
+// scheduled release active.
+func createRelease(tx *gorm.DB, release *models.Release) error {
+	active := release.IsActive
+	if err := tx.Omit("Patches").Create(release).Error; err != nil {
+		return err
+	}
+	if active {
+		return nil
+	}
+	release.IsActive = false
+	return tx.Model(&models.Release{}).Where("id = ?", release.ID).Update("is_active", false).Error
 }
 
-// GetByID retrieves a release by its UUID.
-func (r *ReleaseRepository) GetByID(id uuid.UUID) (*models.Release, error) {
+// GetByID retrieves a release by its UUID, scoped to the owning app.
+func (r *ReleaseRepository) GetByID(appID, id uuid.UUID) (*models.Release, error) {
 	var release models.Release
-	err := r.db.First(&release, "id = ?", id).Error
+	err := r.db.First(&release, "id = ? AND app_id = ?", id, appID).Error
 	if err != nil {
 		return nil, err
 	}
 	return &release, nil
 }
 
-// GetActiveRelease finds the latest active release for a given app, channel combination.
-func (r *ReleaseRepository) GetActiveRelease(appID uuid.UUID, channel string) (*models.Release, error) {
+// GetWithPatches retrieves a release of the app together with its patches.
+func (r *ReleaseRepository) GetWithPatches(appID, id uuid.UUID) (*models.Release, error) {
 	var release models.Release
+	err := r.db.Preload("Patches").First(&release, "id = ? AND app_id = ?", id, appID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// GetActiveReleases returns every active release for an app+channel, newest first.
+// A channel can hold several active releases at once, one per targeted native version range.
+func (r *ReleaseRepository) GetActiveReleases(appID uuid.UUID, channel string) ([]models.Release, error) {
+	var releases []models.Release
 	err := r.db.
 		Preload("Patches").
 		Where("app_id = ? AND channel = ? AND is_active = true", appID, channel).
 		Order("created_at DESC").
+		Find(&releases).Error
+	return releases, err
+}
+
+// GetLatestActive finds the most recently created release for a channel and native version target,
+// regardless of whether it's currently active.
+func (r *ReleaseRepository) GetLatestActive(appID uuid.UUID, channel, targetNativeVersion string) (*models.Release, error) {
+	var release models.Release
+	err := r.db.
+		Where("app_id = ? AND channel = ? AND target_native_version = ?", appID, channel, targetNativeVersion).
+		Order("created_at DESC").
 		First(&release).Error
 	if err != nil {
 		return nil, err
@@ -45,11 +86,12 @@ func (r *ReleaseRepository) GetActiveRelease(appID uuid.UUID, channel string) (*
 	return &release, nil
 }
 
-// GetLatestActive finds the most recently created release for a channel, regardless of whether it's currently active.
-func (r *ReleaseRepository) GetLatestActive(appID uuid.UUID, channel string) (*models.Release, error) {
+// GetLaneActive finds the release currently active for a channel and native version target, the newest one
+// should several be live.
+func (r *ReleaseRepository) GetLaneActive(appID uuid.UUID, channel, targetNativeVersion string) (*models.Release, error) {
 	var release models.Release
 	err := r.db.
-		Where("app_id = ? AND channel = ?", appID, channel).
+		Where("app_id = ? AND channel = ? AND target_native_version = ? AND is_active = true", appID, channel, targetNativeVersion).
 		Order("created_at DESC").
 		First(&release).Error
 	if err != nil {
@@ -59,7 +101,7 @@ func (r *ReleaseRepository) GetLatestActive(appID uuid.UUID, channel string) (*m
 }
 
 // List retrieves releases with pagination and optional filters.
-func (r *ReleaseRepository) List(appID uuid.UUID, channel string, isActive *bool, page, perPage int) ([]models.Release, int64, error) {
+func (r *ReleaseRepository) List(appID uuid.UUID, channel, status string, isActive, scheduled *bool, page, perPage int) ([]models.Release, int64, error) {
 	var releases []models.Release
 	var total int64
 
@@ -68,9 +110,19 @@ func (r *ReleaseRepository) List(appID uuid.UUID, channel string, isActive *bool
 	if channel != "" {
 		query = query.Where("channel = ?", channel)
 	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
 	if isActive != nil {
 		query = query.Where("is_active = ?", *isActive)
 	}
+	if scheduled != nil {
+		if *scheduled {
+			query = query.Where("publish_at IS NOT NULL")
+		} else {
+			query = query.Where("publish_at IS NULL")
+		}
+	}
 
 	query.Count(&total)
 
@@ -84,36 +136,162 @@ func (r *ReleaseRepository) List(appID uuid.UUID, channel string, isActive *bool
 	return releases, total, err
 }
 
-// DeactivatePreviousReleases marks all previous active releases for the same app+channel as inactive.
-func (r *ReleaseRepository) DeactivatePreviousReleases(appID uuid.UUID, channel string, excludeID uuid.UUID) error {
+// DeactivatePreviousReleases marks all previous active releases for the same app+channel and
+// native version target as inactive. Releases targeting other native builds stay live.
+func (r *ReleaseRepository) DeactivatePreviousReleases(appID uuid.UUID, channel, targetNativeVersion string, excludeID uuid.UUID) error {
 	return r.db.
 		Model(&models.Release{}).
-		Where("app_id = ? AND channel = ? AND is_active = true AND id != ?", appID, channel, excludeID).
+		Where("app_id = ? AND channel = ? AND target_native_version = ? AND is_active = true AND id != ?", appID, channel, targetNativeVersion, excludeID).
 		Update("is_active", false).Error
 }
 
 // UpdateRollout updates the rollout percentage for a release.
-func (r *ReleaseRepository) UpdateRollout(id uuid.UUID, percentage int) error {
+func (r *ReleaseRepository) UpdateRollout(appID, id uuid.UUID, percentage int) error {
 	return r.db.
 		Model(&models.Release{}).
-		Where("id = ?", id).
+		Where("id = ? AND app_id = ?", id, appID).
 		Update("rollout_percentage", percentage).Error
 }
 
-// Activate reactivates a specific release (for rollback).
+// UpdateRolloutSalt replaces the cohort bucketing salt of a release.
+func (r *ReleaseRepository) UpdateRolloutSalt(appID, id uuid.UUID, salt string) error {
+	return r.db.
+		Model(&models.Release{}).
+		Where("id = ? AND app_id = ?", id, appID).
+		Update("rollout_salt", salt).Error
+}
+
+// Activate reactivates a specific release (for rollback), dropping any pending schedule.
 func (r *ReleaseRepository) Activate(id uuid.UUID) error {
 	return r.db.
 		Model(&models.Release{}).
 		Where("id = ?", id).
-		Update("is_active", true).Error
+		Updates(map[string]interface{}{
+			"is_active":  true,
+			"publish_at": nil,
+		}).Error
+}
+
+// ListLane returns the releases in the channel that target the given native version range and are in one of
+// the given statuses.
+func (r *ReleaseRepository) ListLane(appID uuid.UUID, channel, targetNativeVersion string, statuses []string) ([]models.Release, error) {
+	var releases []models.Release
+	err := r.db.
+		Where("app_id = ? AND channel = ? AND target_native_version = ? AND status IN ?", appID, channel, targetNativeVersion, statuses).
+		Find(&releases).Error
+	return releases, err
+}
+
+// MarkRolledBack flags the given releases as rolled back. Devices still running one of these
+// releases are instructed to revert on their next update check.
+func (r *ReleaseRepository) MarkRolledBack(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.
+		Model(&models.Release{}).
+		Where("id IN ? AND rolled_back_at IS NULL", ids).
+		Updates(map[string]interface{}{
+			"rolled_back_at": time.Now(),
+			"publish_at":     nil, // a pulled release that was still scheduled never goes live
+		}).Error
+}
+
+// ClearRolledBack removes the rolled-back flag from a release that is being reinstated.
+func (r *ReleaseRepository) ClearRolledBack(id uuid.UUID) error {
+	return r.db.
+		Model(&models.Release{}).
+		Where("id = ?", id).
+		Update("rolled_back_at", nil).Error
+}
+
+// GetRolledBackByVersion finds a rolled-back release in a channel by its version string.
+func (r *ReleaseRepository) GetRolledBackByVersion(appID uuid.UUID, channel, version string) (*models.Release, error) {
+	var release models.Release
+	err := r.db.
+		Where("app_id = ? AND channel = ? AND version = ? AND rolled_back_at IS NOT NULL", appID, channel, version).
+		First(&release).Error
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// Halt stops serving an active release to new devices by dropping its rollout to 0% and records why.
+// Returns false when the release is no longer active or was already halted, e.g. by another server instance.
+func (r *ReleaseRepository) Halt(appID, id uuid.UUID, reason string, at time.Time) (bool, error) {
+	result := r.db.
+		Model(&models.Release{}).
+		Where("id = ? AND app_id = ? AND is_active = true AND halted_at IS NULL", id, appID).
+		Updates(map[string]interface{}{
+			"halted_at":          at,
+			"halt_reason":        reason,
+			"rollout_percentage": 0,
+		})
+	return result.RowsAffected > 0, result.Error
 }
 
-// SoftDelete marks a release as inactive (archive).
-func (r *ReleaseRepository) SoftDelete(id uuid.UUID) error {
+// ClearHalt lifts a health gate halt. Installations reported before at no longer count towards the gate.
+func (r *ReleaseRepository) ClearHalt(id uuid.UUID, at time.Time) error {
 	return r.db.
 		Model(&models.Release{}).
 		Where("id = ?", id).
-		Update("is_active", false).Error
+		Updates(map[string]interface{}{
+			"halted_at":           nil,
+			"halt_reason":         "",
+			"health_window_start": at,
+		}).Error
+}
+
+// ListDueScheduled returns scheduled releases whose publish time has come, oldest schedule first.
+// Releases pulled by a rollback while still scheduled are never published.
+func (r *ReleaseRepository) ListDueScheduled(now time.Time, limit int) ([]models.Release, error) {
+	var releases []models.Release
+	err := r.db.
+		Where("status = ? AND publish_at IS NOT NULL AND publish_at <= ? AND is_active = false AND rolled_back_at IS NULL", models.ReleaseScheduled, now).
+		Order("publish_at ASC").
+		Limit(limit).
+		Find(&releases).Error
+	return releases, err
+}
+
+// SetPendingRollout records a rollout change to apply once the release's channel leaves its freeze window.
+func (r *ReleaseRepository) SetPendingRollout(appID, id uuid.UUID, percentage int) error {
+	return r.db.
+		Model(&models.Release{}).
+		Where("id = ? AND app_id = ?", id, appID).
+		Update("pending_rollout", percentage).Error
+}
+
+// ListPendingRollouts returns releases with a deferred rollout change.
+func (r *ReleaseRepository) ListPendingRollouts(limit int) ([]models.Release, error) {
+	var releases []models.Release
+	err := r.db.
+		Where("pending_rollout IS NOT NULL").
+		Limit(limit).
+		Find(&releases).Error
+	return releases, err
+}
+
+// ApplyPendingRollout sets the rollout percentage to the deferred value, only if that value is still pending.
+// Returns false when the pending change was replaced or applied concurrently.
+func (r *ReleaseRepository) ApplyPendingRollout(id uuid.UUID, percentage int) (bool, error) {
+	result := r.db.
+		Model(&models.Release{}).
+		Where("id = ? AND pending_rollout = ?", id, percentage).
+		Updates(map[string]interface{}{
+			"rollout_percentage": percentage,
+			"pending_rollout":    nil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ClearPendingRollout drops a deferred rollout change.
+func (r *ReleaseRepository) ClearPendingRollout(id uuid.UUID) error {
+	return r.db.
+		Model(&models.Release{}).
+		Where("id = ?", id).
+		Update("pending_rollout", nil).Error
 }
 
 // ExistsByVersion checks if a release with the given version already exists for an app+channel.
@@ -126,7 +304,196 @@ func (r *ReleaseRepository) ExistsByVersion(appID uuid.UUID, version, channel st
 	return count > 0, err
 }
 
+// GetByVersion finds the release of an app+channel with the given version.
+func (r *ReleaseRepository) GetByVersion(appID uuid.UUID, channel, version string) (*models.Release, error) {
+	var release models.Release
+	err := r.db.
+		Where("app_id = ? AND channel = ? AND version = ?", appID, channel, version).
+		First(&release).Error
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// GetByVersionAndHash finds a release of an app, in any channel, with the given version and bundle hash.
+func (r *ReleaseRepository) GetByVersionAndHash(appID uuid.UUID, version, hash string) (*models.Release, error) {
+	var release models.Release
+	err := r.db.
+		Where("app_id = ? AND version = ? AND LOWER(hash) = LOWER(?)", appID, version, hash).
+		Order("is_encrypted, is_patch").
+		First(&release).Error
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// ListChainablePatches returns the patches of the channel's releases that can be part of a patch chain:
+// verified patches with a known base hash whose release bundle is stored unencrypted, with their release loaded.
+func (r *ReleaseRepository) ListChainablePatches(appID uuid.UUID, channel string) ([]models.Patch, error) {
+	var patches []models.Patch
+	err := r.db.
+		Joins("Release").
+		Where(`"Release".app_id = ? AND "Release".channel = ? AND "Release".is_encrypted = false AND "Release".is_patch = false AND "Release".status <> ?`, appID, channel, models.ReleaseArchived).
+		Where("patches.base_hash <> '' AND patches.verified = true").
+		Find(&patches).Error
+	return patches, err
+}
+
+// GetManifest retrieves the bundle manifest of a release.
+func (r *ReleaseRepository) GetManifest(releaseID uuid.UUID) (*models.BundleManifest, error) {
+	var manifest models.BundleManifest
+	if err := r.db.Where("release_id = ?", releaseID).First(&manifest).Error; err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// ListSecretFindings retrieves the secret findings of a release in the order they appear in its bundle.
+func (r *ReleaseRepository) ListSecretFindings(releaseID uuid.UUID) ([]models.SecretFinding, error) {
+	var findings []models.SecretFinding
+	err := r.db.Where("release_id = ?", releaseID).Order("path ASC, byte_offset ASC").Find(&findings).Error
+	return findings, err
+}
+
 // CreatePatch inserts a new patch record.
 func (r *ReleaseRepository) CreatePatch(patch *models.Patch) error {
 	return r.db.Create(patch).Error
 }
+
+// ListPatchBases returns the releases of the channel and native version target that devices may be running,
+// newest first: those that are or have been live and were not rolled back or archived.
+func (r *ReleaseRepository) ListPatchBases(appID uuid.UUID, channel, targetNativeVersion string, statuses []string) ([]models.Release, error) {
+	var releases []models.Release
+	err := r.db.
+		Where("app_id = ? AND channel = ? AND target_native_version = ? AND status IN ?", appID, channel, targetNativeVersion, statuses).
+		Order("created_at DESC").
+		Find(&releases).Error
+	return releases, err
+}
+
+// CreatePatchJobs inserts queued patch generation jobs.
+func (r *ReleaseRepository) CreatePatchJobs(jobs []models.PatchJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	return r.db.Create(&jobs).Error
+}
+
+// ListPatchJobs returns the patch generation jobs of a release of the app, oldest first.
+func (r *ReleaseRepository) ListPatchJobs(appID, releaseID uuid.UUID) ([]models.PatchJob, error) {
+	var jobs []models.PatchJob
+	err := r.db.
+		Where("app_id = ? AND release_id = ?", appID, releaseID).
+		Order("created_at ASC").
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// ListClaimablePatchJobs returns queued patch jobs, and running ones started before staleBefore whose
+// server presumably died, oldest first.
+func (r *ReleaseRepository) ListClaimablePatchJobs(staleBefore time.Time, limit int) ([]models.PatchJob, error) {
+	var jobs []models.PatchJob
+	err := r.db.
+		Where("status = ? OR (status = ? AND started_at < ?)", models.PatchJobQueued, models.PatchJobRunning, staleBefore).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// ClaimPatchJob marks a job running for one more attempt. Returns false when another server instance
+// claimed or finished it first.
+func (r *ReleaseRepository) ClaimPatchJob(job *models.PatchJob, now time.Time) (bool, error) {
+	result := r.db.Model(&models.PatchJob{}).
+		Where("id = ? AND status = ? AND attempts = ?", job.ID, job.Status, job.Attempts).
+		Updates(map[string]interface{}{"status": models.PatchJobRunning, "attempts": job.Attempts + 1, "started_at": now})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	job.Status = models.PatchJobRunning
+	job.Attempts++
+	job.StartedAt = &now
+	return true, nil
+}
+
+// FinishPatchJob records the outcome of a job's current attempt, unless the job has since been reclaimed.
+func (r *ReleaseRepository) FinishPatchJob(job *models.PatchJob) error {
+	return r.db.Model(&models.PatchJob{}).
+		Where("id = ? AND status = ? AND attempts = ?", job.ID, models.PatchJobRunning, job.Attempts).
+		Updates(map[string]interface{}{
+			"status":      job.Status,
+			"patch_id":    job.PatchID,
+			"patch_size":  job.PatchSize,
+			"bundle_size": job.BundleSize,
+			"reason":      job.Reason,
+			"finished_at": job.FinishedAt,
+		}).Error
+}
+
+// CreateWithPatches inserts a release together with its patch records in one transaction.
+func (r *ReleaseRepository) CreateWithPatches(release *models.Release, patches []models.Patch) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := createRelease(tx, release); err != nil {
+			return err
+		}
+		if len(patches) == 0 {
+			return nil
+		}
+		return tx.Create(&patches).Error
+	})
+}
+
+// SetStatus moves a release from one lifecycle status to another, applying any further column updates
+// and recording the transition in one transaction. Returns false when the release is no longer in the
+// expected status, e.g. because another request or server instance changed it first.
+func (r *ReleaseRepository) SetStatus(id uuid.UUID, from, to string, updates map[string]interface{}, transition *models.ReleaseTransition) (bool, error) {
+	applied := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		columns := map[string]interface{}{"status": to}
+		for column, value := range updates {
+			columns[column] = value
+		}
+		result := tx.Model(&models.Release{}).Where("id = ? AND status = ?", id, from).Updates(columns)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		applied = true
+		return tx.Create(transition).Error
+	})
+	return applied, err
+}
+
+// CreateTransition inserts a lifecycle transition record.
+func (r *ReleaseRepository) CreateTransition(transition *models.ReleaseTransition) error {
+	return r.db.Create(transition).Error
+}
+
+// ListTransitions returns the lifecycle history of a release of the app, oldest first.
+func (r *ReleaseRepository) ListTransitions(appID, releaseID uuid.UUID) ([]models.ReleaseTransition, error) {
+	var transitions []models.ReleaseTransition
+	err := r.db.
+		Where("app_id = ? AND release_id = ?", appID, releaseID).
+		Order("created_at ASC").
+		Find(&transitions).Error
+	return transitions, err
+}
+
+// CreateApproval inserts a reviewer's decision on a release.
+func (r *ReleaseRepository) CreateApproval(approval *models.ReleaseApproval) error {
+	return r.db.Create(approval).Error
+}
+
+// ListApprovals returns the review decisions on a release of the app, oldest first.
+func (r *ReleaseRepository) ListApprovals(appID, releaseID uuid.UUID) ([]models.ReleaseApproval, error) {
+	var approvals []models.ReleaseApproval
+	err := r.db.
+		Where("app_id = ? AND release_id = ?", appID, releaseID).
+		Order("created_at ASC").
+		Find(&approvals).Error
+	return approvals, err
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"gorm.io/gorm"
+)
+
+// RolloutRepository handles database operations for staged rollout plans.
+type RolloutRepository struct {
+	db *gorm.DB
+}
+
+// NewRolloutRepository creates a new RolloutRepository.
+func NewRolloutRepository(db *gorm.DB) *RolloutRepository {
+	return &RolloutRepository{db: db}
+}
+
+// SavePlan creates a plan, replacing any existing plan of the same release.
+func (r *RolloutRepository) SavePlan(plan *models.RolloutPlan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("release_id = ? AND id != ?", plan.ReleaseID, plan.ID).Delete(&models.RolloutPlan{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(plan).Error
+	})
+}
+
+// GetByRelease retrieves the plan of a release, scoped to the owning app.
+func (r *RolloutRepository) GetByRelease(appID, releaseID uuid.UUID) (*models.RolloutPlan, error) {
+	var plan models.RolloutPlan
+	err := r.db.First(&plan, "release_id = ? AND app_id = ?", releaseID, appID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// ListDue returns active plans whose current step has been held long enough.
+func (r *RolloutRepository) ListDue(now time.Time, limit int) ([]models.RolloutPlan, error) {
+	var plans []models.RolloutPlan
+	err := r.db.
+		Where("status = ? AND next_step_at IS NOT NULL AND next_step_at <= ?", models.RolloutPlanActive, now).
+		Order("next_step_at ASC").
+		Limit(limit).
+		Find(&plans).Error
+	return plans, err
+}
+
+// Transition applies updates to a plan only if it is still at the expected step and status.
+// Returns false when another request or server instance changed the plan first, which makes
+// concurrent schedulers safe without a distributed lock. When percentage is non-zero the
+// release's rollout percentage is updated in the same transaction.
+func (r *RolloutRepository) Transition(plan *models.RolloutPlan, expectStep int, expectStatus string, updates map[string]interface{}, percentage int) (bool, error) {
+	applied := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RolloutPlan{}).
+			Where("id = ? AND current_step = ? AND status = ?", plan.ID, expectStep, expectStatus).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if percentage > 0 {
+			if err := tx.Model(&models.Release{}).
+				Where("id = ?", plan.ReleaseID).
+				Update("rollout_percentage", percentage).Error; err != nil {
+				return err
+			}
+		}
+		applied = true
+		return nil
+	})
+	return applied, err
+}
@@ -53,6 +53,13 @@ func (r *SecurityRepository) ListSigningKeys(appID uuid.UUID) ([]models.SigningK
 	return keys, err
 }
 
+// ListActiveSigningKeys returns the signing keys of an app that releases may be signed with.
+func (r *SecurityRepository) ListActiveSigningKeys(appID uuid.UUID) ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := r.db.Where("app_id = ? AND is_active = ?", appID, true).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
 func (r *SecurityRepository) DeleteSigningKey(appID uuid.UUID, keyID uuid.UUID) error {
 	return r.db.Delete(&models.SigningKey{}, "app_id = ? AND id = ?", appID, keyID).Error
 }
@@ -28,6 +28,20 @@ func (r *SettingsRepository) GetApp(id uuid.UUID) (*models.App, error) {
 	return &app, err
 }
 
+// IsAppOwner reports whether the app exists and is owned by the given user.
+func (r *SettingsRepository) IsAppOwner(appID, ownerID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.App{}).Where("id = ? AND owner_id = ?", appID, ownerID).Count(&count).Error
+	return count > 0, err
+}
+
+// GetAppByAPIKey finds an app by the SHA-256 hash of its API key.
+func (r *SettingsRepository) GetAppByAPIKey(hashedKey string) (*models.App, error) {
+	var app models.App
+	err := r.db.First(&app, "api_key = ?", hashedKey).Error
+	return &app, err
+}
+
 // GetAppBySubscriptionID finds an app by its Stripe subscription ID.
 func (r *SettingsRepository) GetAppBySubscriptionID(subscriptionID string) (*models.App, error) {
 	var app models.App
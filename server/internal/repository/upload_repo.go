@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"gorm.io/gorm"
+)
+
+// UploadRepository handles database operations for resumable upload sessions.
+type UploadRepository struct {
+	db *gorm.DB
+}
+
+// NewUploadRepository creates a new UploadRepository.
+func NewUploadRepository(db *gorm.DB) *UploadRepository {
+	return &UploadRepository{db: db}
+}
+
+// Create inserts a new upload session.
+func (r *UploadRepository) Create(session *models.UploadSession) error {
+	return r.db.Create(session).Error
+}
+
+// Get retrieves an upload session, scoped to the owning app.
+func (r *UploadRepository) Get(appID, id uuid.UUID) (*models.UploadSession, error) {
+	var session models.UploadSession
+	err := r.db.First(&session, "id = ? AND app_id = ?", id, appID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Advance records an appended chunk, only if the session is still in progress at the offset the chunk
+// was appended to, so a concurrent or replayed append cannot move it twice. Returns false when it was not updated.
+func (r *UploadRepository) Advance(session *models.UploadSession, fromOffset int64) (bool, error) {
+	result := r.db.Model(session).
+		Where("status = ? AND upload_offset = ?", models.UploadInProgress, fromOffset).
+		Select("upload_offset", "parts", "hash_state", "updated_at").
+		Updates(session)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Complete marks an in-progress session completed. Returns false when it was not in progress.
+func (r *UploadRepository) Complete(id uuid.UUID, sha256 string) (bool, error) {
+	result := r.db.Model(&models.UploadSession{}).
+		Where("id = ? AND status = ?", id, models.UploadInProgress).
+		Updates(map[string]interface{}{"status": models.UploadCompleted, "sha256": sha256, "updated_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete removes an upload session. Returns false when it no longer exists.
+func (r *UploadRepository) Delete(id uuid.UUID) (bool, error) {
+	result := r.db.Where("id = ?", id).Delete(&models.UploadSession{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListExpired returns sessions that expired at or before the given time, oldest first.
+func (r *UploadRepository) ListExpired(now time.Time, limit int) ([]models.UploadSession, error) {
+	var sessions []models.UploadSession
+	err := r.db.
+		Where("expires_at <= ?", now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&sessions).Error
+	return sessions, err
+}
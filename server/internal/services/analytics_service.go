@@ -2,10 +2,13 @@ package services
 
 import (
 	"context"
+	"errors"
+	"sort"
 
 	"github.com/google/uuid"
 	"github.com/hotpatch/server/internal/models"
 	"github.com/hotpatch/server/internal/repository"
+	"gorm.io/gorm"
 )
 
 // AnalyticsService orchestrates aggregate statistics.
@@ -36,7 +39,7 @@ func (s *AnalyticsService) GetDashboardOverview(ctx context.Context, appID uuid.
 	}
 
 	successRate, _ := s.repo.GetAggregateSuccessRate(appID)
-	_, totalReleases, _ := s.releaseRepo.List(appID, "", nil, 1, 1)
+	_, totalReleases, _ := s.releaseRepo.List(appID, "", "", nil, nil, 1, 1)
 
 	// Count devices active in the last 24 hours (real query)
 	activeLast24h, _ := s.deviceRepo.CountActiveLast24h(appID)
@@ -61,9 +64,14 @@ func (s *AnalyticsService) GetDashboardOverview(ctx context.Context, appID uuid.
 	}, nil
 }
 
-// GetVersionDistribution retrieves the version breakdown.
+// GetVersionDistribution retrieves the version breakdown, newest version first by semver precedence.
 func (s *AnalyticsService) GetVersionDistribution(ctx context.Context, appID uuid.UUID) ([]models.VersionDistribution, error) {
-	return s.repo.GetVersionDistribution(appID)
+	dist, err := s.repo.GetVersionDistribution(appID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(dist, func(i, j int) bool { return compareVersions(dist[i].Version, dist[j].Version) > 0 })
+	return dist, nil
 }
 
 // GetSystemTrends retrieves 30-day trends for DAU and Installations.
@@ -77,9 +85,12 @@ func (s *AnalyticsService) GetSystemTrends(ctx context.Context, appID uuid.UUID)
 	}, nil
 }
 
-// GetReleaseDetails returns analytics for a specific release.
-func (s *AnalyticsService) GetReleaseDetails(ctx context.Context, releaseID uuid.UUID) (*models.ReleaseAnalytics, error) {
-	release, err := s.releaseRepo.GetByID(releaseID)
+// GetReleaseDetails returns analytics for a specific release of the app.
+func (s *AnalyticsService) GetReleaseDetails(ctx context.Context, appID, releaseID uuid.UUID) (*models.ReleaseAnalytics, error) {
+	release, err := s.releaseRepo.GetByID(appID, releaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReleaseNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -98,9 +109,17 @@ func (s *AnalyticsService) GetReleaseDetails(ctx context.Context, releaseID uuid
 	// Get real installation timeline for this release
 	installTimeline, _ := s.repo.GetReleaseInstallTimeline(releaseID, 30)
 
+	var promotedFrom *string
+	if release.PromotedFrom != nil {
+		source := release.PromotedFrom.String()
+		promotedFrom = &source
+	}
+
 	return &models.ReleaseAnalytics{
 		ReleaseID:       releaseID.String(),
 		Version:         release.Version,
+		Channel:         release.Channel,
+		PromotedFrom:    promotedFrom,
 		StatusCounts:    statusCounts,
 		AdoptionPercent: adoption,
 		InstallTimeline: installTimeline,
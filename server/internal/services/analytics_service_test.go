@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+)
+
+// ── Version Distribution Tests ──────────────────────────────
+
+func TestGetVersionDistribution_OrderedBySemver(t *testing.T) {
+	tn := newTenants(t, &models.Device{})
+	service := NewAnalyticsService(repository.NewAnalyticsRepository(tn.db), repository.NewDeviceRepository(tn.db), repository.NewReleaseRepository(tn.db))
+
+	// Counts deliberately disagree with precedence, and string order would put 1.9.0 above 1.10.0
+	devices := map[string]int{"1.9.0": 4, "1.10.0": 1, "1.2.3": 2, "1.2.3-beta.2": 3}
+	for version, n := range devices {
+		for i := 0; i < n; i++ {
+			device := models.Device{ID: uuid.New(), DeviceID: uuid.NewString(), AppID: tn.appA, Platform: "android", CurrentVersion: version}
+			if err := tn.db.Create(&device).Error; err != nil {
+				t.Fatalf("failed to create device: %v", err)
+			}
+		}
+	}
+
+	dist, err := service.GetVersionDistribution(context.Background(), tn.appA)
+	if err != nil {
+		t.Fatalf("GetVersionDistribution failed: %v", err)
+	}
+	want := []string{"1.10.0", "1.9.0", "1.2.3", "1.2.3-beta.2"}
+	if len(dist) != len(want) {
+		t.Fatalf("distribution = %+v, want %d versions", dist, len(want))
+	}
+	for i, version := range want {
+		if dist[i].Version != version {
+			t.Errorf("distribution[%d] = %s, want %s", i, dist[i].Version, version)
+		}
+		if dist[i].Count != int64(devices[version]) || dist[i].Percent != float64(devices[version])*10 {
+			t.Errorf("%s: count = %d (%.1f%%), want %d (%.1f%%)", version, dist[i].Count, dist[i].Percent, devices[version], float64(devices[version])*10)
+		}
+	}
+}
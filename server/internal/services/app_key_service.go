@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidAppKey is returned when an SDK request carries an app key that matches no app.
+var ErrInvalidAppKey = errors.New("invalid app key")
+
+const (
+	appKeyMemoryTTL   = 1 * time.Minute  // bounds how long a regenerated key keeps working on other instances
+	appKeyRedisTTL    = 10 * time.Minute // shared across instances, invalidated on regeneration
+	appKeyNegativeTTL = 30 * time.Second // unknown keys are remembered briefly so they cannot hammer the DB
+	appKeyCacheSize   = 10000            // entries held in memory; junk keys evict each other rather than grow the map
+)
+
+// AppKeyService resolves the app-level API keys sent by the SDK in X-App-Key.
+// Lookups go memory → Redis → database so the update check stays within its latency target.
+// Caches are keyed by the key hash; raw keys are never stored.
+type AppKeyService struct {
+	repo  *repository.SettingsRepository
+	redis *redis.Client
+
+	mu    sync.RWMutex
+	cache map[string]cachedAppKey
+}
+
+type cachedAppKey struct {
+	appID     uuid.UUID // uuid.Nil for a key known to be invalid
+	expiresAt time.Time
+}
+
+// NewAppKeyService creates a new AppKeyService.
+func NewAppKeyService(repo *repository.SettingsRepository, redis *redis.Client) *AppKeyService {
+	return &AppKeyService{
+		repo:  repo,
+		redis: redis,
+		cache: make(map[string]cachedAppKey),
+	}
+}
+
+func appKeyCacheKey(hashedKey string) string {
+	return fmt.Sprintf("appkey:%s", hashedKey)
+}
+
+// ResolveAppKey returns the ID of the app that owns the raw key, or ErrInvalidAppKey.
+func (s *AppKeyService) ResolveAppKey(ctx context.Context, rawKey string) (uuid.UUID, error) {
+	hashedKey := HashApiKey(rawKey)
+	now := time.Now()
+
+	if appID, ok := s.cached(hashedKey, now); ok {
+		if appID == uuid.Nil {
+			return uuid.Nil, ErrInvalidAppKey
+		}
+		return appID, nil
+	}
+
+	if s.redis != nil {
+		if cached, err := s.redis.Get(ctx, appKeyCacheKey(hashedKey)).Result(); err == nil {
+			if appID, err := uuid.Parse(cached); err == nil {
+				s.remember(hashedKey, appID, now.Add(appKeyMemoryTTL))
+				return appID, nil
+			}
+		}
+	}
+
+	app, err := s.repo.GetAppByAPIKey(hashedKey)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.remember(hashedKey, uuid.Nil, now.Add(appKeyNegativeTTL))
+		return uuid.Nil, ErrInvalidAppKey
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to look up app key: %w", err)
+	}
+
+	s.remember(hashedKey, app.ID, now.Add(appKeyMemoryTTL))
+	if s.redis != nil {
+		s.redis.Set(ctx, appKeyCacheKey(hashedKey), app.ID.String(), appKeyRedisTTL)
+	}
+	return app.ID, nil
+}
+
+// InvalidateAppKey drops a key hash from the caches, e.g. after the key is regenerated or the app deleted.
+func (s *AppKeyService) InvalidateAppKey(ctx context.Context, hashedKey string) {
+	s.mu.Lock()
+	delete(s.cache, hashedKey)
+	s.mu.Unlock()
+
+	if s.redis != nil {
+		s.redis.Del(ctx, appKeyCacheKey(hashedKey))
+	}
+}
+
+func (s *AppKeyService) cached(hashedKey string, now time.Time) (uuid.UUID, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.cache[hashedKey]
+	if !ok || now.After(entry.expiresAt) {
+		return uuid.Nil, false
+	}
+	return entry.appID, true
+}
+
+func (s *AppKeyService) remember(hashedKey string, appID uuid.UUID, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Once the map is full, sweep expired entries and, when a flood of fresh junk keys leaves too few of those,
+	// drop arbitrary ones (map order is random) down to 90% so the next sweep is some inserts away. An evicted
+	// valid key is only looked up again.
+	if _, ok := s.cache[hashedKey]; !ok && len(s.cache) >= appKeyCacheSize {
+		now := time.Now()
+		for k, entry := range s.cache {
+			if now.After(entry.expiresAt) {
+				delete(s.cache, k)
+			}
+		}
+		for k := range s.cache {
+			if len(s.cache) < appKeyCacheSize-appKeyCacheSize/10 {
+				break
+			}
+			delete(s.cache, k)
+		}
+	}
+	s.cache[hashedKey] = cachedAppKey{appID: appID, expiresAt: expiresAt}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ── AppKeyService Cache Tests ─────────────────────────────────
+
+func TestResolveAppKey_ServedFromMemory(t *testing.T) {
+	s := NewAppKeyService(nil, nil)
+	appID := uuid.New()
+	s.remember(HashApiKey("hp_valid"), appID, time.Now().Add(time.Minute))
+
+	got, err := s.ResolveAppKey(context.Background(), "hp_valid")
+	if err != nil {
+		t.Fatalf("ResolveAppKey failed: %v", err)
+	}
+	if got != appID {
+		t.Errorf("ResolveAppKey = %s, want %s", got, appID)
+	}
+}
+
+func TestResolveAppKey_NegativeCache(t *testing.T) {
+	s := NewAppKeyService(nil, nil)
+	s.remember(HashApiKey("hp_unknown"), uuid.Nil, time.Now().Add(time.Minute))
+
+	_, err := s.ResolveAppKey(context.Background(), "hp_unknown")
+	if !errors.Is(err, ErrInvalidAppKey) {
+		t.Errorf("ResolveAppKey error = %v, want ErrInvalidAppKey", err)
+	}
+}
+
+func TestAppKeyCache_Expiry(t *testing.T) {
+	s := NewAppKeyService(nil, nil)
+	hashed := HashApiKey("hp_expiring")
+	s.remember(hashed, uuid.New(), time.Now().Add(-time.Second))
+
+	if _, ok := s.cached(hashed, time.Now()); ok {
+		t.Error("expired entry should not be served")
+	}
+}
+
+func TestInvalidateAppKey(t *testing.T) {
+	s := NewAppKeyService(nil, nil)
+	hashed := HashApiKey("hp_rotated")
+	s.remember(hashed, uuid.New(), time.Now().Add(time.Minute))
+
+	s.InvalidateAppKey(context.Background(), hashed)
+
+	if _, ok := s.cached(hashed, time.Now()); ok {
+		t.Error("invalidated key should not be served from cache")
+	}
+}
+
+func TestAppKeyCache_KeyedByHash(t *testing.T) {
+	s := NewAppKeyService(nil, nil)
+	s.remember(HashApiKey("hp_secret"), uuid.New(), time.Now().Add(time.Minute))
+
+	if _, ok := s.cache["hp_secret"]; ok {
+		t.Error("raw keys must never be stored in the cache")
+	}
+}
+
+func TestAppKeyCache_Bounded(t *testing.T) {
+	s := NewAppKeyService(nil, nil)
+	expiresAt := time.Now().Add(time.Minute)
+
+	// Unknown keys that have not expired yet, as a caller sending fresh random keys leaves behind
+	for i := 0; i < 3*appKeyCacheSize; i++ {
+		s.remember(HashApiKey(uuid.NewString()), uuid.Nil, expiresAt)
+		if len(s.cache) > appKeyCacheSize {
+			t.Fatalf("cache holds %d entries after %d keys, want at most %d", len(s.cache), i+1, appKeyCacheSize)
+		}
+	}
+
+	// The key just remembered is always served
+	hashed := HashApiKey("hp_valid")
+	s.remember(hashed, uuid.New(), expiresAt)
+	if _, ok := s.cached(hashed, time.Now()); !ok {
+		t.Error("a key remembered into a full cache should be served")
+	}
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSelfApproval is returned when the uploader of a release tries to review it.
+	ErrSelfApproval = errors.New("a release cannot be reviewed by its uploader")
+	// ErrAlreadyReviewed is returned when a reviewer decides on the same release twice.
+	ErrAlreadyReviewed = errors.New("release already reviewed")
+)
+
+// requiredApprovals returns how many approvals a release needs before going live in the channel.
+// Channels without a channel record require none.
+func (s *ReleaseService) requiredApprovals(appID uuid.UUID, channel string) (int, error) {
+	ch, err := s.channelRepo.GetBySlug(appID, channel)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load channel: %w", err)
+	}
+	return ch.RequiredApprovals, nil
+}
+
+// submitForApproval moves a draft into review instead of publishing or scheduling it. The publish time,
+// if any, is kept and applies once the release is approved.
+func (s *ReleaseService) submitForApproval(release *models.Release, required int, publishAt *time.Time, actor, reason string) error {
+	updates := map[string]interface{}{"required_approvals": required}
+	if publishAt != nil {
+		updates["publish_at"] = *publishAt
+	}
+	if err := s.lifecycle.transition(release, models.ReleasePendingApproval, actor, reason, updates); err != nil {
+		return err
+	}
+	release.RequiredApprovals = required
+	release.PublishAt = publishAt
+	return nil
+}
+
+// Approve records a reviewer's approval of a release awaiting approval. The approval that completes
+// the required count publishes the release, or schedules it when its publish time is still ahead
+// or its channel defers changes during a freeze.
+func (s *ReleaseService) Approve(ctx context.Context, appID, releaseID uuid.UUID, req *models.ReviewReleaseRequest, actor, ip string) (*models.Release, error) {
+	release, approvals, err := s.pendingReview(appID, releaseID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	approvers := []string{actor}
+	for _, a := range approvals {
+		if a.Decision == models.ApprovalApproved {
+			approvers = append(approvers, a.Actor)
+		}
+	}
+
+	// Check the release can go live before the final approval is recorded
+	now := time.Now()
+	final := len(approvers) >= release.RequiredApprovals
+	var publishAt *time.Time
+	if final {
+		if publishAt, err = s.approvedPublishAt(release, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.recordReview(release, models.ApprovalApproved, actor, req.Comment); err != nil {
+		return nil, err
+	}
+	metadata := fmt.Sprintf("Approval %d/%d", len(approvers), release.RequiredApprovals)
+	if req.Comment != "" {
+		metadata += ", comment: " + req.Comment
+	}
+	s.securityService.Log(appID, actor, "release.approve", releaseID.String(), metadata, ip)
+
+	if final {
+		reason := "Approved by " + strings.Join(approvers, ", ")
+		if err := s.releaseApproved(ctx, release, publishAt, actor, reason, now); err != nil {
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// Reject records a reviewer's rejection of a release awaiting approval. A rejected release is never published.
+func (s *ReleaseService) Reject(ctx context.Context, appID, releaseID uuid.UUID, req *models.ReviewReleaseRequest, actor, ip string) (*models.Release, error) {
+	release, _, err := s.pendingReview(appID, releaseID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recordReview(release, models.ApprovalRejected, actor, req.Comment); err != nil {
+		return nil, err
+	}
+	if err := s.lifecycle.transition(release, models.ReleaseRejected, actor, req.Comment, map[string]interface{}{"publish_at": nil}); err != nil {
+		return nil, err
+	}
+	release.PublishAt = nil
+
+	s.securityService.Log(appID, actor, "release.reject", releaseID.String(), req.Comment, ip)
+	return release, nil
+}
+
+// OverrideApproval publishes a release awaiting approval without its remaining approvals. Meant for
+// emergencies; the override and its reason are recorded with the release's reviews and in the audit log.
+func (s *ReleaseService) OverrideApproval(ctx context.Context, appID, releaseID uuid.UUID, req *models.OverrideApprovalRequest, actor, ip string) (*models.Release, error) {
+	release, err := s.getRelease(appID, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if release.Status != models.ReleasePendingApproval {
+		return nil, fmt.Errorf("%w: a %s release is not awaiting approval", ErrInvalidTransition, release.Status)
+	}
+	approvals, err := s.repo.ListApprovals(appID, releaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load approvals: %w", err)
+	}
+
+	now := time.Now()
+	publishAt, err := s.approvedPublishAt(release, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// A reviewer who already approved keeps their approval; the override shows in the audit log
+	approved, reviewed := 0, false
+	for _, a := range approvals {
+		if a.Decision == models.ApprovalApproved {
+			approved++
+		}
+		reviewed = reviewed || a.Actor == actor
+	}
+	if !reviewed {
+		if err := s.recordReview(release, models.ApprovalOverride, actor, req.Reason); err != nil {
+			return nil, err
+		}
+	}
+	s.securityService.Log(appID, actor, "release.approval_override", releaseID.String(), fmt.Sprintf("Approvals: %d/%d, reason: %s", approved, release.RequiredApprovals, req.Reason), ip)
+
+	if err := s.releaseApproved(ctx, release, publishAt, actor, "Approval overridden: "+req.Reason, now); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// Approvals returns the review decisions on a release, oldest first.
+func (s *ReleaseService) Approvals(appID, releaseID uuid.UUID) ([]models.ReleaseApproval, error) {
+	if _, err := s.getRelease(appID, releaseID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListApprovals(appID, releaseID)
+}
+
+// pendingReview loads a release awaiting approval together with its reviews so far, checking that
+// the reviewer is neither its uploader nor has already decided on it.
+func (s *ReleaseService) pendingReview(appID, releaseID uuid.UUID, reviewer string) (*models.Release, []models.ReleaseApproval, error) {
+	release, err := s.getRelease(appID, releaseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if release.Status != models.ReleasePendingApproval {
+		return nil, nil, fmt.Errorf("%w: a %s release is not awaiting approval", ErrInvalidTransition, release.Status)
+	}
+	if reviewer == release.CreatedBy {
+		return nil, nil, ErrSelfApproval
+	}
+
+	approvals, err := s.repo.ListApprovals(appID, releaseID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load approvals: %w", err)
+	}
+	for _, a := range approvals {
+		if a.Actor == reviewer {
+			return nil, nil, fmt.Errorf("%w: %s already %s this release", ErrAlreadyReviewed, reviewer, a.Decision)
+		}
+	}
+	return release, approvals, nil
+}
+
+// recordReview stores a reviewer's decision on a release.
+func (s *ReleaseService) recordReview(release *models.Release, decision, actor, comment string) error {
+	err := s.repo.CreateApproval(&models.ReleaseApproval{
+		ID:        uuid.New(),
+		AppID:     release.AppID,
+		ReleaseID: release.ID,
+		Actor:     actor,
+		Decision:  decision,
+		Comment:   comment,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record review: %w", err)
+	}
+	return nil
+}
+
+// approvedPublishAt returns when an approved release goes live: its publish time if still ahead, the
+// end of its channel's freeze window when the channel defers changes, or nil to publish now. A release
+// that cannot go live now is refused, so no approval is recorded for it.
+func (s *ReleaseService) approvedPublishAt(release *models.Release, now time.Time) (*time.Time, error) {
+	if release.PublishAt != nil && release.PublishAt.After(now) {
+		return release.PublishAt, nil
+	}
+
+	freeze, err := s.freezeService.Check(release.AppID, release.Channel, now)
+	if err != nil {
+		return nil, err
+	}
+	if freeze != nil {
+		if freeze.Action != models.FreezeActionDefer {
+			return nil, frozenError(freeze)
+		}
+		until := freeze.Until
+		return &until, nil
+	}
+
+	newer, err := s.overtakenBy(release)
+	if err != nil {
+		return nil, err
+	}
+	if newer != nil {
+		return nil, fmt.Errorf("%w: version %s is already live in %s", ErrInvalidTransition, newer.Version, release.Channel)
+	}
+	return nil, nil
+}
+
+// releaseApproved publishes an approved release, or hands it to the scheduler when it has a publish time.
+func (s *ReleaseService) releaseApproved(ctx context.Context, release *models.Release, publishAt *time.Time, actor, reason string, now time.Time) error {
+	if publishAt == nil {
+		return s.activate(ctx, release, actor, reason, now)
+	}
+	if err := s.lifecycle.transition(release, models.ReleaseScheduled, actor, reason, map[string]interface{}{"publish_at": *publishAt}); err != nil {
+		return err
+	}
+	release.PublishAt = publishAt
+	return nil
+}
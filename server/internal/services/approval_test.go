@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+)
+
+// ── Release Approval Tests ──────────────────────────────────
+
+// approvalTest holds app A's release service over the tables that review and promotion touch.
+type approvalTest struct {
+	tenants
+	releaseService *ReleaseService
+}
+
+func newApprovalTest(t *testing.T) *approvalTest {
+	t.Helper()
+	tn := newTenants(t, &models.Channel{}, &models.ReleaseTransition{}, &models.ReleaseApproval{}, &models.Patch{}, &models.PatchJob{}, &models.BundleManifest{}, &models.SecretFinding{}, &models.RolloutPlan{}, &models.AuditLog{})
+	releaseService, _ := newReleaseService(tn, PatchGeneration{})
+	return &approvalTest{tenants: tn, releaseService: releaseService}
+}
+
+// requireApprovals makes app A's production channel require the given number of approvals.
+func requireApprovals(t *testing.T, tn tenants, n int) {
+	t.Helper()
+	channel := models.Channel{ID: uuid.New(), AppID: tn.appA, Name: "Production", Slug: "production", RequiredApprovals: n}
+	if err := tn.db.Create(&channel).Error; err != nil {
+		t.Fatalf("failed to create channel: %v", err)
+	}
+}
+
+func TestApproval_ReleaseGoesLiveOnceApproved(t *testing.T) {
+	f := newApprovalTest(t)
+	ctx := context.Background()
+	requireApprovals(t, f.tenants, 2)
+	source := stagingRelease(t, f.tenants, "1.1.0")
+
+	pending, err := f.releaseService.Promote(ctx, f.appA, source.ID, &models.PromoteReleaseRequest{Channel: "production"}, "alice", "")
+	if err != nil {
+		t.Fatalf("Promote failed: %v", err)
+	}
+	if pending.Status != models.ReleasePendingApproval || pending.IsActive || pending.RequiredApprovals != 2 {
+		t.Fatalf("promoted release = (%s, active %v, %d approvals), want pending_approval, inactive, 2", pending.Status, pending.IsActive, pending.RequiredApprovals)
+	}
+	if got := f.release(t, f.releaseA); !got.IsActive {
+		t.Error("live release replaced before approval")
+	}
+
+	review := &models.ReviewReleaseRequest{Comment: "looks good"}
+	if _, err := f.releaseService.Approve(ctx, f.appA, pending.ID, review, "alice", ""); !errors.Is(err, ErrSelfApproval) {
+		t.Errorf("uploader approving: err = %v, want ErrSelfApproval", err)
+	}
+	if _, err := f.releaseService.Approve(ctx, f.appA, pending.ID, review, "bob", ""); err != nil {
+		t.Fatalf("first approval failed: %v", err)
+	}
+	if _, err := f.releaseService.Approve(ctx, f.appA, pending.ID, review, "bob", ""); !errors.Is(err, ErrAlreadyReviewed) {
+		t.Errorf("approving twice: err = %v, want ErrAlreadyReviewed", err)
+	}
+	if got := f.release(t, pending.ID); got.Status != models.ReleasePendingApproval || got.IsActive {
+		t.Fatalf("release after one of two approvals = (%s, active %v), want pending_approval and inactive", got.Status, got.IsActive)
+	}
+
+	approved, err := f.releaseService.Approve(ctx, f.appA, pending.ID, review, "carol", "")
+	if err != nil {
+		t.Fatalf("second approval failed: %v", err)
+	}
+	if approved.Status != models.ReleaseCompleted || !f.release(t, pending.ID).IsActive {
+		t.Errorf("approved release = (%s, active %v), want completed and active", approved.Status, f.release(t, pending.ID).IsActive)
+	}
+	if got := f.release(t, f.releaseA).Status; got != models.ReleaseSuperseded {
+		t.Errorf("previous release status = %s, want superseded", got)
+	}
+
+	var logs []models.AuditLog
+	if err := f.db.Where("action = ? AND entity_id = ?", "release.approve", pending.ID.String()).Order("created_at ASC").Find(&logs).Error; err != nil {
+		t.Fatalf("failed to load audit logs: %v", err)
+	}
+	if len(logs) != 2 || logs[0].Actor != "bob" || logs[1].Actor != "carol" {
+		t.Errorf("approval audit logs = %+v, want one each for bob and carol", logs)
+	}
+}
+
+func TestApproval_RejectAndOverride(t *testing.T) {
+	f := newApprovalTest(t)
+	ctx := context.Background()
+	requireApprovals(t, f.tenants, 2)
+
+	// Publishing a draft in a gated channel submits it for approval
+	rejected := draftRelease(t, f.tenants, "1.1.0")
+	submitted, err := f.releaseService.SetStatus(ctx, f.appA, rejected.ID, &models.SetReleaseStatusRequest{Status: models.ReleaseRollingOut}, "alice", "")
+	if err != nil {
+		t.Fatalf("submitting draft failed: %v", err)
+	}
+	if submitted.Status != models.ReleasePendingApproval {
+		t.Fatalf("submitted draft status = %s, want pending_approval", submitted.Status)
+	}
+	if _, err := f.releaseService.Reject(ctx, f.appA, rejected.ID, &models.ReviewReleaseRequest{Comment: "crashes on launch"}, "bob", ""); err != nil {
+		t.Fatalf("Reject failed: %v", err)
+	}
+	if got := f.release(t, rejected.ID); got.Status != models.ReleaseRejected || got.IsActive {
+		t.Errorf("rejected release = (%s, active %v), want rejected and inactive", got.Status, got.IsActive)
+	}
+	if _, err := f.releaseService.Approve(ctx, f.appA, rejected.ID, &models.ReviewReleaseRequest{}, "carol", ""); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("approving a rejected release: err = %v, want ErrInvalidTransition", err)
+	}
+
+	// An override publishes without the remaining approvals and is recorded with the reviews
+	source := stagingRelease(t, f.tenants, "1.2.0")
+	pending, err := f.releaseService.Promote(ctx, f.appA, source.ID, &models.PromoteReleaseRequest{Channel: "production"}, "alice", "")
+	if err != nil {
+		t.Fatalf("Promote failed: %v", err)
+	}
+	if _, err := f.releaseService.Approve(ctx, f.appA, pending.ID, &models.ReviewReleaseRequest{}, "bob", ""); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	live, err := f.releaseService.OverrideApproval(ctx, f.appA, pending.ID, &models.OverrideApprovalRequest{Reason: "hotfix for outage"}, "alice", "")
+	if err != nil {
+		t.Fatalf("OverrideApproval failed: %v", err)
+	}
+	if live.Status != models.ReleaseCompleted || !f.release(t, pending.ID).IsActive {
+		t.Errorf("overridden release = (%s, active %v), want completed and active", live.Status, f.release(t, pending.ID).IsActive)
+	}
+
+	approvals, err := f.releaseService.Approvals(f.appA, pending.ID)
+	if err != nil {
+		t.Fatalf("Approvals failed: %v", err)
+	}
+	if len(approvals) != 2 || approvals[0].Decision != models.ApprovalApproved || approvals[1].Decision != models.ApprovalOverride || approvals[1].Actor != "alice" {
+		t.Errorf("approvals = %+v, want bob's approval then alice's override", approvals)
+	}
+	var overrides int64
+	f.db.Model(&models.AuditLog{}).Where("action = ? AND actor = ?", "release.approval_override", "alice").Count(&overrides)
+	if overrides != 1 {
+		t.Errorf("override audit logs = %d, want 1", overrides)
+	}
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrCorruptPatch is returned when a patch is not a well-formed BSDIFF40 patch for the bundle it is applied to.
+var ErrCorruptPatch = errors.New("corrupt patch")
+
+// bsdiffMagic opens a BSDIFF40 patch, the format the CLI produces and the SDKs apply.
+const bsdiffMagic = "BSDIFF40"
+
+// bsdiff returns a BSDIFF40 patch that turns oldData into newData. It is Colin Percival's bsdiff 4.3:
+// matches are found through a suffix array of the old file, approximate matches are extended forwards and
+// backwards, and the patch holds (add, copy, seek) control triples, the bytewise differences of the
+// matched regions and the unmatched bytes, each block bzip2-compressed.
+func bsdiff(oldData, newData []byte) []byte {
+	suffixes := bsdiffSuffixArray(oldData)
+	oldSize, newSize := len(oldData), len(newData)
+
+	var ctrl, diff, extra []byte
+	var scan, length, pos, lastScan, lastPos, lastOffset int
+	for scan < newSize {
+		oldScore := 0
+		scan += length
+		for scsc := scan; scan < newSize; scan++ {
+			length, pos = bsdiffSearch(suffixes, oldData, newData[scan:], 0, oldSize)
+			for ; scsc < scan+length; scsc++ {
+				if scsc+lastOffset < oldSize && oldData[scsc+lastOffset] == newData[scsc] {
+					oldScore++
+				}
+			}
+			if (length == oldScore && length != 0) || length > oldScore+8 {
+				break
+			}
+			if scan+lastOffset < oldSize && oldData[scan+lastOffset] == newData[scan] {
+				oldScore--
+			}
+		}
+
+		if length == oldScore && scan != newSize {
+			continue
+		}
+
+		// Extend the previous match forwards and this one backwards, as far as they pay off
+		lenf := 0
+		for s, best, i := 0, 0, 0; lastScan+i < scan && lastPos+i < oldSize; {
+			if oldData[lastPos+i] == newData[lastScan+i] {
+				s++
+			}
+			i++
+			if s*2-i > best*2-lenf {
+				best, lenf = s, i
+			}
+		}
+		lenb := 0
+		if scan < newSize {
+			for s, best, i := 0, 0, 1; scan >= lastScan+i && pos >= i; i++ {
+				if oldData[pos-i] == newData[scan-i] {
+					s++
+				}
+				if s*2-i > best*2-lenb {
+					best, lenb = s, i
+				}
+			}
+		}
+		// Split any overlap where it matches best
+		if lastScan+lenf > scan-lenb {
+			overlap := lastScan + lenf - (scan - lenb)
+			s, best, lens := 0, 0, 0
+			for i := 0; i < overlap; i++ {
+				if newData[lastScan+lenf-overlap+i] == oldData[lastPos+lenf-overlap+i] {
+					s++
+				}
+				if newData[scan-lenb+i] == oldData[pos-lenb+i] {
+					s--
+				}
+				if s > best {
+					best, lens = s, i+1
+				}
+			}
+			lenf += lens - overlap
+			lenb -= lens
+		}
+
+		for i := 0; i < lenf; i++ {
+			diff = append(diff, newData[lastScan+i]-oldData[lastPos+i])
+		}
+		extra = append(extra, newData[lastScan+lenf:scan-lenb]...)
+		ctrl = bsdiffAppendInt(ctrl, lenf)
+		ctrl = bsdiffAppendInt(ctrl, (scan-lenb)-(lastScan+lenf))
+		ctrl = bsdiffAppendInt(ctrl, (pos-lenb)-(lastPos+lenf))
+
+		lastScan, lastPos, lastOffset = scan-lenb, pos-lenb, pos-scan
+	}
+
+	ctrlBlock, diffBlock, extraBlock := bzip2Compress(ctrl), bzip2Compress(diff), bzip2Compress(extra)
+	patch := make([]byte, 0, 32+len(ctrlBlock)+len(diffBlock)+len(extraBlock))
+	patch = append(patch, bsdiffMagic...)
+	patch = bsdiffAppendInt(patch, len(ctrlBlock))
+	patch = bsdiffAppendInt(patch, len(diffBlock))
+	patch = bsdiffAppendInt(patch, newSize)
+	patch = append(patch, ctrlBlock...)
+	patch = append(patch, diffBlock...)
+	return append(patch, extraBlock...)
+}
+
+// bspatch applies a BSDIFF40 patch to oldData. The result is refused when it would exceed maxSize bytes.
+func bspatch(oldData, patch []byte, maxSize int64) ([]byte, error) {
+	var newData bytes.Buffer
+	if err := bspatchTo(&newData, bytes.NewReader(oldData), int64(len(oldData)), patch, maxSize); err != nil {
+		return nil, err
+	}
+	return newData.Bytes(), nil
+}
+
+// bspatchChunk is how much output bspatchTo produces at a time.
+const bspatchChunk = 64 << 10
+
+// bspatchTo applies a BSDIFF40 patch to the oldSize bytes of old and writes the result to out as it is
+// produced, so neither the old nor the new bundle has to be held in memory. The result is refused when it
+// would exceed maxSize bytes; out may have been written to by then.
+func bspatchTo(out io.Writer, old io.ReaderAt, oldSize int64, patch []byte, maxSize int64) error {
+	if len(patch) < 32 || string(patch[:8]) != bsdiffMagic {
+		return fmt.Errorf("%w: missing %s header", ErrCorruptPatch, bsdiffMagic)
+	}
+	ctrlLen, diffLen, newSize := bsdiffInt(patch[8:]), bsdiffInt(patch[16:]), bsdiffInt(patch[24:])
+	if ctrlLen < 0 || diffLen < 0 || newSize < 0 || ctrlLen > int64(len(patch)-32) || diffLen > int64(len(patch)-32)-ctrlLen {
+		return fmt.Errorf("%w: invalid block lengths", ErrCorruptPatch)
+	}
+	if newSize > maxSize {
+		return fmt.Errorf("%w: patch output of %d bytes exceeds the limit of %d", ErrCorruptPatch, newSize, maxSize)
+	}
+	body := patch[32:]
+	ctrl := bzip2.NewReader(bytes.NewReader(body[:ctrlLen]))
+	diff := bzip2.NewReader(bytes.NewReader(body[ctrlLen : ctrlLen+diffLen]))
+	extra := bzip2.NewReader(bytes.NewReader(body[ctrlLen+diffLen:]))
+
+	chunk, oldChunk := make([]byte, bspatchChunk), make([]byte, bspatchChunk)
+	var triple [24]byte
+	var oldPos, newPos int64
+	for newPos < newSize {
+		if _, err := io.ReadFull(ctrl, triple[:]); err != nil {
+			return fmt.Errorf("%w: truncated control block: %v", ErrCorruptPatch, err)
+		}
+		add, copyLen, seek := bsdiffInt(triple[:]), bsdiffInt(triple[8:]), bsdiffInt(triple[16:])
+		if add < 0 || copyLen < 0 || add > newSize-newPos || copyLen > newSize-newPos-add {
+			return fmt.Errorf("%w: control triple overruns the output", ErrCorruptPatch)
+		}
+
+		// Diff bytes are added to the old bytes at oldPos; those outside the old bundle count as zero
+		for done := int64(0); done < add; {
+			n := min(add-done, bspatchChunk)
+			if _, err := io.ReadFull(diff, chunk[:n]); err != nil {
+				return fmt.Errorf("%w: truncated diff block: %v", ErrCorruptPatch, err)
+			}
+			if err := readOldRange(old, oldSize, oldPos+done, oldChunk[:n]); err != nil {
+				return err
+			}
+			for i := range chunk[:n] {
+				chunk[i] += oldChunk[i]
+			}
+			if _, err := out.Write(chunk[:n]); err != nil {
+				return err
+			}
+			done += n
+		}
+		newPos += add
+		oldPos += add
+
+		for done := int64(0); done < copyLen; {
+			n := min(copyLen-done, bspatchChunk)
+			if _, err := io.ReadFull(extra, chunk[:n]); err != nil {
+				return fmt.Errorf("%w: truncated extra block: %v", ErrCorruptPatch, err)
+			}
+			if _, err := out.Write(chunk[:n]); err != nil {
+				return err
+			}
+			done += n
+		}
+		newPos += copyLen
+		oldPos += seek
+	}
+	return nil
+}
+
+// readOldRange fills buf with the old bytes from pos on, zeroing the part of it outside the old bundle.
+func readOldRange(old io.ReaderAt, oldSize, pos int64, buf []byte) error {
+	clear(buf)
+	start, end := max(pos, 0), min(pos+int64(len(buf)), oldSize)
+	if start >= end {
+		return nil
+	}
+	if _, err := old.ReadAt(buf[start-pos:end-pos], start); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read base bundle: %w", err)
+	}
+	return nil
+}
+
+// bsdiffSuffixArray returns the suffix array of data, including the empty suffix at index 0.
+func bsdiffSuffixArray(data []byte) []int32 {
+	symbols := make([]int32, len(data)+1)
+	for i, b := range data {
+		symbols[i] = int32(b) + 1
+	}
+	// The terminating 0 sorts below every byte, so rotation order is suffix order
+	return sortRotations(symbols, 257)
+}
+
+// sortRotations returns the start positions of the cyclic rotations of s in lexicographic order, by prefix
+// doubling with radix sorts in O(n log n). Symbols must lie in [0, alphabet). Ending s with a unique smallest
+// symbol makes the order of its rotations that of its suffixes.
+func sortRotations(s []int32, alphabet int) []int32 {
+	n := len(s)
+	if n == 0 {
+		return nil
+	}
+	order := make([]int32, n)
+	rank := make([]int32, n)
+	scratch := make([]int32, n)
+	count := make([]int32, max(alphabet, n))
+
+	for _, c := range s {
+		count[c]++
+	}
+	for i := 1; i < alphabet; i++ {
+		count[i] += count[i-1]
+	}
+	for i := n - 1; i >= 0; i-- {
+		count[s[i]]--
+		order[count[s[i]]] = int32(i)
+	}
+	classes := 1
+	rank[order[0]] = 0
+	for i := 1; i < n; i++ {
+		if s[order[i]] != s[order[i-1]] {
+			classes++
+		}
+		rank[order[i]] = int32(classes - 1)
+	}
+
+	for k := 1; k < n && classes < n; k <<= 1 {
+		// Rotations in order of their second half: the one at p-k sorts where the one at p does
+		for i, p := range order {
+			q := int(p) - k
+			if q < 0 {
+				q += n
+			}
+			scratch[i] = int32(q)
+		}
+		// Stable counting sort by the first half
+		clear(count[:classes])
+		for _, p := range scratch {
+			count[rank[p]]++
+		}
+		for i := 1; i < classes; i++ {
+			count[i] += count[i-1]
+		}
+		for i := n - 1; i >= 0; i-- {
+			p := scratch[i]
+			count[rank[p]]--
+			order[count[rank[p]]] = p
+		}
+		// Rank by both halves, reusing scratch for the new ranks
+		scratch[order[0]] = 0
+		classes = 1
+		for i := 1; i < n; i++ {
+			cur, prev := int(order[i]), int(order[i-1])
+			if rank[cur] != rank[prev] || rank[(cur+k)%n] != rank[(prev+k)%n] {
+				classes++
+			}
+			scratch[cur] = int32(classes - 1)
+		}
+		rank, scratch = scratch, rank
+	}
+	return order
+}
+
+// huffmanCodeLengths returns Huffman code lengths of at most maxLen bits for the given symbol frequencies.
+
+// bsdiffSearch finds the longest match of target among the suffixes of oldData between st and en in the
+// suffix array by binary search, returning its length and position.
+func bsdiffSearch(suffixes []int32, oldData, target []byte, st, en int) (int, int) {
+	for en-st >= 2 {
+		x := st + (en-st)/2
+		suffix := oldData[suffixes[x]:]
+		n := min(len(suffix), len(target))
+		if bytes.Compare(suffix[:n], target[:n]) < 0 {
+			st = x
+		} else {
+			en = x
+		}
+	}
+	x := bsdiffMatchLen(oldData[suffixes[st]:], target)
+	y := bsdiffMatchLen(oldData[suffixes[en]:], target)
+	if x > y {
+		return x, int(suffixes[st])
+	}
+	return y, int(suffixes[en])
+}
+
+func bsdiffMatchLen(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// bsdiffAppendInt appends x as bsdiff encodes integers: eight bytes of little-endian magnitude with the
+// sign in the top bit.
+func bsdiffAppendInt(b []byte, x int) []byte {
+	v := uint64(x)
+	if x < 0 {
+		v = uint64(-x) | 1<<63
+	}
+	return binary.LittleEndian.AppendUint64(b, v)
+}
+
+// bsdiffInt decodes an integer written by bsdiffAppendInt.
+func bsdiffInt(b []byte) int64 {
+	v := binary.LittleEndian.Uint64(b)
+	x := int64(v &^ (1 << 63))
+	if v&(1<<63) != 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"errors"
+	"io"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// ── bzip2 and bsdiff Tests ──────────────────────────────────
+
+func TestBzip2Compress_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := make([]byte, 300_000)
+	rng.Read(random)
+	sparse := make([]byte, 2_000_000) // spans three blocks; mostly zeros, like a bsdiff diff block
+	for i := range sparse {
+		if rng.Intn(20) == 0 {
+			sparse[i] = byte(rng.Intn(256))
+		}
+	}
+
+	cases := map[string][]byte{
+		"empty":            nil,
+		"single byte":      []byte("a"),
+		"run of four":      []byte("aaaa"),
+		"run past 255":     bytes.Repeat([]byte{0}, 1000),
+		"text":             []byte("the quick brown fox jumps over the lazy dog, banana bandana"),
+		"periodic":         bytes.Repeat([]byte("abc"), 400_000),
+		"random":           random,
+		"sparse, 3 blocks": sparse,
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			compressed := bzip2Compress(data)
+			got, err := io.ReadAll(bzip2.NewReader(bytes.NewReader(compressed)))
+			if err != nil {
+				t.Fatalf("compress/bzip2 rejected the stream: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("round trip returned %d bytes, want the %d written", len(got), len(data))
+			}
+		})
+	}
+}
+
+func TestSortRotations_MatchesNaiveSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, input := range []string{"banana", "aaaa", "abab", "mississippi", "x"} {
+		checkRotationOrder(t, []byte(input))
+	}
+	for i := 0; i < 20; i++ {
+		data := make([]byte, 1+rng.Intn(200))
+		for j := range data {
+			data[j] = byte('a' + rng.Intn(3))
+		}
+		checkRotationOrder(t, data)
+	}
+}
+
+func checkRotationOrder(t *testing.T, data []byte) {
+	t.Helper()
+	symbols := make([]int32, len(data))
+	for i, b := range data {
+		symbols[i] = int32(b)
+	}
+	rotation := func(i int32) []byte { return append(append([]byte(nil), data[i:]...), data[:i]...) }
+	order := sortRotations(symbols, 256)
+	if !sort.SliceIsSorted(order, func(a, b int) bool { return bytes.Compare(rotation(order[a]), rotation(order[b])) < 0 }) {
+		t.Errorf("rotations of %q are not in order: %v", data, order)
+	}
+}
+
+func TestBsdiff_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	bundle := make([]byte, 200_000)
+	for i := range bundle {
+		bundle[i] = "abcdefghij{}();=\n "[rng.Intn(18)]
+	}
+	edited := append([]byte(nil), bundle[:50_000]...)
+	edited = append(edited, "function added() { return 42 }"...)
+	edited = append(edited, bundle[60_000:150_000]...) // 10k bytes removed
+	edited = append(edited, bundle[20_000:30_000]...)  // moved block: a backwards seek
+	edited = append(edited, bundle[150_000:]...)
+	edited[100] ^= 0x20
+
+	cases := []struct {
+		name     string
+		old, new []byte
+	}{
+		{"edited bundle", bundle, edited},
+		{"identical", bundle, bundle},
+		{"from nothing", nil, []byte("first")},
+		{"to nothing", bundle, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			patch := bsdiff(tc.old, tc.new)
+			got, err := bspatch(tc.old, patch, int64(len(tc.new)))
+			if err != nil {
+				t.Fatalf("bspatch failed: %v", err)
+			}
+			if !bytes.Equal(got, tc.new) {
+				t.Fatalf("bspatch reproduced %d bytes that differ from the %d expected", len(got), len(tc.new))
+			}
+		})
+	}
+
+	if patch := bsdiff(bundle, edited); len(patch) > len(edited)/10 {
+		t.Errorf("patch of a small edit is %d bytes for a %d byte bundle", len(patch), len(edited))
+	}
+}
+
+func TestBspatch_RejectsCorruptPatches(t *testing.T) {
+	old := []byte("console.log('v1')")
+	patch := bsdiff(old, []byte("console.log('v2')"))
+
+	truncated := patch[:40]
+	badMagic := append([]byte("BSDIFF41"), patch[8:]...)
+
+	for name, p := range map[string][]byte{"truncated": truncated, "bad magic": badMagic, "empty": nil} {
+		if _, err := bspatch(old, p, 1<<20); !errors.Is(err, ErrCorruptPatch) {
+			t.Errorf("bspatch(%s) err = %v, want ErrCorruptPatch", name, err)
+		}
+	}
+	if _, err := bspatch(old, patch, 5); !errors.Is(err, ErrCorruptPatch) {
+		t.Errorf("bspatch over the size limit: err = %v, want ErrCorruptPatch", err)
+	}
+}
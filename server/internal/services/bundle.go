@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/hotpatch/server/internal/models"
+)
+
+// ErrBundleTooLarge is returned when an uploaded bundle exceeds the size limit of the app's tier.
+var ErrBundleTooLarge = errors.New("bundle too large")
+
+// tierBundleLimits caps the bundle size per tier; tiers without an entry are only bound by the server's maximum.
+var tierBundleLimits = map[string]int64{
+	"free": 25 << 20,
+	"pro":  100 << 20,
+}
+
+// bundleSizeLimit returns the largest bundle, in bytes, an app of the given tier may upload.
+func (s *ReleaseService) bundleSizeLimit(tier string) int64 {
+	limit := s.maxBundleSize
+	if tierLimit, ok := tierBundleLimits[tier]; ok && tierLimit < limit {
+		limit = tierLimit
+	}
+	return limit
+}
+
+// uploadBundle streams a bundle or patch to storage in one pass: it is size-checked and fed to check
+// (which hashes and verifies it) as it is read, encrypted in the chunked format when encryptionKey is set,
+// and uploaded part by part, so only one storage part is held in memory however large the artifact is.
+// Returns the number of bytes stored.
+func (s *ReleaseService) uploadBundle(ctx context.Context, objectKey, contentType string, bundle io.Reader, limit int64, encryptionKey string, check io.Writer) (int64, error) {
+	body := io.TeeReader(&limitedReader{r: bundle, limit: limit}, check)
+
+	if encryptionKey != "" {
+		encrypted, _, err := s.encryptionService.EncryptBundle(body, encryptionKey)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encrypt bundle: %w", err)
+		}
+		body = encrypted
+	}
+
+	size, err := s.storage.UploadStream(ctx, objectKey, body, contentType)
+	if err != nil {
+		if errors.Is(err, ErrBundleTooLarge) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("failed to upload bundle: %w", err)
+	}
+	return size, nil
+}
+
+// discardObject deletes an uploaded object that will not be used, logging rather than returning failures.
+func (s *ReleaseService) discardObject(ctx context.Context, objectKey string) {
+	if err := s.storage.Delete(context.WithoutCancel(ctx), objectKey); err != nil {
+		log.Printf("releases: failed to delete rejected upload %s: %v", objectKey, err)
+	}
+}
+
+var (
+	// errStoredBundleTooLarge is returned by readStoredBundle for a bundle over the limit it was given.
+	errStoredBundleTooLarge = errors.New("stored bundle too large")
+	// errStoredBundleChanged is returned by readStoredBundle when the stored bytes do not match the release hash.
+	errStoredBundleChanged = errors.New("stored bundle does not match its hash")
+	// errStoredBundleSealed is returned by spoolStoredBundle for an encrypted bundle it cannot decrypt.
+	errStoredBundleSealed = errors.New("stored bundle cannot be decrypted")
+)
+
+// readStoredBundle reads a release's stored bundle into memory, up to limit bytes, and checks it is still the
+// bundle the release was created with.
+func (s *ReleaseService) readStoredBundle(ctx context.Context, release *models.Release, limit int64) ([]byte, error) {
+	body, err := s.storage.Open(ctx, release.BundleKey)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle of version %s: %w", release.Version, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: the bundle of version %s is larger than %d MiB", errStoredBundleTooLarge, release.Version, limit>>20)
+	}
+	sum := sha256.Sum256(data)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), release.Hash) {
+		return nil, fmt.Errorf("%w: the stored bundle of version %s does not match its hash", errStoredBundleChanged, release.Version)
+	}
+	return data, nil
+}
+
+// spoolStoredBundle copies a release's stored bundle to a temporary file, up to limit bytes, and checks it is
+// still the bundle the release was created with. A bundle the server encrypted is decrypted with encryptionKey
+// on the way, so the spool holds the bytes that were uploaded and that devices install. The caller must close it.
+func (s *ReleaseService) spoolStoredBundle(ctx context.Context, release *models.Release, encryptionKey string, limit int64) (*bundleSpool, error) {
+	if release.IsEncrypted && release.EncryptionFormat != models.EncryptionChunked {
+		return nil, fmt.Errorf("%w: the bundle of version %s is sealed in one piece, which cannot be streamed", errStoredBundleSealed, release.Version)
+	}
+	body, err := s.storage.Open(ctx, release.BundleKey)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	spool, err := newBundleSpool()
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.New()
+	dst := &cappedWriter{w: io.MultiWriter(spool, digest), limit: limit}
+	if release.IsEncrypted {
+		err = s.encryptionService.DecryptStream(dst, body, encryptionKey)
+	} else {
+		_, err = io.Copy(dst, body)
+	}
+	switch {
+	case errors.Is(err, errStoredBundleTooLarge):
+		err = fmt.Errorf("%w: the bundle of version %s is larger than %d MiB", errStoredBundleTooLarge, release.Version, limit>>20)
+	case errors.Is(err, ErrCorruptStream):
+		err = fmt.Errorf("%w: the bundle of version %s does not decrypt with the app's key: %v", errStoredBundleSealed, release.Version, err)
+	case err != nil:
+		err = fmt.Errorf("failed to read bundle of version %s: %w", release.Version, err)
+	case !strings.EqualFold(hex.EncodeToString(digest.Sum(nil)), release.Hash):
+		err = fmt.Errorf("%w: the stored bundle of version %s does not match its hash", errStoredBundleChanged, release.Version)
+	}
+	if err != nil {
+		spool.Close()
+		return nil, err
+	}
+	return spool, nil
+}
+
+// cappedWriter writes to w until more than limit bytes have been written, then fails with errStoredBundleTooLarge.
+type cappedWriter struct {
+	w       io.Writer
+	limit   int64
+	written int64
+}
+
+func (c *cappedWriter) Write(p []byte) (int, error) {
+	if c.written += int64(len(p)); c.written > c.limit {
+		return 0, errStoredBundleTooLarge
+	}
+	return c.w.Write(p)
+}
+
+// limitedReader reads from r until more than limit bytes have been read, then fails with ErrBundleTooLarge.
+// Unlike io.LimitReader it reports an oversized body instead of silently truncating it.
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	// Read at most one byte past the limit so a body of exactly the limit still succeeds
+	if remaining := l.limit - l.read; int64(len(p)) > remaining+1 {
+		p = p[:remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return 0, bundleTooLarge(l.limit)
+	}
+	return n, err
+}
+
+// bundleTooLarge reports a bundle over the given limit.
+func bundleTooLarge(limit int64) error {
+	return fmt.Errorf("%w: bundles are limited to %d MiB", ErrBundleTooLarge, limit>>20)
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"gorm.io/gorm"
+)
+
+// DiffReleases compares the bundle of a release with that of another release of the app, file by file.
+func (s *ReleaseService) DiffReleases(appID, releaseID, againstID uuid.UUID) (*models.ManifestDiff, error) {
+	release, manifest, err := s.releaseManifest(appID, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	against, againstManifest, err := s.releaseManifest(appID, againstID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffManifests(againstManifest, manifest)
+	diff.ReleaseID, diff.AgainstID = release.ID, against.ID
+	diff.Summary.OldBundleSize, diff.Summary.NewBundleSize = against.Size, release.Size
+	diff.Summary.BundleSizeDelta = release.Size - against.Size
+	return diff, nil
+}
+
+// releaseManifest loads a release of the app with its bundle manifest.
+func (s *ReleaseService) releaseManifest(appID, releaseID uuid.UUID) (*models.Release, *models.BundleManifest, error) {
+	release, err := s.getRelease(appID, releaseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest, err := s.repo.GetManifest(releaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, fmt.Errorf("%w: version %s in %s has no bundle manifest", ErrManifestNotFound, release.Version, release.Channel)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load bundle manifest: %w", err)
+	}
+	return release, manifest, nil
+}
+
+// diffManifests lists the files added, removed and modified from old to new, each sorted by path. A file is
+// modified when its contents hash differently.
+func diffManifests(old, new *models.BundleManifest) *models.ManifestDiff {
+	diff := &models.ManifestDiff{Added: []models.FileChange{}, Removed: []models.FileChange{}, Modified: []models.FileChange{}}
+	oldFiles := make(map[string]models.BundleFile, len(old.Files))
+	for _, f := range old.Files {
+		oldFiles[f.Path] = f
+	}
+
+	for _, f := range new.Files {
+		before, ok := oldFiles[f.Path]
+		delete(oldFiles, f.Path)
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, models.FileChange{Path: f.Path, Kind: f.Kind, NewSize: f.Size, SizeDelta: f.Size, NewHash: f.Hash})
+		case !strings.EqualFold(before.Hash, f.Hash):
+			diff.Modified = append(diff.Modified, models.FileChange{Path: f.Path, Kind: f.Kind, OldSize: before.Size, NewSize: f.Size, SizeDelta: f.Size - before.Size, OldHash: before.Hash, NewHash: f.Hash})
+		default:
+			diff.Summary.FilesUnchanged++
+		}
+	}
+	for _, f := range oldFiles {
+		diff.Removed = append(diff.Removed, models.FileChange{Path: f.Path, Kind: f.Kind, OldSize: f.Size, SizeDelta: -f.Size, OldHash: f.Hash})
+	}
+	for _, changes := range [][]models.FileChange{diff.Added, diff.Removed, diff.Modified} {
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	}
+
+	diff.Summary.FilesAdded, diff.Summary.FilesRemoved, diff.Summary.FilesModified = len(diff.Added), len(diff.Removed), len(diff.Modified)
+	diff.Summary.OldUnpackedSize, diff.Summary.NewUnpackedSize = old.UnpackedSize, new.UnpackedSize
+	diff.Summary.UnpackedSizeDelta = new.UnpackedSize - old.UnpackedSize
+	return diff
+}
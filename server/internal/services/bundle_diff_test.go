@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+)
+
+// ── Bundle Diff Tests ───────────────────────────────────────
+
+func TestDiffManifests(t *testing.T) {
+	old := &models.BundleManifest{UnpackedSize: 1300, Files: []models.BundleFile{
+		{Path: "drawable-mdpi/logo.png", Size: 200, Hash: "logo", Kind: models.BundleFileAsset},
+		{Path: "drawable-mdpi/old.png", Size: 100, Hash: "old", Kind: models.BundleFileAsset},
+		{Path: "index.android.bundle", Size: 1000, Hash: "js1", Kind: models.BundleFileBundle},
+	}}
+	new := &models.BundleManifest{UnpackedSize: 1750, Files: []models.BundleFile{
+		{Path: "drawable-mdpi/logo.png", Size: 200, Hash: "LOGO", Kind: models.BundleFileAsset},
+		{Path: "drawable-mdpi/new.png", Size: 300, Hash: "new", Kind: models.BundleFileAsset},
+		{Path: "index.android.bundle", Size: 1250, Hash: "js2", Kind: models.BundleFileBundle},
+	}}
+
+	diff := diffManifests(old, new)
+	if len(diff.Added) != 1 || diff.Added[0] != (models.FileChange{Path: "drawable-mdpi/new.png", Kind: models.BundleFileAsset, NewSize: 300, SizeDelta: 300, NewHash: "new"}) {
+		t.Errorf("added = %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != (models.FileChange{Path: "drawable-mdpi/old.png", Kind: models.BundleFileAsset, OldSize: 100, SizeDelta: -100, OldHash: "old"}) {
+		t.Errorf("removed = %+v", diff.Removed)
+	}
+	if len(diff.Modified) != 1 || diff.Modified[0] != (models.FileChange{Path: "index.android.bundle", Kind: models.BundleFileBundle, OldSize: 1000, NewSize: 1250, SizeDelta: 250, OldHash: "js1", NewHash: "js2"}) {
+		t.Errorf("modified = %+v", diff.Modified)
+	}
+	want := models.DiffSummary{FilesAdded: 1, FilesRemoved: 1, FilesModified: 1, FilesUnchanged: 1, OldUnpackedSize: 1300, NewUnpackedSize: 1750, UnpackedSizeDelta: 450}
+	if diff.Summary != want {
+		t.Errorf("summary = %+v, want %+v", diff.Summary, want)
+	}
+}
+
+// diffTest holds app A's release service over the stored bundle manifests.
+type diffTest struct {
+	tenants
+	releaseService *ReleaseService
+}
+
+func newDiffTest(t *testing.T) *diffTest {
+	t.Helper()
+	tn := newTenants(t, &models.BundleManifest{})
+	releaseService, _ := newReleaseService(tn, PatchGeneration{})
+	return &diffTest{tenants: tn, releaseService: releaseService}
+}
+
+func TestDiffReleases(t *testing.T) {
+	f := newDiffTest(t)
+	release := channelRelease(t, f.tenants, "1.1.0", models.ReleaseDraft, 0)
+	if err := f.db.Model(&release).Update("size", 900).Error; err != nil {
+		t.Fatalf("failed to set size: %v", err)
+	}
+
+	if _, err := f.releaseService.DiffReleases(f.appA, release.ID, f.releaseA); !errors.Is(err, ErrManifestNotFound) {
+		t.Errorf("DiffReleases without manifests: err = %v, want ErrManifestNotFound", err)
+	}
+	for _, id := range []uuid.UUID{release.ID, f.releaseA} {
+		manifest := models.BundleManifest{ReleaseID: id, EntryFile: "index.android.bundle", Files: []models.BundleFile{{Path: "index.android.bundle", Size: 10, Hash: "js"}}}
+		if err := f.db.Create(&manifest).Error; err != nil {
+			t.Fatalf("failed to create manifest: %v", err)
+		}
+	}
+
+	diff, err := f.releaseService.DiffReleases(f.appA, release.ID, f.releaseA)
+	if err != nil {
+		t.Fatalf("DiffReleases failed: %v", err)
+	}
+	if diff.AgainstID != f.releaseA || diff.Summary.FilesUnchanged != 1 || diff.Summary.NewBundleSize != 900 || diff.Summary.BundleSizeDelta != 900-f.release(t, f.releaseA).Size {
+		t.Errorf("diff = %+v", diff)
+	}
+
+	if _, err := f.releaseService.DiffReleases(f.appA, release.ID, f.releaseB); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("DiffReleases against another app's release: err = %v, want ErrReleaseNotFound", err)
+	}
+}
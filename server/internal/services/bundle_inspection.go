@@ -0,0 +1,258 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidBundle is returned when an uploaded bundle is not a well-formed React Native bundle archive.
+var ErrInvalidBundle = errors.New("invalid bundle")
+
+// ErrManifestNotFound is returned for releases without a bundle manifest: patch releases, bundles encrypted
+// before upload and releases uploaded before bundles were inspected.
+var ErrManifestNotFound = errors.New("bundle manifest not found")
+
+// errNotBundleArchive is wrapped into ErrInvalidBundle when the upload is not a zip archive at all.
+var errNotBundleArchive = errors.New("not a zip archive")
+
+const (
+	// maxBundleFiles caps the number of files in a bundle archive.
+	maxBundleFiles = 10000
+	// maxUnpackedBundleSize caps the extracted size of a bundle, so a zip bomb is refused rather than unpacked on devices.
+	maxUnpackedBundleSize = 1 << 30
+)
+
+// hermesMagic starts every Hermes bytecode file; the little-endian uint32 bytecode version follows it.
+var hermesMagic = []byte{0xc6, 0x1f, 0xbc, 0x03, 0xc1, 0x03, 0x19, 0x1f}
+
+// entryFiles names the bundle the SDKs load from the root of the archive, per platform.
+var entryFiles = map[string]string{
+	"android": "index.android.bundle",
+	"ios":     "main.jsbundle",
+}
+
+// Manifest returns the manifest of the files in a release's bundle.
+func (s *ReleaseService) Manifest(appID, releaseID uuid.UUID) (*models.BundleManifest, error) {
+	if _, err := s.getRelease(appID, releaseID); err != nil {
+		return nil, err
+	}
+	manifest, err := s.repo.GetManifest(releaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrManifestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bundle manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// bundleSpool keeps a copy of an upload on disk while it streams to storage, so the archive can be inspected
+// once the upload is verified: zip archives are read from their central directory at the end.
+type bundleSpool struct {
+	file *os.File
+	size int64
+}
+
+func newBundleSpool() (*bundleSpool, error) {
+	file, err := os.CreateTemp("", "hotpatch-bundle-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle spool: %w", err)
+	}
+	return &bundleSpool{file: file}, nil
+}
+
+func (b *bundleSpool) Write(p []byte) (int, error) {
+	n, err := b.file.Write(p)
+	b.size += int64(n)
+	return n, err
+}
+
+// Close removes the spooled copy.
+func (b *bundleSpool) Close() {
+	b.file.Close()
+	os.Remove(b.file.Name())
+}
+
+// inspect validates the spooled bundle and returns its manifest and the secrets found in it. A bundle the
+// client encrypted before upload is not an archive the server can read; it has no manifest rather than being refused.
+func (b *bundleSpool) inspect(platform string, encrypted bool) (*models.BundleManifest, []models.SecretFinding, error) {
+	manifest, findings, err := inspectBundle(b.file, b.size, platform)
+	if encrypted && errors.Is(err, errNotBundleArchive) {
+		return nil, nil, nil
+	}
+	return manifest, findings, err
+}
+
+// inspectBundle reads a bundle archive and checks it is one the SDKs can install: every file has a safe,
+// unique relative path and extracts intact within the size limits, and the entry bundle of the platform is
+// at the root. Hermes bytecode bundles are recognised by their header. The entry bundle, source maps and text
+// assets are scanned for secrets on the way. Returns the manifest of the bundle and the secrets found.
+func inspectBundle(archive io.ReaderAt, size int64, platform string) (*models.BundleManifest, []models.SecretFinding, error) {
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidBundle, errNotBundleArchive)
+	}
+	if len(reader.File) > maxBundleFiles {
+		return nil, nil, fmt.Errorf("%w: archive holds more than %d files", ErrInvalidBundle, maxBundleFiles)
+	}
+
+	entryFile := entryFiles[platform]
+	manifest := &models.BundleManifest{}
+	seen := make(map[string]bool, len(reader.File))
+	var entryHead []byte
+	var findings []models.SecretFinding
+	var truncated bool
+	for _, f := range reader.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		name, err := bundleFilePath(f.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if seen[name] {
+			return nil, nil, fmt.Errorf("%w: %s appears twice in the archive", ErrInvalidBundle, name)
+		}
+		seen[name] = true
+
+		isEntry := name == entryFile || (entryFile == "" && isEntryFile(name))
+		var scanner *secretScanner
+		if scansForSecrets(name, isEntry) {
+			scanner = newSecretScanner(name)
+		}
+		file, head, err := readBundleFile(f, name, maxUnpackedBundleSize-manifest.UnpackedSize, scanner)
+		if err != nil {
+			return nil, nil, err
+		}
+		if scanner != nil {
+			findings = append(findings, scanner.findings...)
+			truncated = truncated || scanner.truncated
+		}
+		if len(findings) > maxSecretFindings {
+			findings = mostSevereFindings(findings, maxSecretFindings)
+			truncated = true
+		}
+		switch {
+		case isEntry:
+			if manifest.EntryFile != "" {
+				return nil, nil, fmt.Errorf("%w: archive holds both %s and %s", ErrInvalidBundle, manifest.EntryFile, name)
+			}
+			file.Kind = models.BundleFileBundle
+			manifest.EntryFile = name
+			entryHead = head
+		case strings.HasSuffix(name, ".map"):
+			file.Kind = models.BundleFileSourceMap
+		default:
+			file.Kind = models.BundleFileAsset
+			manifest.AssetCount++
+		}
+		manifest.Files = append(manifest.Files, file)
+		manifest.UnpackedSize += file.Size
+	}
+
+	if manifest.EntryFile == "" {
+		if entryFile == "" {
+			return nil, nil, fmt.Errorf("%w: archive holds no index.android.bundle or main.jsbundle", ErrInvalidBundle)
+		}
+		return nil, nil, fmt.Errorf("%w: archive holds no %s at its root", ErrInvalidBundle, entryFile)
+	}
+	if err := inspectEntryFile(manifest, entryHead); err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	manifest.FileCount = len(manifest.Files)
+	manifest.SecretFindingsTruncated = truncated
+	return manifest, findings, nil
+}
+
+// bundleFilePath checks an archive entry name is a relative path that stays inside the directory the SDKs
+// extract to, and returns it cleaned.
+func bundleFilePath(name string) (string, error) {
+	cleaned := path.Clean(name)
+	if name == "" || strings.Contains(name, "\\") || path.IsAbs(name) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: unsafe file path %q", ErrInvalidBundle, name)
+	}
+	return cleaned, nil
+}
+
+// isEntryFile reports whether an archive path is the entry bundle of any platform.
+func isEntryFile(name string) bool {
+	for _, entry := range entryFiles {
+		if name == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// readBundleFile extracts one archive entry, hashing it, keeping its first bytes and feeding it to scanner
+// when one is given, and fails when it does not decompress intact or is larger than remaining.
+func readBundleFile(f *zip.File, name string, remaining int64, scanner *secretScanner) (models.BundleFile, []byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return models.BundleFile{}, nil, fmt.Errorf("%w: cannot read %s: %v", ErrInvalidBundle, name, err)
+	}
+	defer rc.Close()
+
+	hash := sha256.New()
+	head := &headWriter{limit: len(hermesMagic) + 4}
+	var extracted io.Writer = io.MultiWriter(hash, head)
+	if scanner != nil {
+		extracted = io.MultiWriter(hash, head, scanner)
+	}
+	n, err := io.Copy(extracted, io.LimitReader(rc, remaining+1))
+	if err != nil {
+		return models.BundleFile{}, nil, fmt.Errorf("%w: cannot extract %s: %v", ErrInvalidBundle, name, err)
+	}
+	if n > remaining {
+		return models.BundleFile{}, nil, fmt.Errorf("%w: archive extracts to more than %d MiB", ErrInvalidBundle, maxUnpackedBundleSize>>20)
+	}
+	if scanner != nil {
+		scanner.flush()
+	}
+	return models.BundleFile{Path: name, Size: n, Hash: hex.EncodeToString(hash.Sum(nil))}, head.buf, nil
+}
+
+// inspectEntryFile records whether the entry bundle is Hermes bytecode, and which version, from its first bytes.
+func inspectEntryFile(manifest *models.BundleManifest, head []byte) error {
+	if len(head) == 0 {
+		return fmt.Errorf("%w: %s is empty", ErrInvalidBundle, manifest.EntryFile)
+	}
+	if !bytes.HasPrefix(head, hermesMagic) {
+		return nil
+	}
+	if len(head) < len(hermesMagic)+4 {
+		return fmt.Errorf("%w: %s has a truncated Hermes bytecode header", ErrInvalidBundle, manifest.EntryFile)
+	}
+	manifest.Hermes = true
+	manifest.HermesVersion = binary.LittleEndian.Uint32(head[len(hermesMagic):])
+	return nil
+}
+
+// headWriter keeps the first limit bytes written to it.
+type headWriter struct {
+	buf   []byte
+	limit int
+}
+
+func (h *headWriter) Write(p []byte) (int, error) {
+	if remaining := h.limit - len(h.buf); remaining > 0 {
+		h.buf = append(h.buf, p[:min(remaining, len(p))]...)
+	}
+	return len(p), nil
+}
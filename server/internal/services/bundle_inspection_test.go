@@ -0,0 +1,161 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+)
+
+// ── Bundle Inspection Tests ─────────────────────────────────
+
+type archiveFile struct {
+	name string
+	data []byte
+}
+
+// bundleArchive zips the files in order, as the CLI does. Files named .raw are stored uncompressed.
+func bundleArchive(t *testing.T, files ...archiveFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range files {
+		header := &zip.FileHeader{Name: f.name, Method: zip.Deflate}
+		if strings.HasSuffix(f.name, ".raw") {
+			header.Method = zip.Store
+		}
+		fw, err := w.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", f.name, err)
+		}
+		fw.Write(f.data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func hermesBundle(version uint32) []byte {
+	data := append([]byte(nil), hermesMagic...)
+	data = binary.LittleEndian.AppendUint32(data, version)
+	return append(data, make([]byte, 64)...)
+}
+
+func TestInspectBundle_Manifest(t *testing.T) {
+	js := []byte("var a = require('./a');\n")
+	archive := bundleArchive(t,
+		archiveFile{"index.android.bundle", js},
+		archiveFile{"drawable-mdpi/", nil},
+		archiveFile{"drawable-mdpi/logo.png", []byte("png")},
+		archiveFile{"index.android.bundle.map", []byte("{}")},
+		archiveFile{"main.jsbundle", []byte("ios")}, // only an asset in an Android bundle
+	)
+
+	manifest, findings, err := inspectBundle(bytes.NewReader(archive), int64(len(archive)), "android")
+	if err != nil {
+		t.Fatalf("inspectBundle failed: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("findings = %+v, want none", findings)
+	}
+	if manifest.EntryFile != "index.android.bundle" || manifest.Hermes || manifest.FileCount != 4 || manifest.AssetCount != 2 || manifest.UnpackedSize != int64(len(js)+8) {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	want := []struct{ path, kind string }{
+		{"drawable-mdpi/logo.png", models.BundleFileAsset},
+		{"index.android.bundle", models.BundleFileBundle},
+		{"index.android.bundle.map", models.BundleFileSourceMap},
+		{"main.jsbundle", models.BundleFileAsset},
+	}
+	for i, w := range want {
+		if f := manifest.Files[i]; f.Path != w.path || f.Kind != w.kind {
+			t.Errorf("file %d = %s (%s), want %s (%s)", i, f.Path, f.Kind, w.path, w.kind)
+		}
+	}
+	if sum := sha256.Sum256(js); manifest.Files[1].Hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("entry hash = %s, want %x", manifest.Files[1].Hash, sum)
+	}
+
+	hermes := bundleArchive(t, archiveFile{"main.jsbundle", hermesBundle(96)})
+	manifest, _, err = inspectBundle(bytes.NewReader(hermes), int64(len(hermes)), "ios")
+	if err != nil {
+		t.Fatalf("inspectBundle of a Hermes bundle failed: %v", err)
+	}
+	if !manifest.Hermes || manifest.HermesVersion != 96 {
+		t.Errorf("Hermes bundle detected as hermes=%v version %d, want version 96", manifest.Hermes, manifest.HermesVersion)
+	}
+}
+
+func TestInspectBundle_Rejections(t *testing.T) {
+	entry := archiveFile{"index.android.bundle", []byte("var a;")}
+	corrupt := bundleArchive(t, entry, archiveFile{"raw/logo.raw", bytes.Repeat([]byte("x"), 100)})
+	corrupt[bytes.LastIndex(corrupt, []byte("xxxx"))] = 'y' // stored data no longer matches its checksum
+
+	cases := map[string][]byte{
+		"not a zip":            []byte("var a;"),
+		"no entry bundle":      bundleArchive(t, archiveFile{"drawable-mdpi/logo.png", []byte("png")}),
+		"other platform entry": bundleArchive(t, archiveFile{"main.jsbundle", []byte("var a;")}),
+		"entry not at root":    bundleArchive(t, archiveFile{"build/index.android.bundle", []byte("var a;")}),
+		"empty entry":          bundleArchive(t, archiveFile{"index.android.bundle", nil}),
+		"truncated hermes":     bundleArchive(t, archiveFile{"index.android.bundle", hermesMagic}),
+		"path escapes":         bundleArchive(t, entry, archiveFile{"../../files/evil.js", []byte("x")}),
+		"absolute path":        bundleArchive(t, entry, archiveFile{"/data/evil.js", []byte("x")}),
+		"duplicate file":       bundleArchive(t, entry, archiveFile{"./index.android.bundle", []byte("x")}),
+		"corrupt file":         corrupt,
+	}
+	for name, archive := range cases {
+		if _, _, err := inspectBundle(bytes.NewReader(archive), int64(len(archive)), "android"); !errors.Is(err, ErrInvalidBundle) {
+			t.Errorf("%s: err = %v, want ErrInvalidBundle", name, err)
+		}
+	}
+}
+
+func TestBundleSpool_EncryptedUploads(t *testing.T) {
+	spool, err := newBundleSpool()
+	if err != nil {
+		t.Fatalf("newBundleSpool failed: %v", err)
+	}
+	defer spool.Close()
+	spool.Write([]byte("ciphertext"))
+
+	if manifest, _, err := spool.inspect("android", true); manifest != nil || err != nil {
+		t.Errorf("inspect of a client-encrypted bundle = %v, %v; want no manifest", manifest, err)
+	}
+	if _, _, err := spool.inspect("android", false); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("inspect of an unencrypted non-archive: err = %v, want ErrInvalidBundle", err)
+	}
+}
+
+// manifestTest holds app A's release service over the stored bundle manifests.
+type manifestTest struct {
+	tenants
+	releaseService *ReleaseService
+}
+
+func newManifestTest(t *testing.T) *manifestTest {
+	t.Helper()
+	tn := newTenants(t, &models.BundleManifest{})
+	releaseService, _ := newReleaseService(tn, PatchGeneration{})
+	return &manifestTest{tenants: tn, releaseService: releaseService}
+}
+
+func TestManifest_NotFound(t *testing.T) {
+	f := newManifestTest(t)
+	if _, err := f.releaseService.Manifest(f.appA, f.releaseA); !errors.Is(err, ErrManifestNotFound) {
+		t.Errorf("Manifest of a release without one: err = %v, want ErrManifestNotFound", err)
+	}
+	if _, err := f.releaseService.Manifest(f.appA, f.releaseB); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("Manifest of another app's release: err = %v, want ErrReleaseNotFound", err)
+	}
+	if _, err := f.releaseService.Manifest(f.appA, uuid.New()); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("Manifest of an unknown release: err = %v, want ErrReleaseNotFound", err)
+	}
+}
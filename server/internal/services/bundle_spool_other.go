@@ -0,0 +1,15 @@
+//go:build !unix
+
+package services
+
+import "fmt"
+
+// mapped reads the spooled copy back into memory. Servers run on Linux, where it is mapped instead; this
+// fallback only keeps the package building elsewhere.
+func (b *bundleSpool) mapped() (data []byte, unmap func(), err error) {
+	data = make([]byte, b.size)
+	if _, err := b.file.ReadAt(data, 0); err != nil {
+		return nil, nil, fmt.Errorf("failed to read bundle spool: %w", err)
+	}
+	return data, func() {}, nil
+}
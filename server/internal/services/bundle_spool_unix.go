@@ -0,0 +1,21 @@
+//go:build unix
+
+package services
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// mapped maps the spooled copy into memory read-only, so it can be handed around as one slice without being
+// read onto the heap. The slice must not be used after calling unmap.
+func (b *bundleSpool) mapped() (data []byte, unmap func(), err error) {
+	if b.size == 0 {
+		return []byte{}, func() {}, nil
+	}
+	data, err = syscall.Mmap(int(b.file.Fd()), 0, int(b.size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to map bundle spool: %w", err)
+	}
+	return data, func() { syscall.Munmap(data) }, nil
+}
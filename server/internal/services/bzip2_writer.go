@@ -0,0 +1,24 @@
+package services
+
+import (
+	"bytes"
+
+	bzip2enc "github.com/dsnet/compress/bzip2"
+)
+
+// bzip2Compress compresses data in the bzip2 format. The standard library only decompresses bzip2, and
+// BSDIFF40 patches, the format the SDKs apply, carry their blocks bzip2-compressed.
+func bzip2Compress(data []byte) []byte {
+	var buf bytes.Buffer
+	w, err := bzip2enc.NewWriter(&buf, &bzip2enc.WriterConfig{Level: bzip2enc.BestCompression})
+	if err != nil {
+		panic(err) // only an invalid level fails
+	}
+	if _, err := w.Write(data); err != nil {
+		panic(err) // writes to a bytes.Buffer cannot fail
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
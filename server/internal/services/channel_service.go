@@ -51,6 +51,12 @@ func (s *ChannelService) Create(ctx context.Context, appID uuid.UUID, req *model
 		Description: req.Description,
 		Color:       req.Color,
 		AutoRollout: true,
+
+		HealthMaxFailureRate:  5,
+		HealthMaxRollbackRate: 2,
+		HealthMinSampleSize:   100,
+		HealthAction:          models.HealthActionPause,
+		FreezeAction:          models.FreezeActionReject,
 	}
 
 	if err := s.repo.Create(channel); err != nil {
@@ -89,6 +95,42 @@ func (s *ChannelService) Update(appID uuid.UUID, slug string, req *models.Update
 	if req.AutoRollout != nil {
 		channel.AutoRollout = *req.AutoRollout
 	}
+	if req.HealthGateEnabled != nil {
+		channel.HealthGateEnabled = *req.HealthGateEnabled
+	}
+	if req.HealthMaxFailureRate != nil {
+		channel.HealthMaxFailureRate = *req.HealthMaxFailureRate
+	}
+	if req.HealthMaxRollbackRate != nil {
+		channel.HealthMaxRollbackRate = *req.HealthMaxRollbackRate
+	}
+	if req.HealthMinSampleSize != nil {
+		channel.HealthMinSampleSize = *req.HealthMinSampleSize
+	}
+	if req.HealthAction != nil {
+		channel.HealthAction = *req.HealthAction
+	}
+	if req.FreezeWindows != nil {
+		if err := validateFreezeWindows(*req.FreezeWindows); err != nil {
+			return nil, err
+		}
+		channel.FreezeWindows = *req.FreezeWindows
+	}
+	if req.FreezeAction != nil {
+		channel.FreezeAction = *req.FreezeAction
+	}
+	if req.RequiredApprovals != nil {
+		channel.RequiredApprovals = *req.RequiredApprovals
+	}
+	if req.MaxBundleSize != nil {
+		channel.MaxBundleSize = *req.MaxBundleSize
+	}
+	if req.MaxSizeGrowthPercent != nil {
+		channel.MaxSizeGrowthPercent = *req.MaxSizeGrowthPercent
+	}
+	if req.SecretBlockSeverity != nil {
+		channel.SecretBlockSeverity = *req.SecretBlockSeverity
+	}
 
 	if err := s.repo.Update(channel); err != nil {
 		return nil, fmt.Errorf("failed to update channel: %w", err)
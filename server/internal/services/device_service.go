@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,9 @@ import (
 	"github.com/hotpatch/server/internal/repository"
 )
 
+// ErrDeviceAppMismatch is returned when an SDK request refers to a device or release of another app.
+var ErrDeviceAppMismatch = errors.New("device or release belongs to a different app")
+
 // DeviceService handles device registration and installation tracking.
 type DeviceService struct {
 	repo            *repository.DeviceRepository
@@ -27,6 +31,11 @@ func (s *DeviceService) RegisterOrUpdate(req *models.RegisterDeviceRequest) (*mo
 		return nil, fmt.Errorf("invalid app_id: %w", err)
 	}
 
+	// Device IDs are globally unique; never let one app overwrite another app's device
+	if existing, err := s.repo.GetByDeviceID(req.DeviceID); err == nil && existing.AppID != appID {
+		return nil, ErrDeviceAppMismatch
+	}
+
 	device := &models.Device{
 		ID:             uuid.New(),
 		DeviceID:       req.DeviceID,
@@ -43,18 +52,28 @@ func (s *DeviceService) RegisterOrUpdate(req *models.RegisterDeviceRequest) (*mo
 	return device, nil
 }
 
-// ReportInstallation records an installation event from the SDK.
-func (s *DeviceService) ReportInstallation(req *models.ReportInstallationRequest) (*models.Installation, error) {
+// ReportInstallation records an installation event from the SDK for a device of the given app.
+func (s *DeviceService) ReportInstallation(appID uuid.UUID, req *models.ReportInstallationRequest) (*models.Installation, error) {
 	// Find the device by its SDK-generated device_id
 	device, err := s.repo.GetByDeviceID(req.DeviceID)
 	if err != nil {
 		return nil, fmt.Errorf("device not found: %w", err)
 	}
+	if device.AppID != appID {
+		return nil, ErrDeviceAppMismatch
+	}
 
 	releaseID, err := uuid.Parse(req.ReleaseID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid release_id: %w", err)
 	}
+	ok, err := s.repo.ReleaseBelongsToApp(releaseID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check release: %w", err)
+	}
+	if !ok {
+		return nil, ErrDeviceAppMismatch
+	}
 
 	installation := &models.Installation{
 		ID:           uuid.New(),
@@ -70,6 +89,22 @@ func (s *DeviceService) ReportInstallation(req *models.ReportInstallationRequest
 		return nil, fmt.Errorf("failed to record installation: %w", err)
 	}
 
+	// Close out a server-issued revert instruction
+	if req.RevertID != "" {
+		revertID, err := uuid.Parse(req.RevertID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid revert_id: %w", err)
+		}
+		revertStatus := "completed"
+		if req.Status == "failed" {
+			revertStatus = "failed"
+		}
+		if err := s.repo.CompleteRevert(revertID, device.ID, revertStatus); err != nil {
+			return nil, fmt.Errorf("revert not found: %w", err)
+		}
+		s.securityService.Log(device.AppID, "sdk", "installation.revert", revertID.String(), fmt.Sprintf("Device %s revert %s", device.DeviceID, revertStatus), "")
+	}
+
 	// Automated rollback logging for audit trail (Page 17 of docs)
 	if req.Status == "rolled_back" {
 		s.securityService.Log(device.AppID, "sdk", "installation.rollback", releaseID.String(), fmt.Sprintf("Device %s automatically rolled back", device.DeviceID), "")
@@ -94,7 +129,14 @@ func (s *DeviceService) GetDeviceCount(appID uuid.UUID) (int64, error) {
 	return s.repo.CountByApp(appID)
 }
 
-// GetInstallationStats returns installation counts grouped by status for a release.
-func (s *DeviceService) GetInstallationStats(releaseID uuid.UUID) (map[string]int64, error) {
+// GetInstallationStats returns installation counts grouped by status for a release of the app.
+func (s *DeviceService) GetInstallationStats(appID, releaseID uuid.UUID) (map[string]int64, error) {
+	ok, err := s.repo.ReleaseBelongsToApp(releaseID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check release: %w", err)
+	}
+	if !ok {
+		return nil, ErrReleaseNotFound
+	}
 	return s.repo.CountInstallationsByStatus(releaseID)
 }
@@ -10,7 +10,8 @@ import (
 )
 
 // EncryptionService handles AES-256-GCM encryption/decryption for OTA bundles.
-// The SDK-side decryption mirrors this exact format:
+// Bundles are encrypted in the chunked format (see EncryptStream); older releases used the
+// single-shot format, which the SDKs still decrypt:
 //
 //	output = [12-byte nonce] + [ciphertext + GCM auth tag]
 type EncryptionService struct{}
@@ -35,22 +36,9 @@ func (s *EncryptionService) GenerateKey() (string, error) {
 // The output format is: [12-byte nonce][ciphertext + 16-byte GCM auth tag]
 // This format is compatible with the Android and iOS SDK decryption routines.
 func (s *EncryptionService) Encrypt(plaintext []byte, keyHex string) ([]byte, error) {
-	key, err := hex.DecodeString(keyHex)
-	if err != nil {
-		return nil, fmt.Errorf("invalid hex key: %w", err)
-	}
-	if len(key) != 32 {
-		return nil, fmt.Errorf("encryption key must be 256 bits (32 bytes), got %d bytes", len(key))
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
-	}
-
-	aesGCM, err := cipher.NewGCM(block)
+	aesGCM, err := newAESGCM(keyHex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create GCM: %w", err)
+		return nil, err
 	}
 
 	// Generate a random 12-byte nonce (standard GCM nonce size)
@@ -71,22 +59,9 @@ func (s *EncryptionService) Encrypt(plaintext []byte, keyHex string) ([]byte, er
 // Expected input format: [12-byte nonce][ciphertext + 16-byte GCM auth tag]
 // This is the same format produced by Encrypt() and consumed by the SDKs.
 func (s *EncryptionService) Decrypt(encrypted []byte, keyHex string) ([]byte, error) {
-	key, err := hex.DecodeString(keyHex)
-	if err != nil {
-		return nil, fmt.Errorf("invalid hex key: %w", err)
-	}
-	if len(key) != 32 {
-		return nil, fmt.Errorf("encryption key must be 256 bits (32 bytes), got %d bytes", len(key))
-	}
-
-	block, err := aes.NewCipher(key)
+	aesGCM, err := newAESGCM(keyHex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
-	}
-
-	aesGCM, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create GCM: %w", err)
+		return nil, err
 	}
 
 	nonceSize := aesGCM.NonceSize() // 12 bytes
@@ -105,20 +80,41 @@ func (s *EncryptionService) Decrypt(encrypted []byte, keyHex string) ([]byte, er
 	return plaintext, nil
 }
 
-// EncryptBundle is a convenience method that encrypts bundle data for a release.
-// It returns the encrypted bytes and the key ID (prefix of the hex key for tracking).
-func (s *EncryptionService) EncryptBundle(bundleData []byte, keyHex string) (encryptedData []byte, keyID string, err error) {
-	encryptedData, err = s.Encrypt(bundleData, keyHex)
+// EncryptBundle is a convenience method that encrypts a bundle stream for a release in the chunked format.
+// It returns the encrypted stream and the key ID (prefix of the hex key for tracking).
+func (s *EncryptionService) EncryptBundle(bundle io.Reader, keyHex string) (encrypted io.Reader, keyID string, err error) {
+	encrypted, err = s.EncryptStream(bundle, keyHex)
 	if err != nil {
 		return nil, "", fmt.Errorf("bundle encryption failed: %w", err)
 	}
 
-	// Key ID is the first 8 hex chars of the key for reference
+	return encrypted, encryptionKeyID(keyHex), nil
+}
+
+// encryptionKeyID returns the ID a release records for its encryption key: the first 8 hex chars of the key.
+func encryptionKeyID(keyHex string) string {
 	if len(keyHex) >= 8 {
-		keyID = keyHex[:8]
-	} else {
-		keyID = keyHex
+		return keyHex[:8]
 	}
+	return keyHex
+}
 
-	return encryptedData, keyID, nil
+// newAESGCM builds an AES-256-GCM cipher from a hex key.
+func newAESGCM(keyHex string) (cipher.AEAD, error) {
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 256 bits (32 bytes), got %d bytes", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	aesGCM, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return aesGCM, nil
 }
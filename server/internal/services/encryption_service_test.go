@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
+	"io"
 	"testing"
 )
 
@@ -130,7 +132,7 @@ func TestEncryptionService_EncryptBundle(t *testing.T) {
 
 	bundleData := []byte("fake JS bundle content for React Native OTA")
 
-	encrypted, keyID, err := svc.EncryptBundle(bundleData, keyHex)
+	encrypted, keyID, err := svc.EncryptBundle(bytes.NewReader(bundleData), keyHex)
 	if err != nil {
 		t.Fatalf("EncryptBundle() failed: %v", err)
 	}
@@ -140,17 +142,117 @@ func TestEncryptionService_EncryptBundle(t *testing.T) {
 		t.Errorf("Expected keyID %q, got %q", keyHex[:8], keyID)
 	}
 
-	// Should be decryptable
-	decrypted, err := svc.Decrypt(encrypted, keyHex)
-	if err != nil {
-		t.Fatalf("Decrypt() after EncryptBundle() failed: %v", err)
+	// Should be decryptable as a chunked stream
+	var decrypted bytes.Buffer
+	if err := svc.DecryptStream(&decrypted, encrypted, keyHex); err != nil {
+		t.Fatalf("DecryptStream() after EncryptBundle() failed: %v", err)
 	}
 
-	if !bytes.Equal(decrypted, bundleData) {
+	if !bytes.Equal(decrypted.Bytes(), bundleData) {
 		t.Error("Decrypted bundle data does not match original")
 	}
 }
 
+func TestEncryptionService_StreamRoundTrip(t *testing.T) {
+	svc := NewEncryptionService()
+	keyHex, _ := svc.GenerateKey()
+
+	sizes := map[string]int{
+		"empty":                0,
+		"one byte":             1,
+		"exactly one chunk":    chunkedChunkSize,
+		"one chunk and a byte": chunkedChunkSize + 1,
+		"several chunks":       3*chunkedChunkSize + 1234,
+	}
+
+	for name, size := range sizes {
+		t.Run(name, func(t *testing.T) {
+			plaintext := make([]byte, size)
+			rand.Read(plaintext)
+
+			stream, err := svc.EncryptStream(bytes.NewReader(plaintext), keyHex)
+			if err != nil {
+				t.Fatalf("EncryptStream() failed: %v", err)
+			}
+			encrypted, err := io.ReadAll(stream)
+			if err != nil {
+				t.Fatalf("reading encrypted stream failed: %v", err)
+			}
+
+			// Header plus one auth tag per chunk; an empty bundle still has one (empty) chunk
+			chunks := max(1, (size+chunkedChunkSize-1)/chunkedChunkSize)
+			if want := chunkedHeaderLen + size + chunks*16; len(encrypted) != want {
+				t.Errorf("encrypted size = %d, want %d", len(encrypted), want)
+			}
+
+			var decrypted bytes.Buffer
+			if err := svc.DecryptStream(&decrypted, bytes.NewReader(encrypted), keyHex); err != nil {
+				t.Fatalf("DecryptStream() failed: %v", err)
+			}
+			if !bytes.Equal(decrypted.Bytes(), plaintext) {
+				t.Error("Decrypted stream does not match original plaintext")
+			}
+		})
+	}
+}
+
+func TestEncryptionService_StreamRejectsTampering(t *testing.T) {
+	svc := NewEncryptionService()
+	keyHex, _ := svc.GenerateKey()
+	otherKey, _ := svc.GenerateKey()
+
+	plaintext := make([]byte, 2*chunkedChunkSize+100)
+	rand.Read(plaintext)
+	stream, _ := svc.EncryptStream(bytes.NewReader(plaintext), keyHex)
+	encrypted, _ := io.ReadAll(stream)
+	sealedChunk := chunkedChunkSize + 16
+
+	flipped := bytes.Clone(encrypted)
+	flipped[chunkedHeaderLen+sealedChunk+10] ^= 0xFF
+
+	// Dropping whole trailing chunks must not pass for a shorter bundle
+	truncated := encrypted[:chunkedHeaderLen+2*sealedChunk]
+
+	// Swapping the first two chunks breaks their nonces
+	reordered := bytes.Clone(encrypted)
+	copy(reordered[chunkedHeaderLen:], encrypted[chunkedHeaderLen+sealedChunk:chunkedHeaderLen+2*sealedChunk])
+	copy(reordered[chunkedHeaderLen+sealedChunk:], encrypted[chunkedHeaderLen:chunkedHeaderLen+sealedChunk])
+
+	cases := []struct {
+		name string
+		data []byte
+		key  string
+	}{
+		{"flipped byte", flipped, keyHex},
+		{"truncated", truncated, keyHex},
+		{"reordered", reordered, keyHex},
+		{"wrong key", encrypted, otherKey},
+		{"legacy format", func() []byte { b, _ := svc.Encrypt(plaintext, keyHex); return b }(), keyHex},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := svc.DecryptStream(io.Discard, bytes.NewReader(tc.data), tc.key)
+			if !errors.Is(err, ErrCorruptStream) {
+				t.Errorf("DecryptStream() err = %v, want ErrCorruptStream", err)
+			}
+		})
+	}
+}
+
+func TestLimitedReader(t *testing.T) {
+	data := make([]byte, 1000)
+
+	read, err := io.ReadAll(&limitedReader{r: bytes.NewReader(data), limit: 1000})
+	if err != nil || len(read) != 1000 {
+		t.Errorf("body at the limit: read %d bytes, err = %v; want 1000 bytes and no error", len(read), err)
+	}
+
+	if _, err := io.ReadAll(&limitedReader{r: bytes.NewReader(data), limit: 999}); !errors.Is(err, ErrBundleTooLarge) {
+		t.Errorf("body over the limit: err = %v, want ErrBundleTooLarge", err)
+	}
+}
+
 func TestEncryptionService_NonceUniqueness(t *testing.T) {
 	svc := NewEncryptionService()
 	keyHex, _ := svc.GenerateKey()
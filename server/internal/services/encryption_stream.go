@@ -0,0 +1,182 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Chunked AES-256-GCM format. Bundles are encrypted as a stream of independently sealed chunks, so
+// neither the server nor the SDKs need a whole bundle in memory to encrypt or decrypt it:
+//
+//	output = "HPC1" + [4-byte big-endian chunk size] + [8-byte random nonce prefix] + chunk...
+//	chunk  = [ciphertext + 16-byte GCM auth tag]
+//
+// Every chunk but the last holds exactly chunk-size plaintext bytes; the last holds the rest, and is
+// empty only for an empty bundle. Chunk i is sealed with nonce = prefix + [4-byte big-endian i] and a single byte of additional
+// data, 1 for the last chunk and 0 otherwise, so reordered, dropped or truncated chunks fail to open.
+const (
+	chunkedMagic     = "HPC1"
+	chunkedChunkSize = 64 << 10
+	chunkedPrefixLen = 8
+	chunkedHeaderLen = len(chunkedMagic) + 4 + chunkedPrefixLen
+)
+
+// ErrCorruptStream is returned when a chunked stream is malformed, truncated or fails authentication.
+var ErrCorruptStream = errors.New("corrupt encrypted stream")
+
+// chunkNonce returns the nonce of chunk i of a stream.
+func chunkNonce(prefix []byte, i uint32) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[chunkedPrefixLen:], i)
+	return nonce
+}
+
+// chunkAAD returns the additional data sealed into a chunk.
+func chunkAAD(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// EncryptStream returns a reader producing src encrypted in the chunked format.
+// Plaintext is read one chunk at a time as the returned reader is consumed.
+func (s *EncryptionService) EncryptStream(src io.Reader, keyHex string) (io.Reader, error) {
+	aesGCM, err := newAESGCM(keyHex)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, chunkedPrefixLen)
+	if _, err := io.ReadFull(rand.Reader, prefix); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce prefix: %w", err)
+	}
+	header := make([]byte, 0, chunkedHeaderLen)
+	header = append(header, chunkedMagic...)
+	header = binary.BigEndian.AppendUint32(header, chunkedChunkSize)
+	header = append(header, prefix...)
+
+	return &encryptReader{
+		src:     bufio.NewReader(src),
+		aead:    aesGCM,
+		prefix:  prefix,
+		chunk:   make([]byte, chunkedChunkSize),
+		pending: header,
+	}, nil
+}
+
+// encryptReader seals its source chunk by chunk as it is read.
+type encryptReader struct {
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	chunk   []byte
+	sealed  []byte
+	pending []byte // sealed bytes not yet returned
+	done    bool   // the last chunk has been sealed
+	err     error
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.err = r.seal()
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// seal reads and seals the next chunk of the source.
+func (r *encryptReader) seal() error {
+	n, err := io.ReadFull(r.src, r.chunk)
+	last := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	if err != nil && !last {
+		return err
+	}
+	if !last {
+		// A full chunk is the last one when nothing follows it
+		if _, err := r.src.Peek(1); errors.Is(err, io.EOF) {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+	if !last && r.counter == ^uint32(0) {
+		return fmt.Errorf("stream too long to encrypt")
+	}
+
+	r.sealed = r.aead.Seal(r.sealed[:0], chunkNonce(r.prefix, r.counter), r.chunk[:n], chunkAAD(last))
+	r.pending = r.sealed
+	r.counter++
+	r.done = last
+	return nil
+}
+
+// DecryptStream decrypts a chunked stream from src into dst, one chunk at a time. Plaintext is only
+// written once its chunk has been authenticated, but a stream that fails part-way leaves the chunks
+// before the failure in dst; callers must discard dst when an error is returned.
+func (s *EncryptionService) DecryptStream(dst io.Writer, src io.Reader, keyHex string) error {
+	aesGCM, err := newAESGCM(keyHex)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, chunkedHeaderLen)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return fmt.Errorf("%w: missing header", ErrCorruptStream)
+	}
+	if !bytes.Equal(header[:len(chunkedMagic)], []byte(chunkedMagic)) {
+		return fmt.Errorf("%w: not a chunked stream", ErrCorruptStream)
+	}
+	chunkSize := binary.BigEndian.Uint32(header[len(chunkedMagic):])
+	if chunkSize == 0 || chunkSize > 16<<20 {
+		return fmt.Errorf("%w: invalid chunk size %d", ErrCorruptStream, chunkSize)
+	}
+	prefix := header[len(chunkedMagic)+4:]
+
+	in := bufio.NewReader(src)
+	sealed := make([]byte, int(chunkSize)+aesGCM.Overhead())
+	var plain []byte
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(in, sealed)
+		last := errors.Is(err, io.ErrUnexpectedEOF)
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: truncated", ErrCorruptStream)
+		}
+		if err != nil && !last {
+			return err
+		}
+		if !last {
+			if _, err := in.Peek(1); errors.Is(err, io.EOF) {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		}
+
+		plain, err = aesGCM.Open(plain[:0], chunkNonce(prefix, counter), sealed[:n], chunkAAD(last))
+		if err != nil {
+			return fmt.Errorf("%w: chunk %d failed authentication (bad key or tampered data)", ErrCorruptStream, counter)
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
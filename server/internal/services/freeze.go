@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // freeze windows name IANA time zones; don't depend on the host's zoneinfo
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrChannelFrozen is returned when a change is rejected because its channel is in a freeze window.
+	ErrChannelFrozen = errors.New("channel is frozen")
+	// ErrInvalidFreezeWindow is returned when a channel's freeze windows are malformed.
+	ErrInvalidFreezeWindow = errors.New("invalid freeze window")
+)
+
+const maxFreezeWindows = 20
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// FreezeService answers whether a channel is inside one of its freeze windows.
+type FreezeService struct {
+	channelRepo *repository.ChannelRepository
+}
+
+// NewFreezeService creates a new FreezeService.
+func NewFreezeService(channelRepo *repository.ChannelRepository) *FreezeService {
+	return &FreezeService{channelRepo: channelRepo}
+}
+
+// Check returns the freeze the channel is in at the given time, or nil when changes are allowed.
+// Channels without a channel record (releases may name any channel) are never frozen.
+func (s *FreezeService) Check(appID uuid.UUID, channel string, now time.Time) (*models.ChannelFreeze, error) {
+	if s == nil {
+		return nil, nil
+	}
+	ch, err := s.channelRepo.GetBySlug(appID, channel)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load channel: %w", err)
+	}
+
+	name, until, ok := activeFreezeWindow(ch.FreezeWindows, now)
+	if !ok {
+		return nil, nil
+	}
+	action := ch.FreezeAction
+	if action == "" {
+		action = models.FreezeActionReject
+	}
+	return &models.ChannelFreeze{Channel: channel, Window: name, Until: until, Action: action}, nil
+}
+
+// frozenError builds the error returned for a change rejected by a freeze.
+func frozenError(freeze *models.ChannelFreeze) error {
+	return fmt.Errorf("%w: %s is in freeze window %q until %s", ErrChannelFrozen, freeze.Channel, freeze.Window, freeze.Until.UTC().Format(time.RFC3339))
+}
+
+// validateFreezeWindows checks that every window is either a complete weekly window or a complete one-off window.
+func validateFreezeWindows(windows []models.FreezeWindow) error {
+	if len(windows) > maxFreezeWindows {
+		return fmt.Errorf("%w: at most %d windows are allowed", ErrInvalidFreezeWindow, maxFreezeWindows)
+	}
+	for i, w := range windows {
+		if err := validateFreezeWindow(w); err != nil {
+			return fmt.Errorf("%w: window %d: %v", ErrInvalidFreezeWindow, i+1, err)
+		}
+	}
+	return nil
+}
+
+func validateFreezeWindow(w models.FreezeWindow) error {
+	if w.StartsAt != nil || w.EndsAt != nil {
+		if w.StartDay != "" || w.EndDay != "" {
+			return fmt.Errorf("a window is either weekly or one-off, not both")
+		}
+		if w.StartsAt == nil || w.EndsAt == nil || !w.EndsAt.After(*w.StartsAt) {
+			return fmt.Errorf("one-off windows need starts_at before ends_at")
+		}
+		return nil
+	}
+
+	if _, ok := weekdays[strings.ToLower(w.StartDay)]; !ok {
+		return fmt.Errorf("unknown start_day %q", w.StartDay)
+	}
+	if _, ok := weekdays[strings.ToLower(w.EndDay)]; !ok {
+		return fmt.Errorf("unknown end_day %q", w.EndDay)
+	}
+	if _, _, err := parseClock(w.StartTime); err != nil {
+		return fmt.Errorf("start_time: %v", err)
+	}
+	if _, _, err := parseClock(w.EndTime); err != nil {
+		return fmt.Errorf("end_time: %v", err)
+	}
+	if _, err := time.LoadLocation(w.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", w.Timezone)
+	}
+	return nil
+}
+
+// activeFreezeWindow reports whether now falls inside any of the windows, and when the freeze lifts.
+// Windows that overlap or abut are chained, so the returned time is when changes are next allowed.
+func activeFreezeWindow(windows []models.FreezeWindow, now time.Time) (string, time.Time, bool) {
+	name, until, frozen := "", now, false
+	for range windows {
+		extended := false
+		for _, w := range windows {
+			end, ok := freezeWindowEnd(w, until)
+			if !ok || !end.After(until) {
+				continue
+			}
+			if !frozen {
+				name = w.Name
+			}
+			until, frozen, extended = end, true, true
+		}
+		if !extended {
+			break
+		}
+	}
+	return name, until, frozen
+}
+
+// freezeWindowEnd returns the end of the occurrence of a window that contains t, if any.
+func freezeWindowEnd(w models.FreezeWindow, t time.Time) (time.Time, bool) {
+	if w.StartsAt != nil && w.EndsAt != nil {
+		return *w.EndsAt, !t.Before(*w.StartsAt) && t.Before(*w.EndsAt)
+	}
+
+	startDay, ok1 := weekdays[strings.ToLower(w.StartDay)]
+	endDay, ok2 := weekdays[strings.ToLower(w.EndDay)]
+	sh, sm, err1 := parseClock(w.StartTime)
+	eh, em, err2 := parseClock(w.EndTime)
+	loc, err3 := time.LoadLocation(w.Timezone)
+	if !ok1 || !ok2 || err1 != nil || err2 != nil || err3 != nil {
+		return time.Time{}, false
+	}
+
+	// Most recent start at or before t, then the first end after that start
+	local := t.In(loc)
+	daysBack := (int(local.Weekday()) - int(startDay) + 7) % 7
+	start := time.Date(local.Year(), local.Month(), local.Day()-daysBack, sh, sm, 0, 0, loc)
+	if start.After(local) {
+		start = start.AddDate(0, 0, -7)
+	}
+	daysForward := (int(endDay) - int(startDay) + 7) % 7
+	end := time.Date(start.Year(), start.Month(), start.Day()+daysForward, eh, em, 0, 0, loc)
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 7)
+	}
+	return end, local.Before(end)
+}
+
+// parseClock parses a 24-hour "HH:MM" time of day.
+func parseClock(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q is not an HH:MM time", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+)
+
+// ── Freeze Window Tests ─────────────────────────────────────
+
+func TestValidateFreezeWindows(t *testing.T) {
+	start := time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC)
+	end := start.Add(14 * 24 * time.Hour)
+
+	tests := []struct {
+		name   string
+		window models.FreezeWindow
+		valid  bool
+	}{
+		{"weekly", models.FreezeWindow{StartDay: "friday", StartTime: "18:00", EndDay: "monday", EndTime: "08:00", Timezone: "Europe/Berlin"}, true},
+		{"weekly in UTC", models.FreezeWindow{StartDay: "Saturday", StartTime: "00:00", EndDay: "Sunday", EndTime: "23:59"}, true},
+		{"one-off", models.FreezeWindow{StartsAt: &start, EndsAt: &end}, true},
+		{"unknown day", models.FreezeWindow{StartDay: "funday", StartTime: "18:00", EndDay: "monday", EndTime: "08:00"}, false},
+		{"bad time", models.FreezeWindow{StartDay: "friday", StartTime: "6pm", EndDay: "monday", EndTime: "08:00"}, false},
+		{"unknown timezone", models.FreezeWindow{StartDay: "friday", StartTime: "18:00", EndDay: "monday", EndTime: "08:00", Timezone: "Mars/Olympus"}, false},
+		{"one-off ending before start", models.FreezeWindow{StartsAt: &end, EndsAt: &start}, false},
+		{"one-off without end", models.FreezeWindow{StartsAt: &start}, false},
+		{"both kinds", models.FreezeWindow{StartDay: "friday", StartsAt: &start, EndsAt: &end}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateFreezeWindows([]models.FreezeWindow{tc.window})
+			if tc.valid && err != nil {
+				t.Errorf("validateFreezeWindows() = %v, want nil", err)
+			}
+			if !tc.valid && !errors.Is(err, ErrInvalidFreezeWindow) {
+				t.Errorf("validateFreezeWindows() = %v, want ErrInvalidFreezeWindow", err)
+			}
+		})
+	}
+}
+
+func TestActiveFreezeWindow(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("failed to load time zone: %v", err)
+	}
+	weekend := models.FreezeWindow{Name: "weekend", StartDay: "friday", StartTime: "18:00", EndDay: "monday", EndTime: "08:00", Timezone: "Europe/Berlin"}
+	monday := time.Date(2026, 10, 19, 8, 0, 0, 0, berlin) // the weekend of Friday 16 October 2026 ends here
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		frozen bool
+	}{
+		{"friday afternoon", time.Date(2026, 10, 16, 17, 59, 0, 0, berlin), false},
+		{"friday evening", time.Date(2026, 10, 16, 18, 0, 0, 0, berlin), true},
+		{"sunday", time.Date(2026, 10, 18, 12, 0, 0, 0, berlin), true},
+		{"monday early, in UTC", time.Date(2026, 10, 19, 5, 0, 0, 0, time.UTC), true},
+		{"monday morning", monday, false},
+		{"wednesday", time.Date(2026, 10, 21, 12, 0, 0, 0, berlin), false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			name, until, frozen := activeFreezeWindow([]models.FreezeWindow{weekend}, tc.now)
+			if frozen != tc.frozen {
+				t.Fatalf("frozen = %v, want %v", frozen, tc.frozen)
+			}
+			if frozen && (name != "weekend" || !until.Equal(monday)) {
+				t.Errorf("freeze = (%q, %v), want (weekend, %v)", name, until, monday)
+			}
+		})
+	}
+
+	t.Run("abutting windows are chained", func(t *testing.T) {
+		holidayEnd := monday.Add(48 * time.Hour)
+		holiday := models.FreezeWindow{Name: "holiday", StartsAt: &monday, EndsAt: &holidayEnd}
+		_, until, frozen := activeFreezeWindow([]models.FreezeWindow{holiday, weekend}, time.Date(2026, 10, 17, 12, 0, 0, 0, berlin))
+		if !frozen || !until.Equal(holidayEnd) {
+			t.Errorf("freeze = (%v, %v), want frozen until %v", frozen, until, holidayEnd)
+		}
+	})
+}
+
+// ── Scheduled Release Tests ─────────────────────────────────
+
+// scheduleTest holds app A's release and rollout services over the channels their schedules are checked against.
+type scheduleTest struct {
+	tenants
+	releaseService *ReleaseService
+	rolloutService *RolloutService
+}
+
+func newScheduleTest(t *testing.T) *scheduleTest {
+	t.Helper()
+	tn := newTenants(t, &models.Channel{}, &models.ReleaseTransition{}, &models.RolloutPlan{}, &models.AuditLog{})
+	releaseService, rolloutService := newReleaseService(tn, PatchGeneration{})
+	return &scheduleTest{tenants: tn, releaseService: releaseService, rolloutService: rolloutService}
+}
+
+// freezeChannel puts app A's production channel in a freeze window that lasts for the next hour.
+func freezeChannel(t *testing.T, tn tenants, action string) time.Time {
+	t.Helper()
+	start, end := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	channel := models.Channel{
+		ID: uuid.New(), AppID: tn.appA, Name: "Production", Slug: "production",
+		FreezeWindows: []models.FreezeWindow{{Name: "launch", StartsAt: &start, EndsAt: &end}}, FreezeAction: action,
+	}
+	if err := tn.db.Create(&channel).Error; err != nil {
+		t.Fatalf("failed to create channel: %v", err)
+	}
+	return end
+}
+
+func TestPublishDue_PublishesScheduledRelease(t *testing.T) {
+	f := newScheduleTest(t)
+	ctx := context.Background()
+
+	publishAt := time.Now().Add(time.Hour)
+	scheduled := models.Release{ID: uuid.New(), AppID: f.appA, Version: "1.1.0", Channel: "production", Hash: "c", RolloutPercentage: 5, PublishAt: &publishAt, Status: models.ReleaseScheduled}
+	if err := f.db.Create(&scheduled).Error; err != nil {
+		t.Fatalf("failed to create release: %v", err)
+	}
+	if err := f.db.Model(&scheduled).Update("is_active", false).Error; err != nil {
+		t.Fatalf("failed to deactivate release: %v", err)
+	}
+	if _, err := f.rolloutService.SchedulePlan(ctx, &scheduled, []models.RolloutStep{step(5, 60), step(100, 0)}, "system"); err != nil {
+		t.Fatalf("SchedulePlan failed: %v", err)
+	}
+
+	// Not yet due; the scheduled plan does not advance either
+	if err := f.releaseService.PublishDue(ctx, time.Now()); err != nil {
+		t.Fatalf("PublishDue failed: %v", err)
+	}
+	if err := f.rolloutService.AdvanceDuePlans(ctx, time.Now().Add(24*time.Hour)); err != nil {
+		t.Fatalf("AdvanceDuePlans failed: %v", err)
+	}
+	if got := f.release(t, scheduled.ID); got.IsActive || got.RolloutPercentage != 5 {
+		t.Fatalf("release before publish_at: active=%v rollout=%d", got.IsActive, got.RolloutPercentage)
+	}
+
+	if err := f.releaseService.PublishDue(ctx, publishAt.Add(time.Second)); err != nil {
+		t.Fatalf("PublishDue failed: %v", err)
+	}
+	published := f.release(t, scheduled.ID)
+	if !published.IsActive || published.PublishAt != nil {
+		t.Errorf("published release: active=%v publish_at=%v, want active without schedule", published.IsActive, published.PublishAt)
+	}
+	if previous := f.release(t, f.releaseA); previous.IsActive {
+		t.Error("previous release still active after publish")
+	}
+	plan, _ := f.rolloutService.GetPlan(f.appA, scheduled.ID)
+	if plan.Status != models.RolloutPlanActive || plan.NextStepAt == nil {
+		t.Errorf("plan after publish = (%s, next %v), want active with a next step", plan.Status, plan.NextStepAt)
+	}
+}
+
+func TestPublishDue_FreezeHoldsScheduledRelease(t *testing.T) {
+	f := newScheduleTest(t)
+	ctx := context.Background()
+	end := freezeChannel(t, f.tenants, models.FreezeActionReject)
+
+	publishAt := time.Now().Add(-time.Minute)
+	scheduled := models.Release{ID: uuid.New(), AppID: f.appA, Version: "1.1.0", Channel: "production", Hash: "c", RolloutPercentage: 100, PublishAt: &publishAt, Status: models.ReleaseScheduled}
+	if err := f.db.Create(&scheduled).Error; err != nil {
+		t.Fatalf("failed to create release: %v", err)
+	}
+	if err := f.db.Model(&scheduled).Update("is_active", false).Error; err != nil {
+		t.Fatalf("failed to deactivate release: %v", err)
+	}
+
+	if err := f.releaseService.PublishDue(ctx, time.Now()); err != nil {
+		t.Fatalf("PublishDue failed: %v", err)
+	}
+	if got := f.release(t, scheduled.ID); got.IsActive {
+		t.Fatal("release published during a freeze window")
+	}
+
+	if err := f.releaseService.PublishDue(ctx, end.Add(time.Second)); err != nil {
+		t.Fatalf("PublishDue failed: %v", err)
+	}
+	if got := f.release(t, scheduled.ID); !got.IsActive {
+		t.Error("release not published after the freeze window closed")
+	}
+}
+
+func TestUpdateRollout_FreezeRejects(t *testing.T) {
+	f := newScheduleTest(t)
+	freezeChannel(t, f.tenants, models.FreezeActionReject)
+
+	if _, err := f.releaseService.UpdateRollout(context.Background(), f.appA, f.releaseA, 80); !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("UpdateRollout during freeze: err = %v, want ErrChannelFrozen", err)
+	}
+	if got := f.release(t, f.releaseA).RolloutPercentage; got != 50 {
+		t.Errorf("rollout = %d, want unchanged 50", got)
+	}
+	// App B has no freeze window
+	if _, err := f.releaseService.UpdateRollout(context.Background(), f.appB, f.releaseB, 80); err != nil {
+		t.Errorf("UpdateRollout of an unfrozen channel failed: %v", err)
+	}
+}
+
+func TestUpdateRollout_FreezeDefers(t *testing.T) {
+	f := newScheduleTest(t)
+	ctx := context.Background()
+	end := freezeChannel(t, f.tenants, models.FreezeActionDefer)
+
+	release, err := f.releaseService.UpdateRollout(ctx, f.appA, f.releaseA, 80)
+	if err != nil {
+		t.Fatalf("UpdateRollout failed: %v", err)
+	}
+	if release.PendingRollout == nil || *release.PendingRollout != 80 || release.RolloutPercentage != 50 {
+		t.Fatalf("deferred rollout = (%d, pending %v), want (50, pending 80)", release.RolloutPercentage, release.PendingRollout)
+	}
+
+	if err := f.releaseService.PublishDue(ctx, time.Now()); err != nil {
+		t.Fatalf("PublishDue failed: %v", err)
+	}
+	if got := f.release(t, f.releaseA).RolloutPercentage; got != 50 {
+		t.Errorf("rollout during freeze = %d, want 50", got)
+	}
+
+	if err := f.releaseService.PublishDue(ctx, end.Add(time.Second)); err != nil {
+		t.Fatalf("PublishDue failed: %v", err)
+	}
+	got := f.release(t, f.releaseA)
+	if got.RolloutPercentage != 80 || got.PendingRollout != nil {
+		t.Errorf("rollout after freeze = (%d, pending %v), want (80, none)", got.RolloutPercentage, got.PendingRollout)
+	}
+}
+
+func TestAdvanceDuePlans_FreezeHoldsPlan(t *testing.T) {
+	f := newScheduleTest(t)
+	ctx := context.Background()
+
+	if _, err := f.rolloutService.SetPlan(ctx, f.appA, f.releaseA, []models.RolloutStep{step(5, 30), step(100, 0)}, "alice"); err != nil {
+		t.Fatalf("SetPlan failed: %v", err)
+	}
+	freezeChannel(t, f.tenants, models.FreezeActionReject)
+
+	// The hold has elapsed, but the channel is frozen for another 15 minutes
+	if err := f.rolloutService.AdvanceDuePlans(ctx, time.Now().Add(45*time.Minute)); err != nil {
+		t.Fatalf("AdvanceDuePlans failed: %v", err)
+	}
+	if got := f.release(t, f.releaseA).RolloutPercentage; got != 5 {
+		t.Errorf("rollout during freeze = %d, want 5", got)
+	}
+	if _, err := f.rolloutService.Skip(ctx, f.appA, f.releaseA, "alice"); !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("Skip during freeze: err = %v, want ErrChannelFrozen", err)
+	}
+
+	if err := f.rolloutService.AdvanceDuePlans(ctx, time.Now().Add(2*time.Hour)); err != nil {
+		t.Fatalf("AdvanceDuePlans failed: %v", err)
+	}
+	if got := f.release(t, f.releaseA).RolloutPercentage; got != 100 {
+		t.Errorf("rollout after freeze = %d, want 100", got)
+	}
+}
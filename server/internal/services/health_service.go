@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// HealthService evaluates channel health gates against the installations reported for active
+// releases, halting (and optionally rolling back) releases whose failure or rollback rate is too high.
+type HealthService struct {
+	channelRepo     *repository.ChannelRepository
+	releaseRepo     *repository.ReleaseRepository
+	deviceRepo      *repository.DeviceRepository
+	releaseService  *ReleaseService
+	rolloutService  *RolloutService
+	lifecycle       *ReleaseLifecycle
+	settingsService *SettingsService
+	securityService *SecurityService
+	redis           *redis.Client
+}
+
+// NewHealthService creates a new HealthService.
+func NewHealthService(
+	channelRepo *repository.ChannelRepository,
+	releaseRepo *repository.ReleaseRepository,
+	deviceRepo *repository.DeviceRepository,
+	releaseService *ReleaseService,
+	rolloutService *RolloutService,
+	lifecycle *ReleaseLifecycle,
+	settingsService *SettingsService,
+	securityService *SecurityService,
+	redis *redis.Client,
+) *HealthService {
+	return &HealthService{
+		channelRepo:     channelRepo,
+		releaseRepo:     releaseRepo,
+		deviceRepo:      deviceRepo,
+		releaseService:  releaseService,
+		rolloutService:  rolloutService,
+		lifecycle:       lifecycle,
+		settingsService: settingsService,
+		securityService: securityService,
+		redis:           redis,
+	}
+}
+
+// measureHealth computes install metrics from a release's installation counts grouped by status.
+// Server-driven reverts are not install outcomes, so they are left out of the sample.
+func measureHealth(release *models.Release, counts map[string]int64) models.ReleaseHealth {
+	health := models.ReleaseHealth{
+		ReleaseID:  release.ID,
+		Version:    release.Version,
+		Channel:    release.Channel,
+		Applied:    counts["applied"],
+		Failed:     counts["failed"],
+		RolledBack: counts["rolled_back"],
+	}
+	health.SampleSize = health.Applied + health.Failed + health.RolledBack
+	if health.SampleSize > 0 {
+		health.FailureRate = float64(health.Failed) / float64(health.SampleSize) * 100
+		health.RollbackRate = float64(health.RolledBack) / float64(health.SampleSize) * 100
+	}
+	return health
+}
+
+// healthBreaches describes every threshold of the channel that the metrics exceed.
+// Returns nil while the sample is smaller than the channel's minimum.
+func healthBreaches(channel *models.Channel, health models.ReleaseHealth) []string {
+	if health.SampleSize < int64(channel.HealthMinSampleSize) {
+		return nil
+	}
+
+	var breaches []string
+	if health.FailureRate > channel.HealthMaxFailureRate {
+		breaches = append(breaches, fmt.Sprintf("failure rate %.1f%% exceeds %.1f%%", health.FailureRate, channel.HealthMaxFailureRate))
+	}
+	if health.RollbackRate > channel.HealthMaxRollbackRate {
+		breaches = append(breaches, fmt.Sprintf("rollback rate %.1f%% exceeds %.1f%%", health.RollbackRate, channel.HealthMaxRollbackRate))
+	}
+	return breaches
+}
+
+// EvaluateActiveReleases checks every active release of every health-gated channel and halts
+// those that breach their channel's thresholds. Run periodically by the Scheduler.
+func (s *HealthService) EvaluateActiveReleases(ctx context.Context, now time.Time) error {
+	channels, err := s.channelRepo.ListHealthGated()
+	if err != nil {
+		return fmt.Errorf("failed to load health-gated channels: %w", err)
+	}
+
+	for i := range channels {
+		channel := &channels[i]
+		releases, err := s.releaseRepo.GetActiveReleases(channel.AppID, channel.Slug)
+		if err != nil {
+			log.Printf("health: failed to load active releases of channel %s: %v", channel.ID, err)
+			continue
+		}
+		for j := range releases {
+			release := &releases[j]
+			if release.HaltedAt != nil {
+				continue
+			}
+			if err := s.evaluate(ctx, channel, release, now); err != nil {
+				log.Printf("health: failed to evaluate release %s: %v", release.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *HealthService) evaluate(ctx context.Context, channel *models.Channel, release *models.Release, now time.Time) error {
+	since := release.CreatedAt
+	if release.HealthWindowStart != nil {
+		since = *release.HealthWindowStart
+	}
+	counts, err := s.deviceRepo.CountInstallationsByStatusSince(release.ID, since)
+	if err != nil {
+		return fmt.Errorf("failed to count installations: %w", err)
+	}
+
+	health := measureHealth(release, counts)
+	breaches := healthBreaches(channel, health)
+	if len(breaches) == 0 {
+		return nil
+	}
+	return s.halt(ctx, channel, release, health, breaches, now)
+}
+
+// halt stops a release from reaching more devices and, when the channel asks for it, rolls the channel back.
+func (s *HealthService) halt(ctx context.Context, channel *models.Channel, release *models.Release, health models.ReleaseHealth, breaches []string, now time.Time) error {
+	reason := strings.Join(breaches, "; ")
+
+	// Pause the plan first so the rollout scheduler cannot raise the percentage again
+	s.rolloutService.PauseForHealthGate(release.AppID, release.ID, reason)
+
+	halted, err := s.releaseRepo.Halt(release.AppID, release.ID, reason, now)
+	if err != nil {
+		return fmt.Errorf("failed to halt release: %w", err)
+	}
+	if !halted {
+		// Already halted by another server instance, or no longer active
+		return nil
+	}
+	release.HaltedAt = &now
+	s.lifecycle.record(release, models.ReleaseHalted, "health_gate", reason)
+	if s.redis != nil {
+		s.redis.Del(ctx, activeReleaseCacheKey(release.AppID, release.Channel))
+	}
+
+	action := models.HealthActionPause
+	if channel.HealthAction == models.HealthActionRollback {
+		// The release stays halted at 0% even if the rollback fails
+		if err := s.rollback(release); err != nil {
+			log.Printf("health: failed to roll back release %s: %v", release.ID, err)
+		} else {
+			action = models.HealthActionRollback
+		}
+	}
+
+	s.securityService.Log(release.AppID, "health_gate", "release.halted", release.ID.String(), fmt.Sprintf("%s (action: %s)", reason, action), "")
+	s.settingsService.DispatchEvent(release.AppID, "release.halted", map[string]interface{}{
+		"release_id": release.ID,
+		"version":    release.Version,
+		"channel":    release.Channel,
+		"action":     action,
+		"reasons":    breaches,
+		"metrics":    health,
+		"thresholds": map[string]interface{}{
+			"max_failure_rate":  channel.HealthMaxFailureRate,
+			"max_rollback_rate": channel.HealthMaxRollbackRate,
+			"min_sample_size":   channel.HealthMinSampleSize,
+		},
+	})
+	return nil
+}
+
+// rollback reinstates the newest healthy release below the halted one in its lane,
+// or pulls the halted release back to the embedded bundle when there is none.
+func (s *HealthService) rollback(release *models.Release) error {
+	lane, err := s.releaseRepo.ListLane(release.AppID, release.Channel, release.TargetNativeVersion, publishedStatuses)
+	if err != nil {
+		return fmt.Errorf("failed to load releases: %w", err)
+	}
+
+	var previous *models.Release
+	for i := range lane {
+		r := &lane[i]
+		if r.RolledBackAt != nil || r.HaltedAt != nil || !wasPublished(r.Status) || compareVersions(r.Version, release.Version) >= 0 {
+			continue
+		}
+		if previous == nil || isVersionGreater(r.Version, previous.Version) {
+			previous = r
+		}
+	}
+
+	if previous == nil {
+		_, err = s.releaseService.RollbackToEmbedded(release.AppID, release.ID)
+		return err
+	}
+	_, err = s.releaseService.Rollback(release.AppID, previous.ID)
+	return err
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+)
+
+// ── Health Gate Tests ───────────────────────────────────────
+
+func TestMeasureHealth(t *testing.T) {
+	release := &models.Release{ID: uuid.New(), Version: "1.0.0", Channel: "production"}
+	health := measureHealth(release, map[string]int64{"applied": 90, "failed": 6, "rolled_back": 4, "reverted": 50})
+
+	if health.SampleSize != 100 {
+		t.Errorf("sample size = %d, want 100 (reverts excluded)", health.SampleSize)
+	}
+	if health.FailureRate != 6 || health.RollbackRate != 4 {
+		t.Errorf("rates = (%.1f, %.1f), want (6.0, 4.0)", health.FailureRate, health.RollbackRate)
+	}
+
+	empty := measureHealth(release, map[string]int64{})
+	if empty.SampleSize != 0 || empty.FailureRate != 0 || empty.RollbackRate != 0 {
+		t.Errorf("empty health = %+v, want zero metrics", empty)
+	}
+}
+
+func TestHealthBreaches(t *testing.T) {
+	channel := &models.Channel{HealthMaxFailureRate: 5, HealthMaxRollbackRate: 2, HealthMinSampleSize: 100}
+
+	tests := []struct {
+		name       string
+		counts     map[string]int64
+		breachesOf int
+	}{
+		{"healthy", map[string]int64{"applied": 98, "failed": 1, "rolled_back": 1}, 0},
+		{"at threshold", map[string]int64{"applied": 93, "failed": 5, "rolled_back": 2}, 0},
+		{"too many failures", map[string]int64{"applied": 90, "failed": 10}, 1},
+		{"too many rollbacks", map[string]int64{"applied": 95, "rolled_back": 5}, 1},
+		{"both", map[string]int64{"applied": 80, "failed": 10, "rolled_back": 10}, 2},
+		{"below minimum sample", map[string]int64{"applied": 10, "failed": 50}, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			health := measureHealth(&models.Release{}, tc.counts)
+			if got := healthBreaches(channel, health); len(got) != tc.breachesOf {
+				t.Errorf("healthBreaches() = %v, want %d breaches", got, tc.breachesOf)
+			}
+		})
+	}
+}
+
+// healthTest holds app A's release and rollout services, which the health gate acts through.
+type healthTest struct {
+	tenants
+	releaseService *ReleaseService
+	rolloutService *RolloutService
+}
+
+// newHealthFixture gates app A's production channel and wires a HealthService over it.
+func newHealthFixture(t *testing.T, action string) (*HealthService, *healthTest) {
+	t.Helper()
+	tn := newTenants(t, &models.Channel{}, &models.ReleaseTransition{}, &models.RolloutPlan{}, &models.Patch{}, &models.Device{}, &models.Installation{}, &models.AuditLog{})
+	releaseService, rolloutService := newReleaseService(tn, PatchGeneration{})
+	f := &healthTest{tenants: tn, releaseService: releaseService, rolloutService: rolloutService}
+
+	channel := models.Channel{
+		ID: uuid.New(), AppID: f.appA, Name: "Production", Slug: "production",
+		HealthGateEnabled: true, HealthMaxFailureRate: 5, HealthMaxRollbackRate: 2, HealthMinSampleSize: 10, HealthAction: action,
+	}
+	if err := f.db.Create(&channel).Error; err != nil {
+		t.Fatalf("failed to create channel: %v", err)
+	}
+
+	service := NewHealthService(releaseService.channelRepo, releaseService.repo, repository.NewDeviceRepository(f.db),
+		releaseService, rolloutService, releaseService.lifecycle, releaseService.settingsService, releaseService.securityService, nil)
+	return service, f
+}
+
+func reportInstalls(t *testing.T, tn tenants, releaseID uuid.UUID, status string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		installation := models.Installation{ID: uuid.New(), DeviceID: uuid.New(), ReleaseID: releaseID, Status: status}
+		if err := tn.db.Create(&installation).Error; err != nil {
+			t.Fatalf("failed to create installation: %v", err)
+		}
+	}
+}
+
+func TestHealth_HealthyReleaseKeepsRollingOut(t *testing.T) {
+	service, f := newHealthFixture(t, models.HealthActionPause)
+	reportInstalls(t, f.tenants, f.releaseA, "applied", 20)
+
+	if err := service.EvaluateActiveReleases(context.Background(), time.Now()); err != nil {
+		t.Fatalf("EvaluateActiveReleases failed: %v", err)
+	}
+	release := f.release(t, f.releaseA)
+	if release.HaltedAt != nil || release.RolloutPercentage != 50 {
+		t.Errorf("healthy release changed: halted=%v rollout=%d", release.HaltedAt, release.RolloutPercentage)
+	}
+}
+
+func TestHealth_BreachPausesRollout(t *testing.T) {
+	service, f := newHealthFixture(t, models.HealthActionPause)
+	ctx := context.Background()
+
+	if _, err := f.rolloutService.SetPlan(ctx, f.appA, f.releaseA, []models.RolloutStep{step(10, 60), step(100, 0)}, "alice"); err != nil {
+		t.Fatalf("SetPlan failed: %v", err)
+	}
+	reportInstalls(t, f.tenants, f.releaseA, "applied", 8)
+	reportInstalls(t, f.tenants, f.releaseA, "failed", 2)
+	// App B has no health gate, so its equally bad release is left alone
+	reportInstalls(t, f.tenants, f.releaseB, "failed", 10)
+
+	if err := service.EvaluateActiveReleases(ctx, time.Now()); err != nil {
+		t.Fatalf("EvaluateActiveReleases failed: %v", err)
+	}
+
+	release := f.release(t, f.releaseA)
+	if release.HaltedAt == nil || release.HaltReason == "" {
+		t.Fatalf("release was not halted: %+v", release)
+	}
+	if !release.IsActive || release.RolloutPercentage != 0 {
+		t.Errorf("halted release: active=%v rollout=%d, want active at 0%%", release.IsActive, release.RolloutPercentage)
+	}
+	plan, _ := f.rolloutService.GetPlan(f.appA, f.releaseA)
+	if plan.Status != models.RolloutPlanPaused {
+		t.Errorf("plan status = %s, want paused", plan.Status)
+	}
+	if other := f.release(t, f.releaseB); other.HaltedAt != nil {
+		t.Error("release of an ungated channel was halted")
+	}
+
+	// Clearing the halt restarts the health window, so old failures no longer count
+	if _, err := f.releaseService.UpdateRollout(ctx, f.appA, f.releaseA, 20); err != nil {
+		t.Fatalf("UpdateRollout failed: %v", err)
+	}
+	if err := service.EvaluateActiveReleases(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("EvaluateActiveReleases failed: %v", err)
+	}
+	release = f.release(t, f.releaseA)
+	if release.HaltedAt != nil || release.RolloutPercentage != 20 {
+		t.Errorf("resumed release: halted=%v rollout=%d, want running at 20%%", release.HaltedAt, release.RolloutPercentage)
+	}
+}
+
+func TestHealth_BreachRollsBackToPreviousRelease(t *testing.T) {
+	service, f := newHealthFixture(t, models.HealthActionRollback)
+
+	bad := models.Release{ID: uuid.New(), AppID: f.appA, Version: "1.1.0", Channel: "production", Hash: "c", RolloutPercentage: 100, IsActive: true}
+	if err := f.db.Create(&bad).Error; err != nil {
+		t.Fatalf("failed to create release: %v", err)
+	}
+	if err := f.db.Model(&models.Release{}).Where("id = ?", f.releaseA).Update("is_active", false).Error; err != nil {
+		t.Fatalf("failed to deactivate release: %v", err)
+	}
+	reportInstalls(t, f.tenants, bad.ID, "applied", 7)
+	reportInstalls(t, f.tenants, bad.ID, "rolled_back", 3)
+
+	if err := service.EvaluateActiveReleases(context.Background(), time.Now()); err != nil {
+		t.Fatalf("EvaluateActiveReleases failed: %v", err)
+	}
+
+	halted := f.release(t, bad.ID)
+	if halted.HaltedAt == nil || halted.IsActive || halted.RolledBackAt == nil {
+		t.Errorf("bad release: halted=%v active=%v rolledBack=%v, want halted, inactive and rolled back",
+			halted.HaltedAt, halted.IsActive, halted.RolledBackAt)
+	}
+	if previous := f.release(t, f.releaseA); !previous.IsActive {
+		t.Error("previous release was not reinstated")
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+)
+
+// ErrInvalidTransition is returned when a release cannot move from its current lifecycle status to the requested one.
+var ErrInvalidTransition = errors.New("invalid release status transition")
+
+// releaseTransitions lists the statuses each lifecycle status may move to.
+var releaseTransitions = map[string][]string{
+	models.ReleaseDraft:           {models.ReleasePendingApproval, models.ReleaseScheduled, models.ReleaseRollingOut, models.ReleaseCompleted, models.ReleaseRolledBack, models.ReleaseArchived},
+	models.ReleasePendingApproval: {models.ReleaseScheduled, models.ReleaseRollingOut, models.ReleaseCompleted, models.ReleaseRejected, models.ReleaseRolledBack, models.ReleaseArchived},
+	models.ReleaseRejected:        {models.ReleaseArchived},
+	models.ReleaseScheduled:       {models.ReleaseDraft, models.ReleaseRollingOut, models.ReleaseCompleted, models.ReleaseSuperseded, models.ReleaseRolledBack, models.ReleaseArchived},
+	models.ReleaseRollingOut:      {models.ReleaseCompleted, models.ReleasePaused, models.ReleaseHalted, models.ReleaseSuperseded, models.ReleaseRolledBack, models.ReleaseArchived},
+	models.ReleaseCompleted:       {models.ReleaseRollingOut, models.ReleasePaused, models.ReleaseHalted, models.ReleaseSuperseded, models.ReleaseRolledBack, models.ReleaseArchived},
+	models.ReleasePaused:          {models.ReleaseRollingOut, models.ReleaseCompleted, models.ReleaseHalted, models.ReleaseSuperseded, models.ReleaseRolledBack, models.ReleaseArchived},
+	models.ReleaseHalted:          {models.ReleaseRollingOut, models.ReleaseCompleted, models.ReleaseSuperseded, models.ReleaseRolledBack, models.ReleaseArchived},
+	models.ReleaseSuperseded:      {models.ReleaseRollingOut, models.ReleaseCompleted, models.ReleaseRolledBack, models.ReleaseArchived},
+	models.ReleaseRolledBack:      {models.ReleaseRollingOut, models.ReleaseCompleted, models.ReleaseArchived},
+	models.ReleaseArchived:        {},
+}
+
+// canTransition reports whether a release may move from one lifecycle status to another.
+func canTransition(from, to string) bool {
+	for _, allowed := range releaseTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// liveStatus is the status of a live release serving the given rollout percentage.
+func liveStatus(percentage int) string {
+	if percentage >= 100 {
+		return models.ReleaseCompleted
+	}
+	return models.ReleaseRollingOut
+}
+
+// isServing reports whether a release in the given status is offered to devices that have not installed it yet.
+// Releases cached before statuses existed have none and keep being served.
+func isServing(status string) bool {
+	return status != models.ReleasePaused && status != models.ReleaseHalted
+}
+
+// ReleaseLifecycle records release status changes: it validates each transition, stores it in the
+// release's history and notifies webhooks. Shared by the services that change release state.
+type ReleaseLifecycle struct {
+	repo            *repository.ReleaseRepository
+	settingsService *SettingsService
+}
+
+// NewReleaseLifecycle creates a new ReleaseLifecycle.
+func NewReleaseLifecycle(repo *repository.ReleaseRepository, settingsService *SettingsService) *ReleaseLifecycle {
+	return &ReleaseLifecycle{repo: repo, settingsService: settingsService}
+}
+
+// created records the first status of a newly stored release.
+func (l *ReleaseLifecycle) created(release *models.Release, actor, reason string) {
+	transition := &models.ReleaseTransition{
+		ID:        uuid.New(),
+		AppID:     release.AppID,
+		ReleaseID: release.ID,
+		ToStatus:  release.Status,
+		Actor:     actor,
+		Reason:    reason,
+	}
+	if err := l.repo.CreateTransition(transition); err != nil {
+		log.Printf("lifecycle: failed to record status of release %s: %v", release.ID, err)
+	}
+}
+
+// transition moves a release to a new status, applying updates to its other columns in the same write.
+// Moving to the current status is a no-op.
+func (l *ReleaseLifecycle) transition(release *models.Release, to, actor, reason string, updates map[string]interface{}) error {
+	from := release.Status
+	if from == to {
+		return nil
+	}
+	if !canTransition(from, to) {
+		return fmt.Errorf("%w: a %s release cannot become %s", ErrInvalidTransition, from, to)
+	}
+
+	ok, err := l.repo.SetStatus(release.ID, from, to, updates, &models.ReleaseTransition{
+		ID:         uuid.New(),
+		AppID:      release.AppID,
+		ReleaseID:  release.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      actor,
+		Reason:     reason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update release status: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: release status changed concurrently", ErrInvalidTransition)
+	}
+	release.Status = to
+
+	l.settingsService.DispatchEvent(release.AppID, "release.status."+to, map[string]interface{}{
+		"release_id":  release.ID,
+		"version":     release.Version,
+		"channel":     release.Channel,
+		"from_status": from,
+		"to_status":   to,
+		"actor":       actor,
+		"reason":      reason,
+	})
+	return nil
+}
+
+// record notes a status change the caller has already applied to the release's other columns.
+// Changes that do not apply to the release's current status, such as a rollback reaching an
+// archived release, are ignored.
+func (l *ReleaseLifecycle) record(release *models.Release, to, actor, reason string) {
+	if release.Status == to || !canTransition(release.Status, to) {
+		return
+	}
+	if err := l.transition(release, to, actor, reason, nil); err != nil {
+		log.Printf("lifecycle: failed to record release %s as %s: %v", release.ID, to, err)
+	}
+}
+
+// settle records a live release as rolling out or completed after its rollout percentage changed
+// or its halt was lifted. Paused releases stay paused and halted ones stay halted until cleared.
+func (l *ReleaseLifecycle) settle(release *models.Release, actor, reason string) {
+	switch release.Status {
+	case models.ReleaseHalted:
+		if release.HaltedAt != nil {
+			return
+		}
+	case models.ReleaseRollingOut, models.ReleaseCompleted:
+	default:
+		return
+	}
+	l.record(release, liveStatus(release.RolloutPercentage), actor, reason)
+}
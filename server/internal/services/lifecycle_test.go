@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/config"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+	"github.com/hotpatch/server/internal/storage"
+)
+
+// ── Release Lifecycle Tests ─────────────────────────────────
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		allowed  bool
+	}{
+		{models.ReleaseDraft, models.ReleaseScheduled, true},
+		{models.ReleaseDraft, models.ReleasePaused, false},
+		{models.ReleaseScheduled, models.ReleaseRollingOut, true},
+		{models.ReleaseRollingOut, models.ReleaseCompleted, true},
+		{models.ReleaseCompleted, models.ReleasePaused, true},
+		{models.ReleasePaused, models.ReleaseRollingOut, true},
+		{models.ReleaseHalted, models.ReleasePaused, false},
+		{models.ReleaseSuperseded, models.ReleaseCompleted, true},
+		{models.ReleaseSuperseded, models.ReleaseScheduled, false},
+		{models.ReleaseRolledBack, models.ReleaseHalted, false},
+		{models.ReleaseArchived, models.ReleaseRollingOut, false},
+	}
+
+	for _, tc := range tests {
+		if got := canTransition(tc.from, tc.to); got != tc.allowed {
+			t.Errorf("canTransition(%s, %s) = %v, want %v", tc.from, tc.to, got, tc.allowed)
+		}
+	}
+}
+
+// lifecycleTest holds app A's release and rollout services over the recorded release transitions.
+type lifecycleTest struct {
+	tenants
+	releaseService *ReleaseService
+	rolloutService *RolloutService
+}
+
+func newLifecycleTest(t *testing.T) *lifecycleTest {
+	t.Helper()
+	tn := newTenants(t, &models.Channel{}, &models.ReleaseTransition{}, &models.RolloutPlan{}, &models.Patch{}, &models.SecretFinding{}, &models.AuditLog{})
+	releaseService, rolloutService := newReleaseService(tn, PatchGeneration{})
+	return &lifecycleTest{tenants: tn, releaseService: releaseService, rolloutService: rolloutService}
+}
+
+// draftRelease creates a draft of app A's production channel.
+func draftRelease(t *testing.T, tn tenants, version string) models.Release {
+	t.Helper()
+	release := models.Release{ID: uuid.New(), AppID: tn.appA, Version: version, Channel: "production", Hash: "d", BundleKey: "bundles/" + version + ".zip", RolloutPercentage: 100, Status: models.ReleaseDraft}
+	if err := tn.db.Create(&release).Error; err != nil {
+		t.Fatalf("failed to create release: %v", err)
+	}
+	if err := tn.db.Model(&release).Update("is_active", false).Error; err != nil {
+		t.Fatalf("failed to deactivate release: %v", err)
+	}
+	return release
+}
+
+// assertHistory checks a release's transitions, each written as "from>to".
+func assertHistory(t *testing.T, transitions []models.ReleaseTransition, want ...string) {
+	t.Helper()
+	var got []string
+	for _, tr := range transitions {
+		got = append(got, tr.FromStatus+">"+tr.ToStatus)
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("history = %v, want %v", got, want)
+	}
+}
+
+func TestSetStatus_PublishPauseAndResume(t *testing.T) {
+	f := newLifecycleTest(t)
+	ctx := context.Background()
+	draft := draftRelease(t, f.tenants, "1.1.0")
+
+	store, err := storage.NewS3Storage(&config.Config{S3Region: "us-east-1", CDNBaseURL: "https://cdn.example.com"})
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	updates := NewUpdateService(repository.NewReleaseRepository(f.db), repository.NewDeviceRepository(f.db), nil, store, nil)
+	check := func() bool {
+		t.Helper()
+		resp, err := updates.CheckForUpdate(ctx, &models.UpdateCheckRequest{AppID: f.appA.String(), DeviceID: "device-1", Version: "1.0.0", Platform: "android", Channel: "production"})
+		if err != nil {
+			t.Fatalf("CheckForUpdate failed: %v", err)
+		}
+		return resp.UpdateAvailable
+	}
+
+	if check() {
+		t.Fatal("draft offered to devices")
+	}
+
+	published, err := f.releaseService.SetStatus(ctx, f.appA, draft.ID, &models.SetReleaseStatusRequest{Status: models.ReleaseRollingOut}, "alice", "")
+	if err != nil {
+		t.Fatalf("publishing draft failed: %v", err)
+	}
+	if published.Status != models.ReleaseCompleted || !f.release(t, draft.ID).IsActive {
+		t.Errorf("published draft = (%s, active %v), want completed and active", published.Status, f.release(t, draft.ID).IsActive)
+	}
+	if got := f.release(t, f.releaseA).Status; got != models.ReleaseSuperseded {
+		t.Errorf("previous release status = %s, want superseded", got)
+	}
+	if !check() {
+		t.Fatal("published release not offered")
+	}
+
+	if _, err := f.releaseService.SetStatus(ctx, f.appA, draft.ID, &models.SetReleaseStatusRequest{Status: models.ReleasePaused, Reason: "crash spike"}, "alice", ""); err != nil {
+		t.Fatalf("pausing failed: %v", err)
+	}
+	if check() {
+		t.Error("paused release offered to a new device")
+	}
+	if got := f.release(t, draft.ID); !got.IsActive || got.Status != models.ReleasePaused {
+		t.Errorf("paused release = (%s, active %v), want paused and still active", got.Status, got.IsActive)
+	}
+
+	if _, err := f.releaseService.SetStatus(ctx, f.appA, draft.ID, &models.SetReleaseStatusRequest{Status: models.ReleaseRollingOut}, "alice", ""); err != nil {
+		t.Fatalf("resuming failed: %v", err)
+	}
+	if !check() {
+		t.Error("resumed release not offered")
+	}
+
+	history, err := f.releaseService.History(f.appA, draft.ID)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	assertHistory(t, history, "draft>completed", "completed>paused", "paused>completed")
+	if len(history) == 3 && (history[1].Actor != "alice" || history[1].Reason != "crash spike") {
+		t.Errorf("pause recorded as (%s, %q), want (alice, crash spike)", history[1].Actor, history[1].Reason)
+	}
+}
+
+func TestSetStatus_RejectsInvalidTransitions(t *testing.T) {
+	f := newLifecycleTest(t)
+	ctx := context.Background()
+	draft := draftRelease(t, f.tenants, "1.1.0")
+	set := func(id uuid.UUID, req models.SetReleaseStatusRequest) error {
+		_, err := f.releaseService.SetStatus(ctx, f.appA, id, &req, "alice", "")
+		return err
+	}
+
+	if err := set(draft.ID, models.SetReleaseStatusRequest{Status: models.ReleasePaused}); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("pausing a draft: err = %v, want ErrInvalidTransition", err)
+	}
+	if err := set(draft.ID, models.SetReleaseStatusRequest{Status: models.ReleaseScheduled}); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("scheduling without publish_at: err = %v, want ErrInvalidSchedule", err)
+	}
+	if err := set(f.releaseA, models.SetReleaseStatusRequest{Status: models.ReleaseDraft}); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("returning a live release to draft: err = %v, want ErrInvalidTransition", err)
+	}
+
+	// Rolling back to a release that never went live is refused
+	if _, err := f.releaseService.Rollback(f.appA, draft.ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("rolling back to a draft: err = %v, want ErrInvalidTransition", err)
+	}
+
+	if err := f.releaseService.Archive(ctx, f.appA, f.releaseA); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if err := set(f.releaseA, models.SetReleaseStatusRequest{Status: models.ReleaseRollingOut}); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("resuming an archived release: err = %v, want ErrInvalidTransition", err)
+	}
+}
+
+func TestLifecycle_SystemTransitionsAreRecorded(t *testing.T) {
+	f := newLifecycleTest(t)
+	ctx := context.Background()
+
+	// A plan step reaching 100% completes the rollout
+	if _, err := f.rolloutService.SetPlan(ctx, f.appA, f.releaseA, []models.RolloutStep{step(10, 0), step(100, 0)}, "alice"); err != nil {
+		t.Fatalf("SetPlan failed: %v", err)
+	}
+	if err := f.rolloutService.AdvanceDuePlans(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("AdvanceDuePlans failed: %v", err)
+	}
+	if got := f.release(t, f.releaseA).Status; got != models.ReleaseCompleted {
+		t.Fatalf("status after final step = %s, want completed", got)
+	}
+
+	newer := draftRelease(t, f.tenants, "1.1.0")
+	if _, err := f.releaseService.SetStatus(ctx, f.appA, newer.ID, &models.SetReleaseStatusRequest{Status: models.ReleaseRollingOut}, "alice", ""); err != nil {
+		t.Fatalf("publishing failed: %v", err)
+	}
+	if _, err := f.releaseService.Rollback(f.appA, f.releaseA); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if got := f.release(t, newer.ID).Status; got != models.ReleaseRolledBack {
+		t.Errorf("pulled release status = %s, want rolled_back", got)
+	}
+	if got := f.release(t, f.releaseA).Status; got != models.ReleaseCompleted {
+		t.Errorf("reinstated release status = %s, want completed", got)
+	}
+
+	history, err := f.releaseService.History(f.appA, f.releaseA)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	assertHistory(t, history, "rolling_out>completed", "completed>superseded", "superseded>completed")
+}
+
+func TestRollback_LeavesUnpublishedReleasesAlone(t *testing.T) {
+	f := newLifecycleTest(t)
+	ctx := context.Background()
+
+	newer := draftRelease(t, f.tenants, "1.1.0")
+	if _, err := f.releaseService.SetStatus(ctx, f.appA, newer.ID, &models.SetReleaseStatusRequest{Status: models.ReleaseRollingOut}, "alice", ""); err != nil {
+		t.Fatalf("publishing failed: %v", err)
+	}
+	archived := draftRelease(t, f.tenants, "1.2.0")
+	if err := f.releaseService.Archive(ctx, f.appA, archived.ID); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	draft := draftRelease(t, f.tenants, "1.3.0")
+	// A scheduled release would publish itself over the rollback, so it is pulled too
+	scheduled := draftRelease(t, f.tenants, "1.4.0")
+	if err := f.db.Model(&scheduled).Updates(map[string]interface{}{"status": models.ReleaseScheduled, "publish_at": time.Now().Add(time.Hour)}).Error; err != nil {
+		t.Fatalf("failed to schedule release: %v", err)
+	}
+
+	if _, err := f.releaseService.Rollback(f.appA, f.releaseA); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	for name, id := range map[string]uuid.UUID{"published": newer.ID, "scheduled": scheduled.ID} {
+		if got := f.release(t, id); got.Status != models.ReleaseRolledBack || got.RolledBackAt == nil {
+			t.Errorf("%s release above the target: status = %s, rolled back at %v; want rolled_back", name, got.Status, got.RolledBackAt)
+		}
+	}
+	for name, id := range map[string]uuid.UUID{"archived": archived.ID, "draft": draft.ID} {
+		if got := f.release(t, id); got.RolledBackAt != nil {
+			t.Errorf("%s release above the target was flagged rolled back", name)
+		}
+		history, err := f.releaseService.History(f.appA, id)
+		if err != nil {
+			t.Fatalf("History failed: %v", err)
+		}
+		for _, tr := range history {
+			if tr.ToStatus == models.ReleaseRolledBack {
+				t.Errorf("%s release above the target recorded %s>%s", name, tr.FromStatus, tr.ToStatus)
+			}
+		}
+	}
+}
+
+func TestRollback_RefusesHaltedTargets(t *testing.T) {
+	f := newLifecycleTest(t)
+	now := time.Now()
+
+	// Halted and later superseded, and halted while still active
+	superseded := channelRelease(t, f.tenants, "0.9.0", models.ReleaseSuperseded, time.Hour)
+	if err := f.db.Model(&superseded).Updates(map[string]interface{}{"halted_at": now, "rollout_percentage": 0, "is_active": false}).Error; err != nil {
+		t.Fatalf("failed to halt release: %v", err)
+	}
+	if err := f.db.Model(&models.Release{}).Where("id = ?", f.releaseA).Updates(map[string]interface{}{"status": models.ReleaseHalted, "halted_at": now, "rollout_percentage": 0}).Error; err != nil {
+		t.Fatalf("failed to halt release: %v", err)
+	}
+	newer := channelRelease(t, f.tenants, "1.1.0", models.ReleaseRollingOut, 0)
+
+	for name, id := range map[string]uuid.UUID{"superseded after a halt": superseded.ID, "halted": f.releaseA} {
+		if _, err := f.releaseService.Rollback(f.appA, id); !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("rolling back to a %s release: err = %v, want ErrInvalidTransition", name, err)
+		}
+	}
+	if got := f.release(t, newer.ID); !got.IsActive || got.RolledBackAt != nil {
+		t.Errorf("a refused rollback pulled the newer release: active = %v, rolled back at %v", got.IsActive, got.RolledBackAt)
+	}
+}
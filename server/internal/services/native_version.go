@@ -0,0 +1,277 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidVersionRange is returned when a release declares a native version range that cannot be parsed.
+var ErrInvalidVersionRange = errors.New("invalid native version range")
+
+// versionRange is a parsed semver range: a set of alternatives ("||"),
+// each of which is a list of comparators that must all hold.
+type versionRange [][]versionComparator
+
+type versionComparator struct {
+	op      string // ">=", ">", "<=", "<", "="
+	version semVersion
+}
+
+// parseVersionRange parses the npm-style range syntax used for native version targeting:
+//
+//	"", "*", "x"             any native version
+//	"2.3.1", "=2.3.1"        exactly 2.3.1
+//	"2.x", "2.3.*", "2.3"    any version with that prefix
+//	">=2.3.0 <3.0.0"         comparators, all of which must match
+//	"^2.3.0", "~2.3.0"       caret / tilde ranges
+//	"2.0.0 - 2.4.0"          inclusive hyphen range
+//	"^1.9.0 || ^2.0.0"       alternatives
+//
+// Exclusive upper bounds exclude pre-releases of the bound itself, so "<3.0.0" and "^2.0.0"
+// do not match "3.0.0-beta". A full version may carry a pre-release ("2.0.0-rc.1").
+func parseVersionRange(expr string) (versionRange, error) {
+	var r versionRange
+	for _, alt := range strings.Split(expr, "||") {
+		set, err := parseComparatorSet(strings.TrimSpace(alt))
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidVersionRange, expr, err)
+		}
+		r = append(r, set)
+	}
+	return r, nil
+}
+
+// contains reports whether a version satisfies the range.
+func (r versionRange) contains(version string) bool {
+	v := parseVersion(version)
+	for _, set := range r {
+		matched := true
+		for _, c := range set {
+			if !c.matches(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (c versionComparator) matches(v semVersion) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case ">=":
+		return cmp >= 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case "<":
+		return cmp < 0
+	default:
+		return cmp == 0
+	}
+}
+
+func parseComparatorSet(expr string) ([]versionComparator, error) {
+	fields := strings.Fields(expr)
+
+	// Hyphen range: "1.2.3 - 2.3.4"
+	if len(fields) == 3 && fields[1] == "-" {
+		lower, n, err := parsePartialVersion(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		upper, m, err := parsePartialVersion(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		set := []versionComparator{}
+		if n > 0 {
+			set = append(set, versionComparator{">=", lower})
+		}
+		if m == 3 {
+			set = append(set, versionComparator{"<=", upper})
+		} else if m > 0 {
+			set = append(set, versionComparator{"<", bumpVersion(upper, m)})
+		}
+		return set, nil
+	}
+
+	set := []versionComparator{}
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		// Allow whitespace between an operator and its version (">= 2.0.0")
+		if strings.Trim(field, "<>=^~") == "" && i+1 < len(fields) {
+			i++
+			field += fields[i]
+		}
+		comparators, err := parseComparator(field)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, comparators...)
+	}
+	return set, nil
+}
+
+// parseComparator desugars a single range token into plain comparators.
+func parseComparator(token string) ([]versionComparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(token, prefix) {
+			op = prefix
+			token = strings.TrimSpace(token[len(prefix):])
+			break
+		}
+	}
+
+	v, n, err := parsePartialVersion(token)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		// Wildcard: any version (a "<*" or ">*" bound is meaningless and matches nothing)
+		if op == "<" || op == ">" {
+			return []versionComparator{{"<", minVersion}}, nil
+		}
+		return nil, nil
+	}
+
+	lower := v
+	switch op {
+	case ">=":
+		return []versionComparator{{">=", lower}}, nil
+	case "<":
+		return []versionComparator{{"<", exclusiveBound(lower)}}, nil
+	case ">":
+		if n < 3 {
+			return []versionComparator{{">=", bumpVersion(v, n)}}, nil
+		}
+		return []versionComparator{{">", lower}}, nil
+	case "<=":
+		if n < 3 {
+			return []versionComparator{{"<", bumpVersion(v, n)}}, nil
+		}
+		return []versionComparator{{"<=", lower}}, nil
+	case "^":
+		// Allow changes that do not modify the left-most non-zero component
+		upper := bumpVersion(v, 1)
+		if v.major == 0 && n >= 2 {
+			upper = bumpVersion(v, 2)
+			if v.minor == 0 && n == 3 {
+				upper = bumpVersion(v, 3)
+			}
+		}
+		return []versionComparator{{">=", lower}, {"<", upper}}, nil
+	case "~":
+		// Allow patch-level changes when a minor version is given, minor-level otherwise
+		upper := bumpVersion(v, 1)
+		if n >= 2 {
+			upper = bumpVersion(v, 2)
+		}
+		return []versionComparator{{">=", lower}, {"<", upper}}, nil
+	default:
+		if n == 3 {
+			return []versionComparator{{"=", lower}}, nil
+		}
+		return []versionComparator{{">=", lower}, {"<", bumpVersion(v, n)}}, nil
+	}
+}
+
+// minVersion is the lowest possible version, 0.0.0-0.
+var minVersion = semVersion{prerelease: []string{"0"}}
+
+// parsePartialVersion parses "1", "1.2", "1.2.3", "1.2.3-rc.1", "1.x" or "*" and returns the
+// version padded with zeros and how many numeric components were given (wildcards stop parsing).
+// Pre-release identifiers are only allowed on a full major.minor.patch version.
+func parsePartialVersion(s string) (semVersion, int, error) {
+	if len(s) > 0 && (s[0] == 'v' || s[0] == 'V') {
+		s = s[1:]
+	}
+	if s == "" {
+		return semVersion{}, 0, fmt.Errorf("missing version")
+	}
+
+	if strings.ContainsAny(s, "-+") {
+		v, err := parseSemver(s)
+		if err != nil {
+			return semVersion{}, 0, err
+		}
+		return v, 3, nil
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return semVersion{}, 0, fmt.Errorf("too many version components in %q", s)
+	}
+
+	var v semVersion
+	components := []*uint64{&v.major, &v.minor, &v.patch}
+	n := 0
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			break
+		}
+		num, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return semVersion{}, 0, fmt.Errorf("invalid version component %q", p)
+		}
+		*components[i] = num
+		n++
+	}
+	return v, n, nil
+}
+
+// exclusiveBound turns a release version into the lowest version of its pre-release line
+// (2.0.0 -> 2.0.0-0) so "<2.0.0" also excludes 2.0.0 pre-releases.
+func exclusiveBound(v semVersion) semVersion {
+	if len(v.prerelease) > 0 {
+		return v
+	}
+	v.prerelease = []string{"0"}
+	return v
+}
+
+// bumpVersion increments the component at position n-1 and zeroes everything after it,
+// producing the exclusive upper bound of a partial version (e.g. 1.2 -> 1.3.0-0).
+func bumpVersion(v semVersion, n int) semVersion {
+	bumped := semVersion{major: v.major}
+	switch n {
+	case 1:
+		bumped.major++
+	case 2:
+		bumped.minor = v.minor + 1
+	default:
+		bumped.minor = v.minor
+		bumped.patch = v.patch + 1
+	}
+	return exclusiveBound(bumped)
+}
+
+// validateNativeVersionRange checks that a release's target native version range is well formed.
+func validateNativeVersionRange(expr string) error {
+	_, err := parseVersionRange(expr)
+	return err
+}
+
+// isNativeCompatible reports whether a release targeting the given range can run on a native build.
+// Releases without a target run everywhere; targeted releases are never offered to a binary
+// that does not report its native version.
+func isNativeCompatible(targetRange, nativeVersion string) bool {
+	if strings.TrimSpace(targetRange) == "" {
+		return true
+	}
+	if nativeVersion == "" {
+		return false
+	}
+	r, err := parseVersionRange(targetRange)
+	if err != nil {
+		return false
+	}
+	return r.contains(nativeVersion)
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hotpatch/server/internal/models"
+)
+
+// ── parseVersionRange Tests ─────────────────────────────────
+
+func TestVersionRangeContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		version  string
+		expected bool
+	}{
+		// Wildcards
+		{"empty matches all", "", "9.9.9", true},
+		{"star matches all", "*", "1.0.0", true},
+		{"x matches all", "x", "0.0.1", true},
+
+		// Exact and partial versions
+		{"exact match", "2.3.1", "2.3.1", true},
+		{"exact mismatch", "2.3.1", "2.3.2", false},
+		{"equals operator", "=2.3.1", "2.3.1", true},
+		{"major x-range", "2.x", "2.9.0", true},
+		{"major x-range excludes next major", "2.x", "3.0.0", false},
+		{"minor star-range", "2.3.*", "2.3.7", true},
+		{"minor star-range excludes next minor", "2.3.*", "2.4.0", false},
+		{"partial version as range", "2.3", "2.3.9", true},
+
+		// Comparators
+		{"gte lower bound", ">=2.3.0", "2.3.0", true},
+		{"gte below", ">=2.3.0", "2.2.9", false},
+		{"gt excludes bound", ">2.3.0", "2.3.0", false},
+		{"gt partial", ">2.3", "2.3.9", false},
+		{"gt partial next minor", ">2.3", "2.4.0", true},
+		{"lte partial", "<=2.3", "2.3.9", true},
+		{"lt excludes bound", "<3.0.0", "3.0.0", false},
+		{"lt excludes pre-release of bound", "<3.0.0", "3.0.0-beta.1", false},
+		{"and-ed comparators", ">=2.3.0 <3.0.0", "2.9.9", true},
+		{"and-ed comparators upper", ">=2.3.0 <3.0.0", "3.0.0", false},
+		{"operator followed by space", ">= 2.3.0 < 3.0.0", "2.5.0", true},
+
+		// Caret and tilde
+		{"caret same major", "^2.3.0", "2.9.0", true},
+		{"caret next major", "^2.3.0", "3.0.0", false},
+		{"caret excludes next major pre-release", "^2.3.0", "3.0.0-rc.1", false},
+		{"caret lower bound excludes its pre-release", "^2.3.0", "2.3.0-rc.1", false},
+		{"pre-release lower bound", ">=2.3.0-rc.1", "2.3.0-rc.2", true},
+		{"exact pre-release", "2.3.0-rc.1", "2.3.0-rc.1", true},
+		{"caret below", "^2.3.0", "2.2.0", false},
+		{"caret zero major", "^0.2.3", "0.2.9", true},
+		{"caret zero major next minor", "^0.2.3", "0.3.0", false},
+		{"caret zero minor", "^0.0.3", "0.0.4", false},
+		{"tilde same minor", "~2.3.1", "2.3.9", true},
+		{"tilde next minor", "~2.3.1", "2.4.0", false},
+		{"tilde major only", "~2", "2.9.0", true},
+
+		// Hyphen ranges
+		{"hyphen inclusive upper", "1.9.0 - 2.4.0", "2.4.0", true},
+		{"hyphen above", "1.9.0 - 2.4.0", "2.4.1", false},
+		{"hyphen partial upper", "1.9.0 - 2.4", "2.4.7", true},
+
+		// Alternatives
+		{"or first", "^1.9.0 || ^3.0.0", "1.9.5", true},
+		{"or second", "^1.9.0 || ^3.0.0", "3.1.0", true},
+		{"or neither", "^1.9.0 || ^3.0.0", "2.0.0", false},
+
+		// Prefixes
+		{"v prefix in range", "^v2.0.0", "2.1.0", true},
+		{"v prefix in version", "^2.0.0", "v2.1.0", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := parseVersionRange(tc.expr)
+			if err != nil {
+				t.Fatalf("parseVersionRange(%q) failed: %v", tc.expr, err)
+			}
+			if got := r.contains(tc.version); got != tc.expected {
+				t.Errorf("range %q contains %q = %v, want %v", tc.expr, tc.version, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseVersionRange_Invalid(t *testing.T) {
+	invalid := []string{
+		">=",
+		"abc",
+		"1.2.3.4",
+		">=1.two.0",
+		"^1.2.3 || >=",
+		"-1.0.0",
+		"2.3-rc.1",
+	}
+
+	for _, expr := range invalid {
+		t.Run(expr, func(t *testing.T) {
+			_, err := parseVersionRange(expr)
+			if err == nil {
+				t.Fatalf("parseVersionRange(%q) succeeded, want error", expr)
+			}
+			if !errors.Is(err, ErrInvalidVersionRange) {
+				t.Errorf("error %v does not wrap ErrInvalidVersionRange", err)
+			}
+		})
+	}
+}
+
+// ── isNativeCompatible Tests ────────────────────────────────
+
+func TestIsNativeCompatible(t *testing.T) {
+	tests := []struct {
+		name          string
+		target        string
+		nativeVersion string
+		expected      bool
+	}{
+		{"untargeted release, known binary", "", "2.0.0", true},
+		{"untargeted release, unknown binary", "", "", true},
+		{"targeted release, unknown binary", "^2.0.0", "", false},
+		{"targeted release, compatible binary", "^2.0.0", "2.1.0", true},
+		{"targeted release, old binary", "^2.0.0", "1.9.0", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isNativeCompatible(tc.target, tc.nativeVersion); got != tc.expected {
+				t.Errorf("isNativeCompatible(%q, %q) = %v, want %v", tc.target, tc.nativeVersion, got, tc.expected)
+			}
+		})
+	}
+}
+
+// ── selectCompatibleRelease Tests ───────────────────────────
+
+func TestSelectCompatibleRelease(t *testing.T) {
+	releases := []models.Release{
+		{Version: "3.1.0", TargetNativeVersion: "^3.0.0"},
+		{Version: "2.4.0", TargetNativeVersion: ">=2.0.0 <3.0.0"},
+		{Version: "1.0.0", TargetNativeVersion: ""},
+	}
+
+	tests := []struct {
+		nativeVersion string
+		expected      string
+	}{
+		{"3.2.0", "3.1.0"},
+		{"2.5.0", "2.4.0"},
+		{"1.0.0", "1.0.0"},
+		{"", "1.0.0"},
+	}
+
+	for _, tc := range tests {
+		t.Run("native "+tc.nativeVersion, func(t *testing.T) {
+			got := selectCompatibleRelease(releases, &models.UpdateCheckRequest{NativeVersion: tc.nativeVersion})
+			if got == nil {
+				t.Fatalf("expected release %s, got none", tc.expected)
+			}
+			if got.Version != tc.expected {
+				t.Errorf("selected %s, want %s", got.Version, tc.expected)
+			}
+		})
+	}
+
+	t.Run("no compatible release", func(t *testing.T) {
+		targeted := releases[:2]
+		if got := selectCompatibleRelease(targeted, &models.UpdateCheckRequest{NativeVersion: "1.0.0"}); got != nil {
+			t.Errorf("expected no release, got %s", got.Version)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrOverrideNotFound is returned when a device has no release override.
+	ErrOverrideNotFound = errors.New("device override not found")
+	// ErrInvalidOverride is returned when an override request is malformed.
+	ErrInvalidOverride = errors.New("invalid device override")
+)
+
+// overrideBatchSize caps how many expired overrides a single scheduler tick removes.
+const overrideBatchSize = 500
+
+// deviceOverridesCacheKey is the Redis key under which the active overrides of an app are cached.
+// Apps pin a handful of QA and support devices, so one entry per app keeps the update check to a single read.
+func deviceOverridesCacheKey(appID uuid.UUID) string {
+	return fmt.Sprintf("overrides:active:%s", appID)
+}
+
+// OverrideService manages per-device release pins set by support engineers and QA.
+type OverrideService struct {
+	repo            *repository.OverrideRepository
+	releaseRepo     *repository.ReleaseRepository
+	securityService *SecurityService
+	redis           *redis.Client
+}
+
+// NewOverrideService creates a new OverrideService.
+func NewOverrideService(repo *repository.OverrideRepository, releaseRepo *repository.ReleaseRepository, securityService *SecurityService, redis *redis.Client) *OverrideService {
+	return &OverrideService{repo: repo, releaseRepo: releaseRepo, securityService: securityService, redis: redis}
+}
+
+func (s *OverrideService) invalidateCache(ctx context.Context, appID uuid.UUID) {
+	if s.redis != nil {
+		s.redis.Del(ctx, deviceOverridesCacheKey(appID))
+	}
+}
+
+// Set pins a device to a release of the app, replacing any existing pin of that device.
+func (s *OverrideService) Set(ctx context.Context, appID uuid.UUID, deviceID string, req *models.SetDeviceOverrideRequest, actor, ip string) (*models.DeviceOverride, error) {
+	deviceID = strings.TrimSpace(deviceID)
+	if deviceID == "" {
+		return nil, fmt.Errorf("%w: device ID is required", ErrInvalidOverride)
+	}
+	releaseID, err := uuid.Parse(req.ReleaseID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid release ID", ErrInvalidOverride)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidOverride)
+	}
+
+	release, err := s.releaseRepo.GetByID(appID, releaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReleaseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load release: %w", err)
+	}
+
+	override := &models.DeviceOverride{
+		ID:        uuid.New(),
+		AppID:     appID,
+		DeviceID:  deviceID,
+		ReleaseID: release.ID,
+		Reason:    req.Reason,
+		CreatedBy: actor,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.repo.Upsert(override); err != nil {
+		return nil, fmt.Errorf("failed to save device override: %w", err)
+	}
+	s.invalidateCache(ctx, appID)
+
+	expiry := "never"
+	if req.ExpiresAt != nil {
+		expiry = req.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	s.securityService.Log(appID, actor, "device.pin", deviceID, fmt.Sprintf("Pinned to %s (%s), expires: %s, reason: %s", release.Version, release.Channel, expiry, req.Reason), ip)
+
+	// Re-read so a replaced pin reports its original ID and creation time
+	return s.Get(appID, deviceID)
+}
+
+// Get returns the override of a device, including one that has expired but not yet been removed.
+func (s *OverrideService) Get(appID uuid.UUID, deviceID string) (*models.DeviceOverride, error) {
+	override, err := s.repo.Get(appID, deviceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOverrideNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load device override: %w", err)
+	}
+	return override, nil
+}
+
+// List returns every unexpired override of the app.
+func (s *OverrideService) List(appID uuid.UUID) ([]models.DeviceOverride, error) {
+	return s.repo.ListActive(appID, time.Now())
+}
+
+// Delete unpins a device, returning it to its channel's normal release selection.
+func (s *OverrideService) Delete(ctx context.Context, appID uuid.UUID, deviceID, actor, ip string) error {
+	deleted, err := s.repo.Delete(appID, deviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete device override: %w", err)
+	}
+	if !deleted {
+		return ErrOverrideNotFound
+	}
+	s.invalidateCache(ctx, appID)
+
+	s.securityService.Log(appID, actor, "device.unpin", deviceID, "", ip)
+	return nil
+}
+
+// PruneExpired removes overrides whose expiry has passed. Expired pins are already ignored by
+// the update check; this keeps the table and the audit trail tidy. Meant to run on the scheduler.
+func (s *OverrideService) PruneExpired(ctx context.Context, now time.Time) error {
+	expired, err := s.repo.ListExpired(now, overrideBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to load expired device overrides: %w", err)
+	}
+
+	for _, override := range expired {
+		deleted, err := s.repo.DeleteIfExpired(override.ID, now)
+		if err != nil {
+			log.Printf("overrides: failed to remove expired override %s: %v", override.ID, err)
+			continue
+		}
+		if !deleted {
+			continue
+		}
+		s.invalidateCache(ctx, override.AppID)
+		s.securityService.Log(override.AppID, "system", "device.unpin", override.DeviceID, "Override expired", "")
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/config"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+	"github.com/hotpatch/server/internal/storage"
+)
+
+// newOverrideFixture wires an OverrideService and an UpdateService that serves CDN URLs over two tenants.
+func newOverrideFixture(t *testing.T) (*OverrideService, *UpdateService, tenants) {
+	t.Helper()
+	f := newTenants(t, &models.Patch{}, &models.Device{}, &models.DeviceOverride{}, &models.AuditLog{})
+
+	store, err := storage.NewS3Storage(&config.Config{S3Region: "us-east-1", CDNBaseURL: "https://cdn.example.com"})
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+
+	releaseRepo := repository.NewReleaseRepository(f.db)
+	overrideRepo := repository.NewOverrideRepository(f.db)
+	securityService := NewSecurityService(repository.NewSecurityRepository(f.db))
+	overrides := NewOverrideService(overrideRepo, releaseRepo, securityService, nil)
+	updates := NewUpdateService(releaseRepo, repository.NewDeviceRepository(f.db), overrideRepo, store, nil)
+	return overrides, updates, f
+}
+
+func pinRequest(releaseID uuid.UUID, expiresAt *time.Time) *models.SetDeviceOverrideRequest {
+	return &models.SetDeviceOverrideRequest{ReleaseID: releaseID.String(), Reason: "QA", ExpiresAt: expiresAt}
+}
+
+func TestOverride_PinnedDeviceGetsReleaseOutsideRollout(t *testing.T) {
+	overrides, updates, f := newOverrideFixture(t)
+	ctx := context.Background()
+
+	// An older, inactive release in another channel
+	pinned := models.Release{ID: uuid.New(), AppID: f.appA, Version: "0.9.0", Channel: "staging", BundleKey: "bundles/old.zip", Hash: "old"}
+	if err := f.db.Create(&pinned).Error; err != nil {
+		t.Fatalf("failed to create release: %v", err)
+	}
+	if _, err := overrides.Set(ctx, f.appA, "qa-phone", pinRequest(pinned.ID, nil), "support@example.com", ""); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	req := &models.UpdateCheckRequest{AppID: f.appA.String(), DeviceID: "qa-phone", Version: "1.0.0", Platform: "android", Channel: "production"}
+	resp, err := updates.CheckForUpdate(ctx, req)
+	if err != nil {
+		t.Fatalf("CheckForUpdate failed: %v", err)
+	}
+	if !resp.UpdateAvailable || resp.ID != pinned.ID.String() || resp.BundleURL != "https://cdn.example.com/bundles/old.zip" {
+		t.Errorf("pinned device got %+v, want release %s", resp, pinned.ID)
+	}
+
+	// Once on the pinned version there is nothing more to install
+	req.Version = "0.9.0"
+	resp, err = updates.CheckForUpdate(ctx, req)
+	if err != nil {
+		t.Fatalf("CheckForUpdate failed: %v", err)
+	}
+	if resp.UpdateAvailable {
+		t.Errorf("device on its pinned version got an update: %+v", resp)
+	}
+
+	// Unpinning returns the device to the channel
+	if err := overrides.Delete(ctx, f.appA, "qa-phone", "support@example.com", ""); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := overrides.Delete(ctx, f.appA, "qa-phone", "support@example.com", ""); !errors.Is(err, ErrOverrideNotFound) {
+		t.Errorf("second Delete: err = %v, want ErrOverrideNotFound", err)
+	}
+	if override := updates.activeOverride(ctx, f.appA, "qa-phone", time.Now()); override != nil {
+		t.Errorf("unpinned device still has override %+v", override)
+	}
+}
+
+func TestOverride_ExpiredPinIsIgnoredAndPruned(t *testing.T) {
+	overrides, updates, f := newOverrideFixture(t)
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(time.Hour)
+	if _, err := overrides.Set(ctx, f.appA, "support-device", pinRequest(f.releaseA, &expiresAt), "support@example.com", ""); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if override := updates.activeOverride(ctx, f.appA, "support-device", time.Now()); override == nil {
+		t.Fatal("pin not applied before expiry")
+	}
+
+	later := expiresAt.Add(time.Minute)
+	if override := updates.activeOverride(ctx, f.appA, "support-device", later); override != nil {
+		t.Errorf("expired pin still applied: %+v", override)
+	}
+
+	if err := overrides.PruneExpired(ctx, later); err != nil {
+		t.Fatalf("PruneExpired failed: %v", err)
+	}
+	if _, err := overrides.Get(f.appA, "support-device"); !errors.Is(err, ErrOverrideNotFound) {
+		t.Errorf("Get after prune: err = %v, want ErrOverrideNotFound", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	if _, err := overrides.Set(ctx, f.appA, "support-device", pinRequest(f.releaseA, &past), "support@example.com", ""); !errors.Is(err, ErrInvalidOverride) {
+		t.Errorf("Set with past expiry: err = %v, want ErrInvalidOverride", err)
+	}
+}
+
+func TestOverride_RepinReplacesExistingPin(t *testing.T) {
+	overrides, _, f := newOverrideFixture(t)
+	ctx := context.Background()
+
+	other := models.Release{ID: uuid.New(), AppID: f.appA, Version: "1.1.0", Channel: "production", Hash: "c"}
+	if err := f.db.Create(&other).Error; err != nil {
+		t.Fatalf("failed to create release: %v", err)
+	}
+
+	first, err := overrides.Set(ctx, f.appA, "qa-phone", pinRequest(f.releaseA, nil), "alice", "")
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	second, err := overrides.Set(ctx, f.appA, "qa-phone", pinRequest(other.ID, nil), "bob", "")
+	if err != nil {
+		t.Fatalf("second Set failed: %v", err)
+	}
+	if second.ID != first.ID || second.ReleaseID != other.ID || second.CreatedBy != "bob" {
+		t.Errorf("re-pin = (%s, %s, %s), want (%s, %s, bob)", second.ID, second.ReleaseID, second.CreatedBy, first.ID, other.ID)
+	}
+
+	list, err := overrides.List(f.appA)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Errorf("List returned %d overrides, want 1", len(list))
+	}
+}
+
+func TestOverride_ScopedToApp(t *testing.T) {
+	overrides, updates, f := newOverrideFixture(t)
+	ctx := context.Background()
+
+	if _, err := overrides.Set(ctx, f.appA, "qa-phone", pinRequest(f.releaseB, nil), "alice", ""); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("pinning to another app's release: err = %v, want ErrReleaseNotFound", err)
+	}
+	if _, err := overrides.Set(ctx, f.appB, "qa-phone", pinRequest(f.releaseB, nil), "alice", ""); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := overrides.Get(f.appA, "qa-phone"); !errors.Is(err, ErrOverrideNotFound) {
+		t.Errorf("Get of another app's override: err = %v, want ErrOverrideNotFound", err)
+	}
+	if err := overrides.Delete(ctx, f.appA, "qa-phone", "alice", ""); !errors.Is(err, ErrOverrideNotFound) {
+		t.Errorf("Delete of another app's override: err = %v, want ErrOverrideNotFound", err)
+	}
+	if override := updates.activeOverride(ctx, f.appA, "qa-phone", time.Now()); override != nil {
+		t.Errorf("app B's pin applied to app A: %+v", override)
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrPatchGenerationUnavailable is returned when patches cannot be generated for a release: generation is
+// disabled, the app is on the free tier, or the release is encrypted.
+var ErrPatchGenerationUnavailable = errors.New("patch generation unavailable")
+
+// PatchGeneration configures automatic patch generation.
+type PatchGeneration struct {
+	Bases          int // most recent releases of the channel a new release is diffed against; 0 disables generation
+	MaxSizePercent int // generated patches larger than this percentage of the full bundle are not kept
+}
+
+const (
+	// patchJobBatchSize caps how many patches a single scheduler tick generates.
+	patchJobBatchSize = 4
+	// patchJobTimeout is how long a job may run before another server instance takes it over.
+	patchJobTimeout = 30 * time.Minute
+	// maxPatchJobAttempts is how often a job is tried before it is marked failed.
+	maxPatchJobAttempts = 3
+	// maxDiffInputSize caps the bundles diffed; bsdiff needs several times the bundle size in memory.
+	maxDiffInputSize = 64 << 20
+)
+
+// patchBaseStatuses are the statuses of releases devices may be running, and so want patches from.
+var patchBaseStatuses = []string{models.ReleaseRollingOut, models.ReleaseCompleted, models.ReleasePaused, models.ReleaseHalted, models.ReleaseSuperseded}
+
+// patchSkipped reports why no patch is stored for a job. It is not an error worth retrying.
+type patchSkipped struct {
+	reason string
+}
+
+func (p *patchSkipped) Error() string { return p.reason }
+
+func skipPatch(format string, args ...interface{}) error {
+	return &patchSkipped{reason: fmt.Sprintf(format, args...)}
+}
+
+// patchGenerationUnavailable returns why patches cannot be generated for a release of an app on the given
+// tier, or nil when they can. Encrypted bundles are left alone: devices patch the bundle they decrypted.
+func (s *ReleaseService) patchGenerationUnavailable(release *models.Release, tier string) error {
+	switch {
+	case s.patchGeneration.Bases <= 0:
+		return fmt.Errorf("%w: automatic patch generation is disabled", ErrPatchGenerationUnavailable)
+	case tier == "free":
+		return fmt.Errorf("%w: differential patching is a Pro feature. Current tier: %s", ErrPatchGenerationUnavailable, tier)
+	case release.IsEncrypted:
+		return fmt.Errorf("%w: encrypted releases are not patched by the server", ErrPatchGenerationUnavailable)
+	case release.IsPatch:
+		return fmt.Errorf("%w: the release bundle is itself a patch", ErrPatchGenerationUnavailable)
+	}
+	return nil
+}
+
+// queuePatchJobs queues generation of patches to the release from the most recent lower versions of its
+// channel that devices may be running, skipping bases the release already has a patch or an open job for.
+// Returns the jobs queued.
+func (s *ReleaseService) queuePatchJobs(release *models.Release, patches []models.Patch) ([]models.PatchJob, error) {
+	bases, err := s.repo.ListPatchBases(release.AppID, release.Channel, release.TargetNativeVersion, patchBaseStatuses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load patch bases: %w", err)
+	}
+	existing, err := s.repo.ListPatchJobs(release.AppID, release.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load patch jobs: %w", err)
+	}
+
+	queued := make(map[uuid.UUID]bool)
+	for _, job := range existing {
+		if job.Status != models.PatchJobSkipped && job.Status != models.PatchJobFailed {
+			queued[job.BaseReleaseID] = true
+		}
+	}
+
+	jobs := []models.PatchJob{}
+	considered := 0
+	for i := range bases {
+		base := &bases[i]
+		if considered == s.patchGeneration.Bases {
+			break
+		}
+		if base.ID == release.ID || base.IsEncrypted || base.IsPatch || compareVersions(base.Version, release.Version) >= 0 {
+			continue
+		}
+		considered++
+		if queued[base.ID] || hasPatchFrom(patches, base) {
+			continue
+		}
+		jobs = append(jobs, models.PatchJob{
+			ID:            uuid.New(),
+			AppID:         release.AppID,
+			ReleaseID:     release.ID,
+			BaseReleaseID: base.ID,
+			BaseVersion:   base.Version,
+			Status:        models.PatchJobQueued,
+		})
+	}
+	if err := s.repo.CreatePatchJobs(jobs); err != nil {
+		return nil, fmt.Errorf("failed to queue patch jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// hasPatchFrom reports whether one of patches applies to the base release's bundle. Patches without a base
+// hash are matched on the base version.
+func hasPatchFrom(patches []models.Patch, base *models.Release) bool {
+	for _, p := range patches {
+		if p.BaseHash != "" && strings.EqualFold(p.BaseHash, base.Hash) || p.BaseHash == "" && p.BaseVersion == base.Version {
+			return true
+		}
+	}
+	return false
+}
+
+// queuePatchJobsFor queues patch generation for a newly stored release when it is eligible. Failures are
+// logged: the release works without patches, devices just download the full bundle.
+func (s *ReleaseService) queuePatchJobsFor(release *models.Release, patches []models.Patch, tier string) {
+	if s.patchGenerationUnavailable(release, tier) != nil {
+		return
+	}
+	if _, err := s.queuePatchJobs(release, patches); err != nil {
+		log.Printf("patches: failed to queue patch generation for release %s: %v", release.ID, err)
+	}
+}
+
+// GeneratePatches queues generation of the patches a release is missing, e.g. for a release uploaded before
+// generation was enabled or whose earlier jobs failed. Returns the jobs queued, which may be none.
+func (s *ReleaseService) GeneratePatches(appID, releaseID uuid.UUID) ([]models.PatchJob, error) {
+	release, err := s.repo.GetWithPatches(appID, releaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReleaseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load release: %w", err)
+	}
+	app, err := s.settingsService.GetApp(appID)
+	if err != nil {
+		return nil, fmt.Errorf("app not found: %w", err)
+	}
+	if err := s.patchGenerationUnavailable(release, app.Tier); err != nil {
+		return nil, err
+	}
+	return s.queuePatchJobs(release, release.Patches)
+}
+
+// PatchJobs returns the patch generation jobs of a release, oldest first.
+func (s *ReleaseService) PatchJobs(appID, releaseID uuid.UUID) ([]models.PatchJob, error) {
+	if _, err := s.getRelease(appID, releaseID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPatchJobs(appID, releaseID)
+}
+
+// GenerateDuePatches runs queued patch generation jobs, and takes over jobs left running by a server that
+// stopped. A job that errors is retried on later ticks until it has been tried maxPatchJobAttempts times.
+// Meant to run on the scheduler.
+func (s *ReleaseService) GenerateDuePatches(ctx context.Context, now time.Time) error {
+	jobs, err := s.repo.ListClaimablePatchJobs(now.Add(-patchJobTimeout), patchJobBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to load patch jobs: %w", err)
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Attempts >= maxPatchJobAttempts {
+			// Its last attempt never finished
+			finished := time.Now()
+			job.Status, job.Reason, job.FinishedAt = models.PatchJobFailed, "timed out", &finished
+			if err := s.repo.FinishPatchJob(job); err != nil {
+				log.Printf("patches: failed to save patch job %s: %v", job.ID, err)
+			}
+			continue
+		}
+		claimed, err := s.repo.ClaimPatchJob(job, now)
+		if err != nil {
+			log.Printf("patches: failed to claim patch job %s: %v", job.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		err = s.generatePatch(ctx, job)
+		var skipped *patchSkipped
+		switch {
+		case err == nil:
+			job.Status = models.PatchJobCompleted
+			job.Reason = ""
+		case errors.As(err, &skipped):
+			job.Status = models.PatchJobSkipped
+			job.Reason = skipped.reason
+		case job.Attempts < maxPatchJobAttempts:
+			job.Status = models.PatchJobQueued
+			job.Reason = err.Error()
+		default:
+			job.Status = models.PatchJobFailed
+			job.Reason = err.Error()
+		}
+		if job.Status != models.PatchJobQueued {
+			finished := time.Now()
+			job.FinishedAt = &finished
+		}
+		if len(job.Reason) > 500 {
+			job.Reason = job.Reason[:500]
+		}
+		if err := s.repo.FinishPatchJob(job); err != nil {
+			log.Printf("patches: failed to save patch job %s: %v", job.ID, err)
+		}
+	}
+	return nil
+}
+
+// generatePatch diffs the job's base release bundle against its release bundle and stores the patch, unless
+// it is no smaller than the configured share of the full bundle. The patch is applied once before it is
+// stored, so a patch that does not reproduce the release bundle byte for byte never reaches a device.
+func (s *ReleaseService) generatePatch(ctx context.Context, job *models.PatchJob) error {
+	release, err := s.repo.GetWithPatches(job.AppID, job.ReleaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return skipPatch("the release no longer exists")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load release: %w", err)
+	}
+	base, err := s.getRelease(job.AppID, job.BaseReleaseID)
+	if errors.Is(err, ErrReleaseNotFound) {
+		return skipPatch("the base release no longer exists")
+	}
+	if err != nil {
+		return err
+	}
+	if release.IsEncrypted || base.IsEncrypted || release.IsPatch || base.IsPatch {
+		return skipPatch("encrypted releases and patch releases are not patched by the server")
+	}
+	if hasPatchFrom(release.Patches, base) {
+		return skipPatch("the release already has a patch from version %s", base.Version)
+	}
+
+	newData, err := s.readBundleForDiff(ctx, release)
+	if err != nil {
+		return err
+	}
+	oldData, err := s.readBundleForDiff(ctx, base)
+	if err != nil {
+		return err
+	}
+
+	patchData := bsdiff(oldData, newData)
+	job.BundleSize = int64(len(newData))
+	job.PatchSize = int64(len(patchData))
+	if job.PatchSize*100 > job.BundleSize*int64(s.patchGeneration.MaxSizePercent) {
+		return skipPatch("the patch would be %d%% of the full bundle, above the limit of %d%%", job.PatchSize*100/max(job.BundleSize, 1), s.patchGeneration.MaxSizePercent)
+	}
+	patched, err := bspatch(oldData, patchData, job.BundleSize)
+	if err != nil || !bytes.Equal(patched, newData) {
+		return fmt.Errorf("generated patch does not reproduce the release bundle: %v", err)
+	}
+
+	patchID := uuid.New()
+	objectKey := fmt.Sprintf("patches/%s/%s/from-%s-%s.patch", release.AppID, release.ID, base.Version, patchID)
+	if _, err := s.storage.Upload(ctx, objectKey, bytes.NewReader(patchData), "application/octet-stream"); err != nil {
+		return fmt.Errorf("failed to upload patch: %w", err)
+	}
+	sum := sha256.Sum256(patchData)
+	patch := &models.Patch{
+		ID:          patchID,
+		ReleaseID:   release.ID,
+		BaseVersion: base.Version,
+		BaseHash:    strings.ToLower(base.Hash),
+		PatchKey:    objectKey,
+		Hash:        hex.EncodeToString(sum[:]),
+		Generated:   true,
+		Verified:    true,
+		Size:        job.PatchSize,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.repo.CreatePatch(patch); err != nil {
+		s.discardObject(ctx, objectKey)
+		return fmt.Errorf("failed to save patch record: %w", err)
+	}
+	job.PatchID = &patch.ID
+
+	s.securityService.Log(release.AppID, "system", "release.generate_patch", patch.ID.String(), fmt.Sprintf("For Version: %s, Base Version: %s, Size: %d of %d bytes", release.Version, base.Version, job.PatchSize, job.BundleSize), "")
+	s.invalidateCache(ctx, release.AppID, release.Channel)
+	return nil
+}
+
+// readBundleForDiff reads a release's stored bundle and checks it is still the bundle the release was
+// uploaded with, so patches are made from exactly the bytes devices installed.
+func (s *ReleaseService) readBundleForDiff(ctx context.Context, release *models.Release) ([]byte, error) {
+	data, err := s.readStoredBundle(ctx, release, maxDiffInputSize)
+	if errors.Is(err, errStoredBundleTooLarge) || errors.Is(err, errStoredBundleChanged) {
+		return nil, skipPatch("%v", err)
+	}
+	return data, err
+}
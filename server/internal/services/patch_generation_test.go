@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+)
+
+// ── Patch Generation Tests ──────────────────────────────────
+
+// patchGenerationTest holds app A's release service generating patches from up to three bases.
+type patchGenerationTest struct {
+	tenants
+	releaseService *ReleaseService
+}
+
+func newPatchGenerationTest(t *testing.T) *patchGenerationTest {
+	t.Helper()
+	tn := newTenants(t, &models.Channel{}, &models.Patch{}, &models.PatchJob{})
+	releaseService, _ := newReleaseService(tn, PatchGeneration{Bases: 3, MaxSizePercent: 50})
+	return &patchGenerationTest{tenants: tn, releaseService: releaseService}
+}
+
+func jobBases(jobs []models.PatchJob) []string {
+	var bases []string
+	for _, job := range jobs {
+		bases = append(bases, job.BaseVersion)
+	}
+	return bases
+}
+
+func TestQueuePatchJobs_PicksRecentLiveBases(t *testing.T) {
+	f := newPatchGenerationTest(t)
+	channelRelease(t, f.tenants, "2.1.0", models.ReleaseCompleted, time.Hour) // newer than the release
+	channelRelease(t, f.tenants, "1.3.0", models.ReleaseDraft, 2*time.Hour)   // never on devices
+	encrypted := channelRelease(t, f.tenants, "1.2.5", models.ReleaseCompleted, 3*time.Hour)
+	if err := f.db.Model(&encrypted).Update("is_encrypted", true).Error; err != nil {
+		t.Fatalf("failed to encrypt release: %v", err)
+	}
+	channelRelease(t, f.tenants, "1.2.0", models.ReleaseSuperseded, 4*time.Hour) // already patched below
+	channelRelease(t, f.tenants, "1.1.0", models.ReleaseSuperseded, 5*time.Hour)
+	channelRelease(t, f.tenants, "0.9.0", models.ReleaseRolledBack, 6*time.Hour)
+	channelRelease(t, f.tenants, "0.8.0", models.ReleaseSuperseded, 7*time.Hour) // beyond the three most recent
+	release := channelRelease(t, f.tenants, "2.0.0", models.ReleaseDraft, 0)
+
+	jobs, err := f.releaseService.queuePatchJobs(&release, []models.Patch{{BaseVersion: "1.2.0"}})
+	if err != nil {
+		t.Fatalf("queuePatchJobs failed: %v", err)
+	}
+	// The fixture's 1.0.0 was created last of the bases
+	if got, want := jobBases(jobs), []string{"1.0.0", "1.1.0"}; !equalStrings(got, want) {
+		t.Fatalf("queued patches from %v, want %v", got, want)
+	}
+
+	// Bases with open jobs are not queued twice, failed ones are retried
+	again, err := f.releaseService.queuePatchJobs(&release, nil)
+	if err != nil {
+		t.Fatalf("queuePatchJobs failed: %v", err)
+	}
+	if len(again) != 1 || again[0].BaseVersion != "1.2.0" {
+		t.Fatalf("second queue returned %v, want only 1.2.0", jobBases(again))
+	}
+	if err := f.db.Model(&models.PatchJob{}).Where("id = ?", jobs[1].ID).Update("status", models.PatchJobFailed).Error; err != nil {
+		t.Fatalf("failed to fail job: %v", err)
+	}
+	retried, err := f.releaseService.queuePatchJobs(&release, []models.Patch{{BaseVersion: "1.2.0"}})
+	if err != nil {
+		t.Fatalf("queuePatchJobs failed: %v", err)
+	}
+	if got := jobBases(retried); !equalStrings(got, []string{"1.1.0"}) {
+		t.Errorf("retry queued %v, want [1.1.0]", got)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGeneratePatches_Unavailable(t *testing.T) {
+	f := newPatchGenerationTest(t)
+	release := channelRelease(t, f.tenants, "1.1.0", models.ReleaseDraft, 0)
+
+	encrypted := channelRelease(t, f.tenants, "1.2.0", models.ReleaseDraft, 0)
+	if err := f.db.Model(&encrypted).Update("is_encrypted", true).Error; err != nil {
+		t.Fatalf("failed to encrypt release: %v", err)
+	}
+	if _, err := f.releaseService.GeneratePatches(f.appA, encrypted.ID); !errors.Is(err, ErrPatchGenerationUnavailable) {
+		t.Errorf("GeneratePatches of an encrypted release: err = %v, want ErrPatchGenerationUnavailable", err)
+	}
+
+	if _, err := f.releaseService.GeneratePatches(f.appA, f.releaseB); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("GeneratePatches of another app's release: err = %v, want ErrReleaseNotFound", err)
+	}
+	if _, err := f.releaseService.PatchJobs(f.appA, f.releaseB); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("PatchJobs of another app's release: err = %v, want ErrReleaseNotFound", err)
+	}
+
+	if err := f.db.Model(&models.App{}).Where("id = ?", f.appA).Update("tier", "free").Error; err != nil {
+		t.Fatalf("failed to downgrade app: %v", err)
+	}
+	if _, err := f.releaseService.GeneratePatches(f.appA, release.ID); !errors.Is(err, ErrPatchGenerationUnavailable) {
+		t.Errorf("GeneratePatches on the free tier: err = %v, want ErrPatchGenerationUnavailable", err)
+	}
+
+	f.releaseService.patchGeneration.Bases = 0
+	if _, err := f.releaseService.GeneratePatches(f.appB, f.releaseB); !errors.Is(err, ErrPatchGenerationUnavailable) {
+		t.Errorf("GeneratePatches with generation disabled: err = %v, want ErrPatchGenerationUnavailable", err)
+	}
+}
+
+func TestGenerateDuePatches_SkipsAndTimesOut(t *testing.T) {
+	f := newPatchGenerationTest(t)
+	ctx := context.Background()
+	now := time.Now()
+	release := channelRelease(t, f.tenants, "1.1.0", models.ReleaseRollingOut, 0)
+	encrypted := channelRelease(t, f.tenants, "0.9.0", models.ReleaseSuperseded, time.Hour)
+	if err := f.db.Model(&encrypted).Update("is_encrypted", true).Error; err != nil {
+		t.Fatalf("failed to encrypt release: %v", err)
+	}
+
+	stale, recent := now.Add(-2*patchJobTimeout), now.Add(-time.Minute)
+	jobs := map[string]*models.PatchJob{
+		"encrypted base":  {ReleaseID: release.ID, BaseReleaseID: encrypted.ID, BaseVersion: "0.9.0", Status: models.PatchJobQueued},
+		"deleted base":    {ReleaseID: release.ID, BaseReleaseID: uuid.New(), BaseVersion: "0.8.0", Status: models.PatchJobQueued},
+		"abandoned":       {ReleaseID: release.ID, BaseReleaseID: encrypted.ID, BaseVersion: "0.9.0", Status: models.PatchJobRunning, Attempts: 1, StartedAt: &stale},
+		"out of attempts": {ReleaseID: release.ID, BaseReleaseID: f.releaseA, BaseVersion: "1.0.0", Status: models.PatchJobRunning, Attempts: maxPatchJobAttempts, StartedAt: &stale},
+		"still running":   {ReleaseID: release.ID, BaseReleaseID: f.releaseA, BaseVersion: "1.0.0", Status: models.PatchJobRunning, Attempts: 1, StartedAt: &recent},
+	}
+	for _, job := range jobs {
+		job.ID, job.AppID = uuid.New(), f.appA
+		if err := f.db.Create(job).Error; err != nil {
+			t.Fatalf("failed to create job: %v", err)
+		}
+	}
+
+	if err := f.releaseService.GenerateDuePatches(ctx, now); err != nil {
+		t.Fatalf("GenerateDuePatches failed: %v", err)
+	}
+
+	want := map[string]struct {
+		status   string
+		attempts int
+	}{
+		"encrypted base":  {models.PatchJobSkipped, 1},
+		"deleted base":    {models.PatchJobSkipped, 1},
+		"abandoned":       {models.PatchJobSkipped, 2},
+		"out of attempts": {models.PatchJobFailed, maxPatchJobAttempts},
+		"still running":   {models.PatchJobRunning, 1},
+	}
+	for name, job := range jobs {
+		var got models.PatchJob
+		if err := f.db.First(&got, "id = ?", job.ID).Error; err != nil {
+			t.Fatalf("failed to reload job: %v", err)
+		}
+		if got.Status != want[name].status || got.Attempts != want[name].attempts {
+			t.Errorf("%s: job is %s after %d attempts, want %s after %d", name, got.Status, got.Attempts, want[name].status, want[name].attempts)
+		}
+		if got.Status != models.PatchJobRunning && (got.Reason == "" || got.FinishedAt == nil) {
+			t.Errorf("%s: finished job has reason %q and finished_at %v", name, got.Reason, got.FinishedAt)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+)
+
+// maxPatchHops caps how many patches a device applies in a row to reach a release.
+const maxPatchHops = 4
+
+// patchEdge is a stored patch as update checks route through it: applied to the bundle hashed
+// Patch.BaseHash, it reproduces the bundle of release Version, hashed Hash.
+type patchEdge struct {
+	Patch   models.Patch `json:"patch"`
+	Hash    string       `json:"hash"`
+	Version string       `json:"version"`
+}
+
+// downloadPlan is how a device gets a release: the full bundle when patches is empty, or else the patches
+// applied in order. Size is the bytes downloaded either way.
+type downloadPlan struct {
+	patches []patchEdge
+	size    int64
+}
+
+// patchEdgesCacheKey is the Redis key under which the chainable patches of a channel are cached.
+func patchEdgesCacheKey(appID uuid.UUID, channel string) string {
+	return fmt.Sprintf("patches:edges:v1:%s:%s", appID, channel)
+}
+
+// planDownload picks the smallest download that takes the device to the release. A device that sent its
+// bundle hash is matched to patches by the hash of the bundle they apply to, and when none of the release's
+// own patches applies, to a chain of patches through other releases of the channel, loaded by chainable
+// only then. Devices that did not are matched on the patch base version. A patch no smaller than the full
+// bundle is not worth applying; a release without a recorded size is assumed larger than its patches.
+func planDownload(release *models.Release, req *models.UpdateCheckRequest, chainable func() []patchEdge) downloadPlan {
+	full := downloadPlan{size: release.Size}
+
+	var best *downloadPlan
+	for _, p := range release.Patches {
+		if !patchApplies(p, req) || (best != nil && p.Size >= best.size) {
+			continue
+		}
+		best = &downloadPlan{patches: []patchEdge{{Patch: p, Hash: release.Hash, Version: release.Version}}, size: p.Size}
+	}
+	// Chains only run through unencrypted bundles: the hash of an encrypted one is not of the bytes patched
+	if best == nil && req.BundleHash != "" && !release.IsEncrypted && !release.IsPatch && chainable != nil {
+		best = shortestPatchChain(chainable(), req.BundleHash, release.Hash)
+	}
+
+	if best == nil || (release.Size > 0 && best.size >= release.Size) {
+		return full
+	}
+	return *best
+}
+
+// patchApplies reports whether a patch applies to the bundle the device runs.
+func patchApplies(p models.Patch, req *models.UpdateCheckRequest) bool {
+	if req.BundleHash != "" {
+		return p.BaseHash != "" && strings.EqualFold(p.BaseHash, req.BundleHash)
+	}
+	return compareVersions(p.BaseVersion, req.Version) == 0
+}
+
+// shortestPatchChain finds the chain of at most maxPatchHops patches with the fewest bytes that turns the
+// bundle hashed from into the one hashed to, or returns nil when there is none.
+func shortestPatchChain(edges []patchEdge, from, to string) *downloadPlan {
+	from, to = strings.ToLower(from), strings.ToLower(to)
+	// Bounded Bellman-Ford: after round n, best holds the cheapest chain of at most n patches to each bundle
+	best := map[string]downloadPlan{from: {}}
+	for hop := 0; hop < maxPatchHops; hop++ {
+		next := make(map[string]downloadPlan, len(best))
+		for hash, plan := range best {
+			next[hash] = plan
+		}
+		for _, e := range edges {
+			plan, ok := best[strings.ToLower(e.Patch.BaseHash)]
+			if !ok {
+				continue
+			}
+			hash := strings.ToLower(e.Hash)
+			size := plan.size + e.Patch.Size
+			if current, ok := next[hash]; ok && current.size <= size {
+				continue
+			}
+			patches := append(append(make([]patchEdge, 0, len(plan.patches)+1), plan.patches...), e)
+			next[hash] = downloadPlan{patches: patches, size: size}
+		}
+		best = next
+	}
+
+	if plan, ok := best[to]; ok && len(plan.patches) > 0 {
+		return &plan
+	}
+	return nil
+}
+
+// chainablePatches returns the patches of a channel that patch chains may run through, cached alongside the
+// channel's active releases. Errors leave the device with the release's own patches or the full bundle.
+func (s *UpdateService) chainablePatches(ctx context.Context, appID uuid.UUID, channel string) []patchEdge {
+	var edges []patchEdge
+	if s.redis != nil {
+		cached, err := s.redis.Get(ctx, patchEdgesCacheKey(appID, channel)).Result()
+		if err == nil && json.Unmarshal([]byte(cached), &edges) == nil {
+			return edges
+		}
+	}
+
+	patches, err := s.releaseRepo.ListChainablePatches(appID, channel)
+	if err != nil {
+		return nil
+	}
+	edges = make([]patchEdge, 0, len(patches))
+	for _, p := range patches {
+		hash, version := p.Release.Hash, p.Release.Version
+		p.Release = models.Release{}
+		edges = append(edges, patchEdge{Patch: p, Hash: hash, Version: version})
+	}
+
+	if s.redis != nil {
+		data, _ := json.Marshal(edges)
+		s.redis.Set(ctx, patchEdgesCacheKey(appID, channel), data, 5*time.Minute)
+	}
+	return edges
+}
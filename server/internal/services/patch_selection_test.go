@@ -0,0 +1,137 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+)
+
+// ── Patch Selection Tests ───────────────────────────────────
+
+// bundleHash returns a distinct 64 character hash for a bundle name.
+func bundleHash(name string) string {
+	return (name + strings.Repeat("0", 64))[:64]
+}
+
+func edge(from, to string, size int64) patchEdge {
+	return patchEdge{Patch: models.Patch{ID: uuid.New(), BaseHash: bundleHash(from), PatchKey: from + "-" + to, Size: size}, Hash: bundleHash(to), Version: to}
+}
+
+func planKeys(plan downloadPlan) []string {
+	var keys []string
+	for _, p := range plan.patches {
+		keys = append(keys, p.Patch.PatchKey)
+	}
+	return keys
+}
+
+func TestPlanDownload_MatchesBundleHash(t *testing.T) {
+	release := &models.Release{Version: "2.0.0", Hash: bundleHash("c"), Size: 1000, Patches: []models.Patch{
+		{BaseVersion: "1.0.0", BaseHash: bundleHash("a"), PatchKey: "from-a", Size: 100},
+		{BaseVersion: "1.0.0", BaseHash: bundleHash("a2"), PatchKey: "from-a2", Size: 50}, // 1.0.0 of another channel
+		{BaseVersion: "1.5.0", PatchKey: "legacy-1.5.0", Size: 10},                        // uploaded before base hashes
+		{BaseVersion: "1.6.0", BaseHash: bundleHash("big"), PatchKey: "from-big", Size: 1000},
+	}}
+	noChains := func() []patchEdge {
+		t.Fatal("chainable patches loaded although a direct patch applies")
+		return nil
+	}
+
+	cases := []struct {
+		name    string
+		req     models.UpdateCheckRequest
+		want    []string
+		size    int64
+		chained func() []patchEdge
+	}{
+		{"hash picks the patch for that bundle", models.UpdateCheckRequest{Version: "1.0.0", BundleHash: strings.ToUpper(bundleHash("a"))}, []string{"from-a"}, 100, noChains},
+		{"version alone picks the cheapest patch from it", models.UpdateCheckRequest{Version: "1.0.0"}, []string{"from-a2"}, 50, noChains},
+		{"legacy patches match devices without a hash", models.UpdateCheckRequest{Version: "1.5.0"}, []string{"legacy-1.5.0"}, 10, noChains},
+		{"legacy patches never match a hash", models.UpdateCheckRequest{Version: "1.5.0", BundleHash: bundleHash("x")}, nil, 1000, nil},
+		{"a patch as large as the bundle is not sent", models.UpdateCheckRequest{Version: "1.6.0", BundleHash: bundleHash("big")}, nil, 1000, nil},
+		{"locally modified bundle gets the full bundle", models.UpdateCheckRequest{Version: "1.0.0", BundleHash: bundleHash("modified")}, nil, 1000, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := planDownload(release, &tc.req, tc.chained)
+			if got := planKeys(plan); !equalStrings(got, tc.want) || plan.size != tc.size {
+				t.Errorf("planDownload = %v (%d bytes), want %v (%d bytes)", got, plan.size, tc.want, tc.size)
+			}
+		})
+	}
+}
+
+func TestPlanDownload_ChainsPatches(t *testing.T) {
+	release := &models.Release{Version: "4.0.0", Hash: bundleHash("d"), Size: 1000}
+	edges := []patchEdge{
+		// a→d in three hops, 300 bytes; a→c→d is 600
+		edge("a", "b", 100), edge("b", "c", 100), edge("c", "d", 100), edge("a", "c", 500),
+		// x→d takes five hops
+		edge("x", "y", 10), edge("y", "z", 10), edge("z", "w", 10), edge("w", "v", 10), edge("v", "d", 10),
+		// e→d is larger than the bundle
+		edge("e", "f", 600), edge("f", "d", 600),
+	}
+	chainable := func() []patchEdge { return edges }
+
+	cases := []struct {
+		from string
+		want []string
+		size int64
+	}{
+		{"a", []string{"a-b", "b-c", "c-d"}, 300},
+		{"b", []string{"b-c", "c-d"}, 200},
+		{"x", nil, 1000}, // beyond maxPatchHops
+		{"e", nil, 1000},
+		{"unknown", nil, 1000},
+	}
+	for _, tc := range cases {
+		plan := planDownload(release, &models.UpdateCheckRequest{Version: "1.0.0", BundleHash: bundleHash(tc.from)}, chainable)
+		if got := planKeys(plan); !equalStrings(got, tc.want) || plan.size != tc.size {
+			t.Errorf("from %s: planDownload = %v (%d bytes), want %v (%d bytes)", tc.from, got, plan.size, tc.want, tc.size)
+		}
+	}
+
+	plan := planDownload(release, &models.UpdateCheckRequest{Version: "1.0.0", BundleHash: bundleHash("a")}, chainable)
+	if hops := plan.patches; hops[0].Hash != bundleHash("b") || hops[2].Hash != release.Hash {
+		t.Errorf("chain hops verify against %s…%s, want the bundles they reproduce", hops[0].Hash, hops[2].Hash)
+	}
+
+	release.IsEncrypted = true
+	if plan := planDownload(release, &models.UpdateCheckRequest{Version: "1.0.0", BundleHash: bundleHash("a")}, chainable); len(plan.patches) != 0 {
+		t.Errorf("encrypted release was offered the chain %v", planKeys(plan))
+	}
+}
+
+func TestListChainablePatches(t *testing.T) {
+	f := newTenants(t, &models.Patch{})
+	chained := channelRelease(t, f, "1.1.0", models.ReleaseSuperseded, 0)
+	archived := channelRelease(t, f, "1.2.0", models.ReleaseArchived, 0)
+	encrypted := channelRelease(t, f, "1.3.0", models.ReleaseCompleted, 0)
+	if err := f.db.Model(&encrypted).Update("is_encrypted", true).Error; err != nil {
+		t.Fatalf("failed to encrypt release: %v", err)
+	}
+	for _, p := range []models.Patch{
+		{ReleaseID: chained.ID, BaseVersion: "1.0.0", BaseHash: bundleHash("a"), PatchKey: "chained", Verified: true},
+		{ReleaseID: chained.ID, BaseVersion: "0.9.0", PatchKey: "no base hash", Verified: true},
+		{ReleaseID: chained.ID, BaseVersion: "0.8.0", BaseHash: bundleHash("c"), PatchKey: "unverified"},
+		{ReleaseID: archived.ID, BaseVersion: "1.1.0", BaseHash: bundleHash("b"), PatchKey: "archived", Verified: true},
+		{ReleaseID: encrypted.ID, BaseVersion: "1.1.0", BaseHash: bundleHash("b"), PatchKey: "encrypted", Verified: true},
+		{ReleaseID: f.releaseB, BaseVersion: "0.9.0", BaseHash: bundleHash("a"), PatchKey: "other app", Verified: true},
+	} {
+		p.ID, p.Hash, p.Signature = uuid.New(), "h", "s"
+		if err := f.db.Create(&p).Error; err != nil {
+			t.Fatalf("failed to create patch: %v", err)
+		}
+	}
+
+	edges := (&UpdateService{releaseRepo: repository.NewReleaseRepository(f.db)}).chainablePatches(t.Context(), f.appA, "production")
+	if len(edges) != 1 || edges[0].Patch.PatchKey != "chained" {
+		t.Fatalf("chainable patches = %v, want only the chained one", edges)
+	}
+	if edges[0].Hash != chained.Hash || edges[0].Version != "1.1.0" {
+		t.Errorf("edge leads to %s (%s), want %s (1.1.0)", edges[0].Hash, edges[0].Version, chained.Hash)
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hotpatch/server/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrPatchVerificationFailed is returned when an uploaded patch cannot be shown to turn its base bundle into
+// the release bundle: it does not apply, reproduces something else, or its base is not stored in a form the
+// server can read.
+var ErrPatchVerificationFailed = errors.New("patch verification failed")
+
+// patchBase finds the release whose bundle an uploaded patch applies to: the app's release of the base version
+// with the declared base hash, or else the channel's release of the base version. Patch releases hold a diff
+// rather than a bundle, so patches to or from them cannot be verified.
+func (s *ReleaseService) patchBase(release *models.Release, req *models.AddPatchRequest) (*models.Release, error) {
+	if release.IsPatch {
+		return nil, fmt.Errorf("%w: release %s is not stored as a full bundle, so patches to it cannot be checked", ErrPatchVerificationFailed, release.Version)
+	}
+
+	var base *models.Release
+	var err error
+	if declared := strings.TrimSpace(req.BaseHash); declared != "" {
+		if raw, decodeErr := hex.DecodeString(declared); decodeErr != nil || len(raw) != sha256.Size {
+			return nil, fmt.Errorf("%w: base_hash must be a hex-encoded SHA-256", ErrHashMismatch)
+		}
+		base, err = s.repo.GetByVersionAndHash(release.AppID, req.BaseVersion, declared)
+	} else {
+		base, err = s.repo.GetByVersion(release.AppID, release.Channel, req.BaseVersion)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: no release of version %s with that bundle to apply the patch to", ErrPatchVerificationFailed, req.BaseVersion)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load base release: %w", err)
+	}
+	if base.IsPatch {
+		return nil, fmt.Errorf("%w: version %s is not stored as a full bundle, so patches from it cannot be checked", ErrPatchVerificationFailed, base.Version)
+	}
+	return base, nil
+}
+
+// verifyUploadedPatch applies an uploaded patch to the stored bundle of its base release and checks the result
+// is the release bundle, so a bad diff is refused before any device downloads it. Devices patch the bundle
+// they decrypted, so an encrypted base is decrypted with the app's key first; the release hash is always of
+// the bundle before the server encrypted it. The base is spooled to disk and the output hashed as it is
+// produced, so neither is held in memory.
+func (s *ReleaseService) verifyUploadedPatch(ctx context.Context, base, release *models.Release, encryptionKey string, patch []byte, limit int64) error {
+	spool, err := s.spoolStoredBundle(ctx, base, encryptionKey, limit)
+	if errors.Is(err, errStoredBundleTooLarge) || errors.Is(err, errStoredBundleChanged) || errors.Is(err, errStoredBundleSealed) {
+		return fmt.Errorf("%w: %v", ErrPatchVerificationFailed, err)
+	}
+	if err != nil {
+		return err
+	}
+	defer spool.Close()
+	return checkPatchOutput(spool.file, spool.size, patch, release.Hash, limit)
+}
+
+// verifySpooledPatch runs verifyUploadedPatch on a patch spooled to disk, mapping it rather than reading it
+// onto the heap.
+func (s *ReleaseService) verifySpooledPatch(ctx context.Context, base, release *models.Release, encryptionKey string, spool *bundleSpool, limit int64) error {
+	patch, unmap, err := spool.mapped()
+	if err != nil {
+		return err
+	}
+	defer unmap()
+	return s.verifyUploadedPatch(ctx, base, release, encryptionKey, patch, limit)
+}
+
+// checkPatchOutput applies a patch to the baseSize bytes of base and checks the output hashes to targetHash.
+func checkPatchOutput(base io.ReaderAt, baseSize int64, patch []byte, targetHash string, limit int64) error {
+	digest := sha256.New()
+	if err := bspatchTo(digest, base, baseSize, patch, limit); err != nil {
+		return fmt.Errorf("%w: %v", ErrPatchVerificationFailed, err)
+	}
+	if got := hex.EncodeToString(digest.Sum(nil)); !strings.EqualFold(got, targetHash) {
+		return fmt.Errorf("%w: the patched bundle hashes to %s, not the release hash %s", ErrPatchVerificationFailed, got, strings.ToLower(targetHash))
+	}
+	return nil
+}
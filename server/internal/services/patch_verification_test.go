@@ -0,0 +1,236 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+)
+
+// ── Patch Verification Tests ────────────────────────────────
+
+func TestCheckPatchOutput(t *testing.T) {
+	base := []byte(strings.Repeat("var app = require('app');\n", 200))
+	target := append([]byte("// 2.0.0\n"), base...)
+	sum := sha256.Sum256(target)
+	targetHash := hex.EncodeToString(sum[:])
+	patch := bsdiff(base, target)
+
+	if err := checkPatchOutput(bytes.NewReader(base), int64(len(base)), patch, strings.ToUpper(targetHash), 1<<20); err != nil {
+		t.Errorf("checkPatchOutput of a good patch: %v", err)
+	}
+
+	cases := []struct {
+		name        string
+		base, patch []byte
+		targetHash  string
+	}{
+		{"diffed from another bundle", target, patch, targetHash},
+		{"diffed to another bundle", base, bsdiff(base, base), targetHash},
+		{"corrupt patch", base, patch[:40], targetHash},
+		{"not a patch", base, target, targetHash},
+	}
+	for _, tc := range cases {
+		if err := checkPatchOutput(bytes.NewReader(tc.base), int64(len(tc.base)), tc.patch, tc.targetHash, 1<<20); !errors.Is(err, ErrPatchVerificationFailed) {
+			t.Errorf("%s: err = %v, want ErrPatchVerificationFailed", tc.name, err)
+		}
+	}
+}
+
+// patchBaseTest holds app A's release service over the releases a patch can be based on.
+type patchBaseTest struct {
+	tenants
+	releaseService *ReleaseService
+}
+
+func newPatchBaseTest(t *testing.T) *patchBaseTest {
+	t.Helper()
+	tn := newTenants(t, &models.Patch{}, &models.Channel{}, &models.SigningKey{}, &models.AuditLog{})
+	releaseService, _ := newReleaseService(tn, PatchGeneration{})
+	return &patchBaseTest{tenants: tn, releaseService: releaseService}
+}
+
+func TestPatchBase(t *testing.T) {
+	f := newPatchBaseTest(t)
+	release := channelRelease(t, f.tenants, "2.0.0", models.ReleaseDraft, 0)
+	plain := channelRelease(t, f.tenants, "1.1.0", models.ReleaseSuperseded, 0)
+	encrypted := channelRelease(t, f.tenants, "1.2.0", models.ReleaseSuperseded, 0)
+	patchRelease := channelRelease(t, f.tenants, "1.3.0", models.ReleaseSuperseded, 0)
+	if err := f.db.Model(&encrypted).Update("is_encrypted", true).Error; err != nil {
+		t.Fatalf("failed to encrypt release: %v", err)
+	}
+	if err := f.db.Model(&patchRelease).Update("is_patch", true).Error; err != nil {
+		t.Fatalf("failed to update release: %v", err)
+	}
+	patchRelease.IsPatch = true
+	plain.Hash = bundleHash("b")
+	if err := f.db.Model(&plain).Update("hash", plain.Hash).Error; err != nil {
+		t.Fatalf("failed to update hash: %v", err)
+	}
+
+	for _, tc := range []struct {
+		req  models.AddPatchRequest
+		want uuid.UUID
+	}{
+		{models.AddPatchRequest{BaseVersion: "1.1.0"}, plain.ID},
+		{models.AddPatchRequest{BaseVersion: "1.1.0", BaseHash: strings.ToUpper(plain.Hash)}, plain.ID},
+		{models.AddPatchRequest{BaseVersion: "1.2.0"}, encrypted.ID}, // decrypted when the patch is verified
+	} {
+		base, err := f.releaseService.patchBase(&release, &tc.req)
+		if err != nil || base.ID != tc.want {
+			t.Errorf("patchBase(%+v) = %v, %v; want release %s", tc.req, base, err, tc.want)
+		}
+	}
+
+	for name, req := range map[string]models.AddPatchRequest{
+		"base release is a patch":    {BaseVersion: "1.3.0"},
+		"no base release":            {BaseVersion: "0.1.0"},
+		"declared hash of no bundle": {BaseVersion: "1.1.0", BaseHash: bundleHash("c")},
+	} {
+		if _, err := f.releaseService.patchBase(&release, &req); !errors.Is(err, ErrPatchVerificationFailed) {
+			t.Errorf("%s: err = %v, want ErrPatchVerificationFailed", name, err)
+		}
+	}
+
+	if _, err := f.releaseService.patchBase(&release, &models.AddPatchRequest{BaseVersion: "1.1.0", BaseHash: "abc"}); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("patchBase of a malformed hash: err = %v, want ErrHashMismatch", err)
+	}
+	if _, err := f.releaseService.patchBase(&patchRelease, &models.AddPatchRequest{BaseVersion: "1.1.0"}); !errors.Is(err, ErrPatchVerificationFailed) {
+		t.Errorf("patchBase to a patch release: err = %v, want ErrPatchVerificationFailed", err)
+	}
+}
+
+// storedRelease creates a production release of app A whose bundle is stored under its version, in the given
+// encryption format ("" for a plain bundle), with the hash of the uploaded bundle.
+func storedRelease(t *testing.T, tn tenants, version, format string, bundle []byte) models.Release {
+	t.Helper()
+	release := channelRelease(t, tn, version, models.ReleaseSuperseded, 0)
+	sum := sha256.Sum256(bundle)
+	release.Hash, release.IsEncrypted, release.EncryptionFormat = hex.EncodeToString(sum[:]), format != "", format
+	if err := tn.db.Model(&release).Updates(map[string]interface{}{"hash": release.Hash, "is_encrypted": release.IsEncrypted, "encryption_format": format}).Error; err != nil {
+		t.Fatalf("failed to update release: %v", err)
+	}
+	return release
+}
+
+func TestVerifyUploadedPatch_EncryptedApp(t *testing.T) {
+	f := newPatchBaseTest(t)
+	ctx := context.Background()
+	encryption := NewEncryptionService()
+	key, _ := encryption.GenerateKey()
+	otherKey, _ := encryption.GenerateKey()
+	seal := func(data []byte) []byte {
+		t.Helper()
+		sealed, err := encryption.EncryptStream(bytes.NewReader(data), key)
+		if err != nil {
+			t.Fatalf("EncryptStream failed: %v", err)
+		}
+		out, err := io.ReadAll(sealed)
+		if err != nil {
+			t.Fatalf("EncryptStream failed: %v", err)
+		}
+		return out
+	}
+
+	// Large enough to span several encryption chunks
+	rng := rand.New(rand.NewSource(21))
+	baseBundle := make([]byte, 150_000)
+	for i := range baseBundle {
+		baseBundle[i] = "abcdefghij{}();=\n "[rng.Intn(18)]
+	}
+	target := append([]byte("// 2.0.0\n"), baseBundle...)
+	legacy, err := encryption.Encrypt(baseBundle, key)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	f.releaseService.storage = newObjectStore(t, map[string][]byte{
+		"bundles/1.1.0.zip": seal(baseBundle),
+		"bundles/1.0.0.zip": baseBundle,
+		"bundles/0.9.0.zip": legacy,
+	})
+	encrypted := storedRelease(t, f.tenants, "1.1.0", models.EncryptionChunked, baseBundle)
+	plain := storedRelease(t, f.tenants, "1.0.0", "", baseBundle)
+	single := storedRelease(t, f.tenants, "0.9.0", models.EncryptionSingle, baseBundle)
+	release := storedRelease(t, f.tenants, "2.0.0", models.EncryptionChunked, target)
+	patch := bsdiff(baseBundle, target)
+
+	for name, base := range map[string]models.Release{"encrypted base": encrypted, "plain base": plain} {
+		if err := f.releaseService.verifyUploadedPatch(ctx, &base, &release, key, patch, 1<<20); err != nil {
+			t.Errorf("%s: verifyUploadedPatch failed: %v", name, err)
+		}
+	}
+
+	cases := []struct {
+		name  string
+		base  models.Release
+		key   string
+		patch []byte
+		limit int64
+	}{
+		{"diffed from another bundle", encrypted, key, bsdiff(target, target), 1 << 20},
+		{"app key changed", encrypted, otherKey, patch, 1 << 20},
+		{"base sealed in one piece", single, key, patch, 1 << 20},
+		{"base over the limit", encrypted, key, patch, 1 << 10},
+	}
+	for _, tc := range cases {
+		err := f.releaseService.verifyUploadedPatch(ctx, &tc.base, &release, tc.key, tc.patch, tc.limit)
+		if !errors.Is(err, ErrPatchVerificationFailed) {
+			t.Errorf("%s: err = %v, want ErrPatchVerificationFailed", tc.name, err)
+		}
+	}
+}
+
+func TestAddPatch_VerifiedFromSpool(t *testing.T) {
+	f := newPatchBaseTest(t)
+	ctx := context.Background()
+	pub, priv := newSigningKey(t)
+	addSigningKey(t, f.tenants, f.appA, pub, true)
+
+	rng := rand.New(rand.NewSource(21))
+	baseBundle := make([]byte, 50_000)
+	for i := range baseBundle {
+		baseBundle[i] = "abcdefghij{}();=\n "[rng.Intn(18)]
+	}
+	target := append([]byte("// 2.0.0\n"), baseBundle...)
+	f.releaseService.storage = newObjectStore(t, map[string][]byte{"bundles/1.1.0.zip": baseBundle})
+	base := storedRelease(t, f.tenants, "1.1.0", "", baseBundle)
+	release := storedRelease(t, f.tenants, "2.0.0", "", target)
+
+	addPatch := func(patch []byte) (*models.Patch, error) {
+		sum := sha256.Sum256(patch)
+		req := &models.AddPatchRequest{
+			BaseVersion: "1.1.0",
+			Hash:        hex.EncodeToString(sum[:]),
+			Signature:   base64.StdEncoding.EncodeToString(ed25519.Sign(priv, patch)),
+			Size:        int64(len(patch)),
+		}
+		return f.releaseService.AddPatch(ctx, f.appA, release.ID, req, bytes.NewReader(patch), "alice", "")
+	}
+
+	patch, err := addPatch(bsdiff(baseBundle, target))
+	if err != nil {
+		t.Fatalf("AddPatch of a correct patch failed: %v", err)
+	}
+	if !patch.Verified || patch.BaseHash != base.Hash {
+		t.Errorf("patch = %+v, want it verified against the 1.1.0 bundle", patch)
+	}
+
+	if _, err := addPatch(bsdiff(target, target)); !errors.Is(err, ErrPatchVerificationFailed) {
+		t.Errorf("AddPatch of a patch from another bundle: err = %v, want ErrPatchVerificationFailed", err)
+	}
+	var count int64
+	f.db.Model(&models.Patch{}).Count(&count)
+	if count != 1 {
+		t.Errorf("%d patches stored, want only the verified one", count)
+	}
+}
@@ -1,10 +1,14 @@
 package services
 
 import (
-	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,32 +16,67 @@ import (
 	"github.com/hotpatch/server/internal/repository"
 	"github.com/hotpatch/server/internal/storage"
 	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
 )
 
+var (
+	// ErrReleaseNotFound is returned when a release does not exist or belongs to another app.
+	ErrReleaseNotFound = errors.New("release not found")
+	// ErrInvalidSchedule is returned when a release's publish time is not in the future.
+	ErrInvalidSchedule = errors.New("invalid publish schedule")
+	// ErrVersionExists is returned when a channel already has a release with the same version.
+	ErrVersionExists = errors.New("version already exists")
+	// ErrInvalidPromotion is returned when a release cannot be promoted to the requested channel.
+	ErrInvalidPromotion = errors.New("invalid promotion")
+)
+
+// publishBatchSize caps how many scheduled releases and deferred rollout changes a single scheduler tick applies.
+const publishBatchSize = 100
+
 // ReleaseService handles release management business logic.
 type ReleaseService struct {
 	repo              *repository.ReleaseRepository
+	channelRepo       *repository.ChannelRepository
 	storage           *storage.S3Storage
 	settingsService   *SettingsService
 	securityService   *SecurityService
 	encryptionService *EncryptionService
+	rolloutService    *RolloutService
+	freezeService     *FreezeService
+	lifecycle         *ReleaseLifecycle
 	redis             *redis.Client
+	maxBundleSize     int64 // bytes; the upper bound of every tier's bundle size limit
+	patchGeneration   PatchGeneration
 }
 
 // NewReleaseService creates a new ReleaseService.
-func NewReleaseService(repo *repository.ReleaseRepository, storage *storage.S3Storage, settingsService *SettingsService, securityService *SecurityService, encryptionService *EncryptionService, redis *redis.Client) *ReleaseService {
-	return &ReleaseService{repo: repo, storage: storage, settingsService: settingsService, securityService: securityService, encryptionService: encryptionService, redis: redis}
+func NewReleaseService(repo *repository.ReleaseRepository, channelRepo *repository.ChannelRepository, storage *storage.S3Storage, settingsService *SettingsService, securityService *SecurityService, encryptionService *EncryptionService, rolloutService *RolloutService, freezeService *FreezeService, lifecycle *ReleaseLifecycle, redis *redis.Client, maxBundleSize int64, patchGeneration PatchGeneration) *ReleaseService {
+	return &ReleaseService{repo: repo, channelRepo: channelRepo, storage: storage, settingsService: settingsService, securityService: securityService, encryptionService: encryptionService, rolloutService: rolloutService, freezeService: freezeService, lifecycle: lifecycle, redis: redis, maxBundleSize: maxBundleSize, patchGeneration: patchGeneration}
 }
 
 func (s *ReleaseService) invalidateCache(ctx context.Context, appID uuid.UUID, channel string) {
 	if s.redis != nil {
-		cacheKey := fmt.Sprintf("release:active:%s:%s", appID, channel)
-		s.redis.Del(ctx, cacheKey)
+		s.redis.Del(ctx, activeReleaseCacheKey(appID, channel), patchEdgesCacheKey(appID, channel))
+	}
+}
+
+// getRelease loads a release owned by appID. Releases of other apps are reported as not found.
+func (s *ReleaseService) getRelease(appID, releaseID uuid.UUID) (*models.Release, error) {
+	release, err := s.repo.GetByID(appID, releaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReleaseNotFound
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load release: %w", err)
+	}
+	return release, nil
 }
 
 // Create validates and stores a new release, uploads the bundle to S3, and deactivates previous releases.
-func (s *ReleaseService) Create(ctx context.Context, req *models.CreateReleaseRequest, appID uuid.UUID, bundleFile io.Reader) (*models.Release, error) {
+// A release with a publish time, or one uploaded while its channel defers changes during a freeze, is stored
+// inactive and published by the scheduler instead. A draft is stored inactive until published by hand, and a
+// release for a channel that requires approvals waits for them before it is published or scheduled.
+func (s *ReleaseService) Create(ctx context.Context, req *models.CreateReleaseRequest, appID uuid.UUID, bundleFile io.Reader, actor, ip string) (*models.Release, error) {
 	// Default channel
 	channel := req.Channel
 	if channel == "" {
@@ -50,13 +89,21 @@ func (s *ReleaseService) Create(ctx context.Context, req *models.CreateReleaseRe
 		rollout = 100
 	}
 
+	// A rollout plan starts at its first step
+	if req.RolloutPlan != nil {
+		if err := validateRolloutPlan(req.RolloutPlan); err != nil {
+			return nil, err
+		}
+		rollout = req.RolloutPlan[0].Percentage
+	}
+
 	// Check for duplicate version
 	exists, err := s.repo.ExistsByVersion(appID, req.Version, channel)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check version: %w", err)
 	}
 	if exists {
-		return nil, fmt.Errorf("version %s already exists for channel %s", req.Version, channel)
+		return nil, fmt.Errorf("%w: version %s already exists for channel %s", ErrVersionExists, req.Version, channel)
 	}
 
 	// Fetch app to check tier and monotonic versioning
@@ -70,85 +117,222 @@ func (s *ReleaseService) Create(ctx context.Context, req *models.CreateReleaseRe
 		return nil, fmt.Errorf("phased rollout (percentage < 100) is a Pro feature. Current tier: %s", app.Tier)
 	}
 
-	// Monotonic versioning check
-	latest, _ := s.repo.GetLatestActive(appID, channel)
+	// Versions must be strict semver so precedence is well defined
+	if err := validateVersion(req.Version); err != nil {
+		return nil, err
+	}
+	if req.BaseVersion != "" {
+		if err := validateVersion(req.BaseVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate the targeted native version range and device targeting rules
+	if err := validateNativeVersionRange(req.TargetNativeVersion); err != nil {
+		return nil, err
+	}
+	if err := validateTargetingRules(req.TargetingRules); err != nil {
+		return nil, err
+	}
+
+	// Monotonic versioning check (per native version target)
+	latest, _ := s.repo.GetLatestActive(appID, channel, req.TargetNativeVersion)
 	if latest != nil {
-		// Simple string comparison for now, in production use a semver library if versions follow semver
-		if req.Version <= latest.Version {
+		if compareVersions(req.Version, latest.Version) <= 0 {
 			return nil, fmt.Errorf("monotonic versioning enforced: new version %s must be greater than current active version %s", req.Version, latest.Version)
 		}
 	}
 
-	// Read bundle into memory for hash and potential encryption
-	bundleData, err := io.ReadAll(bundleFile)
+	required, err := s.requiredApprovals(appID, channel)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read bundle: %w", err)
+		return nil, err
 	}
 
-	finalBundleData := bundleData
-	var keyID *string
+	// Drafts, releases awaiting approval and scheduled releases go live later; otherwise the channel must not be frozen
+	var publishAt *time.Time
+	switch {
+	case req.Draft:
+		if req.PublishAt != nil {
+			return nil, fmt.Errorf("%w: a draft has no publish time; schedule it once uploaded", ErrInvalidSchedule)
+		}
+	case required > 0:
+		if publishAt, err = approvalPublishAt(req.PublishAt); err != nil {
+			return nil, err
+		}
+	default:
+		if publishAt, err = s.resolvePublishAt(appID, channel, req.PublishAt); err != nil {
+			return nil, err
+		}
+	}
+	status := initialStatus(req.Draft, required, publishAt, rollout)
+
+	// Reject oversized bundles up front when the declared size already gives them away
+	sizeLimit := s.bundleSizeLimit(app.Tier)
+	if req.Size > sizeLimit {
+		return nil, bundleTooLarge(sizeLimit)
+	}
+	budget, err := s.sizeBudget(appID, channel)
+	if err != nil {
+		return nil, err
+	}
+	// Growth is measured against what devices in the lane run now, not a newer release that never went live
+	active, err := s.repo.GetLaneActive(appID, channel, req.TargetNativeVersion)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load active release: %w", err)
+	}
+	artifact := "bundle"
+	if req.IsPatch {
+		artifact = "patch"
+	}
+	if _, err := budget.enforce(artifact, req.Size, active, req.OverrideSizeBudget); err != nil {
+		return nil, err
+	}
 
 	// Handle server-side encryption
+	var encryptionKey, encryptionFormat string
+	var keyID *string
 	if req.IsEncrypted {
 		if app.EncryptionKey == "" {
 			return nil, fmt.Errorf("encryption requested but no encryption key configured for app")
 		}
+		encryptionKey = app.EncryptionKey
+		encryptionFormat = models.EncryptionChunked
+		kid := encryptionKeyID(app.EncryptionKey)
+		keyID = &kid
+	}
 
-		encryptedData, kid, err := s.encryptionService.EncryptBundle(bundleData, app.EncryptionKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encrypt bundle: %w", err)
+	// Every release gets its own cohort unless it explicitly reuses another's salt
+	rolloutSalt := req.RolloutSalt
+	if rolloutSalt == "" {
+		if rolloutSalt, err = newRolloutSalt(); err != nil {
+			return nil, err
 		}
-		finalBundleData = encryptedData
-		keyID = &kid
+	}
+	bucketBy := req.BucketBy
+	if bucketBy == "" {
+		bucketBy = models.BucketByDevice
 	}
 
-	// Upload bundle to S3
+	// Stream bundle to S3, hashing it on the way and spooling it to disk, where its signature is checked
+	// and full bundles are unzipped and validated once the upload checks out.
+	check, err := s.newArtifactCheck(appID, req.Hash, req.Signature)
+	if err != nil {
+		return nil, err
+	}
+	spool, err := newBundleSpool()
+	if err != nil {
+		return nil, err
+	}
+	defer spool.Close()
 	objectKey := fmt.Sprintf("bundles/%s/%s/%s/%s.zip", appID, req.Platform, channel, req.Version)
-	_, err = s.storage.Upload(ctx, objectKey, bytes.NewReader(finalBundleData), "application/zip")
+	size, err := s.uploadBundle(ctx, objectKey, "application/zip", bundleFile, sizeLimit, encryptionKey, io.MultiWriter(check, spool))
 	if err != nil {
-		return nil, fmt.Errorf("failed to upload bundle: %w", err)
+		return nil, err
 	}
-
-	// Generate the CDN/presigned URL
-	bundleURL, err := s.storage.GetPresignedURL(ctx, objectKey)
+	signingKeyID, err := verifySpooled(check, spool)
+	if err != nil {
+		s.discardObject(ctx, objectKey)
+		return nil, err
+	}
+	overBudget, err := budget.enforce(artifact, size, active, req.OverrideSizeBudget)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate bundle URL: %w", err)
+		s.discardObject(ctx, objectKey)
+		return nil, err
+	}
+	var manifest *models.BundleManifest
+	var findings []models.SecretFinding
+	if !req.IsPatch {
+		if manifest, findings, err = spool.inspect(req.Platform, req.IsEncrypted); err != nil {
+			s.discardObject(ctx, objectKey)
+			return nil, err
+		}
+	}
+	// A draft is stored with whatever secrets it holds, to be reviewed; it cannot be published until they are gone
+	if status != models.ReleaseDraft {
+		if err := s.checkSecretFindings(appID, channel, findings); err != nil {
+			s.discardObject(ctx, objectKey)
+			return nil, err
+		}
 	}
 
 	// Create release record
 	release := &models.Release{
-		ID:                uuid.New(),
-		AppID:             appID,
-		Version:           req.Version,
-		Channel:           channel,
-		BundleURL:         bundleURL,
-		Hash:              req.Hash,
-		Signature:         req.Signature,
-		IsEncrypted:       req.IsEncrypted,
-		IsPatch:           req.IsPatch,
-		BaseVersion:       req.BaseVersion,
-		KeyID:             keyID,
-		Size:              int64(len(finalBundleData)),
-		Mandatory:         req.Mandatory,
-		RolloutPercentage: rollout,
-		IsActive:          true,
-		CreatedAt:         time.Now(),
+		ID:                  uuid.New(),
+		AppID:               appID,
+		Version:             req.Version,
+		Channel:             channel,
+		BundleKey:           objectKey,
+		Hash:                check.SHA256(),
+		Signature:           req.Signature,
+		SigningKeyID:        &signingKeyID,
+		IsEncrypted:         req.IsEncrypted,
+		EncryptionFormat:    encryptionFormat,
+		IsPatch:             req.IsPatch,
+		BaseVersion:         req.BaseVersion,
+		TargetNativeVersion: req.TargetNativeVersion,
+		TargetingRules:      req.TargetingRules,
+		KeyID:               keyID,
+		Size:                size,
+		Mandatory:           req.Mandatory,
+		RolloutPercentage:   rollout,
+		RolloutSalt:         rolloutSalt,
+		BucketBy:            bucketBy,
+		IsActive:            isLive(status),
+		Status:              status,
+		PublishAt:           publishAt,
+		CreatedBy:           actor,
+		Manifest:            manifest,
+		SecretFindings:      findings,
+		CreatedAt:           time.Now(),
+	}
+	if status == models.ReleasePendingApproval {
+		release.RequiredApprovals = required
 	}
 
 	if err := s.repo.Create(release); err != nil {
 		return nil, fmt.Errorf("failed to create release: %w", err)
 	}
+	s.lifecycle.created(release, actor, "Uploaded")
+	s.queuePatchJobsFor(release, nil, app.Tier)
 
-	// Deactivate previous releases for the same channel
-	if err := s.repo.DeactivatePreviousReleases(appID, channel, release.ID); err != nil {
-		return nil, fmt.Errorf("failed to deactivate previous releases: %w", err)
+	// Deactivate previous releases for the same channel and native version target
+	if release.IsActive {
+		if err := s.deactivateSuperseded(release, actor); err != nil {
+			return nil, err
+		}
 	}
 
 	// Dispatch webhook
 	s.settingsService.DispatchEvent(appID, "release.created", release)
 
+	// Hand the release to the rollout scheduler
+	if req.RolloutPlan != nil {
+		if release.IsActive {
+			_, err = s.rolloutService.StartPlan(ctx, release, req.RolloutPlan, actor)
+		} else {
+			_, err = s.rolloutService.SchedulePlan(ctx, release, req.RolloutPlan, actor)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Log audit trail
-	s.securityService.Log(appID, "system", "release.create", release.ID.String(), fmt.Sprintf("Version: %s, Channel: %s", release.Version, release.Channel), "")
+	metadata := fmt.Sprintf("Version: %s, Channel: %s, SHA-256: %s", release.Version, release.Channel, release.Hash)
+	if release.PublishAt != nil {
+		metadata += fmt.Sprintf(", Publish at: %s", release.PublishAt.UTC().Format(time.RFC3339))
+	}
+	if release.Status == models.ReleasePendingApproval {
+		metadata += fmt.Sprintf(", Awaiting %d approvals", release.RequiredApprovals)
+	}
+	s.securityService.Log(appID, actor, "release.create", release.ID.String(), metadata, ip)
+	if overBudget != nil {
+		s.securityService.Log(appID, actor, "release.size_budget_override", release.ID.String(), overBudget.Error(), ip)
+	}
+	if len(findings) > 0 {
+		metadata := fmt.Sprintf("%d findings, highest severity %s", len(findings), highestSeverity(findings))
+		s.securityService.Log(appID, actor, "release.secrets_found", release.ID.String(), metadata, ip)
+	}
 
 	// Invalidate cache
 	s.invalidateCache(ctx, appID, channel)
@@ -156,32 +340,474 @@ func (s *ReleaseService) Create(ctx context.Context, req *models.CreateReleaseRe
 	return release, nil
 }
 
-// GetByID retrieves a release by ID.
-func (s *ReleaseService) GetByID(id uuid.UUID) (*models.Release, error) {
-	return s.repo.GetByID(id)
+// resolvePublishAt returns when a new release of the channel goes live: the requested time, the end
+// of the channel's freeze window when the channel defers changes, or nil to publish immediately.
+func (s *ReleaseService) resolvePublishAt(appID uuid.UUID, channel string, requested *time.Time) (*time.Time, error) {
+	if requested != nil {
+		if !requested.After(time.Now()) {
+			return nil, fmt.Errorf("%w: publish_at must be in the future", ErrInvalidSchedule)
+		}
+		return requested, nil
+	}
+
+	freeze, err := s.freezeService.Check(appID, channel, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if freeze == nil {
+		return nil, nil
+	}
+	if freeze.Action != models.FreezeActionDefer {
+		return nil, frozenError(freeze)
+	}
+	until := freeze.Until
+	return &until, nil
+}
+
+// approvalPublishAt validates the requested publish time of a release that goes into review. Freeze
+// windows are checked once the release is approved, when it actually goes live.
+func approvalPublishAt(requested *time.Time) (*time.Time, error) {
+	if requested != nil && !requested.After(time.Now()) {
+		return nil, fmt.Errorf("%w: publish_at must be in the future", ErrInvalidSchedule)
+	}
+	return requested, nil
+}
+
+// Promote creates a release in another channel from an existing one without re-uploading it. The new
+// release references the source's stored bundle, patches and signature, so devices in both channels
+// verify and download the same bytes, and records the source in PromotedFrom. Rollout starts afresh
+// with a new cohort; freeze windows of the target channel apply as they do to an upload.
+func (s *ReleaseService) Promote(ctx context.Context, appID, releaseID uuid.UUID, req *models.PromoteReleaseRequest, actor, ip string) (*models.Release, error) {
+	source, err := s.repo.GetWithPatches(appID, releaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReleaseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load release: %w", err)
+	}
+
+	channel := req.Channel
+	if channel == source.Channel {
+		return nil, fmt.Errorf("%w: release is already in channel %s", ErrInvalidPromotion, channel)
+	}
+	if source.RolledBackAt != nil {
+		return nil, fmt.Errorf("%w: version %s was rolled back in %s", ErrInvalidPromotion, source.Version, source.Channel)
+	}
+	if source.HaltedAt != nil {
+		return nil, fmt.Errorf("%w: rollout of version %s is halted in %s: %s", ErrInvalidPromotion, source.Version, source.Channel, source.HaltReason)
+	}
+
+	rollout := req.RolloutPercentage
+	if rollout == 0 {
+		rollout = 100
+	}
+	if req.RolloutPlan != nil {
+		if err := validateRolloutPlan(req.RolloutPlan); err != nil {
+			return nil, err
+		}
+		rollout = req.RolloutPlan[0].Percentage
+	}
+
+	exists, err := s.repo.ExistsByVersion(appID, source.Version, channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check version: %w", err)
+	}
+	if exists {
+		return nil, fmt.Errorf("%w: version %s already exists for channel %s", ErrVersionExists, source.Version, channel)
+	}
+
+	app, err := s.settingsService.GetApp(appID)
+	if err != nil {
+		return nil, fmt.Errorf("app not found: %w", err)
+	}
+	if app.Tier == "free" && rollout < 100 {
+		return nil, fmt.Errorf("phased rollout (percentage < 100) is a Pro feature. Current tier: %s", app.Tier)
+	}
+
+	latest, _ := s.repo.GetLatestActive(appID, channel, source.TargetNativeVersion)
+	if latest != nil && compareVersions(source.Version, latest.Version) <= 0 {
+		return nil, fmt.Errorf("%w: monotonic versioning enforced: version %s must be greater than %s in channel %s", ErrInvalidPromotion, source.Version, latest.Version, channel)
+	}
+
+	required, err := s.requiredApprovals(appID, channel)
+	if err != nil {
+		return nil, err
+	}
+	var publishAt *time.Time
+	if required > 0 {
+		publishAt, err = approvalPublishAt(req.PublishAt)
+	} else {
+		publishAt, err = s.resolvePublishAt(appID, channel, req.PublishAt)
+	}
+	if err != nil {
+		return nil, err
+	}
+	status := initialStatus(false, required, publishAt, rollout)
+
+	rolloutSalt, err := newRolloutSalt()
+	if err != nil {
+		return nil, err
+	}
+	mandatory := source.Mandatory
+	if req.Mandatory != nil {
+		mandatory = *req.Mandatory
+	}
+
+	release := &models.Release{
+		ID:                  uuid.New(),
+		AppID:               appID,
+		Version:             source.Version,
+		Channel:             channel,
+		BundleKey:           source.BundleKey,
+		Hash:                source.Hash,
+		Signature:           source.Signature,
+		SigningKeyID:        source.SigningKeyID,
+		IsEncrypted:         source.IsEncrypted,
+		EncryptionFormat:    source.EncryptionFormat,
+		IsPatch:             source.IsPatch,
+		BaseVersion:         source.BaseVersion,
+		TargetNativeVersion: source.TargetNativeVersion,
+		TargetingRules:      source.TargetingRules,
+		KeyID:               source.KeyID,
+		Size:                source.Size,
+		Mandatory:           mandatory,
+		RolloutPercentage:   rollout,
+		RolloutSalt:         rolloutSalt,
+		BucketBy:            source.BucketBy,
+		IsActive:            isLive(status),
+		Status:              status,
+		PublishAt:           publishAt,
+		PromotedFrom:        &source.ID,
+		CreatedBy:           actor,
+		CreatedAt:           time.Now(),
+	}
+	if status == models.ReleasePendingApproval {
+		release.RequiredApprovals = required
+	}
+
+	// The bundle is the source's, and so is its manifest
+	manifest, err := s.repo.GetManifest(source.ID)
+	switch {
+	case err == nil:
+		manifest.ReleaseID, manifest.CreatedAt = release.ID, release.CreatedAt
+		release.Manifest = manifest
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, fmt.Errorf("failed to load bundle manifest: %w", err)
+	}
+
+	// So are the secrets found in it, checked against the target channel's blocking severity
+	findings, err := s.repo.ListSecretFindings(source.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secret findings: %w", err)
+	}
+	if err := s.checkSecretFindings(appID, channel, findings); err != nil {
+		return nil, err
+	}
+	for i := range findings {
+		findings[i].ID, findings[i].ReleaseID, findings[i].CreatedAt = uuid.New(), release.ID, release.CreatedAt
+	}
+	release.SecretFindings = findings
+
+	// Patches point at the same stored objects as the source's. Their base hash still names the bundle they
+	// apply to, whatever that version is in the target channel.
+	patches := make([]models.Patch, 0, len(source.Patches))
+	for _, p := range source.Patches {
+		patches = append(patches, models.Patch{
+			ID:           uuid.New(),
+			ReleaseID:    release.ID,
+			BaseVersion:  p.BaseVersion,
+			BaseHash:     p.BaseHash,
+			PatchKey:     p.PatchKey,
+			Hash:         p.Hash,
+			Signature:    p.Signature,
+			SigningKeyID: p.SigningKeyID,
+			Generated:    p.Generated,
+			Verified:     p.Verified,
+			Size:         p.Size,
+			CreatedAt:    release.CreatedAt,
+		})
+	}
+
+	if err := s.repo.CreateWithPatches(release, patches); err != nil {
+		return nil, fmt.Errorf("failed to create release: %w", err)
+	}
+	release.Patches = patches
+	s.lifecycle.created(release, actor, fmt.Sprintf("Promoted from %s", source.Channel))
+	s.queuePatchJobsFor(release, patches, app.Tier)
+
+	if release.IsActive {
+		if err := s.deactivateSuperseded(release, actor); err != nil {
+			return nil, err
+		}
+	}
+
+	s.settingsService.DispatchEvent(appID, "release.promoted", release)
+
+	if req.RolloutPlan != nil {
+		if release.IsActive {
+			_, err = s.rolloutService.StartPlan(ctx, release, req.RolloutPlan, actor)
+		} else {
+			_, err = s.rolloutService.SchedulePlan(ctx, release, req.RolloutPlan, actor)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	metadata := fmt.Sprintf("Version: %s, From: %s (%s), To: %s", release.Version, source.Channel, source.ID, channel)
+	if release.PublishAt != nil {
+		metadata += fmt.Sprintf(", Publish at: %s", release.PublishAt.UTC().Format(time.RFC3339))
+	}
+	if release.Status == models.ReleasePendingApproval {
+		metadata += fmt.Sprintf(", Awaiting %d approvals", release.RequiredApprovals)
+	}
+	s.securityService.Log(appID, actor, "release.promote", release.ID.String(), metadata, ip)
+
+	s.invalidateCache(ctx, appID, channel)
+
+	return release, nil
+}
+
+// initialStatus returns the lifecycle status a new release is stored with.
+func initialStatus(draft bool, requiredApprovals int, publishAt *time.Time, rollout int) string {
+	switch {
+	case draft:
+		return models.ReleaseDraft
+	case requiredApprovals > 0:
+		return models.ReleasePendingApproval
+	case publishAt != nil:
+		return models.ReleaseScheduled
+	default:
+		return liveStatus(rollout)
+	}
+}
+
+// isLive reports whether a release in the given status is active in its channel.
+func isLive(status string) bool {
+	switch status {
+	case models.ReleaseRollingOut, models.ReleaseCompleted, models.ReleasePaused, models.ReleaseHalted:
+		return true
+	}
+	return false
+}
+
+// deactivateSuperseded deactivates the releases a newly live release replaces. A targeted release
+// only reaches part of the lane, so the releases it builds on stay live for every other device.
+func (s *ReleaseService) deactivateSuperseded(release *models.Release, actor string) error {
+	if len(release.TargetingRules) > 0 {
+		return nil
+	}
+	return s.deactivateLane(release, release.ID, actor, fmt.Sprintf("Superseded by %s", release.Version))
+}
+
+// deactivateLane deactivates every active release in the lane of release except keep. Those below
+// release's version are recorded as superseded; the caller records any it pulls as rolled back.
+func (s *ReleaseService) deactivateLane(release *models.Release, keep uuid.UUID, actor, reason string) error {
+	lane, err := s.repo.ListLane(release.AppID, release.Channel, release.TargetNativeVersion, publishedStatuses)
+	if err != nil {
+		return fmt.Errorf("failed to load releases: %w", err)
+	}
+	if err := s.repo.DeactivatePreviousReleases(release.AppID, release.Channel, release.TargetNativeVersion, keep); err != nil {
+		return fmt.Errorf("failed to deactivate previous releases: %w", err)
+	}
+	for i := range lane {
+		r := &lane[i]
+		if r.IsActive && r.ID != keep && compareVersions(r.Version, release.Version) < 0 {
+			s.lifecycle.record(r, models.ReleaseSuperseded, actor, reason)
+		}
+	}
+	return nil
+}
+
+// PublishDue publishes scheduled releases whose time has come and applies rollout changes deferred
+// by freeze windows, holding back anything whose channel is still frozen.
+// Run periodically by the Scheduler; safe to run from several server instances at once.
+func (s *ReleaseService) PublishDue(ctx context.Context, now time.Time) error {
+	due, err := s.repo.ListDueScheduled(now, publishBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to load scheduled releases: %w", err)
+	}
+	for i := range due {
+		if err := s.publish(ctx, &due[i], now); err != nil {
+			log.Printf("publish: failed to publish release %s: %v", due[i].ID, err)
+		}
+	}
+
+	pending, err := s.repo.ListPendingRollouts(publishBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to load deferred rollout changes: %w", err)
+	}
+	for i := range pending {
+		if err := s.applyPendingRollout(ctx, &pending[i], now); err != nil {
+			log.Printf("publish: failed to apply deferred rollout of release %s: %v", pending[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// publish makes a scheduled release live unless its channel is frozen. A release overtaken by a
+// newer version published in the meantime is dropped from the schedule instead of replacing it.
+func (s *ReleaseService) publish(ctx context.Context, release *models.Release, now time.Time) error {
+	freeze, err := s.freezeService.Check(release.AppID, release.Channel, now)
+	if err != nil {
+		return err
+	}
+	if freeze != nil {
+		return nil
+	}
+
+	newer, err := s.overtakenBy(release)
+	if err != nil {
+		return err
+	}
+	if newer != nil {
+		err := s.lifecycle.transition(release, models.ReleaseSuperseded, "scheduler", fmt.Sprintf("Superseded by %s before publishing", newer.Version), map[string]interface{}{
+			"publish_at": nil,
+		})
+		if err != nil && !errors.Is(err, ErrInvalidTransition) {
+			return fmt.Errorf("failed to cancel schedule: %w", err)
+		}
+		s.securityService.Log(release.AppID, "scheduler", "release.schedule_cancelled", release.ID.String(), fmt.Sprintf("Superseded by %s", newer.Version), "")
+		return nil
+	}
+
+	err = s.activate(ctx, release, "scheduler", "Publish time reached", now)
+	if errors.Is(err, ErrInvalidTransition) {
+		// Another instance published it first, or it was unscheduled meanwhile
+		return nil
+	}
+	return err
+}
+
+// overtakenBy returns the active untargeted release with a higher version in the lane of a release
+// waiting to go live, or nil. Targeted releases only reach part of the lane and are never overtaken.
+func (s *ReleaseService) overtakenBy(release *models.Release) (*models.Release, error) {
+	if len(release.TargetingRules) > 0 {
+		return nil, nil
+	}
+	lane, err := s.repo.ListLane(release.AppID, release.Channel, release.TargetNativeVersion, publishedStatuses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load releases: %w", err)
+	}
+	for i := range lane {
+		if lane[i].IsActive && compareVersions(lane[i].Version, release.Version) > 0 {
+			return &lane[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// activate makes a draft or scheduled release live: it replaces the lane's previous releases,
+// starts the release's scheduled rollout plan and announces the release.
+func (s *ReleaseService) activate(ctx context.Context, release *models.Release, actor, reason string, now time.Time) error {
+	err := s.lifecycle.transition(release, liveStatus(release.RolloutPercentage), actor, reason, map[string]interface{}{
+		"is_active":  true,
+		"publish_at": nil,
+	})
+	if err != nil {
+		return err
+	}
+	release.IsActive = true
+	release.PublishAt = nil
+
+	if err := s.deactivateSuperseded(release, actor); err != nil {
+		return err
+	}
+	if err := s.rolloutService.StartScheduledPlan(ctx, release, actor, now); err != nil {
+		return err
+	}
+
+	s.settingsService.DispatchEvent(release.AppID, "release.published", release)
+	s.securityService.Log(release.AppID, actor, "release.publish", release.ID.String(), fmt.Sprintf("Version: %s, Channel: %s", release.Version, release.Channel), "")
+	s.invalidateCache(ctx, release.AppID, release.Channel)
+	return nil
+}
+
+// applyPendingRollout applies a rollout change deferred by a freeze window once the window has closed.
+func (s *ReleaseService) applyPendingRollout(ctx context.Context, release *models.Release, now time.Time) error {
+	freeze, err := s.freezeService.Check(release.AppID, release.Channel, now)
+	if err != nil {
+		return err
+	}
+	if freeze != nil {
+		return nil
+	}
+
+	percentage := *release.PendingRollout
+	ok, err := s.repo.ApplyPendingRollout(release.ID, percentage)
+	if err != nil {
+		return fmt.Errorf("failed to apply rollout: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+	release.RolloutPercentage = percentage
+	return s.rolloutChanged(ctx, release, percentage, "scheduler")
+}
+
+// GetByID retrieves a release of the app by ID.
+func (s *ReleaseService) GetByID(ctx context.Context, appID, id uuid.UUID) (*models.Release, error) {
+	release, err := s.getRelease(appID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mintDownloadURL(ctx, release); err != nil {
+		return nil, err
+	}
+	return release, nil
 }
 
 // List retrieves releases with filters and pagination.
-func (s *ReleaseService) List(appID uuid.UUID, channel string, isActive *bool, page, perPage int) ([]models.Release, int64, error) {
+func (s *ReleaseService) List(ctx context.Context, appID uuid.UUID, channel, status string, isActive, scheduled *bool, page, perPage int) ([]models.Release, int64, error) {
 	if page < 1 {
 		page = 1
 	}
 	if perPage < 1 || perPage > 100 {
 		perPage = 20
 	}
-	return s.repo.List(appID, channel, isActive, page, perPage)
+	releases, total, err := s.repo.List(appID, channel, status, isActive, scheduled, page, perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range releases {
+		if err := s.mintDownloadURL(ctx, &releases[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return releases, total, nil
+}
+
+// mintDownloadURL sets the URL the release's stored bundle can be downloaded from right now, so the CLI
+// can fetch earlier bundles to diff against. Without object storage the release has none.
+func (s *ReleaseService) mintDownloadURL(ctx context.Context, release *models.Release) error {
+	if s.storage == nil || release.BundleKey == "" {
+		return nil
+	}
+	url, err := s.storage.DownloadURL(ctx, release.BundleKey)
+	if err != nil {
+		return fmt.Errorf("failed to generate download URL: %w", err)
+	}
+	release.DownloadURL = url
+	return nil
 }
 
 // Rollback designates a previous version as the active release for a channel.
-func (s *ReleaseService) Rollback(releaseID uuid.UUID) (*models.Release, error) {
-	release, err := s.repo.GetByID(releaseID)
+func (s *ReleaseService) Rollback(appID, releaseID uuid.UUID) (*models.Release, error) {
+	release, err := s.getRelease(appID, releaseID)
 	if err != nil {
-		return nil, fmt.Errorf("release not found: %w", err)
+		return nil, err
+	}
+	if !wasPublished(release.Status) {
+		return nil, fmt.Errorf("%w: a %s release cannot be rolled back to", ErrInvalidTransition, release.Status)
+	}
+	// Reactivated as it is, a halted release would reach no new devices and never be gated again
+	if release.HaltedAt != nil {
+		return nil, fmt.Errorf("%w: version %s was halted by the health gate and cannot be rolled back to until the halt is cleared", ErrInvalidTransition, release.Version)
 	}
 
-	// Deactivate all releases in this channel
-	if err := s.repo.DeactivatePreviousReleases(release.AppID, release.Channel, releaseID); err != nil {
-		return nil, fmt.Errorf("failed to deactivate releases: %w", err)
+	// Deactivate all releases in this channel targeting the same native builds
+	if err := s.deactivateLane(release, releaseID, "system", fmt.Sprintf("Rolled back to %s", release.Version)); err != nil {
+		return nil, err
 	}
 
 	// Reactivate the target release
@@ -189,7 +815,19 @@ func (s *ReleaseService) Rollback(releaseID uuid.UUID) (*models.Release, error)
 		return nil, fmt.Errorf("failed to activate release: %w", err)
 	}
 
+	// Every higher version is pulled so devices running it are reverted
+	if err := s.markRolledBack(release, false); err != nil {
+		return nil, err
+	}
+	if release.RolledBackAt != nil {
+		if err := s.repo.ClearRolledBack(releaseID); err != nil {
+			return nil, fmt.Errorf("failed to reinstate release: %w", err)
+		}
+	}
+
 	release.IsActive = true
+	release.RolledBackAt = nil
+	s.lifecycle.record(release, liveStatus(release.RolloutPercentage), "system", "Reinstated by rollback")
 
 	// Dispatch webhook
 	s.settingsService.DispatchEvent(release.AppID, "release.rolled_back", release)
@@ -203,48 +841,360 @@ func (s *ReleaseService) Rollback(releaseID uuid.UUID) (*models.Release, error)
 	return release, nil
 }
 
-// UpdateRollout changes the rollout percentage for a release.
-func (s *ReleaseService) UpdateRollout(ctx context.Context, releaseID uuid.UUID, percentage int) error {
-	release, err := s.repo.GetByID(releaseID)
+// RollbackToEmbedded pulls a release (and every higher version) without reinstating an older OTA release.
+// The channel is left without an active release and devices on the pulled versions revert to their embedded bundle.
+func (s *ReleaseService) RollbackToEmbedded(appID, releaseID uuid.UUID) (*models.Release, error) {
+	release, err := s.getRelease(appID, releaseID)
 	if err != nil {
-		return fmt.Errorf("release not found: %w", err)
+		return nil, err
+	}
+
+	// Deactivate every release in this channel targeting the same native builds
+	if err := s.deactivateLane(release, uuid.Nil, "system", fmt.Sprintf("Channel reverted to the embedded bundle from %s", release.Version)); err != nil {
+		return nil, err
+	}
+
+	if err := s.markRolledBack(release, true); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	release.IsActive = false
+	release.RolledBackAt = &now
+
+	// Dispatch webhook
+	s.settingsService.DispatchEvent(release.AppID, "release.rolled_back", release)
+
+	// Log audit trail
+	s.securityService.Log(release.AppID, "system", "release.rollback", release.ID.String(), fmt.Sprintf("Pulled Version: %s, Target: embedded bundle", release.Version), "")
+
+	// Invalidate cache
+	s.invalidateCache(context.Background(), release.AppID, release.Channel)
+
+	return release, nil
+}
+
+// markRolledBack flags every release in the same channel and native version target whose version
+// is above the given release (or equal to it, when inclusive) as rolled back. Drafts, rejected and
+// archived releases are left alone.
+func (s *ReleaseService) markRolledBack(release *models.Release, inclusive bool) error {
+	lane, err := s.repo.ListLane(release.AppID, release.Channel, release.TargetNativeVersion, pulledStatuses)
+	if err != nil {
+		return fmt.Errorf("failed to load releases: %w", err)
+	}
+
+	var pulled []*models.Release
+	var ids []uuid.UUID
+	for i := range lane {
+		cmp := compareVersions(lane[i].Version, release.Version)
+		if cmp > 0 || (inclusive && cmp == 0) {
+			pulled = append(pulled, &lane[i])
+			ids = append(ids, lane[i].ID)
+		}
+	}
+
+	if err := s.repo.MarkRolledBack(ids); err != nil {
+		return fmt.Errorf("failed to mark rolled back releases: %w", err)
+	}
+	for _, r := range pulled {
+		s.lifecycle.record(r, models.ReleaseRolledBack, "system", fmt.Sprintf("Rolled back from %s", release.Version))
+		if r.ID == release.ID {
+			release.Status = r.Status
+		}
+	}
+	return nil
+}
+
+// UpdateRollout changes the rollout percentage for a release. While the release's channel is in a
+// freeze window the change is rejected, or recorded as pending when the channel defers changes.
+func (s *ReleaseService) UpdateRollout(ctx context.Context, appID, releaseID uuid.UUID, percentage int) (*models.Release, error) {
+	release, err := s.getRelease(appID, releaseID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Check tier
 	app, err := s.settingsService.GetApp(release.AppID)
 	if err != nil {
-		return fmt.Errorf("app not found: %w", err)
+		return nil, fmt.Errorf("app not found: %w", err)
 	}
 
 	if app.Tier == "free" && percentage < 100 {
-		return fmt.Errorf("phased rollout is a Pro feature. Free apps must stay at 100%%")
+		return nil, fmt.Errorf("phased rollout is a Pro feature. Free apps must stay at 100%%")
+	}
+
+	freeze, err := s.freezeService.Check(release.AppID, release.Channel, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if freeze != nil {
+		if freeze.Action != models.FreezeActionDefer {
+			return nil, frozenError(freeze)
+		}
+		if err := s.repo.SetPendingRollout(appID, releaseID, percentage); err != nil {
+			return nil, fmt.Errorf("failed to defer rollout: %w", err)
+		}
+		release.PendingRollout = &percentage
+		s.securityService.Log(release.AppID, "system", "release.rollout_deferred", releaseID.String(), fmt.Sprintf("Rollout to %d%% deferred until %s", percentage, freeze.Until.UTC().Format(time.RFC3339)), "")
+
+		// Keep a running plan from moving past the operator's choice once the freeze lifts
+		s.rolloutService.PauseForManualOverride(appID, releaseID, percentage)
+		return release, nil
+	}
+
+	if err := s.repo.UpdateRollout(appID, releaseID, percentage); err != nil {
+		return nil, err
+	}
+	if release.PendingRollout != nil {
+		// A change made after the freeze replaces the one deferred during it
+		if err := s.repo.ClearPendingRollout(releaseID); err != nil {
+			return nil, fmt.Errorf("failed to clear deferred rollout: %w", err)
+		}
+		release.PendingRollout = nil
+	}
+	release.RolloutPercentage = percentage
+
+	if err := s.rolloutChanged(ctx, release, percentage, "system"); err != nil {
+		return nil, err
 	}
+	return release, nil
+}
 
+// rolloutChanged follows up a manual rollout change that has been written to the release.
+func (s *ReleaseService) rolloutChanged(ctx context.Context, release *models.Release, percentage int, actor string) error {
 	s.invalidateCache(ctx, release.AppID, release.Channel)
 
 	// Log audit trail
-	s.securityService.Log(release.AppID, "system", "release.update_rollout", releaseID.String(), fmt.Sprintf("Rollout set to %d%%", percentage), "")
+	s.securityService.Log(release.AppID, actor, "release.update_rollout", release.ID.String(), fmt.Sprintf("Rollout set to %d%%", percentage), "")
+
+	// Setting the rollout of a halted release by hand is the operator's call that it is healthy again
+	if release.HaltedAt != nil {
+		if err := s.repo.ClearHalt(release.ID, time.Now()); err != nil {
+			return fmt.Errorf("failed to clear halt: %w", err)
+		}
+		release.HaltedAt = nil
+		s.securityService.Log(release.AppID, actor, "release.halt_cleared", release.ID.String(), fmt.Sprintf("Was halted: %s", release.HaltReason), "")
+	}
+	s.lifecycle.settle(release, actor, fmt.Sprintf("Rollout set to %d%%", percentage))
 
-	return s.repo.UpdateRollout(releaseID, percentage)
+	// A manual change takes over from any running rollout plan
+	s.rolloutService.PauseForManualOverride(release.AppID, release.ID, percentage)
+	return nil
+}
+
+// ReshuffleCohort gives a release a new random salt, so its rollout percentage selects a fresh set of devices.
+// Devices that already installed the release keep it.
+func (s *ReleaseService) ReshuffleCohort(ctx context.Context, appID, releaseID uuid.UUID) error {
+	release, err := s.getRelease(appID, releaseID)
+	if err != nil {
+		return err
+	}
+
+	// A new cohort changes who receives the release, so freeze windows reject it
+	freeze, err := s.freezeService.Check(release.AppID, release.Channel, time.Now())
+	if err != nil {
+		return err
+	}
+	if freeze != nil {
+		return frozenError(freeze)
+	}
+
+	salt, err := newRolloutSalt()
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdateRolloutSalt(appID, releaseID, salt); err != nil {
+		return fmt.Errorf("failed to update rollout salt: %w", err)
+	}
+
+	s.invalidateCache(ctx, release.AppID, release.Channel)
+	s.securityService.Log(release.AppID, "system", "release.reshuffle_cohort", releaseID.String(), "", "")
+	return nil
+}
+
+// newRolloutSalt generates a random per-release salt for cohort bucketing.
+func newRolloutSalt() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate rollout salt: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
 }
 
-// Archive soft-deletes a release.
-func (s *ReleaseService) Archive(ctx context.Context, releaseID uuid.UUID) error {
-	release, err := s.repo.GetByID(releaseID)
-	if err == nil {
-		s.invalidateCache(ctx, release.AppID, release.Channel)
+// Archive retires a release: it stops being served, is never published and drops any deferred rollout change.
+func (s *ReleaseService) Archive(ctx context.Context, appID, releaseID uuid.UUID) error {
+	release, err := s.getRelease(appID, releaseID)
+	if err != nil {
+		return err
+	}
+	if err := s.archive(ctx, release, "system", ""); err != nil {
+		return err
 	}
+
 	// Log audit trail
 	s.securityService.Log(release.AppID, "system", "release.archive", releaseID.String(), "", "")
+	return nil
+}
+
+func (s *ReleaseService) archive(ctx context.Context, release *models.Release, actor, reason string) error {
+	err := s.lifecycle.transition(release, models.ReleaseArchived, actor, reason, map[string]interface{}{
+		"is_active":       false,
+		"publish_at":      nil,
+		"pending_rollout": nil,
+	})
+	if err != nil {
+		return err
+	}
+	release.IsActive = false
+	release.PublishAt = nil
+	release.PendingRollout = nil
+	s.invalidateCache(ctx, release.AppID, release.Channel)
+	return nil
+}
 
-	return s.repo.SoftDelete(releaseID)
+// publishedStatuses are the statuses of releases that have been live at some point. Only these make up a lane:
+// drafts, releases awaiting approval or their publish time, rejected and archived ones never reached a device.
+var publishedStatuses = []string{models.ReleaseRollingOut, models.ReleaseCompleted, models.ReleasePaused, models.ReleaseHalted, models.ReleaseSuperseded, models.ReleaseRolledBack}
+
+// pulledStatuses are the statuses of releases a rollback pulls: the published ones, and those that would go live
+// on their own once approved or due and so undo it.
+var pulledStatuses = append([]string{models.ReleasePendingApproval, models.ReleaseScheduled}, publishedStatuses...)
+
+// wasPublished reports whether a release in the given status has been live at some point,
+// which makes it a valid rollback target.
+func wasPublished(status string) bool {
+	return isLive(status) || status == models.ReleaseSuperseded || status == models.ReleaseRolledBack
+}
+
+// SetStatus moves a release through the part of its lifecycle operators control: publishing or
+// scheduling a draft (submitting it for approval when its channel requires approvals), unscheduling,
+// pausing and resuming a live release, and archiving. Halts, supersession and rollbacks are driven
+// by the health gate, new uploads and rollbacks instead.
+func (s *ReleaseService) SetStatus(ctx context.Context, appID, releaseID uuid.UUID, req *models.SetReleaseStatusRequest, actor, ip string) (*models.Release, error) {
+	release, err := s.getRelease(appID, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	from := release.Status
+
+	// A draft holding secrets its channel does not publish can only be archived
+	if from == models.ReleaseDraft && req.Status != models.ReleaseDraft && req.Status != models.ReleaseArchived {
+		if err := s.checkReleaseSecrets(release); err != nil {
+			return nil, err
+		}
+	}
+
+	switch req.Status {
+	case models.ReleaseDraft:
+		if from != models.ReleaseScheduled {
+			return nil, fmt.Errorf("%w: only a scheduled release can return to draft", ErrInvalidTransition)
+		}
+		err = s.lifecycle.transition(release, models.ReleaseDraft, actor, req.Reason, map[string]interface{}{"publish_at": nil})
+		release.PublishAt = nil
+
+	case models.ReleaseScheduled:
+		if from != models.ReleaseDraft {
+			return nil, fmt.Errorf("%w: only a draft can be scheduled", ErrInvalidTransition)
+		}
+		if req.PublishAt == nil || !req.PublishAt.After(time.Now()) {
+			return nil, fmt.Errorf("%w: publish_at must be in the future", ErrInvalidSchedule)
+		}
+		// In a channel that requires approvals the draft goes into review and keeps its publish time
+		var required int
+		if required, err = s.requiredApprovals(appID, release.Channel); err != nil {
+			return nil, err
+		}
+		if required > 0 {
+			err = s.submitForApproval(release, required, req.PublishAt, actor, req.Reason)
+			break
+		}
+		err = s.lifecycle.transition(release, models.ReleaseScheduled, actor, req.Reason, map[string]interface{}{"publish_at": *req.PublishAt})
+		release.PublishAt = req.PublishAt
+
+	case models.ReleaseRollingOut, models.ReleaseCompleted:
+		switch from {
+		case models.ReleaseDraft:
+			var required int
+			if required, err = s.requiredApprovals(appID, release.Channel); err != nil {
+				return nil, err
+			}
+			if required > 0 {
+				err = s.submitForApproval(release, required, nil, actor, req.Reason)
+			} else {
+				err = s.publishNow(ctx, release, actor, req.Reason)
+			}
+		case models.ReleaseScheduled:
+			err = s.publishNow(ctx, release, actor, req.Reason)
+		case models.ReleasePaused:
+			// Resuming picks up at the current rollout percentage
+			err = s.lifecycle.transition(release, liveStatus(release.RolloutPercentage), actor, req.Reason, nil)
+			s.invalidateCache(ctx, release.AppID, release.Channel)
+		default:
+			return nil, fmt.Errorf("%w: a %s release cannot be published or resumed", ErrInvalidTransition, from)
+		}
+
+	case models.ReleasePaused:
+		if from != models.ReleaseRollingOut && from != models.ReleaseCompleted {
+			return nil, fmt.Errorf("%w: a %s release cannot be paused", ErrInvalidTransition, from)
+		}
+		if err = s.lifecycle.transition(release, models.ReleasePaused, actor, req.Reason, nil); err == nil {
+			// New devices stop getting the release, so its plan must not keep raising the percentage
+			s.rolloutService.pauseIfRunning(appID, releaseID, actor, "Paused with its release")
+			s.invalidateCache(ctx, release.AppID, release.Channel)
+		}
+
+	case models.ReleaseArchived:
+		err = s.archive(ctx, release, actor, req.Reason)
+
+	default:
+		return nil, fmt.Errorf("%w: %s is set by the server", ErrInvalidTransition, req.Status)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := fmt.Sprintf("%s -> %s", from, release.Status)
+	if req.Reason != "" {
+		metadata += ", reason: " + req.Reason
+	}
+	s.securityService.Log(appID, actor, "release.status", releaseID.String(), metadata, ip)
+	return release, nil
+}
+
+// publishNow makes a draft or scheduled release live immediately. Unlike the scheduler, it refuses
+// rather than waits when the channel is frozen or a newer version has gone live in the meantime.
+func (s *ReleaseService) publishNow(ctx context.Context, release *models.Release, actor, reason string) error {
+	freeze, err := s.freezeService.Check(release.AppID, release.Channel, time.Now())
+	if err != nil {
+		return err
+	}
+	if freeze != nil {
+		return frozenError(freeze)
+	}
+
+	newer, err := s.overtakenBy(release)
+	if err != nil {
+		return err
+	}
+	if newer != nil {
+		return fmt.Errorf("%w: version %s is already live in %s", ErrInvalidTransition, newer.Version, release.Channel)
+	}
+	return s.activate(ctx, release, actor, reason, time.Now())
+}
+
+// History returns the lifecycle transitions of a release, oldest first.
+func (s *ReleaseService) History(appID, releaseID uuid.UUID) ([]models.ReleaseTransition, error) {
+	if _, err := s.getRelease(appID, releaseID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListTransitions(appID, releaseID)
 }
 
 // AddPatch uploads a patch file and associates it with a release.
-func (s *ReleaseService) AddPatch(ctx context.Context, releaseID uuid.UUID, req *models.AddPatchRequest, patchFile io.Reader) (*models.Patch, error) {
-	release, err := s.repo.GetByID(releaseID)
+func (s *ReleaseService) AddPatch(ctx context.Context, appID, releaseID uuid.UUID, req *models.AddPatchRequest, patchFile io.Reader, actor, ip string) (*models.Patch, error) {
+	release, err := s.getRelease(appID, releaseID)
 	if err != nil {
-		return nil, fmt.Errorf("release not found: %w", err)
+		return nil, err
 	}
 
 	// Check tier
@@ -257,29 +1207,77 @@ func (s *ReleaseService) AddPatch(ctx context.Context, releaseID uuid.UUID, req
 		return nil, fmt.Errorf("differential patching is a Pro feature. Current tier: %s", app.Tier)
 	}
 
-	// Upload patch to S3
-	objectKey := fmt.Sprintf("patches/%s/%s/from-%s.patch", release.AppID, release.ID, req.BaseVersion)
-	_, err = s.storage.Upload(ctx, objectKey, patchFile, "application/octet-stream")
+	if err := validateVersion(req.BaseVersion); err != nil {
+		return nil, err
+	}
+	if compareVersions(req.BaseVersion, release.Version) >= 0 {
+		return nil, fmt.Errorf("%w: patch base version %s must be lower than release version %s", ErrInvalidVersion, req.BaseVersion, release.Version)
+	}
+
+	base, err := s.patchBase(release, req)
+	if err != nil {
+		return nil, err
+	}
+
+	sizeLimit := s.bundleSizeLimit(app.Tier)
+	if req.Size > sizeLimit {
+		return nil, bundleTooLarge(sizeLimit)
+	}
+	budget, err := s.sizeBudget(release.AppID, release.Channel)
 	if err != nil {
-		return nil, fmt.Errorf("failed to upload patch: %w", err)
+		return nil, err
+	}
+	// Patches are held to the channel's maximum size only; growth is measured between full bundles
+	if _, err := budget.enforce("patch", req.Size, nil, req.OverrideSizeBudget); err != nil {
+		return nil, err
 	}
 
-	// Generate CDN URL
-	patchURL, err := s.storage.GetPresignedURL(ctx, objectKey)
+	// Upload patch to S3, checking it against the declared hash and signature on the way and spooling a
+	// copy to disk to apply to the base bundle. The key is unique per patch so a rejected upload never
+	// touches a patch already stored for the base version.
+	check, err := s.newArtifactCheck(release.AppID, req.Hash, req.Signature)
+	if err != nil {
+		return nil, err
+	}
+	spool, err := newBundleSpool()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate patch URL: %w", err)
+		return nil, err
+	}
+	defer spool.Close()
+	patchID := uuid.New()
+	objectKey := fmt.Sprintf("patches/%s/%s/from-%s-%s.patch", release.AppID, release.ID, req.BaseVersion, patchID)
+	size, err := s.uploadBundle(ctx, objectKey, "application/octet-stream", patchFile, sizeLimit, "", io.MultiWriter(check, spool))
+	if err != nil {
+		return nil, err
+	}
+	signingKeyID, err := verifySpooled(check, spool)
+	if err != nil {
+		s.discardObject(ctx, objectKey)
+		return nil, err
+	}
+	overBudget, err := budget.enforce("patch", size, nil, req.OverrideSizeBudget)
+	if err != nil {
+		s.discardObject(ctx, objectKey)
+		return nil, err
+	}
+	if err := s.verifySpooledPatch(ctx, base, release, app.EncryptionKey, spool, sizeLimit); err != nil {
+		s.discardObject(ctx, objectKey)
+		return nil, err
 	}
 
 	// Create patch record
 	patch := &models.Patch{
-		ID:          uuid.New(),
-		ReleaseID:   releaseID,
-		BaseVersion: req.BaseVersion,
-		PatchURL:    patchURL,
-		Hash:        req.Hash,
-		Signature:   req.Signature,
-		Size:        req.Size,
-		CreatedAt:   time.Now(),
+		ID:           patchID,
+		ReleaseID:    releaseID,
+		BaseVersion:  req.BaseVersion,
+		BaseHash:     strings.ToLower(base.Hash),
+		PatchKey:     objectKey,
+		Hash:         check.SHA256(),
+		Signature:    req.Signature,
+		SigningKeyID: &signingKeyID,
+		Verified:     true,
+		Size:         size,
+		CreatedAt:    time.Now(),
 	}
 
 	if err := s.repo.CreatePatch(patch); err != nil {
@@ -287,7 +1285,10 @@ func (s *ReleaseService) AddPatch(ctx context.Context, releaseID uuid.UUID, req
 	}
 
 	// Log audit trail
-	s.securityService.Log(release.AppID, "system", "release.add_patch", patch.ID.String(), fmt.Sprintf("For Version: %s, Base Version: %s", release.Version, req.BaseVersion), "")
+	s.securityService.Log(release.AppID, actor, "release.add_patch", patch.ID.String(), fmt.Sprintf("For Version: %s, Base Version: %s", release.Version, req.BaseVersion), ip)
+	if overBudget != nil {
+		s.securityService.Log(release.AppID, actor, "release.size_budget_override", patch.ID.String(), overBudget.Error(), ip)
+	}
 
 	// Invalidate cache since active release now has a new patch
 	s.invalidateCache(ctx, release.AppID, release.Channel)
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/config"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+	"github.com/hotpatch/server/internal/storage"
+)
+
+// ── Promotion Tests ─────────────────────────────────────────
+
+// releaseTest holds app A's release service and the analytics read back from its releases.
+type releaseTest struct {
+	tenants
+	releaseService   *ReleaseService
+	analyticsService *AnalyticsService
+}
+
+func newReleaseTest(t *testing.T) *releaseTest {
+	t.Helper()
+	tn := newTenants(t, &models.Channel{}, &models.ReleaseTransition{}, &models.Patch{}, &models.PatchJob{}, &models.BundleManifest{}, &models.SecretFinding{}, &models.RolloutPlan{}, &models.Device{}, &models.Installation{}, &models.AuditLog{})
+	releaseService, _ := newReleaseService(tn, PatchGeneration{})
+	analyticsService := NewAnalyticsService(repository.NewAnalyticsRepository(tn.db), repository.NewDeviceRepository(tn.db), releaseService.repo)
+	return &releaseTest{tenants: tn, releaseService: releaseService, analyticsService: analyticsService}
+}
+
+// stagingRelease creates an active staging release of app A with one patch.
+func stagingRelease(t *testing.T, tn tenants, version string) models.Release {
+	t.Helper()
+	release := models.Release{
+		ID: uuid.New(), AppID: tn.appA, Version: version, Channel: "staging", Hash: "s", Signature: "sig",
+		BundleKey: "bundles/staging/" + version + ".zip", Size: 1024, Mandatory: true, RolloutPercentage: 100, IsActive: true,
+	}
+	if err := tn.db.Create(&release).Error; err != nil {
+		t.Fatalf("failed to create release: %v", err)
+	}
+	patch := models.Patch{ID: uuid.New(), ReleaseID: release.ID, BaseVersion: "0.5.0", PatchKey: "patches/staging/from-0.5.0.patch", Hash: "p", Signature: "psig", Size: 64}
+	if err := tn.db.Create(&patch).Error; err != nil {
+		t.Fatalf("failed to create patch: %v", err)
+	}
+	return release
+}
+
+func TestPromote_ReusesStoredBundleAndPatches(t *testing.T) {
+	f := newReleaseTest(t)
+	ctx := context.Background()
+	source := stagingRelease(t, f.tenants, "1.1.0")
+	manifest := models.BundleManifest{ReleaseID: source.ID, EntryFile: "index.android.bundle", FileCount: 1, Files: []models.BundleFile{{Path: "index.android.bundle", Size: 10, Hash: "b", Kind: models.BundleFileBundle}}}
+	if err := f.db.Create(&manifest).Error; err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+
+	promoted, err := f.releaseService.Promote(ctx, f.appA, source.ID, &models.PromoteReleaseRequest{Channel: "production", RolloutPercentage: 10}, "alice", "")
+	if err != nil {
+		t.Fatalf("Promote failed: %v", err)
+	}
+	if promoted.ID == source.ID || promoted.Channel != "production" || promoted.RolloutPercentage != 10 || !promoted.IsActive {
+		t.Errorf("promoted release = %+v, want a new active production release at 10%%", promoted)
+	}
+	if promoted.BundleKey != source.BundleKey || promoted.Hash != source.Hash || promoted.Signature != source.Signature || !promoted.Mandatory {
+		t.Errorf("promoted release does not reuse the source artifact: %+v", promoted)
+	}
+	if promoted.PromotedFrom == nil || *promoted.PromotedFrom != source.ID {
+		t.Errorf("promoted_from = %v, want %s", promoted.PromotedFrom, source.ID)
+	}
+
+	var patches []models.Patch
+	if err := f.db.Where("release_id = ?", promoted.ID).Find(&patches).Error; err != nil {
+		t.Fatalf("failed to load patches: %v", err)
+	}
+	if len(patches) != 1 || patches[0].PatchKey != "patches/staging/from-0.5.0.patch" {
+		t.Errorf("promoted patches = %+v, want the source's patch", patches)
+	}
+	if got, err := f.releaseService.Manifest(f.appA, promoted.ID); err != nil || len(got.Files) != 1 || got.Files[0].Hash != "b" {
+		t.Errorf("promoted manifest = %+v, %v; want the source's", got, err)
+	}
+
+	// The promoted release supersedes production; staging keeps its own copy
+	if f.release(t, f.releaseA).IsActive {
+		t.Error("previous production release still active after promotion")
+	}
+	if !f.release(t, source.ID).IsActive {
+		t.Error("source release deactivated by promotion")
+	}
+
+	details, err := f.analyticsService.GetReleaseDetails(ctx, f.appA, promoted.ID)
+	if err != nil {
+		t.Fatalf("GetReleaseDetails failed: %v", err)
+	}
+	if details.PromotedFrom == nil || *details.PromotedFrom != source.ID.String() {
+		t.Errorf("analytics promoted_from = %v, want %s", details.PromotedFrom, source.ID)
+	}
+}
+
+func TestPromote_Rejections(t *testing.T) {
+	f := newReleaseTest(t)
+	ctx := context.Background()
+	source := stagingRelease(t, f.tenants, "1.1.0")
+	older := stagingRelease(t, f.tenants, "0.9.0")
+	toProduction := &models.PromoteReleaseRequest{Channel: "production"}
+
+	if _, err := f.releaseService.Promote(ctx, f.appA, source.ID, &models.PromoteReleaseRequest{Channel: "staging"}, "alice", ""); !errors.Is(err, ErrInvalidPromotion) {
+		t.Errorf("promoting to the same channel: err = %v, want ErrInvalidPromotion", err)
+	}
+	if _, err := f.releaseService.Promote(ctx, f.appA, older.ID, toProduction, "alice", ""); !errors.Is(err, ErrInvalidPromotion) {
+		t.Errorf("promoting an older version: err = %v, want ErrInvalidPromotion", err)
+	}
+	if _, err := f.releaseService.Promote(ctx, f.appB, source.ID, toProduction, "alice", ""); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("promoting another app's release: err = %v, want ErrReleaseNotFound", err)
+	}
+
+	freezeChannel(t, f.tenants, models.FreezeActionReject)
+	if _, err := f.releaseService.Promote(ctx, f.appA, source.ID, toProduction, "alice", ""); !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("promoting into a frozen channel: err = %v, want ErrChannelFrozen", err)
+	}
+
+	f.db.Model(&models.Channel{}).Where("app_id = ?", f.appA).Update("freeze_windows", "[]")
+	if _, err := f.releaseService.Promote(ctx, f.appA, source.ID, toProduction, "alice", ""); err != nil {
+		t.Fatalf("Promote failed: %v", err)
+	}
+	if _, err := f.releaseService.Promote(ctx, f.appA, source.ID, toProduction, "alice", ""); !errors.Is(err, ErrVersionExists) {
+		t.Errorf("promoting twice: err = %v, want ErrVersionExists", err)
+	}
+}
+
+// ── Download URL Tests ──────────────────────────────────────
+
+func TestReleaseReads_MintDownloadURLs(t *testing.T) {
+	f := newReleaseTest(t)
+	ctx := context.Background()
+	store, err := storage.NewS3Storage(&config.Config{S3Region: "auto", S3Bucket: "bundles", CDNBaseURL: "https://cdn.example.com/"})
+	if err != nil {
+		t.Fatalf("NewS3Storage failed: %v", err)
+	}
+	f.releaseService.storage = store
+	release := channelRelease(t, f.tenants, "2.0.0", models.ReleaseCompleted, 0)
+
+	got, err := f.releaseService.GetByID(ctx, f.appA, release.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if want := "https://cdn.example.com/" + release.BundleKey; got.DownloadURL != want {
+		t.Errorf("GetByID download URL = %q, want %q", got.DownloadURL, want)
+	}
+
+	releases, _, err := f.releaseService.List(ctx, f.appA, "production", "", nil, nil, 1, 20)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	for _, r := range releases {
+		if r.BundleKey != "" && r.DownloadURL != "https://cdn.example.com/"+r.BundleKey {
+			t.Errorf("List download URL of %s = %q", r.Version, r.DownloadURL)
+		}
+	}
+}
@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidRolloutPlan is returned when a rollout plan's steps are malformed.
+	ErrInvalidRolloutPlan = errors.New("invalid rollout plan")
+	// ErrRolloutPlanNotFound is returned when a release has no rollout plan.
+	ErrRolloutPlanNotFound = errors.New("rollout plan not found")
+	// ErrRolloutPlanState is returned when an action does not apply to the plan's current state.
+	ErrRolloutPlanState = errors.New("rollout plan cannot be changed in its current state")
+)
+
+// rolloutBatchSize caps how many due plans a single scheduler tick advances.
+const rolloutBatchSize = 100
+
+// RolloutService drives staged rollout plans: it attaches plans to releases, advances them
+// on schedule, and handles manual pause/resume/skip.
+type RolloutService struct {
+	repo            *repository.RolloutRepository
+	releaseRepo     *repository.ReleaseRepository
+	settingsService *SettingsService
+	securityService *SecurityService
+	freezeService   *FreezeService
+	lifecycle       *ReleaseLifecycle
+	redis           *redis.Client
+}
+
+// NewRolloutService creates a new RolloutService.
+func NewRolloutService(repo *repository.RolloutRepository, releaseRepo *repository.ReleaseRepository, settingsService *SettingsService, securityService *SecurityService, freezeService *FreezeService, lifecycle *ReleaseLifecycle, redis *redis.Client) *RolloutService {
+	return &RolloutService{repo: repo, releaseRepo: releaseRepo, settingsService: settingsService, securityService: securityService, freezeService: freezeService, lifecycle: lifecycle, redis: redis}
+}
+
+func (s *RolloutService) invalidateCache(ctx context.Context, appID uuid.UUID, channel string) {
+	if s.redis != nil {
+		s.redis.Del(ctx, activeReleaseCacheKey(appID, channel))
+	}
+}
+
+// validateRolloutPlan checks that steps ramp up strictly between 1% and 100%.
+func validateRolloutPlan(steps []models.RolloutStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("%w: at least one step is required", ErrInvalidRolloutPlan)
+	}
+	if len(steps) > 20 {
+		return fmt.Errorf("%w: at most 20 steps are allowed", ErrInvalidRolloutPlan)
+	}
+	for i, step := range steps {
+		if step.Percentage < 1 || step.Percentage > 100 {
+			return fmt.Errorf("%w: step %d percentage must be between 1 and 100", ErrInvalidRolloutPlan, i+1)
+		}
+		if step.HoldMinutes < 0 {
+			return fmt.Errorf("%w: step %d hold must not be negative", ErrInvalidRolloutPlan, i+1)
+		}
+		if i > 0 && step.Percentage <= steps[i-1].Percentage {
+			return fmt.Errorf("%w: step %d percentage must be greater than the previous step", ErrInvalidRolloutPlan, i+1)
+		}
+	}
+	return nil
+}
+
+// stepSchedule returns the plan status and next advancement time once step is reached at the given time.
+// The final step completes the plan; every other step is held for its HoldMinutes.
+func stepSchedule(steps []models.RolloutStep, step int, at time.Time) (string, *time.Time) {
+	if step >= len(steps)-1 {
+		return models.RolloutPlanCompleted, nil
+	}
+	next := at.Add(time.Duration(steps[step].HoldMinutes) * time.Minute)
+	return models.RolloutPlanActive, &next
+}
+
+// StartPlan attaches a plan to a release and applies its first step.
+// The release's rollout percentage must already be set to the first step's percentage.
+func (s *RolloutService) StartPlan(ctx context.Context, release *models.Release, steps []models.RolloutStep, actor string) (*models.RolloutPlan, error) {
+	now := time.Now()
+	status, nextAt := stepSchedule(steps, 0, now)
+	plan := &models.RolloutPlan{
+		ID:            uuid.New(),
+		AppID:         release.AppID,
+		ReleaseID:     release.ID,
+		Steps:         steps,
+		CurrentStep:   0,
+		Status:        status,
+		StepStartedAt: now,
+		NextStepAt:    nextAt,
+	}
+	if err := s.repo.SavePlan(plan); err != nil {
+		return nil, fmt.Errorf("failed to save rollout plan: %w", err)
+	}
+
+	s.securityService.Log(release.AppID, actor, "release.rollout_plan_set", release.ID.String(), fmt.Sprintf("%d steps, starting at %d%%", len(steps), steps[0].Percentage), "")
+	s.notifyStep(release, plan)
+	return plan, nil
+}
+
+// SchedulePlan attaches a plan to a release that is not yet published. The plan waits, without
+// advancing, until StartScheduledPlan is called when the release goes live.
+func (s *RolloutService) SchedulePlan(ctx context.Context, release *models.Release, steps []models.RolloutStep, actor string) (*models.RolloutPlan, error) {
+	plan := &models.RolloutPlan{
+		ID:            uuid.New(),
+		AppID:         release.AppID,
+		ReleaseID:     release.ID,
+		Steps:         steps,
+		CurrentStep:   0,
+		Status:        models.RolloutPlanScheduled,
+		StepStartedAt: time.Now(),
+	}
+	if err := s.repo.SavePlan(plan); err != nil {
+		return nil, fmt.Errorf("failed to save rollout plan: %w", err)
+	}
+
+	s.securityService.Log(release.AppID, actor, "release.rollout_plan_set", release.ID.String(), fmt.Sprintf("%d steps, starting at %d%% once published", len(steps), steps[0].Percentage), "")
+	return plan, nil
+}
+
+// StartScheduledPlan starts the scheduled plan of a release that was just published.
+// Releases without a scheduled plan are ignored.
+func (s *RolloutService) StartScheduledPlan(ctx context.Context, release *models.Release, actor string, now time.Time) error {
+	plan, err := s.GetPlan(release.AppID, release.ID)
+	if errors.Is(err, ErrRolloutPlanNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if plan.Status != models.RolloutPlanScheduled {
+		return nil
+	}
+
+	status, nextAt := stepSchedule(plan.Steps, 0, now)
+	ok, err := s.repo.Transition(plan, plan.CurrentStep, plan.Status, map[string]interface{}{
+		"status":          status,
+		"step_started_at": now,
+		"next_step_at":    nextAt,
+	}, 0)
+	if err != nil {
+		return fmt.Errorf("failed to start rollout plan: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: plan changed concurrently", ErrRolloutPlanState)
+	}
+	plan.Status = status
+	plan.StepStartedAt = now
+	plan.NextStepAt = nextAt
+
+	s.securityService.Log(release.AppID, actor, "release.rollout_step", release.ID.String(), fmt.Sprintf("Step 1/%d: rollout set to %d%%", len(plan.Steps), plan.Steps[0].Percentage), "")
+	s.notifyStep(release, plan)
+	return nil
+}
+
+// checkFreeze rejects a manual rollout change while the release's channel is frozen.
+func (s *RolloutService) checkFreeze(release *models.Release) error {
+	freeze, err := s.freezeService.Check(release.AppID, release.Channel, time.Now())
+	if err != nil {
+		return err
+	}
+	if freeze != nil {
+		return frozenError(freeze)
+	}
+	return nil
+}
+
+// SetPlan replaces the rollout plan of an active release and restarts it from the first step.
+func (s *RolloutService) SetPlan(ctx context.Context, appID, releaseID uuid.UUID, steps []models.RolloutStep, actor string) (*models.RolloutPlan, error) {
+	if err := validateRolloutPlan(steps); err != nil {
+		return nil, err
+	}
+	release, err := s.getRelease(appID, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if !release.IsActive {
+		return nil, fmt.Errorf("%w: release is not active", ErrRolloutPlanState)
+	}
+	if err := s.checkFreeze(release); err != nil {
+		return nil, err
+	}
+
+	// Staged rollouts are a Pro feature, like any rollout below 100%
+	app, err := s.settingsService.GetApp(appID)
+	if err != nil {
+		return nil, fmt.Errorf("app not found: %w", err)
+	}
+	if app.Tier == "free" && steps[0].Percentage < 100 {
+		return nil, fmt.Errorf("phased rollout (percentage < 100) is a Pro feature. Current tier: %s", app.Tier)
+	}
+
+	if err := s.releaseRepo.UpdateRollout(appID, releaseID, steps[0].Percentage); err != nil {
+		return nil, fmt.Errorf("failed to update rollout: %w", err)
+	}
+	release.RolloutPercentage = steps[0].Percentage
+	s.lifecycle.settle(release, actor, fmt.Sprintf("Rollout plan restarted at %d%%", steps[0].Percentage))
+	s.invalidateCache(ctx, appID, release.Channel)
+
+	return s.StartPlan(ctx, release, steps, actor)
+}
+
+// GetPlan returns the rollout plan of a release.
+func (s *RolloutService) GetPlan(appID, releaseID uuid.UUID) (*models.RolloutPlan, error) {
+	plan, err := s.repo.GetByRelease(appID, releaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRolloutPlanNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rollout plan: %w", err)
+	}
+	return plan, nil
+}
+
+// Pause stops the scheduler from advancing a plan.
+func (s *RolloutService) Pause(ctx context.Context, appID, releaseID uuid.UUID, actor string) (*models.RolloutPlan, error) {
+	return s.pause(appID, releaseID, actor, "Paused manually")
+}
+
+// PauseForManualOverride pauses the plan of a release whose rollout percentage was set by hand,
+// so the scheduler does not overwrite the operator's choice. Releases without a running plan are ignored.
+func (s *RolloutService) PauseForManualOverride(appID, releaseID uuid.UUID, percentage int) {
+	s.pauseIfRunning(appID, releaseID, "system", fmt.Sprintf("Paused by manual rollout change to %d%%", percentage))
+}
+
+// PauseForHealthGate pauses the plan of a release halted by its channel's health gate.
+// Releases without a running plan are ignored.
+func (s *RolloutService) PauseForHealthGate(appID, releaseID uuid.UUID, reason string) {
+	s.pauseIfRunning(appID, releaseID, "health_gate", "Paused by health gate: "+reason)
+}
+
+func (s *RolloutService) pauseIfRunning(appID, releaseID uuid.UUID, actor, reason string) {
+	_, err := s.pause(appID, releaseID, actor, reason)
+	if err != nil && !errors.Is(err, ErrRolloutPlanNotFound) && !errors.Is(err, ErrRolloutPlanState) {
+		log.Printf("rollout: failed to pause plan of release %s: %v", releaseID, err)
+	}
+}
+
+func (s *RolloutService) pause(appID, releaseID uuid.UUID, actor, reason string) (*models.RolloutPlan, error) {
+	plan, err := s.GetPlan(appID, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != models.RolloutPlanActive {
+		return nil, fmt.Errorf("%w: plan is %s", ErrRolloutPlanState, plan.Status)
+	}
+
+	ok, err := s.repo.Transition(plan, plan.CurrentStep, plan.Status, map[string]interface{}{
+		"status":       models.RolloutPlanPaused,
+		"next_step_at": nil,
+	}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pause rollout plan: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: plan changed concurrently", ErrRolloutPlanState)
+	}
+	plan.Status = models.RolloutPlanPaused
+	plan.NextStepAt = nil
+
+	s.securityService.Log(appID, actor, "release.rollout_pause", releaseID.String(), reason, "")
+	s.settingsService.DispatchEvent(appID, "release.rollout_paused", plan)
+	return plan, nil
+}
+
+// Resume restarts the scheduler on a paused plan. The current step's hold starts over.
+func (s *RolloutService) Resume(ctx context.Context, appID, releaseID uuid.UUID, actor string) (*models.RolloutPlan, error) {
+	plan, err := s.GetPlan(appID, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != models.RolloutPlanPaused {
+		return nil, fmt.Errorf("%w: plan is %s", ErrRolloutPlanState, plan.Status)
+	}
+	release, err := s.getRelease(appID, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if !release.IsActive {
+		return nil, fmt.Errorf("%w: release is not active", ErrRolloutPlanState)
+	}
+	if err := s.checkFreeze(release); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	status, nextAt := stepSchedule(plan.Steps, plan.CurrentStep, now)
+	ok, err := s.repo.Transition(plan, plan.CurrentStep, plan.Status, map[string]interface{}{
+		"status":       status,
+		"next_step_at": nextAt,
+	}, plan.Steps[plan.CurrentStep].Percentage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume rollout plan: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: plan changed concurrently", ErrRolloutPlanState)
+	}
+	plan.Status = status
+	plan.NextStepAt = nextAt
+	if release.HaltedAt != nil {
+		// Resuming is the operator's call that the release is healthy again
+		if err := s.releaseRepo.ClearHalt(releaseID, now); err != nil {
+			return nil, fmt.Errorf("failed to clear halt: %w", err)
+		}
+		release.HaltedAt = nil
+	}
+	release.RolloutPercentage = plan.Steps[plan.CurrentStep].Percentage
+	s.lifecycle.settle(release, actor, fmt.Sprintf("Rollout plan resumed at %d%%", release.RolloutPercentage))
+	s.invalidateCache(ctx, appID, release.Channel)
+
+	s.securityService.Log(appID, actor, "release.rollout_resume", releaseID.String(), fmt.Sprintf("Resumed at %d%%", plan.Steps[plan.CurrentStep].Percentage), "")
+	s.settingsService.DispatchEvent(appID, "release.rollout_resumed", plan)
+	return plan, nil
+}
+
+// Skip moves a plan to its next step immediately. A paused plan stays paused on the new step.
+func (s *RolloutService) Skip(ctx context.Context, appID, releaseID uuid.UUID, actor string) (*models.RolloutPlan, error) {
+	plan, err := s.GetPlan(appID, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != models.RolloutPlanActive && plan.Status != models.RolloutPlanPaused {
+		return nil, fmt.Errorf("%w: plan is %s", ErrRolloutPlanState, plan.Status)
+	}
+	release, err := s.getRelease(appID, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	if !release.IsActive {
+		return nil, fmt.Errorf("%w: release is not active", ErrRolloutPlanState)
+	}
+	if err := s.checkFreeze(release); err != nil {
+		return nil, err
+	}
+
+	skipped := plan.CurrentStep + 1
+	if err := s.advance(ctx, plan, release, actor, time.Now()); err != nil {
+		return nil, err
+	}
+	s.securityService.Log(appID, actor, "release.rollout_skip", releaseID.String(), fmt.Sprintf("Skipped the hold of step %d", skipped), "")
+	return plan, nil
+}
+
+// AdvanceDuePlans moves every plan whose hold has elapsed to its next step.
+// Run periodically by the Scheduler; safe to run from several server instances at once.
+func (s *RolloutService) AdvanceDuePlans(ctx context.Context, now time.Time) error {
+	plans, err := s.repo.ListDue(now, rolloutBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to load due rollout plans: %w", err)
+	}
+
+	for i := range plans {
+		plan := &plans[i]
+		release, err := s.getRelease(plan.AppID, plan.ReleaseID)
+		if err != nil && !errors.Is(err, ErrReleaseNotFound) {
+			log.Printf("rollout: failed to load release %s: %v", plan.ReleaseID, err)
+			continue
+		}
+
+		// A release that was superseded, rolled back or archived no longer rolls out
+		if release == nil || !release.IsActive {
+			if _, err := s.repo.Transition(plan, plan.CurrentStep, plan.Status, map[string]interface{}{
+				"status":       models.RolloutPlanCancelled,
+				"next_step_at": nil,
+			}, 0); err != nil {
+				log.Printf("rollout: failed to cancel plan %s: %v", plan.ID, err)
+			}
+			continue
+		}
+
+		// A frozen channel holds its plans; they move on at the first tick after the window closes
+		freeze, err := s.freezeService.Check(release.AppID, release.Channel, now)
+		if err != nil {
+			log.Printf("rollout: failed to check freeze of release %s: %v", release.ID, err)
+			continue
+		}
+		if freeze != nil {
+			continue
+		}
+
+		if err := s.advance(ctx, plan, release, "scheduler", now); err != nil && !errors.Is(err, ErrRolloutPlanState) {
+			log.Printf("rollout: failed to advance plan %s: %v", plan.ID, err)
+		}
+	}
+	return nil
+}
+
+// advance moves a plan to its next step and applies the step's percentage to the release.
+func (s *RolloutService) advance(ctx context.Context, plan *models.RolloutPlan, release *models.Release, actor string, now time.Time) error {
+	next := plan.CurrentStep + 1
+	if next >= len(plan.Steps) {
+		return fmt.Errorf("%w: plan is already at its final step", ErrRolloutPlanState)
+	}
+
+	status, nextAt := stepSchedule(plan.Steps, next, now)
+	if plan.Status == models.RolloutPlanPaused && status == models.RolloutPlanActive {
+		status, nextAt = models.RolloutPlanPaused, nil
+	}
+	percentage := plan.Steps[next].Percentage
+
+	ok, err := s.repo.Transition(plan, plan.CurrentStep, plan.Status, map[string]interface{}{
+		"current_step":    next,
+		"status":          status,
+		"step_started_at": now,
+		"next_step_at":    nextAt,
+	}, percentage)
+	if err != nil {
+		return fmt.Errorf("failed to advance rollout plan: %w", err)
+	}
+	if !ok {
+		// Another instance or request moved the plan first
+		return fmt.Errorf("%w: plan changed concurrently", ErrRolloutPlanState)
+	}
+
+	plan.CurrentStep = next
+	plan.Status = status
+	plan.StepStartedAt = now
+	plan.NextStepAt = nextAt
+	release.RolloutPercentage = percentage
+	s.lifecycle.settle(release, actor, fmt.Sprintf("Rollout plan step %d/%d", next+1, len(plan.Steps)))
+	s.invalidateCache(ctx, release.AppID, release.Channel)
+
+	s.securityService.Log(release.AppID, actor, "release.rollout_step", release.ID.String(), fmt.Sprintf("Step %d/%d: rollout set to %d%%", next+1, len(plan.Steps), percentage), "")
+	s.notifyStep(release, plan)
+	return nil
+}
+
+// notifyStep dispatches the webhooks for a plan that just reached its current step.
+func (s *RolloutService) notifyStep(release *models.Release, plan *models.RolloutPlan) {
+	s.settingsService.DispatchEvent(release.AppID, "release.rollout_step", map[string]interface{}{
+		"release_id":         release.ID,
+		"version":            release.Version,
+		"channel":            release.Channel,
+		"step":               plan.CurrentStep + 1,
+		"total_steps":        len(plan.Steps),
+		"rollout_percentage": plan.Steps[plan.CurrentStep].Percentage,
+		"next_step_at":       plan.NextStepAt,
+	})
+	if plan.Status == models.RolloutPlanCompleted {
+		s.settingsService.DispatchEvent(release.AppID, "release.rollout_completed", plan)
+	}
+}
+
+func (s *RolloutService) getRelease(appID, releaseID uuid.UUID) (*models.Release, error) {
+	release, err := s.releaseRepo.GetByID(appID, releaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReleaseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load release: %w", err)
+	}
+	return release, nil
+}
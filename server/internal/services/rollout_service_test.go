@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hotpatch/server/internal/models"
+)
+
+func step(percentage, holdMinutes int) models.RolloutStep {
+	return models.RolloutStep{Percentage: percentage, HoldMinutes: holdMinutes}
+}
+
+// ── validateRolloutPlan Tests ───────────────────────────────
+
+func TestValidateRolloutPlan(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []models.RolloutStep
+		valid bool
+	}{
+		{"single full step", []models.RolloutStep{{Percentage: 100}}, true},
+		{"ramp", []models.RolloutStep{step(5, 60), step(25, 120), step(100, 0)}, true},
+		{"ramp ending below 100", []models.RolloutStep{step(5, 60), step(50, 0)}, true},
+		{"empty", nil, false},
+		{"zero percentage", []models.RolloutStep{step(0, 60), step(100, 0)}, false},
+		{"above 100", []models.RolloutStep{step(5, 60), step(101, 0)}, false},
+		{"not increasing", []models.RolloutStep{step(25, 60), step(25, 60), step(100, 0)}, false},
+		{"decreasing", []models.RolloutStep{step(50, 60), step(10, 0)}, false},
+		{"negative hold", []models.RolloutStep{step(5, -1), step(100, 0)}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateRolloutPlan(tc.steps)
+			if tc.valid && err != nil {
+				t.Errorf("validateRolloutPlan() = %v, want nil", err)
+			}
+			if !tc.valid && !errors.Is(err, ErrInvalidRolloutPlan) {
+				t.Errorf("validateRolloutPlan() = %v, want ErrInvalidRolloutPlan", err)
+			}
+		})
+	}
+
+	t.Run("too many steps", func(t *testing.T) {
+		steps := make([]models.RolloutStep, 21)
+		for i := range steps {
+			steps[i] = models.RolloutStep{Percentage: i + 1}
+		}
+		if err := validateRolloutPlan(steps); !errors.Is(err, ErrInvalidRolloutPlan) {
+			t.Errorf("validateRolloutPlan() = %v, want ErrInvalidRolloutPlan", err)
+		}
+	})
+}
+
+// ── stepSchedule Tests ──────────────────────────────────────
+
+func TestStepSchedule(t *testing.T) {
+	steps := []models.RolloutStep{step(5, 60), step(25, 0), step(100, 30)}
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	status, next := stepSchedule(steps, 0, at)
+	if status != models.RolloutPlanActive || next == nil || !next.Equal(at.Add(time.Hour)) {
+		t.Errorf("step 0: got (%s, %v), want (active, %v)", status, next, at.Add(time.Hour))
+	}
+
+	status, next = stepSchedule(steps, 1, at)
+	if status != models.RolloutPlanActive || next == nil || !next.Equal(at) {
+		t.Errorf("step 1 with no hold: got (%s, %v), want (active, %v)", status, next, at)
+	}
+
+	status, next = stepSchedule(steps, 2, at)
+	if status != models.RolloutPlanCompleted || next != nil {
+		t.Errorf("final step: got (%s, %v), want (completed, nil)", status, next)
+	}
+}
+
+// ── Rollout Scheduler Tests ─────────────────────────────────
+
+// rolloutTest holds app A's release service, which manual rollout changes go through.
+type rolloutTest struct {
+	tenants
+	releaseService *ReleaseService
+}
+
+func newRolloutTestService(t *testing.T) (*RolloutService, *rolloutTest) {
+	t.Helper()
+	tn := newTenants(t, &models.Channel{}, &models.ReleaseTransition{}, &models.RolloutPlan{}, &models.AuditLog{})
+	releaseService, rolloutService := newReleaseService(tn, PatchGeneration{})
+	return rolloutService, &rolloutTest{tenants: tn, releaseService: releaseService}
+}
+
+func TestRollout_AdvanceDuePlans(t *testing.T) {
+	service, f := newRolloutTestService(t)
+	ctx := context.Background()
+
+	plan, err := service.SetPlan(ctx, f.appA, f.releaseA, []models.RolloutStep{step(5, 60), step(25, 60), step(100, 0)}, "alice")
+	if err != nil {
+		t.Fatalf("SetPlan failed: %v", err)
+	}
+	if got := f.release(t, f.releaseA).RolloutPercentage; got != 5 {
+		t.Fatalf("rollout after SetPlan = %d, want 5", got)
+	}
+
+	// Hold not yet elapsed
+	if err := service.AdvanceDuePlans(ctx, plan.StepStartedAt.Add(59*time.Minute)); err != nil {
+		t.Fatalf("AdvanceDuePlans failed: %v", err)
+	}
+	if got := f.release(t, f.releaseA).RolloutPercentage; got != 5 {
+		t.Errorf("rollout before hold elapsed = %d, want 5", got)
+	}
+
+	// A server restart loses nothing: a fresh tick after the hold advances one step
+	now := plan.StepStartedAt.Add(61 * time.Minute)
+	if err := service.AdvanceDuePlans(ctx, now); err != nil {
+		t.Fatalf("AdvanceDuePlans failed: %v", err)
+	}
+	if got := f.release(t, f.releaseA).RolloutPercentage; got != 25 {
+		t.Errorf("rollout after first hold = %d, want 25", got)
+	}
+
+	if err := service.AdvanceDuePlans(ctx, now.Add(61*time.Minute)); err != nil {
+		t.Fatalf("AdvanceDuePlans failed: %v", err)
+	}
+	plan, err = service.GetPlan(f.appA, f.releaseA)
+	if err != nil {
+		t.Fatalf("GetPlan failed: %v", err)
+	}
+	if plan.Status != models.RolloutPlanCompleted || plan.CurrentStep != 2 || plan.NextStepAt != nil {
+		t.Errorf("plan = (%s, step %d, next %v), want (completed, step 2, nil)", plan.Status, plan.CurrentStep, plan.NextStepAt)
+	}
+	if got := f.release(t, f.releaseA).RolloutPercentage; got != 100 {
+		t.Errorf("rollout after final step = %d, want 100", got)
+	}
+}
+
+func TestRollout_ConcurrentTransitionAppliesOnce(t *testing.T) {
+	service, f := newRolloutTestService(t)
+	ctx := context.Background()
+
+	if _, err := service.SetPlan(ctx, f.appA, f.releaseA, []models.RolloutStep{step(5, 60), step(25, 60), step(100, 0)}, "alice"); err != nil {
+		t.Fatalf("SetPlan failed: %v", err)
+	}
+
+	// Two instances load the same due plan before either advances it
+	first, _ := service.GetPlan(f.appA, f.releaseA)
+	second, _ := service.GetPlan(f.appA, f.releaseA)
+	release, _ := service.getRelease(f.appA, f.releaseA)
+	now := time.Now().Add(2 * time.Hour)
+
+	if err := service.advance(ctx, first, release, "scheduler", now); err != nil {
+		t.Fatalf("first advance failed: %v", err)
+	}
+	if err := service.advance(ctx, second, release, "scheduler", now); !errors.Is(err, ErrRolloutPlanState) {
+		t.Errorf("second advance: err = %v, want ErrRolloutPlanState", err)
+	}
+
+	plan, _ := service.GetPlan(f.appA, f.releaseA)
+	if plan.CurrentStep != 1 {
+		t.Errorf("current step = %d, want 1", plan.CurrentStep)
+	}
+}
+
+func TestRollout_PauseResumeSkip(t *testing.T) {
+	service, f := newRolloutTestService(t)
+	ctx := context.Background()
+
+	if _, err := service.SetPlan(ctx, f.appA, f.releaseA, []models.RolloutStep{step(5, 60), step(25, 60), step(100, 0)}, "alice"); err != nil {
+		t.Fatalf("SetPlan failed: %v", err)
+	}
+
+	plan, err := service.Pause(ctx, f.appA, f.releaseA, "alice")
+	if err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if plan.Status != models.RolloutPlanPaused {
+		t.Errorf("status after Pause = %s, want paused", plan.Status)
+	}
+	if _, err := service.Pause(ctx, f.appA, f.releaseA, "alice"); !errors.Is(err, ErrRolloutPlanState) {
+		t.Errorf("second Pause: err = %v, want ErrRolloutPlanState", err)
+	}
+
+	// A paused plan is never due
+	if err := service.AdvanceDuePlans(ctx, time.Now().Add(24*time.Hour)); err != nil {
+		t.Fatalf("AdvanceDuePlans failed: %v", err)
+	}
+	if got := f.release(t, f.releaseA).RolloutPercentage; got != 5 {
+		t.Errorf("rollout of paused plan = %d, want 5", got)
+	}
+
+	// Skipping a paused plan moves it on but keeps it paused
+	plan, err = service.Skip(ctx, f.appA, f.releaseA, "alice")
+	if err != nil {
+		t.Fatalf("Skip failed: %v", err)
+	}
+	if plan.CurrentStep != 1 || plan.Status != models.RolloutPlanPaused {
+		t.Errorf("plan after Skip = (step %d, %s), want (step 1, paused)", plan.CurrentStep, plan.Status)
+	}
+	if got := f.release(t, f.releaseA).RolloutPercentage; got != 25 {
+		t.Errorf("rollout after Skip = %d, want 25", got)
+	}
+
+	plan, err = service.Resume(ctx, f.appA, f.releaseA, "alice")
+	if err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if plan.Status != models.RolloutPlanActive || plan.NextStepAt == nil {
+		t.Errorf("plan after Resume = (%s, next %v), want active with a next step", plan.Status, plan.NextStepAt)
+	}
+
+	plan, err = service.Skip(ctx, f.appA, f.releaseA, "alice")
+	if err != nil {
+		t.Fatalf("Skip failed: %v", err)
+	}
+	if plan.Status != models.RolloutPlanCompleted {
+		t.Errorf("status after skipping to the final step = %s, want completed", plan.Status)
+	}
+	if _, err := service.Skip(ctx, f.appA, f.releaseA, "alice"); !errors.Is(err, ErrRolloutPlanState) {
+		t.Errorf("Skip of completed plan: err = %v, want ErrRolloutPlanState", err)
+	}
+
+	// Every manual action is recorded against the operator who took it
+	for _, action := range []string{"release.rollout_plan_set", "release.rollout_pause", "release.rollout_skip", "release.rollout_resume"} {
+		var logs []models.AuditLog
+		if err := f.db.Where("action = ?", action).Find(&logs).Error; err != nil {
+			t.Fatalf("failed to load audit log: %v", err)
+		}
+		if len(logs) == 0 {
+			t.Errorf("%s was not logged", action)
+		}
+		for _, entry := range logs {
+			if entry.Actor != "alice" {
+				t.Errorf("%s logged by %q, want alice", action, entry.Actor)
+			}
+		}
+	}
+}
+
+func TestRollout_ManualOverridePausesPlan(t *testing.T) {
+	service, f := newRolloutTestService(t)
+	ctx := context.Background()
+
+	if _, err := service.SetPlan(ctx, f.appA, f.releaseA, []models.RolloutStep{step(5, 60), step(100, 0)}, "alice"); err != nil {
+		t.Fatalf("SetPlan failed: %v", err)
+	}
+	if _, err := f.releaseService.UpdateRollout(ctx, f.appA, f.releaseA, 40); err != nil {
+		t.Fatalf("UpdateRollout failed: %v", err)
+	}
+
+	plan, _ := service.GetPlan(f.appA, f.releaseA)
+	if plan.Status != models.RolloutPlanPaused {
+		t.Errorf("status after manual override = %s, want paused", plan.Status)
+	}
+}
+
+func TestRollout_InactiveReleaseCancelsPlan(t *testing.T) {
+	service, f := newRolloutTestService(t)
+	ctx := context.Background()
+
+	plan, err := service.SetPlan(ctx, f.appA, f.releaseA, []models.RolloutStep{step(5, 60), step(100, 0)}, "alice")
+	if err != nil {
+		t.Fatalf("SetPlan failed: %v", err)
+	}
+	if err := f.db.Model(&models.Release{}).Where("id = ?", f.releaseA).Update("is_active", false).Error; err != nil {
+		t.Fatalf("failed to deactivate release: %v", err)
+	}
+
+	if err := service.AdvanceDuePlans(ctx, plan.StepStartedAt.Add(2*time.Hour)); err != nil {
+		t.Fatalf("AdvanceDuePlans failed: %v", err)
+	}
+	plan, _ = service.GetPlan(f.appA, f.releaseA)
+	if plan.Status != models.RolloutPlanCancelled {
+		t.Errorf("status = %s, want cancelled", plan.Status)
+	}
+	if got := f.release(t, f.releaseA).RolloutPercentage; got != 5 {
+		t.Errorf("rollout of cancelled plan = %d, want 5", got)
+	}
+}
+
+func TestRollout_PlansAreScopedToApp(t *testing.T) {
+	service, f := newRolloutTestService(t)
+	ctx := context.Background()
+
+	if _, err := service.SetPlan(ctx, f.appA, f.releaseB, []models.RolloutStep{step(5, 60), step(100, 0)}, "alice"); !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("SetPlan on another app's release: err = %v, want ErrReleaseNotFound", err)
+	}
+	if _, err := service.SetPlan(ctx, f.appB, f.releaseB, []models.RolloutStep{step(5, 60), step(100, 0)}, "alice"); err != nil {
+		t.Fatalf("SetPlan failed: %v", err)
+	}
+	if _, err := service.Pause(ctx, f.appA, f.releaseB, "alice"); !errors.Is(err, ErrRolloutPlanNotFound) {
+		t.Errorf("Pause of another app's plan: err = %v, want ErrRolloutPlanNotFound", err)
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Scheduler runs background jobs (such as advancing rollout plans) at a fixed interval.
+// Jobs keep their state in the database, so a restarted server simply resumes on its first tick,
+// and must tolerate running concurrently on several server instances.
+type Scheduler struct {
+	interval time.Duration
+	jobs     []scheduledJob
+}
+
+type scheduledJob struct {
+	name string
+	run  func(ctx context.Context, now time.Time) error
+}
+
+// NewScheduler creates a Scheduler that ticks every interval.
+func NewScheduler(interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &Scheduler{interval: interval}
+}
+
+// Register adds a job that runs on every tick.
+func (s *Scheduler) Register(name string, run func(ctx context.Context, now time.Time) error) {
+	s.jobs = append(s.jobs, scheduledJob{name: name, run: run})
+}
+
+// Start runs the jobs immediately and then on every tick until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.RunOnce(ctx, time.Now())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.RunOnce(ctx, now)
+			}
+		}
+	}()
+}
+
+// RunOnce runs every registered job once. A failing job is logged and does not stop the others.
+func (s *Scheduler) RunOnce(ctx context.Context, now time.Time) {
+	for _, job := range s.jobs {
+		if err := job.run(ctx, now); err != nil {
+			log.Printf("scheduler: %s failed: %v", job.name, err)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrSecretsFound is returned, wrapped in a SecretScanError, when a release whose bundle holds secrets at or
+// above its channel's blocking severity would be published.
+var ErrSecretsFound = errors.New("secrets found in bundle")
+
+// secretBlockOff is the blocking severity of channels that never block releases on their findings.
+const secretBlockOff = "off"
+
+const (
+	// maxSecretFindings caps the findings recorded for one release, keeping the most severe; a bundle with
+	// more has a bigger problem.
+	maxSecretFindings = 100
+	// maxSecretFindingsPerRule caps the findings of each rule recorded for one file, so a minified bundle full
+	// of quoted literals neither floods the release's findings nor crowds out other rules.
+	maxSecretFindingsPerRule = 10
+	// secretScanWindow is how much of a file is matched against the rules at a time.
+	secretScanWindow = 64 << 10
+	// secretScanOverlap is carried over from one window to the next, so matches across the boundary are
+	// found whole. It is longer than any rule matches.
+	secretScanOverlap = 512
+)
+
+// SecretScanError lists the findings that keep a release from being published in its channel, so clients
+// can show them. It matches ErrSecretsFound.
+type SecretScanError struct {
+	Channel       string                 `json:"channel"`
+	BlockSeverity string                 `json:"block_severity"`
+	Findings      []models.SecretFinding `json:"findings"` // Only those at or above BlockSeverity
+}
+
+func (e *SecretScanError) Error() string {
+	return fmt.Sprintf("%v: %d at or above %s severity, which channel %s does not publish (first: %s in %s)",
+		ErrSecretsFound, len(e.Findings), e.BlockSeverity, e.Channel, e.Findings[0].Rule, e.Findings[0].Path)
+}
+
+func (e *SecretScanError) Unwrap() error { return ErrSecretsFound }
+
+// secretRule is a pattern of credentials the scanner looks for. The secret is the pattern's first group, or
+// the whole match when it has none.
+type secretRule struct {
+	name     string
+	severity string
+	pattern  *regexp.Regexp
+	accept   func(secret []byte) bool // Further check of a match; nil accepts every match
+	reveal   bool                     // The match is not secret itself and is stored unredacted
+}
+
+// secretRules are checked in order; a match overlapping one of an earlier rule is not reported again.
+var secretRules = []secretRule{
+	{name: "private_key", severity: models.SecretSeverityCritical, pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`), reveal: true},
+	{name: "stripe_secret_key", severity: models.SecretSeverityCritical, pattern: regexp.MustCompile(`\b(?:sk|rk)_live_[0-9A-Za-z]{24,99}\b`)},
+	{name: "aws_secret_access_key", severity: models.SecretSeverityCritical, pattern: regexp.MustCompile(`(?i)aws.{0,20}?secret.{0,20}?["'\x60]([A-Za-z0-9/+=]{40})["'\x60]`)},
+	{name: "aws_access_key_id", severity: models.SecretSeverityHigh, pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "stripe_test_key", severity: models.SecretSeverityMedium, pattern: regexp.MustCompile(`\b(?:sk|rk)_test_[0-9A-Za-z]{24,99}\b`)},
+	{name: "high_entropy_string", severity: models.SecretSeverityLow, pattern: regexp.MustCompile(`["'\x60]([A-Za-z0-9+/=_\-]{24,200})["'\x60]`), accept: highEntropy},
+}
+
+// minSecretEntropy is the Shannon entropy, in bits per character, above which a quoted token is reported as
+// a likely key. Hex digests stay below it; base64-encoded keys do not.
+const minSecretEntropy = 4.5
+
+// highEntropy reports whether a token is random enough to be a key rather than a word or identifier.
+func highEntropy(token []byte) bool {
+	return shannonEntropy(token) >= minSecretEntropy
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per byte.
+func shannonEntropy(s []byte) float64 {
+	var counts [256]int
+	for _, b := range s {
+		counts[b]++
+	}
+	var entropy float64
+	for _, n := range counts {
+		if n > 0 {
+			p := float64(n) / float64(len(s))
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
+
+// secretScanExtensions are the bundle files other than the entry bundle that are scanned: source maps and
+// text assets. Images, fonts and other binary assets are not.
+var secretScanExtensions = map[string]bool{
+	".map": true, ".js": true, ".json": true, ".txt": true, ".html": true, ".xml": true, ".plist": true,
+	".env": true, ".yml": true, ".yaml": true, ".properties": true, ".pem": true, ".key": true,
+}
+
+// scansForSecrets reports whether a file of the bundle archive is scanned for secrets.
+func scansForSecrets(name string, isEntry bool) bool {
+	return isEntry || secretScanExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// secretScanner matches the rules against a file as it is extracted, a window at a time, and collects the
+// findings, up to maxSecretFindingsPerRule of each rule.
+type secretScanner struct {
+	path      string
+	buf       []byte
+	offset    int64 // Offset in the file of buf[0]
+	findings  []models.SecretFinding
+	counts    []int        // Matches of each rule, counted until one over its cap
+	recent    []secretSpan // Findings that may still overlap matches in the current window
+	truncated bool         // A rule matched more than it was allowed to record
+}
+
+// secretSpan is where a finding lies in the file.
+type secretSpan struct {
+	start, end int64
+}
+
+func newSecretScanner(name string) *secretScanner {
+	return &secretScanner{path: name, counts: make([]int, len(secretRules))}
+}
+
+func (s *secretScanner) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	for len(s.buf) >= secretScanWindow+secretScanOverlap {
+		s.scan(s.buf[:secretScanWindow+secretScanOverlap], secretScanWindow)
+		n := copy(s.buf, s.buf[secretScanWindow:])
+		s.buf = s.buf[:n]
+		s.offset += secretScanWindow
+	}
+	return len(p), nil
+}
+
+// flush scans the rest of the file.
+func (s *secretScanner) flush() {
+	s.scan(s.buf, len(s.buf))
+	s.buf = nil
+}
+
+// scan reports the matches in window that start before limit. Later ones are left to the next window, which
+// starts at limit and holds them whole.
+func (s *secretScanner) scan(window []byte, limit int) {
+	// Only findings that end inside this window can overlap its matches
+	recent := s.recent[:0]
+	for _, span := range s.recent {
+		if span.end > s.offset {
+			recent = append(recent, span)
+		}
+	}
+	s.recent = recent
+
+	for i, rule := range secretRules {
+		if s.counts[i] > maxSecretFindingsPerRule {
+			continue
+		}
+		for _, loc := range rule.pattern.FindAllSubmatchIndex(window, -1) {
+			if loc[0] >= limit {
+				break
+			}
+			if len(loc) > 2 {
+				loc = loc[2:4]
+			}
+			secret := window[loc[0]:loc[1]]
+			start, end := s.offset+int64(loc[0]), s.offset+int64(loc[1])
+			if (rule.accept != nil && !rule.accept(secret)) || s.reported(start, end) {
+				continue
+			}
+			if s.counts[i]++; s.counts[i] > maxSecretFindingsPerRule {
+				s.truncated = true
+				break
+			}
+			match := string(secret)
+			if !rule.reveal {
+				match = redactSecret(secret)
+			}
+			s.findings = append(s.findings, models.SecretFinding{
+				ID:       uuid.New(),
+				Rule:     rule.name,
+				Severity: rule.severity,
+				Path:     s.path,
+				Offset:   start,
+				Match:    match,
+			})
+			s.recent = append(s.recent, secretSpan{start: start, end: end})
+		}
+	}
+}
+
+// reported reports whether a match overlaps one already found, by an earlier rule or in the previous window.
+func (s *secretScanner) reported(start, end int64) bool {
+	for _, span := range s.recent {
+		if start < span.end && span.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// mostSevereFindings keeps the n most severe findings, in the order they were found within each severity.
+func mostSevereFindings(findings []models.SecretFinding, n int) []models.SecretFinding {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank(findings[i].Severity) > severityRank(findings[j].Severity)
+	})
+	return findings[:n]
+}
+
+// redactSecret masks all but the first quarter of a secret, up to 8 characters, so the finding can be
+// recognised without storing the secret.
+func redactSecret(secret []byte) string {
+	keep := min(len(secret)/4, 8)
+	return string(secret[:keep]) + strings.Repeat("*", min(len(secret)-keep, 24))
+}
+
+// severityRank orders secret severities; unknown ones, including "off", rank above all of them.
+func severityRank(severity string) int {
+	for i, s := range models.SecretSeverities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(models.SecretSeverities)
+}
+
+// blockingFindings returns the findings at or above a blocking severity.
+func blockingFindings(findings []models.SecretFinding, blockSeverity string) []models.SecretFinding {
+	var blocking []models.SecretFinding
+	for _, f := range findings {
+		if severityRank(f.Severity) >= severityRank(blockSeverity) {
+			blocking = append(blocking, f)
+		}
+	}
+	return blocking
+}
+
+// secretBlockSeverity loads the blocking severity of a channel. Channels without a record never block.
+func (s *ReleaseService) secretBlockSeverity(appID uuid.UUID, channel string) (string, error) {
+	ch, err := s.channelRepo.GetBySlug(appID, channel)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return secretBlockOff, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load channel: %w", err)
+	}
+	if ch.SecretBlockSeverity == "" {
+		return secretBlockOff, nil
+	}
+	return ch.SecretBlockSeverity, nil
+}
+
+// checkSecretFindings returns a SecretScanError when findings of a release keep it from being published in a channel.
+func (s *ReleaseService) checkSecretFindings(appID uuid.UUID, channel string, findings []models.SecretFinding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+	blockSeverity, err := s.secretBlockSeverity(appID, channel)
+	if err != nil {
+		return err
+	}
+	if blocking := blockingFindings(findings, blockSeverity); len(blocking) > 0 {
+		return &SecretScanError{Channel: channel, BlockSeverity: blockSeverity, Findings: blocking}
+	}
+	return nil
+}
+
+// checkReleaseSecrets checks the recorded findings of a stored release before it is published or scheduled.
+func (s *ReleaseService) checkReleaseSecrets(release *models.Release) error {
+	findings, err := s.repo.ListSecretFindings(release.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load secret findings: %w", err)
+	}
+	return s.checkSecretFindings(release.AppID, release.Channel, findings)
+}
+
+// SecretScan returns the findings of the secret scan of a release's bundle.
+func (s *ReleaseService) SecretScan(appID, releaseID uuid.UUID) (*models.SecretScan, error) {
+	release, err := s.getRelease(appID, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	scan := &models.SecretScan{ReleaseID: release.ID, Findings: []models.SecretFinding{}}
+
+	// Bundles are scanned when they are inspected, so only releases with a manifest were scanned
+	if manifest, err := s.repo.GetManifest(release.ID); err == nil {
+		scan.Scanned = true
+		scan.Truncated = manifest.SecretFindingsTruncated
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load bundle manifest: %w", err)
+	}
+	findings, err := s.repo.ListSecretFindings(release.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secret findings: %w", err)
+	}
+	if len(findings) > 0 {
+		scan.Findings = findings
+	}
+	if scan.BlockSeverity, err = s.secretBlockSeverity(appID, release.Channel); err != nil {
+		return nil, err
+	}
+	scan.Blocked = len(blockingFindings(findings, scan.BlockSeverity)) > 0
+	return scan, nil
+}
+
+// highestSeverity returns the most severe of the findings' severities.
+func highestSeverity(findings []models.SecretFinding) string {
+	highest := ""
+	for _, f := range findings {
+		if highest == "" || severityRank(f.Severity) > severityRank(highest) {
+			highest = f.Severity
+		}
+	}
+	return highest
+}
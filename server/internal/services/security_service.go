@@ -85,6 +85,11 @@ func (s *SecurityService) DeleteApiKey(appID, keyID uuid.UUID) error {
 // ── Signing Keys ──────────────────────────────────────
 
 func (s *SecurityService) CreateSigningKey(appID uuid.UUID, req *models.CreateSigningKeyRequest) (*models.SigningKey, error) {
+	// Uploads are verified against registered keys, so only keys that can verify are accepted
+	if _, err := parseSigningKey(req.PublicKey); err != nil {
+		return nil, err
+	}
+
 	key := &models.SigningKey{
 		ID:        uuid.New(),
 		AppID:     appID,
@@ -106,6 +111,11 @@ func (s *SecurityService) ListSigningKeys(appID uuid.UUID) ([]models.SigningKey,
 	return s.repo.ListSigningKeys(appID)
 }
 
+// ActiveSigningKeys returns the signing keys uploads of the app are verified against.
+func (s *SecurityService) ActiveSigningKeys(appID uuid.UUID) ([]models.SigningKey, error) {
+	return s.repo.ListActiveSigningKeys(appID)
+}
+
 func (s *SecurityService) DeleteSigningKey(appID, keyID uuid.UUID) error {
 	// Log audit trail
 	s.Log(appID, "system", "security.signing_key_delete", keyID.String(), "", "")
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidVersion is returned when an uploaded version is not a valid semantic version.
+var ErrInvalidVersion = errors.New("invalid version")
+
+// semVersion is a parsed semantic version (https://semver.org/spec/v2.0.0.html).
+// Build metadata is discarded since it does not take part in precedence.
+type semVersion struct {
+	major, minor, patch uint64
+	prerelease          []string
+}
+
+// parseSemver strictly parses MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD].
+// Used to validate versions on upload so only well-formed versions enter the database.
+func parseSemver(v string) (semVersion, error) {
+	var sv semVersion
+	if v == "" {
+		return sv, fmt.Errorf("%w: version is empty", ErrInvalidVersion)
+	}
+	if len(v) > 50 {
+		return sv, fmt.Errorf("%w %q: longer than 50 characters", ErrInvalidVersion, v)
+	}
+
+	rest := v
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		if err := validateIdentifiers(rest[i+1:], false); err != nil {
+			return sv, fmt.Errorf("%w %q: build metadata %v", ErrInvalidVersion, v, err)
+		}
+		rest = rest[:i]
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		pre := rest[i+1:]
+		if err := validateIdentifiers(pre, true); err != nil {
+			return sv, fmt.Errorf("%w %q: pre-release %v", ErrInvalidVersion, v, err)
+		}
+		sv.prerelease = strings.Split(pre, ".")
+		rest = rest[:i]
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return sv, fmt.Errorf("%w %q: expected MAJOR.MINOR.PATCH", ErrInvalidVersion, v)
+	}
+	nums := make([]uint64, 3)
+	for i, p := range parts {
+		if !isNumericIdentifier(p) {
+			return sv, fmt.Errorf("%w %q: %q is not a number", ErrInvalidVersion, v, p)
+		}
+		if len(p) > 1 && p[0] == '0' {
+			return sv, fmt.Errorf("%w %q: %q has a leading zero", ErrInvalidVersion, v, p)
+		}
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return sv, fmt.Errorf("%w %q: %q is out of range", ErrInvalidVersion, v, p)
+		}
+		nums[i] = n
+	}
+	sv.major, sv.minor, sv.patch = nums[0], nums[1], nums[2]
+	return sv, nil
+}
+
+// validateIdentifiers checks a dot-separated list of pre-release or build identifiers.
+func validateIdentifiers(s string, prerelease bool) error {
+	if s == "" {
+		return fmt.Errorf("is empty")
+	}
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return fmt.Errorf("contains an empty identifier")
+		}
+		for _, c := range id {
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+				return fmt.Errorf("identifier %q contains %q", id, c)
+			}
+		}
+		if prerelease && isNumericIdentifier(id) && len(id) > 1 && id[0] == '0' {
+			return fmt.Errorf("identifier %q has a leading zero", id)
+		}
+	}
+	return nil
+}
+
+func isNumericIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateVersion checks that a version string is strict semver.
+func validateVersion(v string) error {
+	_, err := parseSemver(v)
+	return err
+}
+
+// parseVersion leniently parses a version reported by a device or stored before strict validation.
+// Tolerates a leading "v", missing components ("1.2"), and non-numeric garbage (treated as 0),
+// while keeping pre-release identifiers so precedence is still honoured.
+func parseVersion(v string) semVersion {
+	var sv semVersion
+
+	// Strip optional "v" prefix
+	if len(v) > 0 && (v[0] == 'v' || v[0] == 'V') {
+		v = v[1:]
+	}
+
+	// Build metadata never affects precedence
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		for _, id := range strings.Split(v[i+1:], ".") {
+			if id != "" {
+				sv.prerelease = append(sv.prerelease, id)
+			}
+		}
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ".")
+	nums := []*uint64{&sv.major, &sv.minor, &sv.patch}
+	for i := 0; i < len(parts) && i < len(nums); i++ {
+		// Keep leading digits only (e.g. "3rc1" -> 3)
+		numStr := parts[i]
+		for j, c := range numStr {
+			if c < '0' || c > '9' {
+				numStr = numStr[:j]
+				break
+			}
+		}
+		n, err := strconv.ParseUint(numStr, 10, 64)
+		if err != nil {
+			n = 0
+		}
+		*nums[i] = n
+	}
+	return sv
+}
+
+// compare returns 1 if a > b, -1 if a < b, and 0 if they have equal precedence.
+func (a semVersion) compare(b semVersion) int {
+	if c := compareUint(a.major, b.major); c != 0 {
+		return c
+	}
+	if c := compareUint(a.minor, b.minor); c != 0 {
+		return c
+	}
+	if c := compareUint(a.patch, b.patch); c != 0 {
+		return c
+	}
+
+	// A pre-release version has lower precedence than the associated normal version
+	switch {
+	case len(a.prerelease) == 0 && len(b.prerelease) == 0:
+		return 0
+	case len(a.prerelease) == 0:
+		return 1
+	case len(b.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.prerelease) && i < len(b.prerelease); i++ {
+		if c := comparePrereleaseIdentifier(a.prerelease[i], b.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	// A larger set of pre-release fields has higher precedence when all preceding ones are equal
+	return compareUint(uint64(len(a.prerelease)), uint64(len(b.prerelease)))
+}
+
+// comparePrereleaseIdentifier orders numeric identifiers numerically, alphanumeric ones
+// lexically in ASCII order, and numeric identifiers below alphanumeric ones.
+func comparePrereleaseIdentifier(a, b string) int {
+	aNum, bNum := isNumericIdentifier(a), isNumericIdentifier(b)
+	switch {
+	case aNum && bNum:
+		// Compare by length first so arbitrarily large numbers order correctly
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			return compareUint(uint64(len(a)), uint64(len(b)))
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// compareVersions compares two version strings by semver precedence.
+// Returns 1 if v1 > v2, -1 if v1 < v2, and 0 if they are equal.
+func compareVersions(v1, v2 string) int {
+	return parseVersion(v1).compare(parseVersion(v2))
+}
+
+// isVersionGreater returns true if v1 > v2 using semantic versioning.
+// Handles versions like "1.2.3", "1.10.0" and pre-releases such as "1.2.3-beta.2".
+func isVersionGreater(v1, v2 string) bool {
+	return compareVersions(v1, v2) > 0
+}
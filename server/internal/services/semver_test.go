@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// ── isVersionGreater Tests ──────────────────────────────────
+
+func TestIsVersionGreater(t *testing.T) {
+	tests := []struct {
+		name     string
+		v1       string
+		v2       string
+		expected bool
+	}{
+		// Basic comparisons
+		{"greater major", "2.0.0", "1.0.0", true},
+		{"greater minor", "1.1.0", "1.0.0", true},
+		{"greater patch", "1.0.1", "1.0.0", true},
+		{"equal versions", "1.0.0", "1.0.0", false},
+		{"lesser major", "1.0.0", "2.0.0", false},
+		{"lesser minor", "1.0.0", "1.1.0", false},
+		{"lesser patch", "1.0.0", "1.0.1", false},
+
+		// Multi-digit versions
+		{"double digit minor", "1.10.0", "1.9.0", true},
+		{"double digit patch", "1.0.10", "1.0.9", true},
+		{"triple digit", "1.100.0", "1.99.0", true},
+
+		// Leading "v" prefix
+		{"v prefix v1", "v2.0.0", "1.0.0", true},
+		{"v prefix v2", "2.0.0", "v1.0.0", true},
+		{"v prefix both", "v2.0.0", "v1.0.0", true},
+		{"V uppercase prefix", "V2.0.0", "1.0.0", true},
+
+		// Pre-release precedence
+		{"release beats its pre-release", "1.2.3", "1.2.3-beta", true},
+		{"pre-release below its release", "1.2.3-beta", "1.2.3", false},
+		{"pre-release of next patch", "1.2.4-rc1", "1.2.3", true},
+		{"pre-release of earlier patch", "1.2.2-alpha", "1.2.3", false},
+		{"alpha.1 after alpha", "1.0.0-alpha.1", "1.0.0-alpha", true},
+		{"alpha.beta after alpha.1", "1.0.0-alpha.beta", "1.0.0-alpha.1", true},
+		{"beta after alpha.beta", "1.0.0-beta", "1.0.0-alpha.beta", true},
+		{"beta.2 after beta", "1.0.0-beta.2", "1.0.0-beta", true},
+		{"numeric identifiers compare numerically", "1.0.0-beta.11", "1.0.0-beta.2", true},
+		{"rc.1 after beta.11", "1.0.0-rc.1", "1.0.0-beta.11", true},
+		{"numeric below alphanumeric", "1.0.0-1", "1.0.0-alpha", false},
+		{"equal pre-releases", "1.0.0-rc.1", "1.0.0-rc.1", false},
+
+		// Build metadata
+		{"build metadata ignored", "1.0.0+build.2", "1.0.0+build.1", false},
+		{"build metadata after pre-release", "1.0.0-rc.1+exp", "1.0.0-beta", true},
+
+		// Varying lengths
+		{"shorter v1", "1.2", "1.2.0", false}, // missing components are 0
+		{"shorter v2", "1.2.1", "1.2", true},
+		{"single digit", "2", "1", true},
+		{"single digit equal", "1", "1", false},
+
+		// Edge cases
+		{"empty v1", "", "1.0.0", false},
+		{"empty v2", "1.0.0", "", true},
+		{"both empty", "", "", false},
+		{"zero versions", "0.0.0", "0.0.0", false},
+		{"zero vs one", "0.0.1", "0.0.0", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := isVersionGreater(tc.v1, tc.v2)
+			if result != tc.expected {
+				t.Errorf("isVersionGreater(%q, %q) = %v, want %v", tc.v1, tc.v2, result, tc.expected)
+			}
+		})
+	}
+}
+
+// ── parseVersion Tests ──────────────────────────────────────
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected semVersion
+	}{
+		{"1.2.3", semVersion{major: 1, minor: 2, patch: 3}},
+		{"v1.2.3", semVersion{major: 1, minor: 2, patch: 3}},
+		{"V1.2.3", semVersion{major: 1, minor: 2, patch: 3}},
+		{"1.2.3-beta", semVersion{major: 1, minor: 2, patch: 3, prerelease: []string{"beta"}}},
+		{"1.2.3-rc.1+build.5", semVersion{major: 1, minor: 2, patch: 3, prerelease: []string{"rc", "1"}}},
+		{"1.2.3+build.5", semVersion{major: 1, minor: 2, patch: 3}},
+		{"1.10.0", semVersion{major: 1, minor: 10}},
+		{"0.0.0", semVersion{}},
+		{"", semVersion{}},
+		{"v", semVersion{}},
+		{"1", semVersion{major: 1}},
+		{"1.2", semVersion{major: 1, minor: 2}},
+		{"abc", semVersion{}},
+		{"1.abc.3", semVersion{major: 1, patch: 3}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			result := parseVersion(tc.input)
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("parseVersion(%q) = %+v, want %+v", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+// ── parseSemver Tests ───────────────────────────────────────
+
+func TestParseSemver(t *testing.T) {
+	valid := []string{
+		"0.0.0",
+		"1.2.3",
+		"10.20.30",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-0.3.7",
+		"1.0.0-x-y-z.--",
+		"1.0.0+20130313144700",
+		"1.0.0-beta+exp.sha.5114f85",
+		"1.0.0+21AF26D3----117B344092BD",
+	}
+	for _, v := range valid {
+		t.Run(v, func(t *testing.T) {
+			if _, err := parseSemver(v); err != nil {
+				t.Errorf("parseSemver(%q) failed: %v", v, err)
+			}
+		})
+	}
+
+	invalid := []string{
+		"",
+		"1",
+		"1.2",
+		"1.2.3.4",
+		"v1.2.3",
+		"01.2.3",
+		"1.02.3",
+		"1.2.3-",
+		"1.2.3-01",
+		"1.2.3-alpha..1",
+		"1.2.3+",
+		"1.2.3-beta_1",
+		"a.b.c",
+	}
+	for _, v := range invalid {
+		t.Run(v, func(t *testing.T) {
+			_, err := parseSemver(v)
+			if err == nil {
+				t.Fatalf("parseSemver(%q) succeeded, want error", v)
+			}
+			if !errors.Is(err, ErrInvalidVersion) {
+				t.Errorf("error %v does not wrap ErrInvalidVersion", err)
+			}
+		})
+	}
+}
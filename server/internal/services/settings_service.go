@@ -8,6 +8,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
@@ -20,6 +21,9 @@ import (
 	"github.com/hotpatch/server/internal/repository"
 )
 
+// ErrAppAccessDenied is returned when a user asks to act on an app they do not own.
+var ErrAppAccessDenied = errors.New("you do not have access to this app")
+
 // SettingsService handles app settings and webhook dispatching.
 type SettingsService struct {
 	repo            *repository.SettingsRepository
@@ -36,6 +40,18 @@ func (s *SettingsService) GetApp(appID uuid.UUID) (*models.App, error) {
 	return s.repo.GetApp(appID)
 }
 
+// AuthorizeAppAccess checks that a dashboard user owns the app they selected.
+func (s *SettingsService) AuthorizeAppAccess(userID, appID uuid.UUID) error {
+	owns, err := s.repo.IsAppOwner(appID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check app ownership: %w", err)
+	}
+	if !owns {
+		return ErrAppAccessDenied
+	}
+	return nil
+}
+
 func (s *SettingsService) UpdateApp(ctx context.Context, appID uuid.UUID, req *models.UpdateAppRequest) (*models.App, error) {
 	app, err := s.repo.GetApp(appID)
 	if err != nil {
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrHashMismatch is returned when an uploaded artifact does not match the SHA-256 declared for it.
+	ErrHashMismatch = errors.New("hash mismatch")
+	// ErrInvalidSignature is returned when an artifact's signature does not verify against any active signing key of the app.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrInvalidSigningKey is returned when a signing key being registered is not an Ed25519 public key.
+	ErrInvalidSigningKey = errors.New("invalid signing key")
+)
+
+// parseSigningKey decodes an Ed25519 public key given as base64 or hex of the raw 32 bytes (as the CLI
+// prints it), or as a PEM-encoded PKIX public key.
+func parseSigningKey(encoded string) (ed25519.PublicKey, error) {
+	encoded = strings.TrimSpace(encoded)
+	if block, _ := pem.Decode([]byte(encoded)); block != nil {
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSigningKey, err)
+		}
+		key, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: PEM key is not an Ed25519 key", ErrInvalidSigningKey)
+		}
+		return key, nil
+	}
+	if raw, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(raw) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(raw), nil
+	}
+	if raw, err := hex.DecodeString(encoded); err == nil && len(raw) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(raw), nil
+	}
+	return nil, fmt.Errorf("%w: expected a 32-byte Ed25519 public key in base64, hex or PEM", ErrInvalidSigningKey)
+}
+
+// artifactCheck verifies an uploaded bundle or patch against the SHA-256 and Ed25519 signature the
+// client declared for it. The artifact is written to it as it streams to storage, which hashes it; verify
+// is called with the whole artifact once it has been received.
+type artifactCheck struct {
+	declaredHash string
+	digest       hash.Hash
+	signature    []byte
+	keyIDs       []uuid.UUID
+	publicKeys   []ed25519.PublicKey
+}
+
+// newArtifactCheck prepares to check an artifact of the app against the declared hash and base64 signature,
+// trying every active signing key of the app.
+func (s *ReleaseService) newArtifactCheck(appID uuid.UUID, declaredHash, signature string) (*artifactCheck, error) {
+	declaredHash = strings.ToLower(strings.TrimSpace(declaredHash))
+	if raw, err := hex.DecodeString(declaredHash); err != nil || len(raw) != sha256.Size {
+		return nil, fmt.Errorf("%w: hash must be a hex-encoded SHA-256", ErrHashMismatch)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w: signature must be a base64-encoded Ed25519 signature", ErrInvalidSignature)
+	}
+
+	keys, err := s.securityService.ActiveSigningKeys(appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+	check := &artifactCheck{declaredHash: declaredHash, digest: sha256.New(), signature: sig}
+	for _, key := range keys {
+		publicKey, err := parseSigningKey(key.PublicKey)
+		if err != nil {
+			log.Printf("releases: skipping signing key %s of app %s: %v", key.ID, appID, err)
+			continue
+		}
+		check.keyIDs = append(check.keyIDs, key.ID)
+		check.publicKeys = append(check.publicKeys, publicKey)
+	}
+	if len(check.publicKeys) == 0 {
+		return nil, fmt.Errorf("%w: the app has no active signing key; register one under /security/signing-keys", ErrInvalidSignature)
+	}
+	return check, nil
+}
+
+func (c *artifactCheck) Write(p []byte) (int, error) {
+	return c.digest.Write(p)
+}
+
+// SHA256 returns the hex SHA-256 of the artifact written so far.
+func (c *artifactCheck) SHA256() string {
+	return hex.EncodeToString(c.digest.Sum(nil))
+}
+
+// verify checks the artifact written to the check against the declared hash and returns the ID of the
+// signing key whose signature it carries. artifact holds the same bytes that were written.
+func (c *artifactCheck) verify(artifact []byte) (uuid.UUID, error) {
+	if err := c.checkHash(); err != nil {
+		return uuid.Nil, err
+	}
+	for i, publicKey := range c.publicKeys {
+		if ed25519.Verify(publicKey, artifact, c.signature) {
+			return c.keyIDs[i], nil
+		}
+	}
+	return uuid.Nil, fmt.Errorf("%w: signature does not verify against any active signing key of the app", ErrInvalidSignature)
+}
+
+func (c *artifactCheck) checkHash() error {
+	if actual := c.SHA256(); actual != c.declaredHash {
+		return fmt.Errorf("%w: uploaded bytes hash to %s, but %s was declared", ErrHashMismatch, actual, c.declaredHash)
+	}
+	return nil
+}
+
+// verifySpooled verifies an artifact that was spooled to disk as it was written to the check. The spooled
+// copy is only mapped once its hash checks out, and is never read onto the heap, so concurrent uploads of
+// large bundles do not each hold one in memory.
+func verifySpooled(check *artifactCheck, spool *bundleSpool) (uuid.UUID, error) {
+	if err := check.checkHash(); err != nil {
+		return uuid.Nil, err
+	}
+	artifact, unmap, err := spool.mapped()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer unmap()
+	return check.verify(artifact)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+)
+
+// ── Upload Signature Verification Tests ─────────────────────
+
+func newSigningKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	return pub, priv
+}
+
+func TestParseSigningKey_Formats(t *testing.T) {
+	pub, _ := newSigningKey(t)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey failed: %v", err)
+	}
+
+	for name, encoded := range map[string]string{
+		"base64": base64.StdEncoding.EncodeToString(pub),
+		"hex":    hex.EncodeToString(pub),
+		"pem":    string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	} {
+		got, err := parseSigningKey(encoded)
+		if err != nil {
+			t.Errorf("parseSigningKey(%s) failed: %v", name, err)
+			continue
+		}
+		if !got.Equal(pub) {
+			t.Errorf("parseSigningKey(%s) returned a different key", name)
+		}
+	}
+
+	for _, encoded := range []string{"", "not a key", base64.StdEncoding.EncodeToString(pub[:16])} {
+		if _, err := parseSigningKey(encoded); !errors.Is(err, ErrInvalidSigningKey) {
+			t.Errorf("parseSigningKey(%q) err = %v, want ErrInvalidSigningKey", encoded, err)
+		}
+	}
+}
+
+// signatureTest holds app A's release service over the registered signing keys.
+type signatureTest struct {
+	tenants
+	releaseService *ReleaseService
+}
+
+func newSignatureTest(t *testing.T) *signatureTest {
+	t.Helper()
+	tn := newTenants(t, &models.SigningKey{})
+	releaseService, _ := newReleaseService(tn, PatchGeneration{})
+	return &signatureTest{tenants: tn, releaseService: releaseService}
+}
+
+// addSigningKey registers an Ed25519 key for the app directly, deactivating it when active is false.
+func addSigningKey(t *testing.T, tn tenants, appID uuid.UUID, pub ed25519.PublicKey, active bool) uuid.UUID {
+	t.Helper()
+	key := &models.SigningKey{ID: uuid.New(), AppID: appID, Name: "ci", PublicKey: base64.StdEncoding.EncodeToString(pub), IsActive: true}
+	if err := tn.db.Create(key).Error; err != nil {
+		t.Fatalf("failed to create signing key: %v", err)
+	}
+	if !active {
+		if err := tn.db.Model(key).Update("is_active", false).Error; err != nil {
+			t.Fatalf("failed to deactivate signing key: %v", err)
+		}
+	}
+	return key.ID
+}
+
+// checkArtifact runs an artifact through the upload check the way uploadBundle does.
+func checkArtifact(f *signatureTest, appID uuid.UUID, artifact []byte, hash, sig string) (uuid.UUID, error) {
+	check, err := f.releaseService.newArtifactCheck(appID, hash, sig)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if _, err := check.Write(artifact); err != nil {
+		return uuid.Nil, err
+	}
+	return check.verify(artifact)
+}
+
+func TestArtifactCheck_RecordsSigningKey(t *testing.T) {
+	f := newSignatureTest(t)
+	pub, priv := newSigningKey(t)
+	retiredPub, retiredPriv := newSigningKey(t)
+	otherAppPub, otherAppPriv := newSigningKey(t)
+	unrelatedPub, _ := newSigningKey(t)
+	addSigningKey(t, f.tenants, f.appA, retiredPub, false)
+	addSigningKey(t, f.tenants, f.appA, unrelatedPub, true)
+	keyID := addSigningKey(t, f.tenants, f.appA, pub, true)
+	addSigningKey(t, f.tenants, f.appB, otherAppPub, true)
+
+	artifact := []byte("bundle contents")
+	sum := sha256.Sum256(artifact)
+	hash := hex.EncodeToString(sum[:])
+	sign := func(priv ed25519.PrivateKey) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, artifact))
+	}
+
+	got, err := checkArtifact(f, f.appA, artifact, strings.ToUpper(hash), sign(priv))
+	if err != nil {
+		t.Fatalf("verify of a correctly signed artifact failed: %v", err)
+	}
+	if got != keyID {
+		t.Errorf("verify returned key %s, want %s", got, keyID)
+	}
+
+	cases := []struct {
+		name    string
+		hash    string
+		sig     string
+		wantErr error
+	}{
+		{"signed with a deactivated key", hash, sign(retiredPriv), ErrInvalidSignature},
+		{"signed with another app's key", hash, sign(otherAppPriv), ErrInvalidSignature},
+		{"declared hash differs", strings.Repeat("0", 64), sign(priv), ErrHashMismatch},
+		{"hash is not a SHA-256", "h", sign(priv), ErrHashMismatch},
+		{"signature is not base64", hash, "s", ErrInvalidSignature},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := checkArtifact(f, f.appA, artifact, tc.hash, tc.sig); !errors.Is(err, tc.wantErr) {
+				t.Errorf("verify err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifySpooled(t *testing.T) {
+	f := newSignatureTest(t)
+	pub, priv := newSigningKey(t)
+	keyID := addSigningKey(t, f.tenants, f.appA, pub, true)
+
+	artifact := []byte(strings.Repeat("bundle bytes ", 1000))
+	sum := sha256.Sum256(artifact)
+	hash := hex.EncodeToString(sum[:])
+	tampered := append([]byte("x"), artifact[1:]...)
+
+	cases := []struct {
+		name    string
+		spooled []byte
+		sig     []byte
+		wantErr error
+	}{
+		{"valid", artifact, ed25519.Sign(priv, artifact), nil},
+		{"signed over other bytes", artifact, ed25519.Sign(priv, tampered), ErrInvalidSignature},
+		{"spooled bytes differ from the declared hash", tampered, ed25519.Sign(priv, tampered), ErrHashMismatch},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			check, err := f.releaseService.newArtifactCheck(f.appA, hash, base64.StdEncoding.EncodeToString(tc.sig))
+			if err != nil {
+				t.Fatalf("newArtifactCheck failed: %v", err)
+			}
+			spool, err := newBundleSpool()
+			if err != nil {
+				t.Fatalf("newBundleSpool failed: %v", err)
+			}
+			defer spool.Close()
+			if _, err := io.MultiWriter(check, spool).Write(tc.spooled); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+
+			got, err := verifySpooled(check, spool)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("verifySpooled err = %v, want %v", err, tc.wantErr)
+			}
+			if tc.wantErr == nil && got != keyID {
+				t.Errorf("verifySpooled returned key %s, want %s", got, keyID)
+			}
+		})
+	}
+}
+
+func TestArtifactCheck_RequiresActiveSigningKey(t *testing.T) {
+	f := newSignatureTest(t)
+	pub, priv := newSigningKey(t)
+	addSigningKey(t, f.tenants, f.appA, pub, false)
+
+	artifact := []byte("bundle contents")
+	sum := sha256.Sum256(artifact)
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, artifact))
+
+	if _, err := f.releaseService.newArtifactCheck(f.appA, hex.EncodeToString(sum[:]), sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("newArtifactCheck without an active key: err = %v, want ErrInvalidSignature", err)
+	}
+}
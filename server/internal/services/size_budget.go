@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrSizeBudgetExceeded is returned, wrapped in a SizeBudgetError, when an upload is over a size budget of its channel.
+var ErrSizeBudgetExceeded = errors.New("size budget exceeded")
+
+// Size budgets a channel can set.
+const (
+	SizeBudgetMaxSize   = "max_size"   // the artifact is larger than the channel allows
+	SizeBudgetMaxGrowth = "max_growth" // the bundle grew more than the channel allows over the active release
+)
+
+// SizeBudgetError reports which size budget of a channel an upload exceeds, with the figures behind it, so
+// clients can show them. It matches ErrSizeBudgetExceeded.
+type SizeBudgetError struct {
+	Channel          string  `json:"channel"`
+	Budget           string  `json:"budget"`   // SizeBudgetMaxSize or SizeBudgetMaxGrowth
+	Artifact         string  `json:"artifact"` // "bundle" or "patch"
+	Size             int64   `json:"size"`
+	MaxSize          int64   `json:"max_size,omitempty"`
+	ActiveVersion    string  `json:"active_version,omitempty"`
+	ActiveSize       int64   `json:"active_size,omitempty"`
+	GrowthPercent    float64 `json:"growth_percent,omitempty"`
+	MaxGrowthPercent float64 `json:"max_growth_percent,omitempty"`
+}
+
+func (e *SizeBudgetError) Error() string {
+	if e.Budget == SizeBudgetMaxGrowth {
+		return fmt.Sprintf("%v: the %s is %d bytes, %.1f%% larger than version %s (%d bytes); channel %s allows %.1f%% growth",
+			ErrSizeBudgetExceeded, e.Artifact, e.Size, e.GrowthPercent, e.ActiveVersion, e.ActiveSize, e.Channel, e.MaxGrowthPercent)
+	}
+	return fmt.Sprintf("%v: the %s is %d bytes; channel %s allows %d", ErrSizeBudgetExceeded, e.Artifact, e.Size, e.Channel, e.MaxSize)
+}
+
+func (e *SizeBudgetError) Unwrap() error { return ErrSizeBudgetExceeded }
+
+// sizeBudget is the size budget of a channel, checked against uploads to it.
+type sizeBudget struct {
+	channel          string
+	maxSize          int64   // 0 = no maximum
+	maxGrowthPercent float64 // 0 = growth is not checked
+}
+
+// sizeBudget loads the size budget of a channel. Channels without a record have none.
+func (s *ReleaseService) sizeBudget(appID uuid.UUID, channel string) (sizeBudget, error) {
+	budget := sizeBudget{channel: channel}
+	ch, err := s.channelRepo.GetBySlug(appID, channel)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return budget, nil
+	}
+	if err != nil {
+		return budget, fmt.Errorf("failed to load channel: %w", err)
+	}
+	budget.maxSize, budget.maxGrowthPercent = ch.MaxBundleSize, ch.MaxSizeGrowthPercent
+	return budget, nil
+}
+
+// check returns the first budget an artifact of the given size exceeds, or nil. Growth is measured against the
+// channel's active release; it is not checked for patches, nor against a release with no recorded bundle size.
+func (b sizeBudget) check(artifact string, size int64, active *models.Release) *SizeBudgetError {
+	if b.maxSize > 0 && size > b.maxSize {
+		return &SizeBudgetError{Channel: b.channel, Budget: SizeBudgetMaxSize, Artifact: artifact, Size: size, MaxSize: b.maxSize}
+	}
+	if b.maxGrowthPercent <= 0 || artifact != "bundle" || active == nil || active.IsPatch || active.Size <= 0 {
+		return nil
+	}
+	growth := float64(size-active.Size) * 100 / float64(active.Size)
+	if growth > b.maxGrowthPercent {
+		return &SizeBudgetError{
+			Channel: b.channel, Budget: SizeBudgetMaxGrowth, Artifact: artifact, Size: size,
+			ActiveVersion: active.Version, ActiveSize: active.Size, GrowthPercent: growth, MaxGrowthPercent: b.maxGrowthPercent,
+		}
+	}
+	return nil
+}
+
+// enforce checks an artifact against the budget. An exceeded budget is an error unless override is set, in
+// which case it is returned to be recorded in the audit log. Patches are held to the maximum size only: a diff
+// has no earlier diff of the same base to grow from, so callers pass no active release for them.
+func (b sizeBudget) enforce(artifact string, size int64, active *models.Release, override bool) (*SizeBudgetError, error) {
+	exceeded := b.check(artifact, size, active)
+	if exceeded != nil && !override {
+		return nil, exceeded
+	}
+	return exceeded, nil
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+)
+
+// ── Size Budget Tests ───────────────────────────────────────
+
+func TestSizeBudget_Check(t *testing.T) {
+	budget := sizeBudget{channel: "production", maxSize: 1000, maxGrowthPercent: 20}
+	active := &models.Release{Version: "1.0.0", Size: 500}
+
+	cases := []struct {
+		name     string
+		artifact string
+		size     int64
+		active   *models.Release
+		want     string
+	}{
+		{"within budget", "bundle", 600, active, ""},
+		{"over the maximum", "bundle", 1001, active, SizeBudgetMaxSize},
+		{"grown too much", "bundle", 601, active, SizeBudgetMaxGrowth},
+		{"no active release", "bundle", 900, nil, ""},
+		{"active release of unknown size", "bundle", 900, &models.Release{Version: "1.0.0"}, ""},
+		{"active patch release", "bundle", 900, &models.Release{Version: "1.0.0", Size: 10, IsPatch: true}, ""},
+		{"patches are not checked for growth", "patch", 900, active, ""},
+		{"patches are held to the maximum", "patch", 1001, nil, SizeBudgetMaxSize},
+	}
+	for _, tc := range cases {
+		got := ""
+		if exceeded := budget.check(tc.artifact, tc.size, tc.active); exceeded != nil {
+			got = exceeded.Budget
+			if !errors.Is(exceeded, ErrSizeBudgetExceeded) {
+				t.Errorf("%s: %v does not match ErrSizeBudgetExceeded", tc.name, exceeded)
+			}
+		}
+		if got != tc.want {
+			t.Errorf("%s: exceeded budget %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	exceeded := budget.check("bundle", 750, active)
+	if exceeded.GrowthPercent != 50 || exceeded.ActiveVersion != "1.0.0" || exceeded.ActiveSize != 500 || !strings.Contains(exceeded.Error(), "50.0% larger than version 1.0.0") {
+		t.Errorf("growth error = %+v (%v)", exceeded, exceeded)
+	}
+
+	if exceeded, err := budget.enforce("bundle", 2000, active, true); err != nil || exceeded == nil {
+		t.Errorf("enforce with override = %v, %v; want the exceeded budget and no error", exceeded, err)
+	}
+	if _, err := (sizeBudget{channel: "production"}).enforce("bundle", 1<<30, active, false); err != nil {
+		t.Errorf("enforce without a budget: %v", err)
+	}
+}
+
+// sizeBudgetTest holds app A's release service, storing uploads in an object store, over the channels and
+// releases whose sizes are budgeted, with a signing key for uploads.
+type sizeBudgetTest struct {
+	tenants
+	releaseService *ReleaseService
+	signingKey     ed25519.PrivateKey
+}
+
+func newSizeBudgetTest(t *testing.T) *sizeBudgetTest {
+	t.Helper()
+	tn := newTenants(t, &models.Channel{}, &models.ReleaseTransition{}, &models.Patch{}, &models.PatchJob{}, &models.BundleManifest{}, &models.SecretFinding{}, &models.RolloutPlan{}, &models.AuditLog{}, &models.SigningKey{})
+	releaseService, _ := newReleaseService(tn, PatchGeneration{})
+	releaseService.storage = newObjectStore(t, nil)
+	pub, priv := newSigningKey(t)
+	addSigningKey(t, tn, tn.appA, pub, true)
+	return &sizeBudgetTest{tenants: tn, releaseService: releaseService, signingKey: priv}
+}
+
+// budgetChannel gives app A's production channel the given size budget.
+func budgetChannel(t *testing.T, f *sizeBudgetTest, maxSize int64, maxGrowthPercent float64) {
+	t.Helper()
+	channel := models.Channel{ID: uuid.New(), AppID: f.appA, Name: "Production", Slug: "production", MaxBundleSize: maxSize, MaxSizeGrowthPercent: maxGrowthPercent}
+	if err := f.db.Create(&channel).Error; err != nil {
+		t.Fatalf("failed to create channel: %v", err)
+	}
+}
+
+// signedUpload returns an Android bundle of about size bytes with a create request for it, signed with the
+// test's key and declaring the bundle's real size.
+func signedUpload(t *testing.T, f *sizeBudgetTest, version string, size int) ([]byte, *models.CreateReleaseRequest) {
+	t.Helper()
+	// Random text, so the bundle is not compressed away
+	rng := rand.New(rand.NewSource(int64(size)))
+	js := make([]byte, size)
+	for i := range js {
+		js[i] = "abcdefghij{}();=\n "[rng.Intn(18)]
+	}
+	bundle := bundleArchive(t, archiveFile{"index.android.bundle", js})
+	sum := sha256.Sum256(bundle)
+	return bundle, &models.CreateReleaseRequest{
+		Version:   version,
+		Platform:  "android",
+		Hash:      hex.EncodeToString(sum[:]),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(f.signingKey, bundle)),
+		Size:      int64(len(bundle)),
+	}
+}
+
+func TestSizeBudget_EnforcedOnUploads(t *testing.T) {
+	f := newSizeBudgetTest(t)
+	ctx := context.Background()
+	budgetChannel(t, f, 1000, 0)
+
+	budget, err := f.releaseService.sizeBudget(f.appA, "production")
+	if err != nil || budget.maxSize != 1000 {
+		t.Fatalf("sizeBudget = %+v, %v; want the channel's maximum", budget, err)
+	}
+	if budget, err := f.releaseService.sizeBudget(f.appA, "beta"); err != nil || budget.maxSize != 0 || budget.maxGrowthPercent != 0 {
+		t.Errorf("sizeBudget of a channel without a record = %+v, %v; want none", budget, err)
+	}
+
+	// Declared sizes over the budget are refused before anything is uploaded
+	var budgetErr *SizeBudgetError
+	_, err = f.releaseService.Create(ctx, &models.CreateReleaseRequest{Version: "2.0.0", Platform: "android", Hash: "h", Signature: "s", Size: 1001}, f.appA, strings.NewReader("bundle"), "alice", "")
+	if !errors.As(err, &budgetErr) || budgetErr.Budget != SizeBudgetMaxSize || budgetErr.Artifact != "bundle" {
+		t.Errorf("Create over budget: err = %v, want a max_size SizeBudgetError", err)
+	}
+	release := channelRelease(t, f.tenants, "2.0.0", models.ReleaseDraft, 0)
+	_, err = f.releaseService.AddPatch(ctx, f.appA, release.ID, &models.AddPatchRequest{BaseVersion: "1.0.0", Hash: "h", Signature: "s", Size: 1001}, strings.NewReader("patch"), "alice", "")
+	if !errors.As(err, &budgetErr) || budgetErr.Artifact != "patch" {
+		t.Errorf("AddPatch over budget: err = %v, want a SizeBudgetError for the patch", err)
+	}
+}
+
+func TestSizeBudget_MeasuredSize(t *testing.T) {
+	f := newSizeBudgetTest(t)
+	ctx := context.Background()
+	budgetChannel(t, f, 4000, 0)
+
+	// The declared size fits the budget, but the bundle uploaded is larger
+	bundle, req := signedUpload(t, f, "2.0.0", 5000)
+	req.Size = 1000
+	_, err := f.releaseService.Create(ctx, req, f.appA, bytes.NewReader(bundle), "alice", "")
+	var budgetErr *SizeBudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Budget != SizeBudgetMaxSize || budgetErr.Size != int64(len(bundle)) {
+		t.Fatalf("Create of a bundle larger than declared: err = %v, want a max_size SizeBudgetError for %d bytes", err, len(bundle))
+	}
+	if body, err := f.releaseService.storage.Open(ctx, "bundles/"+f.appA.String()+"/android/production/2.0.0.zip"); err == nil {
+		body.Close()
+		t.Error("the rejected bundle was left in storage")
+	}
+	var count int64
+	f.db.Model(&models.Release{}).Where("version = ?", "2.0.0").Count(&count)
+	if count != 0 {
+		t.Errorf("%d releases created for the rejected bundle, want 0", count)
+	}
+}
+
+func TestSizeBudget_GrowthAgainstActiveRelease(t *testing.T) {
+	f := newSizeBudgetTest(t)
+	ctx := context.Background()
+	budgetChannel(t, f, 0, 20)
+
+	bundle, req := signedUpload(t, f, "2.0.0", 5000)
+	if err := f.db.Model(&models.Release{}).Where("id = ?", f.releaseA).Update("size", len(bundle)).Error; err != nil {
+		t.Fatalf("failed to update release: %v", err)
+	}
+	// A newer release that never went live is much smaller, and must not be what growth is measured against
+	draft := channelRelease(t, f.tenants, "1.5.0", models.ReleaseDraft, 0)
+	if err := f.db.Model(&draft).Updates(map[string]interface{}{"size": len(bundle) / 2, "is_active": false}).Error; err != nil {
+		t.Fatalf("failed to update release: %v", err)
+	}
+
+	declared := *req
+	declared.Size = int64(len(bundle)) * 2
+	_, err := f.releaseService.Create(ctx, &declared, f.appA, bytes.NewReader(bundle), "alice", "")
+	var budgetErr *SizeBudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Budget != SizeBudgetMaxGrowth || budgetErr.ActiveVersion != "1.0.0" {
+		t.Errorf("Create of a bundle twice the active one: err = %v, want a max_growth SizeBudgetError against 1.0.0", err)
+	}
+
+	if _, err := f.releaseService.Create(ctx, req, f.appA, bytes.NewReader(bundle), "alice", ""); err != nil {
+		t.Errorf("Create of a bundle the size of the active one failed: %v", err)
+	}
+}
+
+func TestSizeBudget_OverrideAudited(t *testing.T) {
+	f := newSizeBudgetTest(t)
+	ctx := context.Background()
+	budgetChannel(t, f, 1000, 0)
+
+	bundle, req := signedUpload(t, f, "2.0.0", 5000)
+	req.OverrideSizeBudget = true
+	release, err := f.releaseService.Create(ctx, req, f.appA, bytes.NewReader(bundle), "alice", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Create with the budget overridden failed: %v", err)
+	}
+
+	var logs []models.AuditLog
+	if err := f.db.Where("action = ?", "release.size_budget_override").Find(&logs).Error; err != nil {
+		t.Fatalf("failed to load audit log: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("%d size budget overrides logged, want 1", len(logs))
+	}
+	entry := logs[0]
+	if entry.Actor != "alice" || entry.EntityID != release.ID.String() || entry.IPAddress != "10.0.0.1" || !strings.Contains(entry.Metadata, "channel production allows 1000") {
+		t.Errorf("override logged as %+v, want alice overriding release %s", entry, release.ID)
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/hotpatch/server/internal/models"
+)
+
+// ErrInvalidTargetingRule is returned when a release's targeting rules are malformed.
+var ErrInvalidTargetingRule = errors.New("invalid targeting rule")
+
+const (
+	maxTargetingRules  = 20
+	maxTargetingValues = 100
+	maxTargetingRegex  = 256
+)
+
+// targetingRegexps caches compiled rule patterns. Active releases are re-read from the cache on
+// every update check, so compiling per request would put regexp compilation on the hot path.
+var targetingRegexps sync.Map // pattern -> *regexp.Regexp
+
+// validateTargetingRules checks that every rule names a known attribute, a known operator,
+// and a value that operator can evaluate.
+func validateTargetingRules(rules []models.TargetingRule) error {
+	if len(rules) > maxTargetingRules {
+		return fmt.Errorf("%w: at most %d rules are allowed", ErrInvalidTargetingRule, maxTargetingRules)
+	}
+	for i, rule := range rules {
+		if err := validateTargetingRule(rule); err != nil {
+			return fmt.Errorf("%w: rule %d: %v", ErrInvalidTargetingRule, i+1, err)
+		}
+	}
+	return nil
+}
+
+func validateTargetingRule(rule models.TargetingRule) error {
+	switch rule.Attribute {
+	case models.TargetingAttrOSVersion, models.TargetingAttrDeviceModel, models.TargetingAttrLocale,
+		models.TargetingAttrCountry, models.TargetingAttrSDKVersion:
+	default:
+		if !strings.HasPrefix(rule.Attribute, models.TargetingAttrTagPrefix) || len(rule.Attribute) == len(models.TargetingAttrTagPrefix) {
+			return fmt.Errorf("unknown attribute %q", rule.Attribute)
+		}
+	}
+
+	switch rule.Operator {
+	case models.TargetingOpEquals:
+		if rule.Value == "" {
+			return fmt.Errorf("%s requires a value", rule.Operator)
+		}
+	case models.TargetingOpIn:
+		if len(rule.Values) == 0 || len(rule.Values) > maxTargetingValues {
+			return fmt.Errorf("%s requires between 1 and %d values", rule.Operator, maxTargetingValues)
+		}
+	case models.TargetingOpSemver:
+		if strings.TrimSpace(rule.Value) == "" {
+			return fmt.Errorf("%s requires a version range", rule.Operator)
+		}
+		if _, err := parseVersionRange(rule.Value); err != nil {
+			return err
+		}
+	case models.TargetingOpRegex:
+		if rule.Value == "" || len(rule.Value) > maxTargetingRegex {
+			return fmt.Errorf("%s requires a pattern of at most %d characters", rule.Operator, maxTargetingRegex)
+		}
+		if _, err := regexp.Compile(rule.Value); err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown operator %q", rule.Operator)
+	}
+	return nil
+}
+
+// matchesTargeting reports whether a device satisfies every targeting rule of a release.
+// A rule on an attribute the device did not report never matches.
+func matchesTargeting(rules []models.TargetingRule, req *models.UpdateCheckRequest) bool {
+	for _, rule := range rules {
+		value, ok := targetingAttribute(req, rule.Attribute)
+		if !ok || !matchesRule(rule, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// targetingAttribute returns the value the device reported for a rule attribute.
+func targetingAttribute(req *models.UpdateCheckRequest, attribute string) (string, bool) {
+	var value string
+	switch attribute {
+	case models.TargetingAttrOSVersion:
+		value = req.OSVersion
+	case models.TargetingAttrDeviceModel:
+		value = req.DeviceModel
+	case models.TargetingAttrLocale:
+		value = req.Locale
+	case models.TargetingAttrCountry:
+		value = req.Country
+	case models.TargetingAttrSDKVersion:
+		value = req.SDKVersion
+	default:
+		value = req.Tags[strings.TrimPrefix(attribute, models.TargetingAttrTagPrefix)]
+	}
+	return value, value != ""
+}
+
+func matchesRule(rule models.TargetingRule, value string) bool {
+	switch rule.Operator {
+	case models.TargetingOpEquals:
+		return strings.EqualFold(value, rule.Value)
+	case models.TargetingOpIn:
+		for _, v := range rule.Values {
+			if strings.EqualFold(value, v) {
+				return true
+			}
+		}
+		return false
+	case models.TargetingOpSemver:
+		r, err := parseVersionRange(rule.Value)
+		if err != nil {
+			return false
+		}
+		return r.contains(value)
+	case models.TargetingOpRegex:
+		re, err := targetingRegexp(rule.Value)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(value)
+	}
+	return false
+}
+
+func targetingRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := targetingRegexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	targetingRegexps.Store(pattern, re)
+	return re, nil
+}
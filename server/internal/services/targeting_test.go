@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hotpatch/server/internal/models"
+)
+
+// ── validateTargetingRules Tests ────────────────────────────
+
+func TestValidateTargetingRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  models.TargetingRule
+		valid bool
+	}{
+		{"equals", models.TargetingRule{Attribute: "country", Operator: "equals", Value: "US"}, true},
+		{"in", models.TargetingRule{Attribute: "locale", Operator: "in", Values: []string{"en-US", "en-GB"}}, true},
+		{"semver", models.TargetingRule{Attribute: "os_version", Operator: "semver", Value: ">=14.0.0 <17.0.0"}, true},
+		{"regex", models.TargetingRule{Attribute: "device_model", Operator: "regex", Value: "^Pixel [6-8]"}, true},
+		{"custom tag", models.TargetingRule{Attribute: "tags.plan", Operator: "equals", Value: "pro"}, true},
+		{"unknown attribute", models.TargetingRule{Attribute: "battery", Operator: "equals", Value: "full"}, false},
+		{"empty tag name", models.TargetingRule{Attribute: "tags.", Operator: "equals", Value: "x"}, false},
+		{"unknown operator", models.TargetingRule{Attribute: "country", Operator: "contains", Value: "U"}, false},
+		{"equals without value", models.TargetingRule{Attribute: "country", Operator: "equals"}, false},
+		{"in without values", models.TargetingRule{Attribute: "country", Operator: "in"}, false},
+		{"invalid range", models.TargetingRule{Attribute: "os_version", Operator: "semver", Value: ">=fourteen"}, false},
+		{"invalid regex", models.TargetingRule{Attribute: "device_model", Operator: "regex", Value: "Pixel ("}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTargetingRules([]models.TargetingRule{tc.rule})
+			if tc.valid && err != nil {
+				t.Errorf("validateTargetingRules() = %v, want nil", err)
+			}
+			if !tc.valid && !errors.Is(err, ErrInvalidTargetingRule) {
+				t.Errorf("validateTargetingRules() = %v, want ErrInvalidTargetingRule", err)
+			}
+		})
+	}
+}
+
+// ── matchesTargeting Tests ──────────────────────────────────
+
+func TestMatchesTargeting(t *testing.T) {
+	device := &models.UpdateCheckRequest{
+		OSVersion:   "16.4",
+		DeviceModel: "Pixel 7",
+		Locale:      "en-US",
+		Country:     "us",
+		SDKVersion:  "0.1.0",
+		Tags:        map[string]string{"plan": "pro"},
+	}
+
+	tests := []struct {
+		name     string
+		rules    []models.TargetingRule
+		expected bool
+	}{
+		{"no rules", nil, true},
+		{"equals ignores case", []models.TargetingRule{{Attribute: "country", Operator: "equals", Value: "US"}}, true},
+		{"equals mismatch", []models.TargetingRule{{Attribute: "country", Operator: "equals", Value: "CA"}}, false},
+		{"in", []models.TargetingRule{{Attribute: "locale", Operator: "in", Values: []string{"en-GB", "en-US"}}}, true},
+		{"in mismatch", []models.TargetingRule{{Attribute: "locale", Operator: "in", Values: []string{"de-DE"}}}, false},
+		{"semver partial os version", []models.TargetingRule{{Attribute: "os_version", Operator: "semver", Value: ">=16.0.0 <17.0.0"}}, true},
+		{"semver out of range", []models.TargetingRule{{Attribute: "os_version", Operator: "semver", Value: "^17.0.0"}}, false},
+		{"regex", []models.TargetingRule{{Attribute: "device_model", Operator: "regex", Value: "^Pixel [6-8]$"}}, true},
+		{"regex mismatch", []models.TargetingRule{{Attribute: "device_model", Operator: "regex", Value: "^SM-"}}, false},
+		{"custom tag", []models.TargetingRule{{Attribute: "tags.plan", Operator: "equals", Value: "pro"}}, true},
+		{"missing tag never matches", []models.TargetingRule{{Attribute: "tags.cohort", Operator: "regex", Value: ".*"}}, false},
+		{"all rules must match", []models.TargetingRule{
+			{Attribute: "country", Operator: "equals", Value: "US"},
+			{Attribute: "sdk_version", Operator: "semver", Value: ">=0.2.0"},
+		}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := matchesTargeting(tc.rules, device); got != tc.expected {
+				t.Errorf("matchesTargeting() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSelectCompatibleRelease_Targeting(t *testing.T) {
+	releases := []models.Release{
+		{Version: "1.0.1", TargetingRules: []models.TargetingRule{{Attribute: "os_version", Operator: "semver", Value: "^17.0.0"}}},
+		{Version: "1.0.0"},
+	}
+
+	if got := selectCompatibleRelease(releases, &models.UpdateCheckRequest{OSVersion: "17.1"}); got == nil || got.Version != "1.0.1" {
+		t.Errorf("targeted device got %v, want 1.0.1", got)
+	}
+	if got := selectCompatibleRelease(releases, &models.UpdateCheckRequest{OSVersion: "16.0"}); got == nil || got.Version != "1.0.0" {
+		t.Errorf("untargeted device got %v, want 1.0.0", got)
+	}
+}
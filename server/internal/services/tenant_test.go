@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+)
+
+// tenantTest holds two apps and the services that take an app ID on every call.
+type tenantTest struct {
+	tenants
+	releaseService   *ReleaseService
+	deviceService    *DeviceService
+	analyticsService *AnalyticsService
+}
+
+func newTenantTest(t *testing.T) *tenantTest {
+	t.Helper()
+	tn := newTenants(t, &models.Channel{}, &models.ReleaseTransition{}, &models.Patch{}, &models.Device{}, &models.Installation{}, &models.AuditLog{})
+	releaseService, _ := newReleaseService(tn, PatchGeneration{})
+	deviceRepo := repository.NewDeviceRepository(tn.db)
+
+	return &tenantTest{
+		tenants:          tn,
+		releaseService:   releaseService,
+		deviceService:    NewDeviceService(deviceRepo, NewSecurityService(repository.NewSecurityRepository(tn.db))),
+		analyticsService: NewAnalyticsService(repository.NewAnalyticsRepository(tn.db), deviceRepo, releaseService.repo),
+	}
+}
+
+// ── Cross-Tenant Access Tests ───────────────────────────────
+
+func TestTenant_OwnReleaseIsAccessible(t *testing.T) {
+	f := newTenantTest(t)
+
+	release, err := f.releaseService.GetByID(context.Background(), f.appA, f.releaseA)
+	if err != nil {
+		t.Fatalf("GetByID of own release failed: %v", err)
+	}
+	if release.ID != f.releaseA {
+		t.Errorf("GetByID returned %s, want %s", release.ID, f.releaseA)
+	}
+}
+
+func TestTenant_CrossTenantReadsAreNotFound(t *testing.T) {
+	f := newTenantTest(t)
+	ctx := context.Background()
+
+	checks := map[string]func() error{
+		"GetByID": func() error {
+			_, err := f.releaseService.GetByID(context.Background(), f.appA, f.releaseB)
+			return err
+		},
+		"GetInstallationStats": func() error {
+			_, err := f.deviceService.GetInstallationStats(f.appA, f.releaseB)
+			return err
+		},
+		"GetReleaseDetails": func() error {
+			_, err := f.analyticsService.GetReleaseDetails(ctx, f.appA, f.releaseB)
+			return err
+		},
+	}
+	for name, check := range checks {
+		t.Run(name, func(t *testing.T) {
+			if err := check(); !errors.Is(err, ErrReleaseNotFound) {
+				t.Errorf("%s of another app's release: err = %v, want ErrReleaseNotFound", name, err)
+			}
+		})
+	}
+}
+
+func TestTenant_CrossTenantMutationsAreRejected(t *testing.T) {
+	f := newTenantTest(t)
+	ctx := context.Background()
+
+	mutations := map[string]func() error{
+		"Rollback": func() error {
+			_, err := f.releaseService.Rollback(f.appA, f.releaseB)
+			return err
+		},
+		"RollbackToEmbedded": func() error {
+			_, err := f.releaseService.RollbackToEmbedded(f.appA, f.releaseB)
+			return err
+		},
+		"UpdateRollout": func() error {
+			_, err := f.releaseService.UpdateRollout(ctx, f.appA, f.releaseB, 100)
+			return err
+		},
+		"Archive": func() error {
+			return f.releaseService.Archive(ctx, f.appA, f.releaseB)
+		},
+		"AddPatch": func() error {
+			_, err := f.releaseService.AddPatch(ctx, f.appA, f.releaseB, &models.AddPatchRequest{BaseVersion: "0.9.0"}, strings.NewReader("patch"), "alice", "")
+			return err
+		},
+	}
+	for name, mutate := range mutations {
+		t.Run(name, func(t *testing.T) {
+			if err := mutate(); !errors.Is(err, ErrReleaseNotFound) {
+				t.Errorf("%s of another app's release: err = %v, want ErrReleaseNotFound", name, err)
+			}
+		})
+	}
+
+	// The victim's release must be untouched
+	release := f.release(t, f.releaseB)
+	if !release.IsActive || release.RolloutPercentage != 50 || release.RolledBackAt != nil {
+		t.Errorf("release of app B was modified: active=%v rollout=%d rolledBackAt=%v",
+			release.IsActive, release.RolloutPercentage, release.RolledBackAt)
+	}
+}
+
+func TestTenant_InstallationReportsAreScopedToKeyApp(t *testing.T) {
+	f := newTenantTest(t)
+
+	device := models.Device{ID: uuid.New(), DeviceID: "device-b", AppID: f.appB, Platform: "android"}
+	if err := f.db.Create(&device).Error; err != nil {
+		t.Fatalf("failed to create device: %v", err)
+	}
+
+	// App A reporting for app B's device
+	_, err := f.deviceService.ReportInstallation(f.appA, &models.ReportInstallationRequest{
+		DeviceID: "device-b", ReleaseID: f.releaseB.String(), Status: "applied",
+	})
+	if !errors.Is(err, ErrDeviceAppMismatch) {
+		t.Errorf("report for another app's device: err = %v, want ErrDeviceAppMismatch", err)
+	}
+
+	// App B reporting an install of app A's release
+	_, err = f.deviceService.ReportInstallation(f.appB, &models.ReportInstallationRequest{
+		DeviceID: "device-b", ReleaseID: f.releaseA.String(), Status: "applied",
+	})
+	if !errors.Is(err, ErrDeviceAppMismatch) {
+		t.Errorf("report of another app's release: err = %v, want ErrDeviceAppMismatch", err)
+	}
+
+	// App A registering a device ID owned by app B
+	_, err = f.deviceService.RegisterOrUpdate(&models.RegisterDeviceRequest{
+		DeviceID: "device-b", AppID: f.appA.String(), Platform: "android",
+	})
+	if !errors.Is(err, ErrDeviceAppMismatch) {
+		t.Errorf("registering another app's device: err = %v, want ErrDeviceAppMismatch", err)
+	}
+}
+
+func TestTenant_AuthorizeAppAccess(t *testing.T) {
+	f := newTenantTest(t)
+	settingsService := NewSettingsService(repository.NewSettingsRepository(f.db), nil)
+
+	var app models.App
+	if err := f.db.First(&app, "id = ?", f.appA).Error; err != nil {
+		t.Fatalf("failed to load app: %v", err)
+	}
+
+	if err := settingsService.AuthorizeAppAccess(app.OwnerID, f.appA); err != nil {
+		t.Errorf("owner denied access to own app: %v", err)
+	}
+	if err := settingsService.AuthorizeAppAccess(app.OwnerID, f.appB); !errors.Is(err, ErrAppAccessDenied) {
+		t.Errorf("access to another user's app: err = %v, want ErrAppAccessDenied", err)
+	}
+}
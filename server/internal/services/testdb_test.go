@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/config"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+	"github.com/hotpatch/server/internal/storage"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqliteCompatPool strips the Postgres-only gen_random_uuid() column defaults so the
+// models can be migrated into an in-memory SQLite database. IDs are always set in code.
+type sqliteCompatPool struct {
+	*sql.DB
+}
+
+func (p sqliteCompatPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.DB.ExecContext(ctx, strings.ReplaceAll(query, "DEFAULT gen_random_uuid()", ""), args...)
+}
+
+// newTestDB opens an in-memory SQLite database holding tables for the given models only.
+func newTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1) // every connection to :memory: is a separate database
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(sqlite.New(sqlite.Config{Conn: sqliteCompatPool{sqlDB}}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open gorm: %v", err)
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+// tenants is a test database holding two pro-tier apps, A and B, each with an active production release of
+// version 1.0.0 rolling out to 50%.
+type tenants struct {
+	db                 *gorm.DB
+	appA, appB         uuid.UUID
+	releaseA, releaseB uuid.UUID
+}
+
+// newTenants opens a test database with the apps and releases tables and the given ones, and stores both tenants.
+func newTenants(t *testing.T, tables ...interface{}) tenants {
+	t.Helper()
+	db := newTestDB(t, append([]interface{}{&models.App{}, &models.Release{}}, tables...)...)
+	tn := tenants{db: db, appA: uuid.New(), appB: uuid.New(), releaseA: uuid.New(), releaseB: uuid.New()}
+
+	for _, app := range []models.App{
+		{ID: tn.appA, Name: "app-a", Platform: "android", APIKey: HashApiKey("hp_a"), OwnerID: uuid.New(), Tier: "pro"},
+		{ID: tn.appB, Name: "app-b", Platform: "android", APIKey: HashApiKey("hp_b"), OwnerID: uuid.New(), Tier: "pro"},
+	} {
+		if err := db.Create(&app).Error; err != nil {
+			t.Fatalf("failed to create app: %v", err)
+		}
+	}
+	for _, release := range []models.Release{
+		{ID: tn.releaseA, AppID: tn.appA, Version: "1.0.0", Channel: "production", Hash: "a", RolloutPercentage: 50, IsActive: true, Status: models.ReleaseRollingOut},
+		{ID: tn.releaseB, AppID: tn.appB, Version: "1.0.0", Channel: "production", Hash: "b", RolloutPercentage: 50, IsActive: true, Status: models.ReleaseRollingOut},
+	} {
+		if err := db.Create(&release).Error; err != nil {
+			t.Fatalf("failed to create release: %v", err)
+		}
+	}
+	return tn
+}
+
+func (tn tenants) release(t *testing.T, id uuid.UUID) models.Release {
+	t.Helper()
+	var release models.Release
+	if err := tn.db.First(&release, "id = ?", id).Error; err != nil {
+		t.Fatalf("failed to reload release: %v", err)
+	}
+	return release
+}
+
+// channelRelease stores a production release of app A created ago, with hash "h" + version.
+func channelRelease(t *testing.T, tn tenants, version, status string, ago time.Duration) models.Release {
+	t.Helper()
+	release := models.Release{ID: uuid.New(), AppID: tn.appA, Version: version, Channel: "production", Hash: "h" + version, BundleKey: "bundles/" + version + ".zip", RolloutPercentage: 100, Status: status, CreatedAt: time.Now().Add(-ago)}
+	if err := tn.db.Create(&release).Error; err != nil {
+		t.Fatalf("failed to create release: %v", err)
+	}
+	return release
+}
+
+// newReleaseService wires a ReleaseService without storage over the test database, along with the
+// RolloutService it drives.
+func newReleaseService(tn tenants, patchGeneration PatchGeneration) (*ReleaseService, *RolloutService) {
+	releaseRepo := repository.NewReleaseRepository(tn.db)
+	channelRepo := repository.NewChannelRepository(tn.db)
+	securityService := NewSecurityService(repository.NewSecurityRepository(tn.db))
+	settingsService := NewSettingsService(repository.NewSettingsRepository(tn.db), securityService)
+	freezeService := NewFreezeService(channelRepo)
+	lifecycle := NewReleaseLifecycle(releaseRepo, settingsService)
+
+	rolloutService := NewRolloutService(repository.NewRolloutRepository(tn.db), releaseRepo, settingsService, securityService, freezeService, lifecycle, nil)
+	releaseService := NewReleaseService(releaseRepo, channelRepo, nil, settingsService, securityService, NewEncryptionService(), rolloutService, freezeService, lifecycle, nil, 200<<20, patchGeneration)
+	return releaseService, rolloutService
+}
+
+// newObjectStore serves the "bundles" bucket, starting with the given objects, over enough of the S3 API for
+// the storage client to read, upload and delete single-part objects.
+func newObjectStore(t *testing.T, objects map[string][]byte) *storage.S3Storage {
+	t.Helper()
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/bundles/")
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if objects == nil {
+				objects = make(map[string][]byte)
+			}
+			objects[key] = data
+			w.Header().Set("ETag", `"etag"`)
+		case http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	store, err := storage.NewS3Storage(&config.Config{S3Endpoint: server.URL, S3Region: "auto", S3Bucket: "bundles", AWSAccessKey: "test", AWSSecretKey: "test"})
+	if err != nil {
+		t.Fatalf("NewS3Storage failed: %v", err)
+	}
+	return store
+}
@@ -5,32 +5,41 @@ import (
 	"encoding/json"
 	"fmt"
 	"hash/fnv"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hotpatch/server/internal/models"
 	"github.com/hotpatch/server/internal/repository"
+	"github.com/hotpatch/server/internal/storage"
 	"github.com/redis/go-redis/v9"
 )
 
 // UpdateService handles the high-performance update check logic.
 type UpdateService struct {
-	releaseRepo *repository.ReleaseRepository
-	deviceRepo  *repository.DeviceRepository
-	redis       *redis.Client
+	releaseRepo  *repository.ReleaseRepository
+	deviceRepo   *repository.DeviceRepository
+	overrideRepo *repository.OverrideRepository
+	storage      *storage.S3Storage
+	redis        *redis.Client
 }
 
 // NewUpdateService creates a new UpdateService.
-func NewUpdateService(releaseRepo *repository.ReleaseRepository, deviceRepo *repository.DeviceRepository, redis *redis.Client) *UpdateService {
+func NewUpdateService(releaseRepo *repository.ReleaseRepository, deviceRepo *repository.DeviceRepository, overrideRepo *repository.OverrideRepository, storage *storage.S3Storage, redis *redis.Client) *UpdateService {
 	return &UpdateService{
-		releaseRepo: releaseRepo,
-		deviceRepo:  deviceRepo,
-		redis:       redis,
+		releaseRepo:  releaseRepo,
+		deviceRepo:   deviceRepo,
+		overrideRepo: overrideRepo,
+		storage:      storage,
+		redis:        redis,
 	}
 }
 
+// activeReleaseCacheKey is the Redis key under which the active release of a channel is cached.
+// The "v2" segment keeps entries written before releases stored object keys from being served.
+func activeReleaseCacheKey(appID uuid.UUID, channel string) string {
+	return fmt.Sprintf("release:active:v2:%s:%s", appID, channel)
+}
+
 // CheckForUpdate determines if an update is available for a device.
 // This is the most critical function in the system — it must be fast.
 func (s *UpdateService) CheckForUpdate(ctx context.Context, req *models.UpdateCheckRequest) (*models.UpdateCheckResponse, error) {
@@ -39,141 +48,320 @@ func (s *UpdateService) CheckForUpdate(ctx context.Context, req *models.UpdateCh
 		return nil, fmt.Errorf("invalid app_id: %w", err)
 	}
 
-	var release *models.Release
+	// A pinned device gets its release regardless of channel, rollout and targeting
+	if override := s.activeOverride(ctx, appID, req.DeviceID, time.Now()); override != nil {
+		pinned, err := s.releaseRepo.GetWithPatches(appID, override.ReleaseID)
+		if err == nil {
+			if compareVersions(pinned.Version, req.Version) == 0 {
+				return &models.UpdateCheckResponse{UpdateAvailable: false}, nil
+			}
+			return s.offerRelease(ctx, pinned, req)
+		}
+		// The pinned release was archived; fall back to the channel
+	}
+
+	var releases []models.Release
+	cacheHit := false
 
 	// Try Redis Cache first
 	if s.redis != nil {
-		cacheKey := fmt.Sprintf("release:active:%s:%s", appID, req.Channel)
-		cached, err := s.redis.Get(ctx, cacheKey).Result()
-		if err == nil {
-			_ = json.Unmarshal([]byte(cached), &release)
+		cached, err := s.redis.Get(ctx, activeReleaseCacheKey(appID, req.Channel)).Result()
+		if err == nil && json.Unmarshal([]byte(cached), &releases) == nil {
+			cacheHit = true
 		}
 	}
 
-	if release == nil {
+	if !cacheHit {
 		// Cache miss — hit the DB
-		release, err = s.releaseRepo.GetActiveRelease(appID, req.Channel)
+		releases, err = s.releaseRepo.GetActiveReleases(appID, req.Channel)
 		if err != nil {
-			// No active release found — no update available
-			return &models.UpdateCheckResponse{UpdateAvailable: false}, nil
+			return nil, fmt.Errorf("failed to load active releases: %w", err)
 		}
 
 		// Save to Redis (TTL 5 minutes for active releases)
 		if s.redis != nil {
-			cacheKey := fmt.Sprintf("release:active:%s:%s", appID, req.Channel)
-			data, _ := json.Marshal(release)
-			s.redis.Set(ctx, cacheKey, data, 5*time.Minute)
+			data, _ := json.Marshal(releases)
+			s.redis.Set(ctx, activeReleaseCacheKey(appID, req.Channel), data, 5*time.Minute)
 		}
 	}
 
+	// Pick the newest active release this native build can run and this device is targeted by
+	release := selectCompatibleRelease(releases, req)
+	if release == nil {
+		// No compatible release — devices on a pulled release fall back to the embedded bundle
+		return s.checkForRevert(ctx, appID, req, nil)
+	}
+
 	// Check if the current version is already up to date or newer
 	if !isVersionGreater(release.Version, req.Version) {
+		// A device ahead of the active release may be running a release that was rolled back
+		if isVersionGreater(req.Version, release.Version) {
+			return s.checkForRevert(ctx, appID, req, release)
+		}
+		return &models.UpdateCheckResponse{UpdateAvailable: false}, nil
+	}
+
+	// Paused and halted releases stay on the devices that have them but reach no new ones
+	if !isServing(release.Status) {
 		return &models.UpdateCheckResponse{UpdateAvailable: false}, nil
 	}
 
 	// Check rollout percentage using stable cohort bucketing
 	if release.RolloutPercentage < 100 {
-		if !isInRollout(req.DeviceID, release.RolloutPercentage) {
+		if !isInRollout(release.RolloutSalt, rolloutBucketKey(release, req), release.RolloutPercentage) {
 			return &models.UpdateCheckResponse{UpdateAvailable: false}, nil
 		}
 	}
 
-	// Determine if we can send a patch instead of a full bundle
-	targetURL := release.BundleURL
+	return s.offerRelease(ctx, release, req)
+}
+
+// offerRelease builds the update response for a release, sending the patch or chain of patches that
+// reaches it from the device's bundle when that is a smaller download than the full bundle.
+func (s *UpdateService) offerRelease(ctx context.Context, release *models.Release, req *models.UpdateCheckRequest) (*models.UpdateCheckResponse, error) {
+	plan := planDownload(release, req, func() []patchEdge {
+		return s.chainablePatches(ctx, release.AppID, release.Channel)
+	})
+
+	targetKey := release.BundleKey
 	targetHash := release.Hash
 	targetSignature := release.Signature
 	isEncrypted := release.IsEncrypted
+	encryptionFormat := bundleEncryptionFormat(release)
 	isPatch := release.IsPatch
 	baseVersion := release.BaseVersion
 
-	for _, p := range release.Patches {
-		if p.BaseVersion == req.Version {
-			targetURL = p.PatchURL
+	var chain []models.PatchHop
+	switch {
+	case len(plan.patches) == 1:
+		p := plan.patches[0].Patch
+		targetKey = p.PatchKey
+		isPatch = true
+		baseVersion = p.BaseVersion
+		// The SDK verifies the bundle it reconstructs, so a patch known to reproduce the release is
+		// checked against the release's own hash and signature; the server cannot sign the ones it generates
+		if !p.Verified {
 			targetHash = p.Hash
 			targetSignature = p.Signature
-			isPatch = true
-			isEncrypted = release.IsEncrypted
-			baseVersion = p.BaseVersion
-			break
+			encryptionFormat = patchEncryptionFormat(release)
+		} else {
+			// It was checked by applying it as uploaded to the decrypted base, so what it rebuilds is plain
+			isEncrypted = false
+			encryptionFormat = ""
 		}
+	case len(plan.patches) > 1:
+		// Every hop is checked against the bundle it reproduces, the last one against the release's
+		for _, hop := range plan.patches {
+			url, err := s.downloadURL(ctx, hop.Patch.PatchKey)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, models.PatchHop{URL: url, Hash: hop.Hash, Size: hop.Patch.Size, Version: hop.Version})
+		}
+		targetKey = plan.patches[0].Patch.PatchKey
+		isPatch = true
+		baseVersion = plan.patches[0].Patch.BaseVersion
+	}
+
+	// Mint a fresh download URL for the chosen artifact
+	targetURL, err := s.downloadURL(ctx, targetKey)
+	if err != nil {
+		return nil, err
 	}
 
 	// Update available — return the bundle or patch info
 	return &models.UpdateCheckResponse{
-		ID:              release.ID.String(),
-		UpdateAvailable: true,
-		BundleURL:       targetURL,
-		Hash:            targetHash,
-		Signature:       targetSignature,
-		Mandatory:       release.Mandatory,
-		Version:         release.Version,
-		IsEncrypted:     isEncrypted,
-		IsPatch:         isPatch,
-		BaseVersion:     baseVersion,
+		ID:               release.ID.String(),
+		UpdateAvailable:  true,
+		Action:           models.UpdateActionUpdate,
+		BundleURL:        targetURL,
+		Hash:             targetHash,
+		Signature:        targetSignature,
+		Mandatory:        release.Mandatory,
+		Version:          release.Version,
+		IsEncrypted:      isEncrypted,
+		EncryptionFormat: encryptionFormat,
+		IsPatch:          isPatch,
+		BaseVersion:      baseVersion,
+		Size:             plan.size,
+		PatchChain:       chain,
 	}, nil
 }
 
-// isInRollout implements stable cohort bucketing using FNV-1a hash.
-func isInRollout(deviceID string, rolloutPct int) bool {
-	h := fnv.New32a()
-	h.Write([]byte(deviceID))
-	bucket := int(h.Sum32() % 100) // 0-99, stable per device
-	return bucket < rolloutPct
+// bundleEncryptionFormat returns how the SDK decrypts a release's full bundle, or "" when it is not encrypted.
+func bundleEncryptionFormat(release *models.Release) string {
+	if !release.IsEncrypted {
+		return ""
+	}
+	if release.EncryptionFormat == "" {
+		return models.EncryptionSingle
+	}
+	return release.EncryptionFormat
 }
 
-// isVersionGreater returns true if v1 > v2 using semantic versioning.
-// Handles versions like "1.2.3", "1.10.0", etc.
-func isVersionGreater(v1, v2 string) bool {
-	p1 := parseVersion(v1)
-	p2 := parseVersion(v2)
+// patchEncryptionFormat returns how the SDK decrypts a release's patches. Patches are stored as uploaded,
+// never re-encrypted by the server, so an encrypted one is in the single-shot format.
+func patchEncryptionFormat(release *models.Release) string {
+	if !release.IsEncrypted {
+		return ""
+	}
+	return models.EncryptionSingle
+}
 
-	maxLen := len(p1)
-	if len(p2) > maxLen {
-		maxLen = len(p2)
+// activeOverride returns the unexpired override pinning a device, or nil when it has none.
+// The app's overrides are cached as one list so unpinned devices cost a single cache read.
+func (s *UpdateService) activeOverride(ctx context.Context, appID uuid.UUID, deviceID string, now time.Time) *models.DeviceOverride {
+	if s.overrideRepo == nil {
+		return nil
 	}
 
-	for i := 0; i < maxLen; i++ {
-		var a, b int
-		if i < len(p1) {
-			a = p1[i]
+	var overrides []models.DeviceOverride
+	cacheHit := false
+	if s.redis != nil {
+		cached, err := s.redis.Get(ctx, deviceOverridesCacheKey(appID)).Result()
+		if err == nil && json.Unmarshal([]byte(cached), &overrides) == nil {
+			cacheHit = true
 		}
-		if i < len(p2) {
-			b = p2[i]
+	}
+
+	if !cacheHit {
+		var err error
+		overrides, err = s.overrideRepo.ListActive(appID, now)
+		if err != nil {
+			// Overrides are a support tool; never fail the update check over them
+			return nil
 		}
-		if a > b {
-			return true
+		if s.redis != nil {
+			data, _ := json.Marshal(overrides)
+			s.redis.Set(ctx, deviceOverridesCacheKey(appID), data, 5*time.Minute)
 		}
-		if a < b {
-			return false
+	}
+
+	for i := range overrides {
+		if overrides[i].DeviceID == deviceID && !overrides[i].IsExpired(now) {
+			return &overrides[i]
 		}
 	}
-	return false // equal
+	return nil
 }
 
-// parseVersion splits a version string like "1.2.3" into [1, 2, 3].
-// Tolerates leading "v" prefix and non-numeric suffixes (e.g. "1.2.3-beta").
-func parseVersion(v string) []int {
-	// Strip optional "v" prefix
-	if len(v) > 0 && (v[0] == 'v' || v[0] == 'V') {
-		v = v[1:]
-	}
-
-	parts := strings.Split(v, ".")
-	result := make([]int, 0, len(parts))
-	for _, p := range parts {
-		// Strip any pre-release suffix (e.g. "3-beta" -> "3")
-		numStr := p
-		for i, c := range p {
-			if c < '0' || c > '9' {
-				numStr = p[:i]
-				break
-			}
+// selectCompatibleRelease returns the highest-versioned release whose native version target
+// includes the device's binary and whose targeting rules match the device, or nil if none does.
+func selectCompatibleRelease(releases []models.Release, req *models.UpdateCheckRequest) *models.Release {
+	var best *models.Release
+	for i := range releases {
+		r := &releases[i]
+		if !isNativeCompatible(r.TargetNativeVersion, req.NativeVersion) || !matchesTargeting(r.TargetingRules, req) {
+			continue
+		}
+		if best == nil || isVersionGreater(r.Version, best.Version) {
+			best = r
 		}
-		n, err := strconv.Atoi(numStr)
+	}
+	return best
+}
+
+// checkForRevert instructs a device running a rolled-back release to move to the target release,
+// or to its embedded bundle when target is nil. Devices on any other version get no update.
+func (s *UpdateService) checkForRevert(ctx context.Context, appID uuid.UUID, req *models.UpdateCheckRequest, target *models.Release) (*models.UpdateCheckResponse, error) {
+	pulled, err := s.releaseRepo.GetRolledBackByVersion(appID, req.Channel, req.Version)
+	if err != nil {
+		return &models.UpdateCheckResponse{UpdateAvailable: false}, nil
+	}
+
+	response := &models.UpdateCheckResponse{
+		Action:           models.UpdateActionRevert,
+		Mandatory:        true,
+		RevertToEmbedded: target == nil,
+	}
+
+	var targetID *uuid.UUID
+	if target != nil {
+		targetURL, err := s.downloadURL(ctx, target.BundleKey)
 		if err != nil {
-			n = 0
+			return nil, err
+		}
+		targetID = &target.ID
+		response.ID = target.ID.String()
+		response.UpdateAvailable = true
+		response.BundleURL = targetURL
+		response.Hash = target.Hash
+		response.Signature = target.Signature
+		response.Version = target.Version
+		response.IsEncrypted = target.IsEncrypted
+		response.EncryptionFormat = bundleEncryptionFormat(target)
+	}
+
+	response.RevertID = s.trackRevert(appID, req.DeviceID, pulled.ID, targetID)
+	return response, nil
+}
+
+// trackRevert records (or refreshes) the pending revert for a device so the SDK can report completion.
+// Returns an empty ID when the device has never registered; the revert is still served untracked.
+func (s *UpdateService) trackRevert(appID uuid.UUID, sdkDeviceID string, fromReleaseID uuid.UUID, toReleaseID *uuid.UUID) string {
+	device, err := s.deviceRepo.GetByDeviceID(sdkDeviceID)
+	if err != nil || device.AppID != appID {
+		return ""
+	}
+
+	revert, err := s.deviceRepo.GetPendingRevert(device.ID, fromReleaseID)
+	if err != nil {
+		revert = &models.Revert{
+			ID:            uuid.New(),
+			AppID:         appID,
+			DeviceID:      device.ID,
+			FromReleaseID: fromReleaseID,
+			Status:        "pending",
 		}
-		result = append(result, n)
+	} else if sameReleaseID(revert.ToReleaseID, toReleaseID) {
+		return revert.ID.String()
 	}
-	return result
+
+	revert.ToReleaseID = toReleaseID
+	if err := s.deviceRepo.SaveRevert(revert); err != nil {
+		return ""
+	}
+	return revert.ID.String()
+}
+
+func sameReleaseID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// downloadURL resolves a stored object key to a URL the SDK can download from.
+func (s *UpdateService) downloadURL(ctx context.Context, key string) (string, error) {
+	if s.storage == nil {
+		return "", fmt.Errorf("object storage is not configured")
+	}
+	url, err := s.storage.DownloadURL(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate download URL: %w", err)
+	}
+	return url, nil
+}
+
+// rolloutBucketKey returns the identity a release buckets the caller by: the SDK's user ID when the
+// release buckets by user and one was sent, otherwise the device ID.
+func rolloutBucketKey(release *models.Release, req *models.UpdateCheckRequest) string {
+	if release.BucketBy == models.BucketByUser && req.UserID != "" {
+		return req.UserID
+	}
+	return req.DeviceID
+}
+
+// isInRollout implements stable cohort bucketing using FNV-1a hash.
+// The release's salt gives every release its own cohort, while raising the percentage of one
+// release only adds devices to it. An empty salt hashes the key alone, as releases did before salting.
+func isInRollout(salt, key string, rolloutPct int) bool {
+	h := fnv.New32a()
+	if salt != "" {
+		h.Write([]byte(salt))
+		h.Write([]byte{':'})
+	}
+	h.Write([]byte(key))
+	bucket := int(h.Sum32() % 100) // 0-99, stable per key and salt
+	return bucket < rolloutPct
 }
@@ -1,116 +1,22 @@
 package services
 
 import (
+	"context"
+	"hash/fnv"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/hotpatch/server/internal/models"
 )
 
-// ── isVersionGreater Tests ──────────────────────────────────
-
-func TestIsVersionGreater(t *testing.T) {
-	tests := []struct {
-		name     string
-		v1       string
-		v2       string
-		expected bool
-	}{
-		// Basic comparisons
-		{"greater major", "2.0.0", "1.0.0", true},
-		{"greater minor", "1.1.0", "1.0.0", true},
-		{"greater patch", "1.0.1", "1.0.0", true},
-		{"equal versions", "1.0.0", "1.0.0", false},
-		{"lesser major", "1.0.0", "2.0.0", false},
-		{"lesser minor", "1.0.0", "1.1.0", false},
-		{"lesser patch", "1.0.0", "1.0.1", false},
-
-		// Multi-digit versions
-		{"double digit minor", "1.10.0", "1.9.0", true},
-		{"double digit patch", "1.0.10", "1.0.9", true},
-		{"triple digit", "1.100.0", "1.99.0", true},
-
-		// Leading "v" prefix
-		{"v prefix v1", "v2.0.0", "1.0.0", true},
-		{"v prefix v2", "2.0.0", "v1.0.0", true},
-		{"v prefix both", "v2.0.0", "v1.0.0", true},
-		{"V uppercase prefix", "V2.0.0", "1.0.0", true},
-
-		// Pre-release suffixes (stripped, only numeric compared)
-		{"pre-release suffix", "1.2.3", "1.2.3-beta", false}, // numeric parts equal -> false
-		{"pre-release greater", "1.2.4-rc1", "1.2.3", true},
-		{"pre-release lesser", "1.2.2-alpha", "1.2.3", false},
-
-		// Varying lengths
-		{"shorter v1", "1.2", "1.2.0", false}, // [1,2] vs [1,2,0] — equal
-		{"shorter v2", "1.2.1", "1.2", true},  // [1,2,1] vs [1,2] -> 1 > 0
-		{"single digit", "2", "1", true},
-		{"single digit equal", "1", "1", false},
-
-		// Edge cases
-		{"empty v1", "", "1.0.0", false},
-		{"empty v2", "1.0.0", "", true},
-		{"both empty", "", "", false},
-		{"zero versions", "0.0.0", "0.0.0", false},
-		{"zero vs one", "0.0.1", "0.0.0", true},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			result := isVersionGreater(tc.v1, tc.v2)
-			if result != tc.expected {
-				t.Errorf("isVersionGreater(%q, %q) = %v, want %v", tc.v1, tc.v2, result, tc.expected)
-			}
-		})
-	}
-}
-
-// ── parseVersion Tests ──────────────────────────────────────
-
-func TestParseVersion(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected []int
-	}{
-		{"1.2.3", []int{1, 2, 3}},
-		{"v1.2.3", []int{1, 2, 3}},
-		{"V1.2.3", []int{1, 2, 3}},
-		{"1.2.3-beta", []int{1, 2, 3}},
-		{"1.10.0", []int{1, 10, 0}},
-		{"0.0.0", []int{0, 0, 0}},
-		{"", []int{0}},
-		{"v", []int{0}},
-		{"1", []int{1}},
-		{"1.2", []int{1, 2}},
-		{"abc", []int{0}},
-		{"1.abc.3", []int{1, 0, 3}},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.input, func(t *testing.T) {
-			result := parseVersion(tc.input)
-			if len(result) != len(tc.expected) {
-				t.Errorf("parseVersion(%q) returned %v (len=%d), want %v (len=%d)",
-					tc.input, result, len(result), tc.expected, len(tc.expected))
-				return
-			}
-			for i, v := range result {
-				if v != tc.expected[i] {
-					t.Errorf("parseVersion(%q)[%d] = %d, want %d", tc.input, i, v, tc.expected[i])
-				}
-			}
-		})
-	}
-}
-
 // ── isInRollout Tests ────────────────────────────────────────
 
 func TestIsInRollout(t *testing.T) {
 	t.Run("0 percent excludes all", func(t *testing.T) {
 		for i := 0; i < 100; i++ {
 			deviceID := uuid.New().String()
-			if isInRollout(deviceID, 0) {
-				t.Errorf("isInRollout(%q, 0) = true, want false", deviceID)
+			if isInRollout("", deviceID, 0) {
+				t.Errorf("isInRollout(\"\", %q, 0) = true, want false", deviceID)
 			}
 		}
 	})
@@ -118,17 +24,17 @@ func TestIsInRollout(t *testing.T) {
 	t.Run("100 percent includes all", func(t *testing.T) {
 		for i := 0; i < 100; i++ {
 			deviceID := uuid.New().String()
-			if !isInRollout(deviceID, 100) {
-				t.Errorf("isInRollout(%q, 100) = false, want true", deviceID)
+			if !isInRollout("", deviceID, 100) {
+				t.Errorf("isInRollout(\"\", %q, 100) = false, want true", deviceID)
 			}
 		}
 	})
 
 	t.Run("stable results for same device", func(t *testing.T) {
 		deviceID := "stable-device-test-123"
-		first := isInRollout(deviceID, 50)
+		first := isInRollout("", deviceID, 50)
 		for i := 0; i < 100; i++ {
-			result := isInRollout(deviceID, 50)
+			result := isInRollout("", deviceID, 50)
 			if result != first {
 				t.Fatalf("isInRollout returned inconsistent results for same device: call 0=%v, call %d=%v", first, i+1, result)
 			}
@@ -139,7 +45,7 @@ func TestIsInRollout(t *testing.T) {
 		inCount := 0
 		total := 10000
 		for i := 0; i < total; i++ {
-			if isInRollout(uuid.New().String(), 50) {
+			if isInRollout("", uuid.New().String(), 50) {
 				inCount++
 			}
 		}
@@ -154,7 +60,7 @@ func TestIsInRollout(t *testing.T) {
 		inCount := 0
 		total := 10000
 		for i := 0; i < total; i++ {
-			if isInRollout(uuid.New().String(), 10) {
+			if isInRollout("", uuid.New().String(), 10) {
 				inCount++
 			}
 		}
@@ -168,7 +74,7 @@ func TestIsInRollout(t *testing.T) {
 		inCount := 0
 		total := 10000
 		for i := 0; i < total; i++ {
-			if isInRollout(uuid.New().String(), 90) {
+			if isInRollout("", uuid.New().String(), 90) {
 				inCount++
 			}
 		}
@@ -179,6 +85,102 @@ func TestIsInRollout(t *testing.T) {
 	})
 }
 
+func TestIsInRollout_Salted(t *testing.T) {
+	t.Run("empty salt matches unsalted bucketing", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			deviceID := uuid.New().String()
+			h := fnv.New32a()
+			h.Write([]byte(deviceID))
+			want := int(h.Sum32()%100) < 30
+			if got := isInRollout("", deviceID, 30); got != want {
+				t.Fatalf("isInRollout(\"\", %q, 30) = %v, want %v", deviceID, got, want)
+			}
+		}
+	})
+
+	t.Run("raising the percentage keeps the cohort", func(t *testing.T) {
+		salt := "release-salt"
+		for i := 0; i < 1000; i++ {
+			deviceID := uuid.New().String()
+			if isInRollout(salt, deviceID, 5) && !isInRollout(salt, deviceID, 25) {
+				t.Fatalf("device %q left the cohort when rollout grew from 5%% to 25%%", deviceID)
+			}
+		}
+	})
+
+	t.Run("different salts pick different cohorts", func(t *testing.T) {
+		both, first := 0, 0
+		for i := 0; i < 10000; i++ {
+			deviceID := uuid.New().String()
+			inA := isInRollout("salt-a", deviceID, 10)
+			if inA {
+				first++
+				if isInRollout("salt-b", deviceID, 10) {
+					both++
+				}
+			}
+		}
+		// Independent 10% cohorts overlap on ~10% of the first one
+		if first == 0 || float64(both)/float64(first) > 0.2 {
+			t.Errorf("cohorts of different salts overlap on %d/%d devices", both, first)
+		}
+	})
+}
+
+// ── rolloutBucketKey Tests ──────────────────────────────────
+
+func TestRolloutBucketKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		bucketBy string
+		userID   string
+		expected string
+	}{
+		{"device bucketing", models.BucketByDevice, "user-1", "device-1"},
+		{"user bucketing", models.BucketByUser, "user-1", "user-1"},
+		{"user bucketing without user ID", models.BucketByUser, "", "device-1"},
+		{"unset bucketing", "", "user-1", "device-1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			release := &models.Release{BucketBy: tc.bucketBy}
+			req := &models.UpdateCheckRequest{DeviceID: "device-1", UserID: tc.userID}
+			if got := rolloutBucketKey(release, req); got != tc.expected {
+				t.Errorf("rolloutBucketKey() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+// ── sameReleaseID Tests ─────────────────────────────────────
+
+func TestSameReleaseID(t *testing.T) {
+	a := uuid.New()
+	b := uuid.New()
+	aCopy := a
+
+	tests := []struct {
+		name     string
+		x, y     *uuid.UUID
+		expected bool
+	}{
+		{"both embedded", nil, nil, true},
+		{"embedded vs release", nil, &a, false},
+		{"release vs embedded", &a, nil, false},
+		{"same release", &a, &aCopy, true},
+		{"different releases", &a, &b, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sameReleaseID(tc.x, tc.y); got != tc.expected {
+				t.Errorf("sameReleaseID() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
 // ── CheckForUpdate Tests (with mock repositories) ─────────────
 
 // mockReleaseRepo implements the methods used by UpdateService.
@@ -252,7 +254,7 @@ func TestCheckForUpdate_RolloutFiltering(t *testing.T) {
 		excluded := 0
 		for i := 0; i < 100; i++ {
 			id := deviceID + uuid.New().String()
-			if !isInRollout(id, rolloutPct) {
+			if !isInRollout("", id, rolloutPct) {
 				excluded++
 			}
 		}
@@ -268,7 +270,7 @@ func TestCheckForUpdate_PatchSelection(t *testing.T) {
 		release := &models.Release{
 			ID:                uuid.New(),
 			Version:           "2.0.0",
-			BundleURL:         "https://cdn.example.com/bundles/2.0.0.zip",
+			BundleKey:         "bundles/app/android/production/2.0.0.zip",
 			Hash:              "full-hash",
 			Signature:         "full-sig",
 			IsEncrypted:       false,
@@ -277,13 +279,13 @@ func TestCheckForUpdate_PatchSelection(t *testing.T) {
 			Patches: []models.Patch{
 				{
 					BaseVersion: "1.0.0",
-					PatchURL:    "https://cdn.example.com/patches/1.0.0-to-2.0.0.zip",
+					PatchKey:    "patches/app/release/from-1.0.0.patch",
 					Hash:        "patch-hash",
 					Signature:   "patch-sig",
 				},
 				{
 					BaseVersion: "1.5.0",
-					PatchURL:    "https://cdn.example.com/patches/1.5.0-to-2.0.0.zip",
+					PatchKey:    "patches/app/release/from-1.5.0.patch",
 					Hash:        "patch-hash-2",
 					Signature:   "patch-sig-2",
 				},
@@ -293,13 +295,13 @@ func TestCheckForUpdate_PatchSelection(t *testing.T) {
 		// Device at 1.0.0 should get the first patch
 		deviceVersion := "1.0.0"
 
-		targetURL := release.BundleURL
+		targetKey := release.BundleKey
 		targetHash := release.Hash
 		isPatch := release.IsPatch
 
 		for _, p := range release.Patches {
 			if p.BaseVersion == deviceVersion {
-				targetURL = p.PatchURL
+				targetKey = p.PatchKey
 				targetHash = p.Hash
 				isPatch = true
 				break
@@ -309,8 +311,8 @@ func TestCheckForUpdate_PatchSelection(t *testing.T) {
 		if !isPatch {
 			t.Error("Expected patch to be selected for device at 1.0.0")
 		}
-		if targetURL != "https://cdn.example.com/patches/1.0.0-to-2.0.0.zip" {
-			t.Errorf("Expected patch URL for 1.0.0, got %s", targetURL)
+		if targetKey != "patches/app/release/from-1.0.0.patch" {
+			t.Errorf("Expected patch key for 1.0.0, got %s", targetKey)
 		}
 		if targetHash != "patch-hash" {
 			t.Errorf("Expected patch hash, got %s", targetHash)
@@ -321,22 +323,22 @@ func TestCheckForUpdate_PatchSelection(t *testing.T) {
 		release := &models.Release{
 			ID:                uuid.New(),
 			Version:           "2.0.0",
-			BundleURL:         "https://cdn.example.com/bundles/2.0.0.zip",
+			BundleKey:         "bundles/app/android/production/2.0.0.zip",
 			Hash:              "full-hash",
 			Signature:         "full-sig",
 			RolloutPercentage: 100,
 			Patches: []models.Patch{
-				{BaseVersion: "1.5.0", PatchURL: "patch-url", Hash: "ph", Signature: "ps"},
+				{BaseVersion: "1.5.0", PatchKey: "patch-key", Hash: "ph", Signature: "ps"},
 			},
 		}
 
 		deviceVersion := "1.0.0"
-		targetURL := release.BundleURL
+		targetKey := release.BundleKey
 		isPatch := false
 
 		for _, p := range release.Patches {
 			if p.BaseVersion == deviceVersion {
-				targetURL = p.PatchURL
+				targetKey = p.PatchKey
 				isPatch = true
 				break
 			}
@@ -345,8 +347,48 @@ func TestCheckForUpdate_PatchSelection(t *testing.T) {
 		if isPatch {
 			t.Error("No patch should match for device at 1.0.0 (only 1.5.0 patch exists)")
 		}
-		if targetURL != "https://cdn.example.com/bundles/2.0.0.zip" {
-			t.Errorf("Expected full bundle URL, got %s", targetURL)
+		if targetKey != "bundles/app/android/production/2.0.0.zip" {
+			t.Errorf("Expected full bundle key, got %s", targetKey)
 		}
 	})
 }
+
+func TestOfferRelease_PatchEncryption(t *testing.T) {
+	service := &UpdateService{storage: newObjectStore(t, nil)}
+	release := &models.Release{
+		ID:               uuid.New(),
+		Version:          "2.0.0",
+		BundleKey:        "bundles/2.0.0.zip",
+		Hash:             "full-hash",
+		Size:             1000,
+		IsEncrypted:      true,
+		EncryptionFormat: models.EncryptionChunked,
+	}
+
+	cases := []struct {
+		name          string
+		verified      bool
+		wantHash      string
+		wantEncrypted bool
+		wantFormat    string
+	}{
+		// Checked against the decrypted base, it rebuilds the plain bundle the release hash is of
+		{"verified patch", true, "full-hash", false, ""},
+		{"unverified patch", false, "patch-hash", true, models.EncryptionSingle},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			release.Patches = []models.Patch{{BaseVersion: "1.0.0", PatchKey: "patches/from-1.0.0.patch", Hash: "patch-hash", Size: 10, Verified: tc.verified}}
+			resp, err := service.offerRelease(context.Background(), release, &models.UpdateCheckRequest{Version: "1.0.0"})
+			if err != nil {
+				t.Fatalf("offerRelease failed: %v", err)
+			}
+			if !resp.IsPatch || resp.Hash != tc.wantHash {
+				t.Errorf("isPatch = %v, hash = %s, want a patch checked against %s", resp.IsPatch, resp.Hash, tc.wantHash)
+			}
+			if resp.IsEncrypted != tc.wantEncrypted || resp.EncryptionFormat != tc.wantFormat {
+				t.Errorf("isEncrypted = %v, format = %q, want %v, %q", resp.IsEncrypted, resp.EncryptionFormat, tc.wantEncrypted, tc.wantFormat)
+			}
+		})
+	}
+}
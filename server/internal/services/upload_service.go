@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+	"github.com/hotpatch/server/internal/storage"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUploadNotFound is returned when an upload session does not exist, has expired or belongs to another app.
+	ErrUploadNotFound = errors.New("upload not found")
+	// ErrUploadConflict is returned when a chunk is appended at the wrong offset or to a completed upload,
+	// or when an incomplete upload is completed or released.
+	ErrUploadConflict = errors.New("upload conflict")
+	// ErrInvalidChunk is returned when an appended chunk is empty, too small, too large or overruns the upload.
+	ErrInvalidChunk = errors.New("invalid upload chunk")
+)
+
+const (
+	// maxChunkSize caps a single appended chunk; each chunk is held in memory while it is stored.
+	maxChunkSize = 2 * storage.PartSize
+	// uploadBatchSize caps how many expired upload sessions a single scheduler tick removes.
+	uploadBatchSize = 100
+)
+
+// UploadService manages resumable bundle uploads. A session is created with the bundle's size, filled
+// by appending chunks at the offset the server reports, completed, and finally turned into a release.
+type UploadService struct {
+	repo            *repository.UploadRepository
+	storage         *storage.S3Storage
+	releaseService  *ReleaseService
+	settingsService *SettingsService
+	ttl             time.Duration
+}
+
+// NewUploadService creates a new UploadService whose sessions expire ttl after they are created.
+func NewUploadService(repo *repository.UploadRepository, storage *storage.S3Storage, releaseService *ReleaseService, settingsService *SettingsService, ttl time.Duration) *UploadService {
+	return &UploadService{repo: repo, storage: storage, releaseService: releaseService, settingsService: settingsService, ttl: ttl}
+}
+
+// Create starts an upload session for a bundle of the given size.
+func (s *UploadService) Create(ctx context.Context, appID uuid.UUID, req *models.CreateUploadRequest, actor string) (*models.UploadSession, error) {
+	app, err := s.settingsService.GetApp(appID)
+	if err != nil {
+		return nil, fmt.Errorf("app not found: %w", err)
+	}
+	if limit := s.releaseService.bundleSizeLimit(app.Tier); req.Size > limit {
+		return nil, bundleTooLarge(limit)
+	}
+
+	hashState, err := sha256.New().(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise upload hash: %w", err)
+	}
+
+	id := uuid.New()
+	objectKey := fmt.Sprintf("uploads/%s/%s", appID, id)
+	multipartID, err := s.storage.CreateMultipartUpload(ctx, objectKey, "application/zip")
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.UploadSession{
+		ID:          id,
+		AppID:       appID,
+		ObjectKey:   objectKey,
+		MultipartID: multipartID,
+		Size:        req.Size,
+		HashState:   hashState,
+		Status:      models.UploadInProgress,
+		CreatedBy:   actor,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	}
+	if err := s.repo.Create(session); err != nil {
+		if abortErr := s.storage.AbortMultipartUpload(context.WithoutCancel(ctx), objectKey, multipartID); abortErr != nil {
+			log.Printf("uploads: failed to abort multipart upload of %s: %v", objectKey, abortErr)
+		}
+		return nil, fmt.Errorf("failed to save upload session: %w", err)
+	}
+	return session, nil
+}
+
+// Get returns an unexpired upload session of the app. Clients resume by appending at its offset.
+func (s *UploadService) Get(appID, id uuid.UUID) (*models.UploadSession, error) {
+	session, err := s.repo.Get(appID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load upload session: %w", err)
+	}
+	if !time.Now().Before(session.ExpiresAt) {
+		return nil, ErrUploadNotFound
+	}
+	return session, nil
+}
+
+// Append stores the next chunk of an upload, which must start at the session's current offset. Every chunk
+// but the last must be at least 5 MiB, as it becomes one part of the multipart upload. Appends to a session
+// must be made one at a time; a chunk whose response was lost is retried after checking the offset.
+func (s *UploadService) Append(ctx context.Context, appID, id uuid.UUID, offset int64, chunk io.Reader) (*models.UploadSession, error) {
+	session, err := s.Get(appID, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.UploadInProgress {
+		return nil, fmt.Errorf("%w: upload is already complete", ErrUploadConflict)
+	}
+	if offset != session.Offset {
+		return nil, fmt.Errorf("%w: chunk starts at offset %d but the upload is at offset %d", ErrUploadConflict, offset, session.Offset)
+	}
+
+	remaining := session.Size - session.Offset
+	data, err := io.ReadAll(io.LimitReader(chunk, min(remaining, maxChunkSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chunk: %w", err)
+	}
+	switch size := int64(len(data)); {
+	case size == 0:
+		return nil, fmt.Errorf("%w: chunk is empty", ErrInvalidChunk)
+	case size > remaining:
+		return nil, fmt.Errorf("%w: chunk overruns the declared size of %d bytes", ErrInvalidChunk, session.Size)
+	case size > maxChunkSize:
+		return nil, fmt.Errorf("%w: chunks are limited to %d MiB", ErrInvalidChunk, maxChunkSize>>20)
+	case size < storage.MinPartSize && size < remaining:
+		return nil, fmt.Errorf("%w: every chunk but the last must be at least %d MiB", ErrInvalidChunk, storage.MinPartSize>>20)
+	}
+
+	digest, err := restoreHash(session.HashState)
+	if err != nil {
+		return nil, err
+	}
+	digest.Write(data)
+	hashState, err := digest.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to save upload hash: %w", err)
+	}
+
+	// A retried chunk reuses its part number, replacing the part stored by the lost attempt
+	etag, err := s.storage.UploadPart(ctx, session.ObjectKey, session.MultipartID, int32(len(session.Parts)+1), data)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Parts = append(session.Parts, models.UploadPart{ETag: etag, Size: int64(len(data))})
+	session.Offset += int64(len(data))
+	session.HashState = hashState
+	advanced, err := s.repo.Advance(session, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save upload progress: %w", err)
+	}
+	if !advanced {
+		return nil, fmt.Errorf("%w: the upload moved on while the chunk was stored", ErrUploadConflict)
+	}
+	return session, nil
+}
+
+// Complete assembles the uploaded chunks once every byte has been received. Completing a completed upload is a no-op.
+func (s *UploadService) Complete(ctx context.Context, appID, id uuid.UUID) (*models.UploadSession, error) {
+	session, err := s.Get(appID, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == models.UploadCompleted {
+		return session, nil
+	}
+	if session.Offset != session.Size {
+		return nil, fmt.Errorf("%w: received %d of %d bytes", ErrUploadConflict, session.Offset, session.Size)
+	}
+
+	digest, err := restoreHash(session.HashState)
+	if err != nil {
+		return nil, err
+	}
+	etags := make([]string, len(session.Parts))
+	for i, part := range session.Parts {
+		etags[i] = part.ETag
+	}
+	if err := s.storage.CompleteMultipartUpload(ctx, session.ObjectKey, session.MultipartID, etags); err != nil {
+		return nil, err
+	}
+
+	sha := hex.EncodeToString(digest.Sum(nil))
+	if _, err := s.repo.Complete(session.ID, sha); err != nil {
+		return nil, fmt.Errorf("failed to save upload session: %w", err)
+	}
+	return s.Get(appID, id)
+}
+
+// Abort discards an upload session and everything stored for it.
+func (s *UploadService) Abort(ctx context.Context, appID, id uuid.UUID) error {
+	session, err := s.Get(appID, id)
+	if err != nil {
+		return err
+	}
+	return s.discard(ctx, session)
+}
+
+// CreateRelease creates a release from a completed upload, streaming the stored bundle through the same
+// checks, hashing and encryption as a direct upload. The session is discarded once the release exists.
+func (s *UploadService) CreateRelease(ctx context.Context, appID, id uuid.UUID, req *models.CreateReleaseRequest, actor, ip string) (*models.Release, error) {
+	session, err := s.Get(appID, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.UploadCompleted {
+		return nil, fmt.Errorf("%w: upload is not complete", ErrUploadConflict)
+	}
+
+	bundle, err := s.storage.Open(ctx, session.ObjectKey)
+	if err != nil {
+		return nil, err
+	}
+	defer bundle.Close()
+
+	req.Size = session.Size
+	release, err := s.releaseService.Create(ctx, req, appID, bundle, actor, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.discard(context.WithoutCancel(ctx), session); err != nil {
+		log.Printf("uploads: failed to discard upload %s after creating release %s: %v", session.ID, release.ID, err)
+	}
+	return release, nil
+}
+
+// PruneExpired discards upload sessions that have expired, aborting unfinished multipart uploads and deleting
+// completed uploads no release was created from. Meant to run on the scheduler.
+func (s *UploadService) PruneExpired(ctx context.Context, now time.Time) error {
+	expired, err := s.repo.ListExpired(now, uploadBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to load expired upload sessions: %w", err)
+	}
+
+	for i := range expired {
+		if err := s.discard(ctx, &expired[i]); err != nil {
+			log.Printf("uploads: failed to discard expired upload %s: %v", expired[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// discard removes what is stored for a session, then the session itself. Storage is cleaned up first
+// so a failure leaves the session in place to be retried.
+func (s *UploadService) discard(ctx context.Context, session *models.UploadSession) error {
+	var err error
+	if session.Status == models.UploadInProgress {
+		err = s.storage.AbortMultipartUpload(ctx, session.ObjectKey, session.MultipartID)
+	} else {
+		err = s.storage.Delete(ctx, session.ObjectKey)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := s.repo.Delete(session.ID); err != nil {
+		return fmt.Errorf("failed to delete upload session: %w", err)
+	}
+	return nil
+}
+
+// restoreHash resumes the SHA-256 of an upload from its saved state.
+func restoreHash(state []byte) (hash.Hash, error) {
+	digest := sha256.New()
+	if err := digest.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		return nil, fmt.Errorf("failed to restore upload hash: %w", err)
+	}
+	return digest, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hotpatch/server/internal/models"
+	"github.com/hotpatch/server/internal/repository"
+)
+
+// ── Resumable Upload Tests ──────────────────────────────────
+
+// newTestUploadService returns an UploadService over the upload sessions of two tenants. It has no
+// storage, so only requests refused before anything is stored can be exercised.
+func newTestUploadService(t *testing.T) (*UploadService, tenants) {
+	t.Helper()
+	tn := newTenants(t, &models.UploadSession{})
+	releaseService, _ := newReleaseService(tn, PatchGeneration{})
+	return NewUploadService(repository.NewUploadRepository(tn.db), nil, releaseService, releaseService.settingsService, time.Hour), tn
+}
+
+// openUpload stores an in-progress upload session of app A directly.
+func openUpload(t *testing.T, tn tenants, size, offset int64, expiresAt time.Time) *models.UploadSession {
+	t.Helper()
+	state, _ := sha256.New().(encoding.BinaryMarshaler).MarshalBinary()
+	session := &models.UploadSession{ID: uuid.New(), AppID: tn.appA, ObjectKey: "uploads/test", MultipartID: "mp", Size: size, Offset: offset, HashState: state, Status: models.UploadInProgress, ExpiresAt: expiresAt}
+	if err := tn.db.Create(session).Error; err != nil {
+		t.Fatalf("failed to create upload session: %v", err)
+	}
+	return session
+}
+
+func TestUpload_RejectsInvalidAppends(t *testing.T) {
+	svc, f := newTestUploadService(t)
+	ctx := context.Background()
+	session := openUpload(t, f, 20<<20, 0, time.Now().Add(time.Hour))
+	small := openUpload(t, f, 1<<20, 0, time.Now().Add(time.Hour))
+
+	cases := []struct {
+		name    string
+		id      uuid.UUID
+		offset  int64
+		size    int
+		wantErr error
+	}{
+		{"wrong offset", session.ID, 5, 6 << 20, ErrUploadConflict},
+		{"empty chunk", session.ID, 0, 0, ErrInvalidChunk},
+		{"chunk below the minimum part size", session.ID, 0, 1 << 20, ErrInvalidChunk},
+		{"chunk over the maximum size", session.ID, 0, maxChunkSize + 1, ErrInvalidChunk},
+		{"chunk overruns the declared size", small.ID, 0, 2 << 20, ErrInvalidChunk},
+		{"unknown upload", uuid.New(), 0, 1 << 20, ErrUploadNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := svc.Append(ctx, f.appA, tc.id, tc.offset, bytes.NewReader(make([]byte, tc.size)))
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("Append() err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+
+	if got, _ := svc.Get(f.appA, session.ID); got.Offset != 0 || len(got.Parts) != 0 {
+		t.Errorf("session after refused appends = (offset %d, %d parts), want untouched", got.Offset, len(got.Parts))
+	}
+}
+
+func TestUpload_ScopedToAppAndExpiry(t *testing.T) {
+	svc, f := newTestUploadService(t)
+	ctx := context.Background()
+	session := openUpload(t, f, 1<<20, 0, time.Now().Add(time.Hour))
+	expired := openUpload(t, f, 1<<20, 0, time.Now().Add(-time.Minute))
+
+	if _, err := svc.Get(f.appB, session.ID); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("Get from another app: err = %v, want ErrUploadNotFound", err)
+	}
+	if _, err := svc.Append(ctx, f.appB, session.ID, 0, bytes.NewReader([]byte("x"))); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("Append from another app: err = %v, want ErrUploadNotFound", err)
+	}
+	if _, err := svc.Get(f.appA, expired.ID); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("Get of an expired upload: err = %v, want ErrUploadNotFound", err)
+	}
+
+	// An upload cannot be completed or released before every byte has arrived
+	if _, err := svc.Complete(ctx, f.appA, session.ID); !errors.Is(err, ErrUploadConflict) {
+		t.Errorf("Complete of a partial upload: err = %v, want ErrUploadConflict", err)
+	}
+	req := &models.CreateReleaseRequest{Version: "1.1.0", Platform: "android", Hash: "h", Signature: "s"}
+	if _, err := svc.CreateRelease(ctx, f.appA, session.ID, req, "alice", ""); !errors.Is(err, ErrUploadConflict) {
+		t.Errorf("CreateRelease from a partial upload: err = %v, want ErrUploadConflict", err)
+	}
+}
+
+func TestUpload_CreateEnforcesTierLimit(t *testing.T) {
+	svc, f := newTestUploadService(t)
+
+	// App A is on the pro tier, capped at 100 MiB
+	_, err := svc.Create(context.Background(), f.appA, &models.CreateUploadRequest{Size: 100<<20 + 1}, "alice")
+	if !errors.Is(err, ErrBundleTooLarge) {
+		t.Errorf("Create over the tier limit: err = %v, want ErrBundleTooLarge", err)
+	}
+}
+
+func TestUpload_HashResumesFromSavedState(t *testing.T) {
+	first := sha256.New()
+	first.Write([]byte("hello "))
+	state, err := first.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+
+	resumed, err := restoreHash(state)
+	if err != nil {
+		t.Fatalf("restoreHash failed: %v", err)
+	}
+	resumed.Write([]byte("world"))
+
+	want := sha256.Sum256([]byte("hello world"))
+	if got := hex.EncodeToString(resumed.Sum(nil)); got != hex.EncodeToString(want[:]) {
+		t.Errorf("resumed hash = %s, want %x", got, want)
+	}
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// PartSize is the size of every part of a streamed multipart upload but the last.
+	PartSize = 8 << 20
+	// MinPartSize is the smallest part S3 and R2 accept for any part but the last.
+	MinPartSize = 5 << 20
+)
+
+// CreateMultipartUpload starts a multipart upload of an object and returns its upload ID.
+func (s *S3Storage) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	out, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	return aws.ToString(out.UploadId), nil
+}
+
+// UploadPart uploads one part of a multipart upload and returns its ETag. Part numbers start at 1.
+func (s *S3Storage) UploadPart(ctx context.Context, key, uploadID string, partNumber int32, data []byte) (string, error) {
+	out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     aws.String(s.bucket),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadID),
+		PartNumber: aws.Int32(partNumber),
+		Body:       bytes.NewReader(data),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+	return aws.ToString(out.ETag), nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts, given as ETags in part order, into the object.
+func (s *S3Storage) CompleteMultipartUpload(ctx context.Context, key, uploadID string, etags []string) error {
+	parts := make([]types.CompletedPart, len(etags))
+	for i, etag := range etags {
+		parts[i] = types.CompletedPart{ETag: aws.String(etag), PartNumber: aws.Int32(int32(i + 1))}
+	}
+	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+// AbortMultipartUpload discards a multipart upload and the parts stored for it.
+// Aborting an upload that no longer exists succeeds, so an interrupted cleanup can simply be retried.
+func (s *S3Storage) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	var noSuchUpload *types.NoSuchUpload
+	if errors.As(err, &noSuchUpload) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+	return nil
+}
+
+// UploadStream uploads an object of unknown length, holding at most one part in memory. Objects that
+// fit in one part are stored with a single PUT; larger ones go through a multipart upload, which is
+// aborted when reading the body or uploading a part fails. Returns the number of bytes stored.
+func (s *S3Storage) UploadStream(ctx context.Context, key string, body io.Reader, contentType string) (int64, error) {
+	buf := make([]byte, PartSize)
+	n, err := io.ReadFull(body, buf)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		if _, err := s.Upload(ctx, key, bytes.NewReader(buf[:n]), contentType); err != nil {
+			return 0, err
+		}
+		return int64(n), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	uploadID, err := s.CreateMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return 0, err
+	}
+	size, err := s.uploadParts(ctx, key, uploadID, body, buf)
+	if err != nil {
+		// Parts of an abandoned upload are billed until aborted
+		if abortErr := s.AbortMultipartUpload(context.WithoutCancel(ctx), key, uploadID); abortErr != nil {
+			return 0, fmt.Errorf("%w (and %v)", err, abortErr)
+		}
+		return 0, err
+	}
+	return size, nil
+}
+
+// uploadParts uploads the full first part in buf and the rest of body as further parts, then completes the upload.
+func (s *S3Storage) uploadParts(ctx context.Context, key, uploadID string, body io.Reader, buf []byte) (int64, error) {
+	var etags []string
+	var size int64
+	n := len(buf)
+	for {
+		etag, err := s.UploadPart(ctx, key, uploadID, int32(len(etags)+1), buf[:n])
+		if err != nil {
+			return 0, err
+		}
+		etags = append(etags, etag)
+		size += int64(n)
+
+		n, err = io.ReadFull(body, buf)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// Short final part
+			etag, err := s.UploadPart(ctx, key, uploadID, int32(len(etags)+1), buf[:n])
+			if err != nil {
+				return 0, err
+			}
+			etags = append(etags, etag)
+			size += int64(n)
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := s.CompleteMultipartUpload(ctx, key, uploadID, etags); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -17,6 +19,17 @@ import (
 type S3Storage struct {
 	client *s3.Client
 	bucket string
+
+	// Download URL delivery
+	cdnBaseURL string
+	urlExpiry  time.Duration
+	mu         sync.RWMutex
+	urlCache   map[string]cachedURL
+}
+
+type cachedURL struct {
+	url       string
+	refreshAt time.Time
 }
 
 // NewS3Storage creates a new S3/R2 storage client.
@@ -49,9 +62,17 @@ func NewS3Storage(cfg *config.Config) (*S3Storage, error) {
 		o.UsePathStyle = true // Required for R2
 	})
 
+	urlExpiry := time.Duration(cfg.DownloadURLExpiry) * time.Minute
+	if urlExpiry <= 0 {
+		urlExpiry = 15 * time.Minute
+	}
+
 	return &S3Storage{
-		client: client,
-		bucket: cfg.S3Bucket,
+		client:     client,
+		bucket:     cfg.S3Bucket,
+		cdnBaseURL: strings.TrimRight(cfg.CDNBaseURL, "/"),
+		urlExpiry:  urlExpiry,
+		urlCache:   make(map[string]cachedURL),
 	}, nil
 }
 
@@ -70,15 +91,27 @@ func (s *S3Storage) Upload(ctx context.Context, key string, body io.Reader, cont
 	return key, nil
 }
 
-// GetPresignedURL generates a presigned download URL for a bundle (valid for 1 hour).
-func (s *S3Storage) GetPresignedURL(ctx context.Context, key string) (string, error) {
+// Open returns a reader streaming an object's content. The caller must close it.
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from S3: %w", err)
+	}
+	return out.Body, nil
+}
+
+// GetPresignedURL generates a presigned download URL for an object, valid for the given duration.
+func (s *S3Storage) GetPresignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
 	presignClient := s3.NewPresignClient(s.client)
 
 	req, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
 	}, func(o *s3.PresignOptions) {
-		o.Expires = 1 * time.Hour
+		o.Expires = expires
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
@@ -87,6 +120,41 @@ func (s *S3Storage) GetPresignedURL(ctx context.Context, key string) (string, er
 	return req.URL, nil
 }
 
+// DownloadURL returns a URL the SDK can fetch an object from right now.
+// Only object keys are persisted; URLs are minted on demand so they never outlive their signature.
+// When a CDN base URL is configured the object is served through it, otherwise a short-lived
+// presigned URL is generated and reused until half of its lifetime has elapsed.
+func (s *S3Storage) DownloadURL(ctx context.Context, key string) (string, error) {
+	if s.cdnBaseURL != "" {
+		return s.cdnBaseURL + "/" + strings.TrimLeft(key, "/"), nil
+	}
+
+	now := time.Now()
+	s.mu.RLock()
+	cached, ok := s.urlCache[key]
+	s.mu.RUnlock()
+	if ok && now.Before(cached.refreshAt) {
+		return cached.url, nil
+	}
+
+	url, err := s.GetPresignedURL(ctx, key, s.urlExpiry)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	// Drop stale entries opportunistically so the cache stays bounded by the set of live objects
+	for k, v := range s.urlCache {
+		if now.After(v.refreshAt) {
+			delete(s.urlCache, k)
+		}
+	}
+	s.urlCache[key] = cachedURL{url: url, refreshAt: now.Add(s.urlExpiry / 2)}
+	s.mu.Unlock()
+
+	return url, nil
+}
+
 // Delete removes a bundle from S3/R2.
 func (s *S3Storage) Delete(ctx context.Context, key string) error {
 	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
@@ -0,0 +1,33 @@
+-- 007_store_object_keys.sql
+-- HotPatch OTA: Persist storage object keys instead of presigned URLs.
+-- Presigned URLs expire, so download URLs are now minted at update-check time.
+-- Existing rows are backfilled by extracting the object key from the stored URL.
+-- The URL path is percent-encoded, so the key is decoded before it is stored.
+
+CREATE FUNCTION pg_temp.url_path_decode(path TEXT) RETURNS TEXT
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT convert_from(COALESCE(string_agg(
+        CASE WHEN tok ~ '^%[0-9A-Fa-f]{2}$' THEN decode(substr(tok, 2), 'hex')
+             ELSE convert_to(tok, 'UTF8') END,
+        ''::bytea ORDER BY ord), ''::bytea), 'UTF8')
+    FROM regexp_matches(path, '%[0-9A-Fa-f]{2}|[^%]+|%', 'g') WITH ORDINALITY AS m(parts, ord),
+         LATERAL (SELECT parts[1] AS tok) t
+$$;
+
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS bundle_key TEXT NOT NULL DEFAULT '';
+
+UPDATE releases
+SET bundle_key = COALESCE(pg_temp.url_path_decode(substring(bundle_url from '(bundles/[^?#]+)')), '')
+WHERE bundle_key = '';
+
+ALTER TABLE releases DROP COLUMN IF EXISTS bundle_url;
+
+ALTER TABLE patches ADD COLUMN IF NOT EXISTS patch_key TEXT NOT NULL DEFAULT '';
+
+UPDATE patches
+SET patch_key = COALESCE(pg_temp.url_path_decode(substring(patch_url from '(patches/[^?#]+)')), '')
+WHERE patch_key = '';
+
+ALTER TABLE patches DROP COLUMN IF EXISTS patch_url;
+
+DROP FUNCTION pg_temp.url_path_decode(TEXT);
@@ -0,0 +1,29 @@
+-- 008_add_release_reverts.sql
+-- HotPatch OTA: Server-driven reverts after a channel rollback.
+-- Releases pulled by a rollback are flagged, and each device told to revert is tracked
+-- until the SDK reports completion via /installations.
+
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS rolled_back_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_releases_rolled_back
+    ON releases(app_id, channel, version)
+    WHERE rolled_back_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS reverts (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id           UUID        NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    device_id        UUID        NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+    from_release_id  UUID        NOT NULL REFERENCES releases(id) ON DELETE CASCADE,
+    to_release_id    UUID        REFERENCES releases(id) ON DELETE SET NULL,  -- NULL = embedded bundle
+    status           VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed', 'failed')),
+    issued_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_reverts_device ON reverts(device_id, from_release_id);
+CREATE INDEX IF NOT EXISTS idx_reverts_app ON reverts(app_id);
+
+-- Allow the SDK to report completed reverts
+ALTER TABLE installations DROP CONSTRAINT IF EXISTS installations_status_check;
+ALTER TABLE installations ADD CONSTRAINT installations_status_check
+    CHECK (status IN ('applied', 'failed', 'rolled_back', 'reverted'));
@@ -0,0 +1,11 @@
+-- 009_add_native_version_targeting.sql
+-- HotPatch OTA: Native binary version targeting.
+-- A release declares the semver range of native builds it is compatible with. A channel can keep
+-- one active release per target range, and /update/check serves the newest compatible one.
+
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS target_native_version VARCHAR(100) NOT NULL DEFAULT '';
+
+DROP INDEX IF EXISTS idx_releases_active;
+CREATE INDEX IF NOT EXISTS idx_releases_active
+    ON releases(app_id, channel, target_native_version, is_active)
+    WHERE is_active = true;
@@ -0,0 +1,22 @@
+-- 010_create_rollout_plans.sql
+-- HotPatch OTA: Staged rollout plans.
+-- A release may carry an ordered list of rollout steps (percentage + hold time). The scheduler
+-- advances each active plan once its hold has elapsed; state lives here so restarts resume cleanly.
+
+CREATE TABLE IF NOT EXISTS rollout_plans (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id           UUID        NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    release_id       UUID        NOT NULL UNIQUE REFERENCES releases(id) ON DELETE CASCADE,
+    steps            TEXT        NOT NULL,   -- JSON: [{"percentage": 5, "hold_minutes": 60}, ...]
+    current_step     INTEGER     NOT NULL DEFAULT 0,
+    status           VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused', 'completed', 'cancelled')),
+    step_started_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    next_step_at     TIMESTAMPTZ,            -- NULL once paused, completed or cancelled
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rollout_plans_app ON rollout_plans(app_id);
+CREATE INDEX IF NOT EXISTS idx_rollout_plans_due
+    ON rollout_plans(next_step_at)
+    WHERE status = 'active';
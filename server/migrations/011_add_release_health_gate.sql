@@ -0,0 +1,10 @@
+-- 011_add_release_health_gate.sql
+-- HotPatch OTA: Health-gated rollouts.
+-- Channels carry failure/rollback thresholds (managed by the server's auto-migration); a release
+-- that breaches them is halted at 0% and records why. Clearing a halt restarts the health window.
+
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS halted_at TIMESTAMPTZ;
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS halt_reason VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS health_window_start TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_installations_release_time ON installations(release_id, installed_at);
@@ -0,0 +1,8 @@
+-- 012_add_release_rollout_salt.sql
+-- HotPatch OTA: Per-release rollout cohorts.
+-- Cohort bucketing hashes the device (or user) ID together with a per-release salt, so each release
+-- rolls out to a different early cohort. Existing releases keep an empty salt and their current cohort.
+
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS rollout_salt VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS bucket_by VARCHAR(10) NOT NULL DEFAULT 'device'
+    CHECK (bucket_by IN ('device', 'user'));
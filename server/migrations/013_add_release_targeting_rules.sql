@@ -0,0 +1,6 @@
+-- 013_add_release_targeting_rules.sql
+-- HotPatch OTA: Attribute-based release targeting.
+-- A release may carry rules (JSON) on device attributes reported with each update check: OS version,
+-- device model, locale, country, SDK version and custom tags. NULL means the release targets every device.
+
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS targeting_rules TEXT;
@@ -0,0 +1,22 @@
+-- 014_create_device_overrides.sql
+-- HotPatch OTA: Per-device release overrides.
+-- Pins one device (by its SDK-generated device ID) to a release regardless of channel, rollout
+-- and targeting. The update check consults this table first; expired rows are ignored and pruned.
+
+CREATE TABLE IF NOT EXISTS device_overrides (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id      UUID         NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    device_id   VARCHAR(255) NOT NULL,  -- devices.device_id; the device need not be registered yet
+    release_id  UUID         NOT NULL REFERENCES releases(id) ON DELETE CASCADE,
+    reason      VARCHAR(500),
+    created_by  VARCHAR(255),
+    expires_at  TIMESTAMPTZ,            -- NULL pins the device until it is unpinned
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_device_overrides_app_device ON device_overrides(app_id, device_id);
+CREATE INDEX IF NOT EXISTS idx_device_overrides_release_id ON device_overrides(release_id);
+CREATE INDEX IF NOT EXISTS idx_device_overrides_expiry
+    ON device_overrides(expires_at)
+    WHERE expires_at IS NOT NULL;
@@ -0,0 +1,16 @@
+-- 015_add_release_scheduling.sql
+-- HotPatch OTA: Scheduled releases and channel freeze windows.
+-- A release may be uploaded inactive with a publish_at time; the scheduler publishes it once due.
+-- Channels carry freeze windows (managed by the server's auto-migration); a rollout change made
+-- while a channel defers changes is held in pending_rollout until its window closes.
+
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS pending_rollout SMALLINT
+    CHECK (pending_rollout BETWEEN 1 AND 100);
+
+CREATE INDEX IF NOT EXISTS idx_releases_publish_at ON releases(publish_at) WHERE publish_at IS NOT NULL;
+
+-- Rollout plans of scheduled releases wait in the new 'scheduled' status
+ALTER TABLE rollout_plans DROP CONSTRAINT IF EXISTS rollout_plans_status_check;
+ALTER TABLE rollout_plans ADD CONSTRAINT rollout_plans_status_check
+    CHECK (status IN ('scheduled', 'active', 'paused', 'completed', 'cancelled'));
@@ -0,0 +1,8 @@
+-- 016_add_release_promotion.sql
+-- HotPatch OTA: Promote releases between channels.
+-- A promoted release reuses the source release's stored bundle and patch objects and records
+-- where it came from, so a build can be traced from staging to production.
+
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS promoted_from UUID REFERENCES releases(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_releases_promoted_from ON releases(promoted_from) WHERE promoted_from IS NOT NULL;
@@ -0,0 +1,35 @@
+-- 017_add_release_lifecycle.sql
+-- HotPatch OTA: Release lifecycle status and transition history.
+-- Every release carries an explicit status; each change of status is recorded with who made it and why.
+-- Existing releases are backfilled from the columns that described their state so far.
+
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'completed';
+
+UPDATE releases SET status = CASE
+    WHEN rolled_back_at IS NOT NULL THEN 'rolled_back'
+    WHEN is_active AND halted_at IS NOT NULL THEN 'halted'
+    WHEN is_active AND rollout_percentage >= 100 THEN 'completed'
+    WHEN is_active THEN 'rolling_out'
+    WHEN publish_at IS NOT NULL THEN 'scheduled'
+    ELSE 'superseded'
+END;
+
+ALTER TABLE releases DROP CONSTRAINT IF EXISTS releases_status_check;
+ALTER TABLE releases ADD CONSTRAINT releases_status_check
+    CHECK (status IN ('draft', 'scheduled', 'rolling_out', 'completed', 'paused', 'halted', 'superseded', 'rolled_back', 'archived'));
+
+CREATE INDEX IF NOT EXISTS idx_releases_status ON releases(status);
+
+CREATE TABLE IF NOT EXISTS release_transitions (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id      UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    release_id  UUID NOT NULL REFERENCES releases(id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL DEFAULT '',
+    to_status   VARCHAR(20) NOT NULL,
+    actor       VARCHAR(255) NOT NULL,
+    reason      VARCHAR(500) NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_release_transitions_app_id ON release_transitions(app_id);
+CREATE INDEX IF NOT EXISTS idx_release_transitions_release ON release_transitions(release_id, created_at);
@@ -0,0 +1,26 @@
+-- 018_create_release_approvals.sql
+-- HotPatch OTA: Two-person approval for releases.
+-- A channel can require approvals from reviewers other than the uploader; its releases wait in
+-- pending_approval until approved, and are rejected or published by override otherwise.
+
+ALTER TABLE channels ADD COLUMN IF NOT EXISTS required_approvals INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS created_by VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS required_approvals INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE releases DROP CONSTRAINT IF EXISTS releases_status_check;
+ALTER TABLE releases ADD CONSTRAINT releases_status_check
+    CHECK (status IN ('draft', 'pending_approval', 'rejected', 'scheduled', 'rolling_out', 'completed', 'paused', 'halted', 'superseded', 'rolled_back', 'archived'));
+
+CREATE TABLE IF NOT EXISTS release_approvals (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id      UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    release_id  UUID NOT NULL REFERENCES releases(id) ON DELETE CASCADE,
+    actor       VARCHAR(255) NOT NULL,
+    decision    VARCHAR(20) NOT NULL CHECK (decision IN ('approved', 'rejected', 'override')),
+    comment     VARCHAR(500) NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_release_approvals_release_actor ON release_approvals(release_id, actor);
+CREATE INDEX IF NOT EXISTS idx_release_approvals_app_id ON release_approvals(app_id);
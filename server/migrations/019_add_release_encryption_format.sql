@@ -0,0 +1,6 @@
+-- 019_add_release_encryption_format.sql
+-- HotPatch OTA: Chunked bundle encryption.
+-- Bundles are now encrypted as independently sealed chunks so the server and SDKs can stream them;
+-- releases encrypted before this keep an empty format and are decrypted in one piece.
+
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS encryption_format VARCHAR(30) NOT NULL DEFAULT '';
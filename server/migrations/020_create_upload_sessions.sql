@@ -0,0 +1,25 @@
+-- 020_create_upload_sessions.sql
+-- HotPatch OTA: Resumable bundle uploads.
+-- Each session is backed by an S3 multipart upload; appended chunks become its parts. Sessions
+-- expire and are garbage collected by the scheduler whether or not a release was created from them.
+
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id        UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    object_key    VARCHAR(255) NOT NULL,
+    multipart_id  VARCHAR(1024) NOT NULL,
+    size          BIGINT NOT NULL,
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    parts         TEXT,
+    hash_state    BYTEA,
+    sha256        VARCHAR(64),
+    status        VARCHAR(20) NOT NULL CHECK (status IN ('uploading', 'completed')),
+    created_by    VARCHAR(255),
+    expires_at    TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_app_id ON upload_sessions(app_id);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_status ON upload_sessions(status);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires_at ON upload_sessions(expires_at);
@@ -0,0 +1,8 @@
+-- 021_add_signing_key_ids.sql
+-- HotPatch OTA: Upload-time signature verification.
+-- Bundles and patches are hashed and their Ed25519 signatures verified against the app's active signing
+-- keys as they are uploaded; the key that verified each artifact is recorded. Artifacts uploaded before
+-- this were never verified by the server and keep a NULL key.
+
+ALTER TABLE releases ADD COLUMN IF NOT EXISTS signing_key_id UUID;
+ALTER TABLE patches ADD COLUMN IF NOT EXISTS signing_key_id UUID;
@@ -0,0 +1,28 @@
+-- 022_create_patch_jobs.sql
+-- HotPatch OTA: Server-generated patches.
+-- New releases are diffed against recent releases of their channel by background jobs; patches the
+-- server generated are flagged, as devices verify them against the release's hash and signature.
+
+ALTER TABLE patches ADD COLUMN IF NOT EXISTS generated BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS patch_jobs (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id          UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    release_id      UUID NOT NULL REFERENCES releases(id) ON DELETE CASCADE,
+    base_release_id UUID NOT NULL REFERENCES releases(id) ON DELETE CASCADE,
+    base_version    VARCHAR(50) NOT NULL,
+    status          VARCHAR(20) NOT NULL CHECK (status IN ('queued', 'running', 'completed', 'skipped', 'failed')),
+    attempts        INT NOT NULL DEFAULT 0,
+    patch_id        UUID REFERENCES patches(id) ON DELETE SET NULL,
+    patch_size      BIGINT NOT NULL DEFAULT 0,
+    bundle_size     BIGINT NOT NULL DEFAULT 0,
+    reason          VARCHAR(500) NOT NULL DEFAULT '',
+    started_at      TIMESTAMPTZ,
+    finished_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_patch_jobs_app_id ON patch_jobs(app_id);
+CREATE INDEX IF NOT EXISTS idx_patch_jobs_release_id ON patch_jobs(release_id);
+CREATE INDEX IF NOT EXISTS idx_patch_jobs_status ON patch_jobs(status);
@@ -0,0 +1,19 @@
+-- 023_add_patch_base_hashes.sql
+-- HotPatch OTA: Patch selection by installed bundle hash.
+-- Patches record the SHA-256 of the bundle they apply to, so update checks match them against the hash of
+-- the bundle a device runs rather than its version string. Existing patches take the hash of their
+-- channel's release of the base version, unless that bundle is encrypted: its recorded hash is not of the
+-- decrypted bytes devices patch.
+
+ALTER TABLE patches ADD COLUMN IF NOT EXISTS base_hash VARCHAR(64) NOT NULL DEFAULT '';
+
+UPDATE patches p
+SET base_hash = LOWER(base.hash)
+FROM releases target, releases base
+WHERE p.release_id = target.id
+  AND base.app_id = target.app_id
+  AND base.channel = target.channel
+  AND base.version = p.base_version
+  AND base.is_encrypted = FALSE
+  AND base.is_patch = FALSE
+  AND p.base_hash = '';
@@ -0,0 +1,9 @@
+-- 024_add_patch_verification.sql
+-- HotPatch OTA: Uploaded patch verification.
+-- Uploaded patches are applied to their base bundle on upload and refused unless they reproduce the release
+-- bundle. Patches known to do so are marked verified: devices check what they reconstruct against the release's
+-- hash and signature. Generated patches were always checked this way; earlier uploads were not.
+
+ALTER TABLE patches ADD COLUMN IF NOT EXISTS verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE patches SET verified = TRUE WHERE generated = TRUE AND verified = FALSE;
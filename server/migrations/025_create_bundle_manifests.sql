@@ -0,0 +1,17 @@
+-- 025_create_bundle_manifests.sql
+-- HotPatch OTA: Bundle inspection on upload.
+-- Uploaded bundles are unzipped and validated before a release is created, and the files they hold are
+-- recorded with their sizes and hashes. Releases uploaded before this, patch releases and bundles encrypted
+-- before upload have no manifest.
+
+CREATE TABLE IF NOT EXISTS bundle_manifests (
+    release_id     UUID PRIMARY KEY REFERENCES releases(id) ON DELETE CASCADE,
+    entry_file     VARCHAR(255) NOT NULL,
+    hermes         BOOLEAN NOT NULL DEFAULT FALSE,
+    hermes_version BIGINT NOT NULL DEFAULT 0,
+    file_count     INT NOT NULL DEFAULT 0,
+    asset_count    INT NOT NULL DEFAULT 0,
+    unpacked_size  BIGINT NOT NULL DEFAULT 0,
+    files          TEXT,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,8 @@
+-- 026_add_channel_size_budgets.sql
+-- HotPatch OTA: Bundle size budgets.
+-- Channels may cap the size of the bundles and patches uploaded to them, and how much a bundle may grow over
+-- the channel's active release. Uploads over budget are refused unless the uploader overrides the budget,
+-- which is recorded in the audit log. Zero leaves a budget unset.
+
+ALTER TABLE channels ADD COLUMN IF NOT EXISTS max_bundle_size BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE channels ADD COLUMN IF NOT EXISTS max_size_growth_percent DOUBLE PRECISION NOT NULL DEFAULT 0;
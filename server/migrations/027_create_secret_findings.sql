@@ -0,0 +1,20 @@
+-- 027_create_secret_findings.sql
+-- HotPatch OTA: Secret scanning of uploaded bundles.
+-- Bundles are scanned for credentials and high-entropy strings when they are inspected on upload, and what is
+-- found is recorded, redacted, against the release. Channels may refuse to publish releases with a finding at
+-- or above a severity; "off" never blocks.
+
+CREATE TABLE IF NOT EXISTS secret_findings (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    release_id  UUID NOT NULL REFERENCES releases(id) ON DELETE CASCADE,
+    rule        VARCHAR(50) NOT NULL,
+    severity    VARCHAR(20) NOT NULL,
+    path        VARCHAR(255) NOT NULL,
+    byte_offset BIGINT NOT NULL DEFAULT 0,
+    match       VARCHAR(100) NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_secret_findings_release_id ON secret_findings(release_id);
+
+ALTER TABLE channels ADD COLUMN IF NOT EXISTS secret_block_severity VARCHAR(20) NOT NULL DEFAULT 'off';
@@ -0,0 +1,6 @@
+-- 028_add_secret_findings_truncated.sql
+-- HotPatch OTA: Capped secret scan findings.
+-- A file records at most a few findings per rule and a release at most 100, the most severe first, so a bundle
+-- full of quoted literals cannot flood the upload or the findings table. The manifest records when more were found.
+
+ALTER TABLE bundle_manifests ADD COLUMN IF NOT EXISTS secret_findings_truncated BOOLEAN NOT NULL DEFAULT FALSE;